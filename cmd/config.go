@@ -20,6 +20,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ontio/ontology/cmd/utils"
 	"github.com/ontio/ontology/common"
@@ -38,6 +39,12 @@ func SetOntologyConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 	setCommonConfig(ctx, cfg.Common)
 	setConsensusConfig(ctx, cfg.Consensus)
 	setP2PNodeConfig(ctx, cfg.P2PNode)
+	if cfg.P2PNode.EnableSnapshotSync {
+		if _, err := common.Uint256FromHexString(cfg.P2PNode.SnapshotSyncManifest); err != nil {
+			return nil, fmt.Errorf("snapshot sync requires the manifest hash of a trusted snapshot, invalid %s %q:%s",
+				utils.GetFlagName(utils.SnapshotSyncManifestFlag), cfg.P2PNode.SnapshotSyncManifest, err)
+		}
+	}
 	setRpcConfig(ctx, cfg.Rpc)
 	setRestfulConfig(ctx, cfg.Restful)
 	setGraphQLConfig(ctx, cfg.GraphQL)
@@ -127,6 +134,16 @@ func setGenesis(ctx *cli.Context, cfg *config.OntologyConfig) error {
 		if len(cfg.Genesis.VBFT.Peers) < config.VBFT_MIN_NODE_NUM {
 			return fmt.Errorf("VBFT consensus at least need %d peers in config", config.VBFT_MIN_NODE_NUM)
 		}
+	case config.CONSENSUS_TYPE_SBFT:
+		if len(cfg.Genesis.SBFT.Bookkeepers) < config.SBFT_MIN_NODE_NUM {
+			return fmt.Errorf("SBFT consensus at least need %d bookkeepers in config", config.SBFT_MIN_NODE_NUM)
+		}
+		if cfg.Genesis.SBFT.GenBlockTime <= 0 {
+			cfg.Genesis.SBFT.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
+		}
+		if cfg.Genesis.SBFT.ViewTimeout <= cfg.Genesis.SBFT.GenBlockTime {
+			cfg.Genesis.SBFT.ViewTimeout = 3 * cfg.Genesis.SBFT.GenBlockTime
+		}
 	default:
 		return fmt.Errorf("Unknow consensus:%s", cfg.Genesis.ConsensusType)
 	}
@@ -142,6 +159,15 @@ func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
 	cfg.DataDir = ctx.String(utils.GetFlagName(utils.DataDirFlag))
 	//add new flag for ethgaslimit
 	cfg.ETHTxGasLimit = ctx.Uint64(utils.GetFlagName(utils.ETHTxGasLimitFlag))
+	cfg.EnableEthLogBloom = ctx.Bool(utils.GetFlagName(utils.EnableEthLogBloomFlag))
+	cfg.EnableArchiveState = ctx.Bool(utils.GetFlagName(utils.EnableArchiveStateFlag))
+	cfg.EnableAddressIndex = ctx.Bool(utils.GetFlagName(utils.EnableAddressIndexFlag))
+	cfg.EnableContractEventIndex = ctx.Bool(utils.GetFlagName(utils.EnableContractEventIndexFlag))
+	cfg.EnableSnapshotServe = ctx.Bool(utils.GetFlagName(utils.EnableSnapshotServeFlag))
+	cfg.EnableParallelExec = ctx.Bool(utils.GetFlagName(utils.EnableParallelExecFlag))
+	cfg.EnableStateUndo = ctx.Bool(utils.GetFlagName(utils.EnableStateUndoFlag))
+	cfg.StateGCHistory = uint32(ctx.Uint(utils.GetFlagName(utils.StateGCHistoryFlag)))
+	cfg.DBEngine = ctx.String(utils.GetFlagName(utils.DBEngineFlag))
 }
 
 func setConsensusConfig(ctx *cli.Context, cfg *config.ConsensusConfig) {
@@ -160,6 +186,8 @@ func setP2PNodeConfig(ctx *cli.Context, cfg *config.P2PNodeConfig) {
 	cfg.MaxConnInBound = ctx.Uint(utils.GetFlagName(utils.MaxConnInBoundFlag))
 	cfg.MaxConnOutBound = ctx.Uint(utils.GetFlagName(utils.MaxConnOutBoundFlag))
 	cfg.MaxConnInBoundForSingleIP = ctx.Uint(utils.GetFlagName(utils.MaxConnInBoundForSingleIPFlag))
+	cfg.EnableSnapshotSync = ctx.Bool(utils.GetFlagName(utils.EnableSnapshotSyncFlag))
+	cfg.SnapshotSyncManifest = ctx.String(utils.GetFlagName(utils.SnapshotSyncManifestFlag))
 
 	rsvfile := ctx.String(utils.GetFlagName(utils.ReservedPeersFileFlag))
 	if cfg.ReservedPeersOnly {
@@ -187,18 +215,34 @@ func setRpcConfig(ctx *cli.Context, cfg *config.RpcConfig) {
 	cfg.HttpJsonPort = ctx.Uint(utils.GetFlagName(utils.RPCPortFlag))
 	cfg.HttpLocalPort = ctx.Uint(utils.GetFlagName(utils.RPCLocalProtFlag))
 	cfg.EthJsonPort = ctx.Uint(utils.GetFlagName(utils.ETHRPCPortFlag))
+	cfg.EthWsPort = ctx.Uint(utils.GetFlagName(utils.ETHWSPortFlag))
+	for _, origin := range strings.Split(ctx.String(utils.GetFlagName(utils.ETHWSOriginsFlag)), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.EthWsOrigins = append(cfg.EthWsOrigins, origin)
+		}
+	}
+	cfg.EthDebugApi = ctx.Bool(utils.GetFlagName(utils.ETHDebugApiFlag))
+	cfg.HttpRateLimit = ctx.Uint(utils.GetFlagName(utils.RPCRateLimitFlag))
+	cfg.HttpRateBurst = ctx.Uint(utils.GetFlagName(utils.RPCRateBurstFlag))
 }
 
 func setRestfulConfig(ctx *cli.Context, cfg *config.RestfulConfig) {
 	cfg.EnableHttpRestful = ctx.Bool(utils.GetFlagName(utils.RestfulEnableFlag))
 	cfg.HttpRestPort = ctx.Uint(utils.GetFlagName(utils.RestfulPortFlag))
 	cfg.HttpMaxConnections = ctx.Uint(utils.GetFlagName(utils.RestfulMaxConnsFlag))
+	cfg.HttpRateLimit = ctx.Uint(utils.GetFlagName(utils.RestfulRateLimitFlag))
+	cfg.HttpRateBurst = ctx.Uint(utils.GetFlagName(utils.RestfulRateBurstFlag))
 }
 
 func setGraphQLConfig(ctx *cli.Context, cfg *config.GraphQLConfig) {
 	cfg.EnableGraphQL = ctx.Bool(utils.GetFlagName(utils.GraphQLEnableFlag))
 	cfg.GraphQLPort = ctx.Uint(utils.GetFlagName(utils.GraphQLPortFlag))
 	cfg.MaxConnections = ctx.Uint(utils.GetFlagName(utils.GraphQLMaxConnsFlag))
+	for _, origin := range strings.Split(ctx.String(utils.GetFlagName(utils.GraphQLWsOriginsFlag)), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.WsOrigins = append(cfg.WsOrigins, origin)
+		}
+	}
 }
 
 func setWebSocketConfig(ctx *cli.Context, cfg *config.WebSocketConfig) {
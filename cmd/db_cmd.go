@@ -0,0 +1,243 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/store/ledgerstore"
+	"github.com/urfave/cli"
+)
+
+var DbCommand = cli.Command{
+	Action:      cli.ShowSubcommandHelp,
+	Name:        "db",
+	Usage:       "Maintain the DB of ledger",
+	ArgsUsage:   "[arguments...]",
+	Description: "Maintain the DB of ledger. Node must be stopped during the maintenance.",
+	Subcommands: []cli.Command{
+		{
+			Action:    rollbackDB,
+			Name:      "rollback",
+			Usage:     "Roll back the DB to a block height",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.RollbackHeightFlag,
+				utils.DataDirFlag,
+				utils.DBEngineFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: fmt.Sprintf("Remove the blocks after the height from DB, and restore the states with the undo "+
+				"logs saved with the blocks when the node runs with --enable-state-undo. At most the latest %d blocks "+
+				"can be rolled back, and the height must be higher than the pruned block height. An interrupted rollback can be resumed by running it again.",
+				ledgerstore.MAX_ROLLBACK_BLOCKS),
+		},
+		{
+			Action:    migrateDB,
+			Name:      "migrate",
+			Usage:     "Copy the DB to another storage engine",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.MigrateTargetDirFlag,
+				utils.DBEngineFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "Copy the DB in data dir to target dir stored with the storage engine of --db-engine, and " +
+				"verify the copy key by key. The DB in data dir is not changed, run the node with the target dir as " +
+				"data dir and the same --db-engine to use the migrated DB.",
+		},
+		{
+			Action:    verifyDB,
+			Name:      "verify",
+			Usage:     "Verify the integrity of the DB",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.VerifyReportFlag,
+				utils.DataDirFlag,
+				utils.DBEngineFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "Check block by block the header links, the tx roots, the block merkle roots, the state " +
+				"merkle roots, the write set hashes of the blocks with undo logs, and the events of the blocks. The " +
+				"report in JSON lists the divergences found with the first divergent height. The data removed by " +
+				"block pruning and state gc is not verified.",
+		},
+	},
+}
+
+const (
+	rollbackProgressInterval = 1000
+	migrateProgressInterval  = 1000000
+	verifyProgressInterval   = 100000
+)
+
+func rollbackDB(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	heightFlag := utils.GetFlagName(utils.RollbackHeightFlag)
+	if !ctx.IsSet(heightFlag) {
+		PrintErrorMsg("Missing %s argument.", utils.RollbackHeightFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	height := uint32(ctx.Uint(heightFlag))
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+
+	PrintInfoMsg("Start rollback DB to height:%d.", height)
+	err = ledger.RollbackLedger(dbDir, stateHashHeight, height, func(h uint32) {
+		if (h-height)%rollbackProgressInterval == 0 {
+			PrintInfoMsg("Block height:%d rolled back.", h)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("rollback DB error:%s", err)
+	}
+
+	// open the ledger as a node does to make sure it is usable
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	if currHeight := ledger.DefLedger.GetCurrentBlockHeight(); currHeight != height {
+		return fmt.Errorf("CurrentBlockHeight:%d after rollback is not %d", currHeight, height)
+	}
+	PrintInfoMsg("Rollback DB completed, CurrentBlockHeight:%d.", height)
+	return nil
+}
+
+func migrateDB(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	targetFlag := utils.GetFlagName(utils.MigrateTargetDirFlag)
+	targetDir := ctx.String(targetFlag)
+	if targetDir == "" {
+		PrintErrorMsg("Missing %s argument.", utils.MigrateTargetDirFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	targetDbDir := utils.GetStoreDirPath(targetDir, config.DefConfig.P2PNode.NetworkName)
+	engine := config.DefConfig.Common.DBEngine
+
+	PrintInfoMsg("Start migrate DB %s to %s with %s.", dbDir, targetDbDir, engine)
+	var reported uint64
+	err = ledger.MigrateLedger(dbDir, targetDbDir, engine, func(store string, keys uint64) {
+		if keys < reported {
+			reported = 0
+		}
+		if keys-reported >= migrateProgressInterval {
+			reported = keys
+			PrintInfoMsg("%s: %d keys copied.", store, keys)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("migrate DB error:%s", err)
+	}
+
+	// open the migrated ledger as a node does to make sure it is usable
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.InitLedger(targetDbDir, stateHashHeight, bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	PrintInfoMsg("Migrate DB completed, CurrentBlockHeight:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
+
+func verifyDB(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	reportFile := ctx.String(utils.GetFlagName(utils.VerifyReportFlag))
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+
+	// progress is only printed if the report is written to file, to keep stdout machine readable
+	var progress func(check string, height uint32)
+	if reportFile != "" {
+		PrintInfoMsg("Start verify DB %s.", dbDir)
+		progress = func(check string, height uint32) {
+			if height%verifyProgressInterval == 0 {
+				PrintInfoMsg("%s: height %d verified.", check, height)
+			}
+		}
+	}
+	report, err := ledger.VerifyLedger(dbDir, stateHashHeight, progress)
+	if err != nil {
+		return fmt.Errorf("verify DB error:%s", err)
+	}
+	data, err := json.MarshalIndent(report, "", "   ")
+	if err != nil {
+		return fmt.Errorf("json.Marshal error:%s", err)
+	}
+	if reportFile == "" {
+		fmt.Println(string(data))
+	} else {
+		if err := ioutil.WriteFile(reportFile, data, 0644); err != nil {
+			return fmt.Errorf("write report error:%s", err)
+		}
+		PrintInfoMsg("Verify report is written to %s.", reportFile)
+	}
+	if !report.OK {
+		return fmt.Errorf("%d divergences found, first divergent height:%d", report.ErrorCount,
+			*report.FirstDivergentHeight)
+	}
+	return nil
+}
@@ -26,7 +26,10 @@ import (
 
 	"github.com/gosuri/uiprogress"
 	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/serialization"
+	"github.com/ontio/ontology/core/ledger"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/urfave/cli"
 )
 
@@ -41,11 +44,21 @@ var ExportCommand = cli.Command{
 		utils.ExportStartHeightFlag,
 		utils.ExportEndHeightFlag,
 		utils.ExportSpeedFlag,
+		utils.ExportOfflineFlag,
+		utils.DataDirFlag,
+		utils.DBEngineFlag,
+		utils.ConfigFlag,
+		utils.NetworkIdFlag,
 	},
-	Description: "",
+	Description: "Export blocks through the rpc of a running node, or export blocks with the state merkle roots " +
+		"and cross chain msgs from DB with --offline when the node is stopped. The offline export is written to " +
+		"--export-file without the heights appended, and can be imported in a parallel pipeline.",
 }
 
 func exportBlocks(ctx *cli.Context) error {
+	if ctx.Bool(utils.GetFlagName(utils.ExportOfflineFlag)) {
+		return exportBlocksOffline(ctx)
+	}
 	SetRpcPort(ctx)
 	exportFile := ctx.String(utils.GetFlagName(utils.ExportFileFlag))
 	if exportFile == "" {
@@ -169,3 +182,128 @@ func exportBlocks(ctx *cli.Context) error {
 	PrintInfoMsg("Export file:%s", exportFile)
 	return nil
 }
+
+func exportBlocksOffline(ctx *cli.Context) error {
+	exportFile := ctx.String(utils.GetFlagName(utils.ExportFileFlag))
+	if exportFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.ExportFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	startHeight := uint32(ctx.Uint(utils.GetFlagName(utils.ExportStartHeightFlag)))
+	endHeight := uint32(ctx.Uint(utils.GetFlagName(utils.ExportEndHeightFlag)))
+	if endHeight > 0 && startHeight > endHeight {
+		return fmt.Errorf("export error: start height should smaller than end height")
+	}
+	err := initOfflineLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledger.DefLedger.Close()
+	currHeight := ledger.DefLedger.GetCurrentBlockHeight()
+	if startHeight > currHeight {
+		PrintWarnMsg("StartBlockHeight:%d larger than CurrentBlockHeight:%d, No blocks to export.", startHeight, currHeight)
+		return nil
+	}
+	if endHeight == 0 || endHeight > currHeight {
+		endHeight = currHeight
+	}
+
+	file, err := os.OpenFile(exportFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("open file:%s error:%s", exportFile, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat file:%s error:%s", exportFile, err)
+	}
+	var writer *utils.BlockFileWriter
+	next := startHeight
+	if info.Size() == 0 {
+		writer, err = utils.NewBlockFileWriter(file, startHeight)
+		if err != nil {
+			return fmt.Errorf("write export metadata error:%s", err)
+		}
+	} else {
+		var complete bool
+		writer, next, complete, err = utils.ResumeBlockFileWriter(file, startHeight)
+		if err != nil {
+			return fmt.Errorf("resume export to file:%s error:%s", exportFile, err)
+		}
+		if complete {
+			PrintWarnMsg("Export file:%s is complete, EndBlockHeight:%d.", exportFile, next-1)
+			return nil
+		}
+		PrintInfoMsg("Resume export from height:%d.", next)
+	}
+
+	uiprogress.Start()
+	bar := uiprogress.AddBar(int(endHeight - startHeight + 1)).
+		AppendCompleted().
+		AppendElapsed().
+		PrependFunc(func(b *uiprogress.Bar) string {
+			return fmt.Sprintf("Block(%d/%d)", b.Current()+int(startHeight), int(endHeight))
+		})
+	bar.Set(int(next - startHeight))
+
+	PrintInfoMsg("Start export.")
+	for next <= endHeight {
+		count := uint32(utils.DEFAULT_BLOCK_CHUNK_SIZE)
+		if endHeight-next+1 < count {
+			count = endHeight - next + 1
+		}
+		records := make([]*utils.BlockRecord, 0, count)
+		for height := next; height < next+count; height++ {
+			record, err := getBlockRecord(height)
+			if err != nil {
+				uiprogress.Stop()
+				return err
+			}
+			records = append(records, record)
+		}
+		err = writer.WriteChunk(records)
+		if err != nil {
+			uiprogress.Stop()
+			return fmt.Errorf("write blocks of height:%d error:%s", next, err)
+		}
+		next += count
+		bar.Set(int(next - startHeight))
+	}
+	uiprogress.Stop()
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("export close file error:%s", err)
+	}
+	PrintInfoMsg("Export blocks successfully.")
+	PrintInfoMsg("StartBlockHeight:%d", startHeight)
+	PrintInfoMsg("EndBlockHeight:%d", endHeight)
+	PrintInfoMsg("Export file:%s", exportFile)
+	return nil
+}
+
+func getBlockRecord(height uint32) (*utils.BlockRecord, error) {
+	block, err := ledger.DefLedger.GetBlockByHeight(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockByHeight:%d error:%s", height, err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block of height:%d not found, the pruned blocks can not be exported", height)
+	}
+	record := &utils.BlockRecord{Block: block}
+	if height > 0 {
+		record.CrossChainMsg, err = ledger.DefLedger.GetCrossChainMsg(height - 1)
+		if err != nil {
+			return nil, fmt.Errorf("GetCrossChainMsg:%d error:%s", height-1, err)
+		}
+	}
+	// the state merkle roots removed by state gc are not exported
+	record.StateRoot, err = ledger.DefLedger.GetStateMerkleRoot(height)
+	if err != nil {
+		if err != scom.ErrNotFound {
+			return nil, fmt.Errorf("GetStateMerkleRoot:%d error:%s", height, err)
+		}
+		record.StateRoot = common.UINT256_EMPTY
+	}
+	return record, nil
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 
 	"github.com/gosuri/uiprogress"
 	"github.com/ontio/ontology/cmd/utils"
@@ -32,6 +33,7 @@ import (
 	"github.com/ontio/ontology/common/serialization"
 	"github.com/ontio/ontology/core/genesis"
 	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/store"
 	"github.com/ontio/ontology/core/types"
 	"github.com/urfave/cli"
 )
@@ -45,9 +47,11 @@ var ImportCommand = cli.Command{
 		utils.ImportFileFlag,
 		utils.ImportEndHeightFlag,
 		utils.DataDirFlag,
+		utils.DBEngineFlag,
 		utils.ConfigFlag,
 		utils.NetworkIdFlag,
 		utils.DisableEventLogFlag,
+		utils.EnableParallelExecFlag,
 	},
 	Description: "Note that import cmd doesn't support testmode",
 }
@@ -104,6 +108,9 @@ func importBlocks(ctx *cli.Context) error {
 	}
 	defer ifile.Close()
 	fReader := bufio.NewReader(ifile)
+	if utils.IsBlockFile(fReader) {
+		return importBlockFile(fReader, currBlockHeight, endBlockHeight)
+	}
 
 	metadata := utils.NewExportBlockMetadata()
 	err = metadata.Deserialize(fReader)
@@ -192,3 +199,165 @@ func importBlocks(ctx *cli.Context) error {
 	PrintInfoMsg("Import block completed, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
 	return nil
 }
+
+type blockChunkResult struct {
+	records []*utils.BlockRecord
+	err     error
+}
+
+type blockChunkJob struct {
+	chunk  *utils.BlockChunk
+	follow chan store.HeaderVerifier // receive the header verifier once the previous chunk followed it
+	next   chan store.HeaderVerifier // pass the header verifier to the next chunk
+	result chan *blockChunkResult
+}
+
+//importBlockFile imports blocks from the chunked block file written by export --offline. The chunks are
+//decompressed and their headers verified by a worker pool, and executed and submitted to the ledger in order.
+func importBlockFile(fReader *bufio.Reader, currBlockHeight, endBlockHeight uint32) error {
+	reader, err := utils.NewBlockFileReader(fReader)
+	if err != nil {
+		return fmt.Errorf("block data file metadata deserialize error:%s", err)
+	}
+	startBlockHeight := reader.Metadata.StartBlockHeight
+	if startBlockHeight > (currBlockHeight + 1) {
+		return fmt.Errorf("import block error: StartBlockHeight:%d larger than NextBlockHeight:%d", startBlockHeight, currBlockHeight+1)
+	}
+
+	verifier, err := ledger.DefLedger.NewHeaderVerifier()
+	if err != nil {
+		return fmt.Errorf("import block error:%s", err)
+	}
+	follow := make(chan store.HeaderVerifier, 1)
+	follow <- verifier
+
+	workers := runtime.NumCPU()
+	exit := make(chan struct{})
+	defer close(exit)
+	jobs := make(chan *blockChunkJob, workers)
+	pending := make(chan chan *blockChunkResult, 2*workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				records, err := job.chunk.Decode()
+				// the chain configs of the headers are followed in chunk order, the signatures in parallel
+				verifier := <-job.follow
+				var sigs []int
+				if err == nil {
+					sigs, err = followBlockRecords(verifier, records, currBlockHeight)
+				}
+				job.next <- verifier
+				if err == nil {
+					err = verifyBlockRecords(verifier, records, sigs)
+				}
+				job.result <- &blockChunkResult{records: records, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for {
+			chunk, err := reader.ReadChunk()
+			if chunk == nil || err != nil {
+				if err != nil {
+					result := make(chan *blockChunkResult, 1)
+					result <- &blockChunkResult{err: err}
+					select {
+					case pending <- result:
+					case <-exit:
+					}
+				}
+				return
+			}
+			if chunk.EndBlockHeight() <= currBlockHeight {
+				continue
+			}
+			if endBlockHeight > 0 && chunk.StartBlockHeight > endBlockHeight {
+				return
+			}
+			job := &blockChunkJob{chunk: chunk, follow: follow, next: make(chan store.HeaderVerifier, 1),
+				result: make(chan *blockChunkResult, 1)}
+			follow = job.next
+			select {
+			case pending <- job.result:
+			case <-exit:
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-exit:
+				return
+			}
+		}
+	}()
+
+	PrintInfoMsg("Start import blocks.")
+	for result := range pending {
+		chunk := <-result
+		if chunk.err != nil {
+			return fmt.Errorf("import block error:%s", chunk.err)
+		}
+		for _, record := range chunk.records {
+			block := record.Block
+			height := block.Header.Height
+			if height <= currBlockHeight {
+				continue
+			}
+			if endBlockHeight > 0 && height > endBlockHeight {
+				break
+			}
+			execResult, err := ledger.DefLedger.ExecuteBlock(block)
+			if err != nil {
+				return fmt.Errorf("block height:%d ExecuteBlock error:%s", height, err)
+			}
+			if record.StateRoot != common.UINT256_EMPTY && execResult.MerkleRoot != record.StateRoot {
+				return fmt.Errorf("block height:%d state merkle root mismatch, expected:%s, actual:%s", height,
+					record.StateRoot.ToHexString(), execResult.MerkleRoot.ToHexString())
+			}
+			err = ledger.DefLedger.SubmitBlock(block, record.CrossChainMsg, execResult)
+			if err != nil {
+				return fmt.Errorf("SubmitBlock block height:%d error:%s", height, err)
+			}
+		}
+		PrintInfoMsg("Imported blocks to height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	}
+	PrintInfoMsg("Import block completed, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
+
+//followBlockRecords checks the headers of the records following the previous chunk except the bookkeeper
+//signatures, and returns the number of signatures each header requires. The records already in the ledger are
+//skipped.
+func followBlockRecords(verifier store.HeaderVerifier, records []*utils.BlockRecord, currBlockHeight uint32) ([]int, error) {
+	sigs := make([]int, len(records))
+	for i, record := range records {
+		header := record.Block.Header
+		if header.Height <= currBlockHeight {
+			continue
+		}
+		m, err := verifier.Follow(header)
+		if err != nil {
+			return nil, fmt.Errorf("block height:%d verify header error:%s", header.Height, err)
+		}
+		sigs[i] = m
+	}
+	return sigs, nil
+}
+
+//verifyBlockRecords verifies the bookkeeper signatures of the block headers against the consensus peers at
+//their heights, which do not depend on the ledger state, so that the chunks can be verified in parallel before
+//they are executed. The submitted blocks skip the verification. The transactions are covered by the transactions
+//root of the signed header, which is checked when the block is deserialized.
+func verifyBlockRecords(verifier store.HeaderVerifier, records []*utils.BlockRecord, sigs []int) error {
+	for i, record := range records {
+		header := record.Block.Header
+		if sigs[i] == 0 {
+			continue
+		}
+		if err := verifier.VerifySig(header, sigs[i]); err != nil {
+			return fmt.Errorf("block height:%d verify signature error:%s", header.Height, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/urfave/cli"
+)
+
+var ReindexCommand = cli.Command{
+	Name:      "reindex",
+	Usage:     "Rebuild the address index of blocks in DB",
+	ArgsUsage: "",
+	Action:    reindexAddress,
+	Flags: []cli.Flag{
+		utils.ReindexStartHeightFlag,
+		utils.DataDirFlag,
+		utils.DBEngineFlag,
+		utils.ConfigFlag,
+		utils.NetworkIdFlag,
+	},
+	Description: "Index the transactions and ONT/ONG transfers of blocks already in DB by address, so that the node can " +
+		"be started with --" + utils.EnableAddressIndexFlag.Name + ". Blocks must not be pruned, and transfers are only " +
+		"indexed if event log was enabled when the blocks were saved. The blocks before start height must have been " +
+		"indexed. Node must be stopped during reindex.",
+}
+
+func reindexAddress(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetOntologyConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+
+	startHeight := uint32(ctx.Uint(utils.GetFlagName(utils.ReindexStartHeightFlag)))
+	currHeight := ledger.DefLedger.GetCurrentBlockHeight()
+	if startHeight > currHeight {
+		PrintWarnMsg("StartHeight:%d larger than CurrentBlockHeight:%d, No blocks to reindex.", startHeight, currHeight)
+		return nil
+	}
+
+	uiprogress.Start()
+	bar := uiprogress.AddBar(int(currHeight - startHeight + 1)).
+		AppendCompleted().
+		AppendElapsed().
+		PrependFunc(func(b *uiprogress.Bar) string {
+			return fmt.Sprintf("Block(%d/%d)", b.Current()+int(startHeight)-1, int(currHeight))
+		})
+
+	PrintInfoMsg("Start reindex blocks.")
+	err = ledger.DefLedger.RebuildAddressIndex(startHeight, func(height uint32) {
+		bar.Incr()
+	})
+	uiprogress.Stop()
+	if err != nil {
+		return fmt.Errorf("RebuildAddressIndex error:%s", err)
+	}
+	PrintInfoMsg("Reindex completed, address index height:%d.", currHeight)
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/urfave/cli"
+)
+
+var SnapshotCommand = cli.Command{
+	Action:    cli.ShowSubcommandHelp,
+	Name:      "snapshot",
+	Usage:     "Export or import the state snapshot of DB",
+	ArgsUsage: "[arguments...]",
+	Description: "Export the states at the current block height to a snapshot file, or bootstrap a new node from a " +
+		"snapshot file without executing the history blocks. Node must be stopped during export and import.",
+	Subcommands: []cli.Command{
+		{
+			Action:    exportSnapshot,
+			Name:      "export",
+			Usage:     "Export the state snapshot at the current block height to a file",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.SnapshotHeightFlag,
+				utils.DataDirFlag,
+				utils.DBEngineFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "The state merkle tree must be available at the current block height, and the headers from " +
+				"the genesis block must be in DB.",
+		},
+		{
+			Action:    importSnapshot,
+			Name:      "import",
+			Usage:     "Import the state snapshot from a file to an empty DB",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.DataDirFlag,
+				utils.DBEngineFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "The blocks before the snapshot height are not imported, the node syncs blocks after the " +
+				"snapshot height from network once started. The snapshot file must come from a trusted source, since the " +
+				"block hashes between the genesis block and the snapshot block are not verified.",
+		},
+	},
+}
+
+func initOfflineLedger(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("SetOntologyConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	return nil
+}
+
+func exportSnapshot(ctx *cli.Context) error {
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	if snapshotFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	err := initOfflineLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledger.DefLedger.Close()
+
+	currHeight := ledger.DefLedger.GetCurrentBlockHeight()
+	height := uint32(ctx.Uint(utils.GetFlagName(utils.SnapshotHeightFlag)))
+	if height != 0 && height != currHeight {
+		return fmt.Errorf("states at height:%d are not available, CurrentBlockHeight:%d", height, currHeight)
+	}
+
+	file, err := os.OpenFile(snapshotFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("OpenFile error:%s", err)
+	}
+	defer file.Close()
+
+	PrintInfoMsg("Start export state snapshot at height:%d.", currHeight)
+	writer, err := utils.NewSnapshotFileWriter(file, currHeight)
+	if err == nil {
+		err = ledger.DefLedger.ExportSnapshot(writer)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(snapshotFile)
+		return fmt.Errorf("export state snapshot error:%s", err)
+	}
+	PrintInfoMsg("Export state snapshot completed, file:%s.", snapshotFile)
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	if snapshotFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	file, err := os.OpenFile(snapshotFile, os.O_RDONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("OpenFile error:%s", err)
+	}
+	defer file.Close()
+
+	PrintInfoMsg("Verify snapshot file checksum.")
+	err = utils.VerifySnapshotFileChecksum(file)
+	if err != nil {
+		return err
+	}
+	reader, err := utils.NewSnapshotFileReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	err = initOfflineLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledger.DefLedger.Close()
+	if currHeight := ledger.DefLedger.GetCurrentBlockHeight(); currHeight != 0 {
+		return fmt.Errorf("snapshot can only be imported to an empty DB, CurrentBlockHeight:%d", currHeight)
+	}
+
+	manifest, blocks, err := reader.ReadManifest()
+	if err != nil {
+		return fmt.Errorf("read snapshot manifest error:%s", err)
+	}
+	blockHashes, txRoots, err := reader.ReadHeaders()
+	if err != nil {
+		return fmt.Errorf("read snapshot headers error:%s", err)
+	}
+	err = ledger.DefLedger.BeginSnapshotImportWithHeaders(manifest, blocks, blockHashes, txRoots)
+	if err != nil {
+		return fmt.Errorf("BeginSnapshotImport error:%s", err)
+	}
+
+	uiprogress.Start()
+	bar := uiprogress.AddBar(int(manifest.KeyCount)).
+		AppendCompleted().
+		AppendElapsed().
+		PrependFunc(func(b *uiprogress.Bar) string {
+			return fmt.Sprintf("State(%d/%d)", b.Current(), manifest.KeyCount)
+		})
+
+	PrintInfoMsg("Start import state snapshot at height:%d.", manifest.Height)
+	count := 0
+	for {
+		kvs, err := reader.ReadStates()
+		if err != nil {
+			uiprogress.Stop()
+			return fmt.Errorf("read snapshot states error:%s", err)
+		}
+		if kvs == nil {
+			break
+		}
+		err = ledger.DefLedger.ImportSnapshotChunk(kvs)
+		if err != nil {
+			uiprogress.Stop()
+			return fmt.Errorf("ImportSnapshotChunk error:%s", err)
+		}
+		count += len(kvs)
+		bar.Set(count)
+	}
+	uiprogress.Stop()
+	err = ledger.DefLedger.FinishSnapshotImport()
+	if err != nil {
+		return fmt.Errorf("FinishSnapshotImport error:%s", err)
+	}
+	PrintInfoMsg("Import state snapshot completed, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
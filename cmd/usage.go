@@ -106,6 +106,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.ETHTxGasLimitFlag,
 			utils.WasmVerifyMethodFlag,
+			utils.EnableEthLogBloomFlag,
+			utils.EnableArchiveStateFlag,
+			utils.EnableAddressIndexFlag,
+			utils.EnableContractEventIndexFlag,
+			utils.EnableSnapshotServeFlag,
+			utils.EnableParallelExecFlag,
+			utils.EnableStateUndoFlag,
+			utils.StateGCHistoryFlag,
+			utils.DBEngineFlag,
 		},
 	},
 	{
@@ -159,6 +168,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MaxConnInBoundFlag,
 			utils.MaxConnOutBoundFlag,
 			utils.MaxConnInBoundForSingleIPFlag,
+			utils.EnableSnapshotSyncFlag,
+			utils.SnapshotSyncManifestFlag,
 		},
 	},
 	{
@@ -169,6 +180,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCLocalEnableFlag,
 			utils.RPCLocalProtFlag,
 			utils.ETHRPCPortFlag,
+			utils.ETHWSPortFlag,
+			utils.ETHWSOriginsFlag,
+			utils.ETHDebugApiFlag,
+			utils.RPCRateLimitFlag,
+			utils.RPCRateBurstFlag,
 		},
 	},
 	{
@@ -177,6 +193,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RestfulEnableFlag,
 			utils.RestfulPortFlag,
 			utils.RestfulMaxConnsFlag,
+			utils.RestfulRateLimitFlag,
+			utils.RestfulRateBurstFlag,
 		},
 	},
 	{
@@ -185,6 +203,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.GraphQLEnableFlag,
 			utils.GraphQLPortFlag,
 			utils.GraphQLMaxConnsFlag,
+			utils.GraphQLWsOriginsFlag,
 		},
 	},
 	{
@@ -256,6 +275,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ExportSpeedFlag,
 			utils.ExportStartHeightFlag,
 			utils.ExportEndHeightFlag,
+			utils.ExportOfflineFlag,
 		},
 	},
 	{
@@ -265,6 +285,27 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ImportEndHeightFlag,
 		},
 	},
+	{
+		Name: "REINDEX",
+		Flags: []cli.Flag{
+			utils.ReindexStartHeightFlag,
+		},
+	},
+	{
+		Name: "SNAPSHOT",
+		Flags: []cli.Flag{
+			utils.SnapshotFileFlag,
+			utils.SnapshotHeightFlag,
+		},
+	},
+	{
+		Name: "DB",
+		Flags: []cli.Flag{
+			utils.RollbackHeightFlag,
+			utils.MigrateTargetDirFlag,
+			utils.VerifyReportFlag,
+		},
+	},
 	{
 		Name: "MISC",
 	},
@@ -0,0 +1,378 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/serialization"
+	"github.com/ontio/ontology/core/types"
+)
+
+//The block file exported from DB is made up of the metadata and the chunks of blocks. Each chunk is framed with the
+//height of its first block, the block count, and the length and sha256 checksum of the compressed chunk data. A frame
+//with no block marks the end of file, a file without it is an interrupted export, which is resumed after the last
+//complete chunk.
+const (
+	BLOCK_FILE_MAGIC         = "ONTBLOCK"
+	BLOCK_FILE_VERSION       = 2
+	BLOCK_CHUNK_FRAME_SIZE   = 4 + 4 + 4 + sha256.Size
+	MAX_BLOCK_CHUNK_SIZE     = 512 * 1024 * 1024
+	DEFAULT_BLOCK_CHUNK_SIZE = 1000 // blocks per chunk
+)
+
+//IsBlockFile return whether the data begins with the magic of block file, the legacy export file does not
+func IsBlockFile(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(BLOCK_FILE_MAGIC))
+	return err == nil && string(magic) == BLOCK_FILE_MAGIC
+}
+
+type BlockFileMetadata struct {
+	Version          byte
+	CompressType     byte
+	StartBlockHeight uint32
+}
+
+func (this *BlockFileMetadata) Serialize(w io.Writer) error {
+	buf := bytes.NewBuffer([]byte(BLOCK_FILE_MAGIC))
+	buf.WriteByte(this.Version)
+	buf.WriteByte(this.CompressType)
+	serialization.WriteUint32(buf, this.StartBlockHeight)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (this *BlockFileMetadata) Deserialize(r io.Reader) error {
+	magic := make([]byte, len(BLOCK_FILE_MAGIC))
+	_, err := io.ReadFull(r, magic)
+	if err != nil {
+		return err
+	}
+	if string(magic) != BLOCK_FILE_MAGIC {
+		return fmt.Errorf("not a block file")
+	}
+	this.Version, err = serialization.ReadByte(r)
+	if err != nil {
+		return err
+	}
+	if this.Version != BLOCK_FILE_VERSION {
+		return fmt.Errorf("unsupported block file version %d", this.Version)
+	}
+	this.CompressType, err = serialization.ReadByte(r)
+	if err != nil {
+		return err
+	}
+	if this.CompressType != COMPRESS_TYPE_ZLIB {
+		return fmt.Errorf("unknown compress type")
+	}
+	this.StartBlockHeight, err = serialization.ReadUint32(r)
+	return err
+}
+
+func blockFileMetadataSize() int64 {
+	return int64(len(BLOCK_FILE_MAGIC) + 1 + 1 + 4)
+}
+
+//BlockRecord is a block in block file with the data saved with it
+type BlockRecord struct {
+	Block         *types.Block
+	CrossChainMsg *types.CrossChainMsg // cross chain msg of the previous block, nil if not exist
+	StateRoot     common.Uint256       // state merkle root after the block, empty if not available
+}
+
+type blockChunkFrame struct {
+	StartBlockHeight uint32
+	Count            uint32
+	Size             uint32
+	Checksum         [sha256.Size]byte
+}
+
+func (this *blockChunkFrame) Serialize(w io.Writer) error {
+	buf := bytes.NewBuffer(nil)
+	serialization.WriteUint32(buf, this.StartBlockHeight)
+	serialization.WriteUint32(buf, this.Count)
+	serialization.WriteUint32(buf, this.Size)
+	buf.Write(this.Checksum[:])
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (this *blockChunkFrame) Deserialize(r io.Reader) error {
+	data := make([]byte, BLOCK_CHUNK_FRAME_SIZE)
+	_, err := io.ReadFull(r, data)
+	if err != nil {
+		return err
+	}
+	source := common.NewZeroCopySource(data)
+	this.StartBlockHeight, _ = source.NextUint32()
+	this.Count, _ = source.NextUint32()
+	this.Size, _ = source.NextUint32()
+	checksum, _ := source.NextBytes(sha256.Size)
+	copy(this.Checksum[:], checksum)
+	if this.Size > MAX_BLOCK_CHUNK_SIZE {
+		return fmt.Errorf("block chunk size %d exceeds limit", this.Size)
+	}
+	return nil
+}
+
+//BlockChunk is a chunk of blocks read from block file, which is decoded separately so that chunks can be decoded in
+//parallel
+type BlockChunk struct {
+	StartBlockHeight uint32
+	Count            uint32
+	compressType     byte
+	checksum         [sha256.Size]byte
+	data             []byte
+}
+
+//EndBlockHeight return the height of the last block in chunk
+func (this *BlockChunk) EndBlockHeight() uint32 {
+	return this.StartBlockHeight + this.Count - 1
+}
+
+//Decode verify the checksum of chunk and decode the blocks in it
+func (this *BlockChunk) Decode() ([]*BlockRecord, error) {
+	if sha256.Sum256(this.data) != this.checksum {
+		return nil, fmt.Errorf("checksum mismatch of block chunk at height %d", this.StartBlockHeight)
+	}
+	data, err := DecompressBlockData(this.data, this.compressType)
+	if err != nil {
+		return nil, err
+	}
+	source := common.NewZeroCopySource(data)
+	records := make([]*BlockRecord, 0, this.Count)
+	for i := uint32(0); i < this.Count; i++ {
+		record, err := decodeBlockRecord(source)
+		if err != nil {
+			return nil, fmt.Errorf("decode block at height %d error %s", this.StartBlockHeight+i, err)
+		}
+		if record.Block.Header.Height != this.StartBlockHeight+i {
+			return nil, fmt.Errorf("block height %d mismatch, expected %d", record.Block.Header.Height,
+				this.StartBlockHeight+i)
+		}
+		records = append(records, record)
+	}
+	if source.Len() != 0 {
+		return nil, fmt.Errorf("unexpected data after blocks of chunk at height %d", this.StartBlockHeight)
+	}
+	return records, nil
+}
+
+func encodeBlockRecord(sink *common.ZeroCopySink, record *BlockRecord) {
+	sink.WriteVarBytes(record.Block.ToArray())
+	sink.WriteBool(record.CrossChainMsg != nil)
+	if record.CrossChainMsg != nil {
+		msg := common.NewZeroCopySink(nil)
+		record.CrossChainMsg.Serialization(msg)
+		sink.WriteVarBytes(msg.Bytes())
+	}
+	sink.WriteHash(record.StateRoot)
+}
+
+func decodeBlockRecord(source *common.ZeroCopySource) (*BlockRecord, error) {
+	raw, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	block, err := types.BlockFromRawBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	record := &BlockRecord{Block: block}
+	hasMsg, irregular, eof := source.NextBool()
+	if irregular || eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if hasMsg {
+		raw, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		record.CrossChainMsg = new(types.CrossChainMsg)
+		if err := record.CrossChainMsg.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+			return nil, err
+		}
+	}
+	record.StateRoot, eof = source.NextHash()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return record, nil
+}
+
+//BlockFileWriter write the blocks exported from DB to a block file chunk by chunk
+type BlockFileWriter struct {
+	file     *os.File
+	buf      *bufio.Writer
+	metadata *BlockFileMetadata
+}
+
+//NewBlockFileWriter write the metadata of block file beginning with the block of startHeight to an empty file
+func NewBlockFileWriter(file *os.File, startHeight uint32) (*BlockFileWriter, error) {
+	this := &BlockFileWriter{file: file, buf: bufio.NewWriter(file)}
+	this.metadata = &BlockFileMetadata{
+		Version:          BLOCK_FILE_VERSION,
+		CompressType:     COMPRESS_TYPE_ZLIB,
+		StartBlockHeight: startHeight,
+	}
+	err := this.metadata.Serialize(this.buf)
+	if err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+//ResumeBlockFileWriter open an interrupted block file beginning with the block of startHeight to append blocks after
+//the last complete chunk, the data after it is truncated. Return the height of the next block to write, and whether
+//the file is already complete.
+func ResumeBlockFileWriter(file *os.File, startHeight uint32) (*BlockFileWriter, uint32, bool, error) {
+	metadata := &BlockFileMetadata{}
+	reader := bufio.NewReader(file)
+	err := metadata.Deserialize(reader)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("block file metadata deserialize error:%s", err)
+	}
+	if metadata.StartBlockHeight != startHeight {
+		return nil, 0, false, fmt.Errorf("block file begins with height %d, not %d", metadata.StartBlockHeight,
+			startHeight)
+	}
+	offset := blockFileMetadataSize()
+	next := metadata.StartBlockHeight
+	for {
+		frame := &blockChunkFrame{}
+		if err := frame.Deserialize(reader); err != nil {
+			break
+		}
+		if frame.Count == 0 {
+			return nil, next, true, nil
+		}
+		if frame.StartBlockHeight != next {
+			break
+		}
+		data := make([]byte, frame.Size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			break
+		}
+		if sha256.Sum256(data) != frame.Checksum {
+			break
+		}
+		offset += BLOCK_CHUNK_FRAME_SIZE + int64(frame.Size)
+		next += frame.Count
+	}
+	if err := file.Truncate(offset); err != nil {
+		return nil, 0, false, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, false, err
+	}
+	return &BlockFileWriter{file: file, buf: bufio.NewWriter(file), metadata: metadata}, next, false, nil
+}
+
+//WriteChunk write the blocks of consecutive heights as a chunk, the chunk is flushed to file after written
+func (this *BlockFileWriter) WriteChunk(records []*BlockRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	for _, record := range records {
+		encodeBlockRecord(sink, record)
+	}
+	data, err := CompressBlockData(sink.Bytes(), this.metadata.CompressType)
+	if err != nil {
+		return err
+	}
+	if len(data) > MAX_BLOCK_CHUNK_SIZE {
+		return fmt.Errorf("block chunk size %d exceeds limit", len(data))
+	}
+	frame := &blockChunkFrame{
+		StartBlockHeight: records[0].Block.Header.Height,
+		Count:            uint32(len(records)),
+		Size:             uint32(len(data)),
+		Checksum:         sha256.Sum256(data),
+	}
+	if err := frame.Serialize(this.buf); err != nil {
+		return err
+	}
+	if _, err := this.buf.Write(data); err != nil {
+		return err
+	}
+	return this.buf.Flush()
+}
+
+//Close write the end of file, the file is not closed
+func (this *BlockFileWriter) Close() error {
+	frame := &blockChunkFrame{}
+	if err := frame.Serialize(this.buf); err != nil {
+		return err
+	}
+	if err := this.buf.Flush(); err != nil {
+		return err
+	}
+	return this.file.Sync()
+}
+
+//BlockFileReader read the chunks of blocks from a block file
+type BlockFileReader struct {
+	Metadata *BlockFileMetadata
+	reader   io.Reader
+	next     uint32
+}
+
+func NewBlockFileReader(r io.Reader) (*BlockFileReader, error) {
+	metadata := &BlockFileMetadata{}
+	err := metadata.Deserialize(r)
+	if err != nil {
+		return nil, fmt.Errorf("block file metadata deserialize error:%s", err)
+	}
+	return &BlockFileReader{Metadata: metadata, reader: r, next: metadata.StartBlockHeight}, nil
+}
+
+//ReadChunk return the next chunk of blocks, nil if the end of file is reached. The chunk is not verified until decoded.
+func (this *BlockFileReader) ReadChunk() (*BlockChunk, error) {
+	frame := &blockChunkFrame{}
+	if err := frame.Deserialize(this.reader); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("block file is incomplete, end of file not found after height %d", this.next)
+		}
+		return nil, err
+	}
+	if frame.Count == 0 {
+		return nil, nil
+	}
+	if frame.StartBlockHeight != this.next {
+		return nil, fmt.Errorf("block chunk height %d mismatch, expected %d", frame.StartBlockHeight, this.next)
+	}
+	data := make([]byte, frame.Size)
+	if _, err := io.ReadFull(this.reader, data); err != nil {
+		return nil, fmt.Errorf("read block chunk at height %d error:%s", frame.StartBlockHeight, err)
+	}
+	this.next += frame.Count
+	return &BlockChunk{
+		StartBlockHeight: frame.StartBlockHeight,
+		Count:            frame.Count,
+		compressType:     this.Metadata.CompressType,
+		checksum:         frame.Checksum,
+		data:             data,
+	}, nil
+}
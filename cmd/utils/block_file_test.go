@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBlockRecord(height uint32) *BlockRecord {
+	block := &types.Block{Header: &types.Header{Height: height, Timestamp: height}}
+	block.RebuildMerkleRoot()
+	record := &BlockRecord{Block: block, StateRoot: common.Uint256{byte(height)}}
+	if height%2 == 0 {
+		record.CrossChainMsg = &types.CrossChainMsg{Height: height - 1, StatesRoot: common.Uint256{1},
+			SigData: [][]byte{{1, 2}}}
+	}
+	return record
+}
+
+func TestBlockFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "blocks")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var records []*BlockRecord
+	for h := uint32(5); h < 10; h++ {
+		records = append(records, newTestBlockRecord(h))
+	}
+	writer, err := NewBlockFileWriter(file, 5)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.WriteChunk(records[:2]))
+	assert.Nil(t, writer.WriteChunk(records[2:3]))
+	assert.Nil(t, writer.WriteChunk(records[3:]))
+	// interrupted while writing the last chunk
+	info, err := file.Stat()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Truncate(info.Size()-3))
+
+	_, err = file.Seek(0, 0)
+	assert.Nil(t, err)
+	writer, next, complete, err := ResumeBlockFileWriter(file, 5)
+	assert.Nil(t, err)
+	assert.False(t, complete)
+	assert.Equal(t, uint32(8), next)
+	assert.Nil(t, writer.WriteChunk(records[3:]))
+	assert.Nil(t, writer.Close())
+
+	_, err = file.Seek(0, 0)
+	assert.Nil(t, err)
+	_, next, complete, err = ResumeBlockFileWriter(file, 5)
+	assert.Nil(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, uint32(10), next)
+
+	_, err = file.Seek(0, 0)
+	assert.Nil(t, err)
+	r := bufio.NewReader(file)
+	assert.True(t, IsBlockFile(r))
+	reader, err := NewBlockFileReader(r)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(5), reader.Metadata.StartBlockHeight)
+	var imported []*BlockRecord
+	for {
+		chunk, err := reader.ReadChunk()
+		assert.Nil(t, err)
+		if chunk == nil {
+			break
+		}
+		decoded, err := chunk.Decode()
+		assert.Nil(t, err)
+		assert.Equal(t, chunk.EndBlockHeight(), decoded[len(decoded)-1].Block.Header.Height)
+		imported = append(imported, decoded...)
+	}
+	assert.Equal(t, len(records), len(imported))
+	for i, record := range records {
+		assert.Equal(t, record.Block.Hash(), imported[i].Block.Hash())
+		assert.Equal(t, record.StateRoot, imported[i].StateRoot)
+		if record.CrossChainMsg == nil {
+			assert.Nil(t, imported[i].CrossChainMsg)
+		} else {
+			assert.Equal(t, record.CrossChainMsg.Hash(), imported[i].CrossChainMsg.Hash())
+		}
+	}
+
+	// corrupted chunk data is detected by checksum
+	_, err = file.WriteAt([]byte{0xff}, blockFileMetadataSize()+BLOCK_CHUNK_FRAME_SIZE)
+	assert.Nil(t, err)
+	_, err = file.Seek(0, 0)
+	assert.Nil(t, err)
+	reader, err = NewBlockFileReader(bufio.NewReader(file))
+	assert.Nil(t, err)
+	chunk, err := reader.ReadChunk()
+	assert.Nil(t, err)
+	_, err = chunk.Decode()
+	assert.NotNil(t, err)
+}
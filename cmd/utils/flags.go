@@ -29,6 +29,7 @@ import (
 
 const (
 	DEFAULT_EXPORT_FILE   = "./OntBlocks.dat"
+	DEFAULT_SNAPSHOT_FILE = "./OntSnapshot.dat"
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
 	DEFAULT_WALLET_PATH   = "./wallet_data"
@@ -62,6 +63,46 @@ var (
 		Name:  "enable-wasmjit-verifier",
 		Usage: "Enable wasmjit verifier to verify wasm contract",
 	}
+	EnableEthLogBloomFlag = cli.BoolFlag{
+		Name:  "enable-eth-log-bloom",
+		Usage: "Build the bloom index of evm logs for each block to speed up eth_getLogs",
+	}
+	EnableArchiveStateFlag = cli.BoolFlag{
+		Name:  "enable-archive-state",
+		Usage: "Keep the state changes of each block to serve state queries at past heights, like eth_call with a block number",
+	}
+	EnableAddressIndexFlag = cli.BoolFlag{
+		Name:  "enable-address-index",
+		Usage: "Index the transactions and ONT/ONG transfers of each block by address, requires event log. The blocks already in DB must be indexed by reindex first",
+	}
+	EnableContractEventIndexFlag = cli.BoolFlag{
+		Name:  "enable-contract-event-index",
+		Usage: "Index the notifies of each block by contract address to query the events of a contract between heights",
+	}
+	EnableSnapshotServeFlag = cli.BoolFlag{
+		Name:  "enable-snapshot-serve",
+		Usage: "Take a state snapshot every 10000 blocks and serve it to the nodes doing snapshot sync",
+	}
+	EnableParallelExecFlag = cli.BoolFlag{
+		Name:  "enable-parallel-exec",
+		Usage: "Execute the transactions of a block in parallel, conflicting transactions are re-executed in order",
+	}
+	EnableStateUndoFlag = cli.BoolFlag{
+		Name: "enable-state-undo",
+		Usage: "Save the undo log of the latest 10000 blocks to roll back the DB with \"db rollback\", which costs " +
+			"a read of every key written by a block and the disk space of 10000 blocks of state changes",
+	}
+	StateGCHistoryFlag = cli.UintFlag{
+		Name: "state-gc-history",
+		Usage: "Remove in background the states no longer needed by the latest `<number>` blocks, like the state " +
+			"merkle roots of pruned blocks. Minimum is 10000, 0 disables state gc. Not available with archive state",
+	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db-engine",
+		Value: config.DEFAULT_DB_ENGINE,
+		Usage: "Storage `<engine>` of a new ledger, leveldb or pebble (only in the builds with pebble tag). " +
+			"An existing ledger must be opened with the engine it is created with",
+	}
 	WalletFileFlag = cli.StringFlag{
 		Name:  "wallet,w",
 		Value: config.DEFAULT_WALLET_FILE_NAME,
@@ -77,6 +118,31 @@ var (
 		Usage: "Stop import block `<height>` of the import.",
 		Value: DEFAULT_EXPORT_HEIGHT,
 	}
+	ReindexStartHeightFlag = cli.UintFlag{
+		Name:  "start-height",
+		Usage: "Start block height `<number>` to reindex",
+	}
+	SnapshotFileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "Path of state snapshot `<file>`",
+		Value: DEFAULT_SNAPSHOT_FILE,
+	}
+	SnapshotHeightFlag = cli.UintFlag{
+		Name:  "height",
+		Usage: "Block `<height>` of the state snapshot to export, must be the current block height. Default is the current block height",
+	}
+	RollbackHeightFlag = cli.UintFlag{
+		Name:  "height",
+		Usage: "Block `<height>` to roll back the DB to",
+	}
+	MigrateTargetDirFlag = cli.StringFlag{
+		Name:  "target-dir",
+		Usage: "Data `<path>` to save the migrated DB to, must not exist or be empty",
+	}
+	VerifyReportFlag = cli.StringFlag{
+		Name:  "report",
+		Usage: "Write the verify report in JSON to `<file>`. Default is printing it to stdout",
+	}
 	DataDirFlag = cli.StringFlag{
 		Name:  "data-dir",
 		Usage: "Block data storage `<path>`",
@@ -158,6 +224,14 @@ var (
 		Usage: "Max connection `<number>` in bound for single ip",
 		Value: config.DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP,
 	}
+	EnableSnapshotSyncFlag = cli.BoolFlag{
+		Name:  "enable-snapshot-sync",
+		Usage: "Download the recent states from peers instead of executing all the blocks when the node starts with an empty ledger. Requires --snapshot-sync-manifest",
+	}
+	SnapshotSyncManifestFlag = cli.StringFlag{
+		Name:  "snapshot-sync-manifest",
+		Usage: "Hash of the snapshot manifest obtained from a trusted node, the only `<hash>` accepted by snapshot sync. Every state chunk downloaded is verified against it",
+	}
 	// RPC settings
 	RPCDisabledFlag = cli.BoolFlag{
 		Name:  "disable-rpc",
@@ -173,6 +247,26 @@ var (
 		Usage: "Eth json rpc server listening port `<number>`",
 		Value: config.DEFAULT_ETH_RPC_PORT,
 	}
+	ETHWSPortFlag = cli.UintFlag{
+		Name:  "ethwsport",
+		Usage: "Eth json rpc websocket server listening port `<number>`, disabled by default",
+	}
+	ETHWSOriginsFlag = cli.StringFlag{
+		Name:  "ethwsorigins",
+		Usage: "Comma separated `<origins>` allowed to connect the eth json rpc websocket server, * for any, default to localhost",
+	}
+	ETHDebugApiFlag = cli.BoolFlag{
+		Name:  "ethdebugapi",
+		Usage: "Serve the debug_traceTransaction and debug_traceCall methods on the eth json rpc servers, and the tracetransaction method on the json rpc server. They re-execute the transactions, so only enable it on nodes not open to untrusted clients",
+	}
+	RPCRateLimitFlag = cli.UintFlag{
+		Name:  "rpc-rate-limit",
+		Usage: "Json rpc requests `<number>` per second of each method from each ip, 0 means no limit",
+	}
+	RPCRateBurstFlag = cli.UintFlag{
+		Name:  "rpc-rate-burst",
+		Usage: "Json rpc burst requests `<number>` of each method from each ip, default to the limit of one second",
+	}
 	RPCLocalEnableFlag = cli.BoolFlag{
 		Name:  "localrpc",
 		Usage: "Enable local rpc server",
@@ -209,6 +303,14 @@ var (
 		Usage: "Restful server maximum connections `<number>`",
 		Value: config.DEFAULT_HTTP_MAX_CONN,
 	}
+	RestfulRateLimitFlag = cli.UintFlag{
+		Name:  "rest-rate-limit",
+		Usage: "Restful requests `<number>` per second of each api from each ip, 0 means no limit",
+	}
+	RestfulRateBurstFlag = cli.UintFlag{
+		Name:  "rest-rate-burst",
+		Usage: "Restful burst requests `<number>` of each api from each ip, default to the limit of one second",
+	}
 
 	//GraphQL setting
 	GraphQLEnableFlag = cli.BoolFlag{
@@ -225,6 +327,10 @@ var (
 		Usage: "GraphQL server maximum connections `<number>`",
 		Value: config.DEFAULT_HTTP_MAX_CONN,
 	}
+	GraphQLWsOriginsFlag = cli.StringFlag{
+		Name:  "graphql-ws-origins",
+		Usage: "Comma separated `<origins>` allowed to connect the graphql subscription websocket, * for any, default to localhost",
+	}
 
 	//Account setting
 	AccountPassFlag = cli.StringFlag{
@@ -497,6 +603,11 @@ var (
 		Usage: "Stop block height `<number>` to export",
 		Value: DEFAULT_EXPORT_HEIGHT,
 	}
+	ExportOfflineFlag = cli.BoolFlag{
+		Name: "offline",
+		Usage: "Export blocks from the DB in data dir with the node stopped, in the chunked format with checksums. " +
+			"An interrupted export is resumed by running it again with the same file and start height",
+	}
 	ExportSpeedFlag = cli.StringFlag{
 		Name:  "export-speed",
 		Usage: "Export block speed `<level>` (h|m|l), h for high speed, m for middle speed and l for low speed",
@@ -0,0 +1,305 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/serialization"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+//The snapshot file is made up of the metadata, the zlib compressed records of the manifest, headers and states,
+//and the sha256 checksum of all the data before it
+const (
+	SNAPSHOT_FILE_MAGIC    = "ONTSNAPS"
+	SNAPSHOT_FILE_VERSION  = 1
+	SNAPSHOT_CHECKSUM_SIZE = sha256.Size
+)
+
+type SnapshotFileMetadata struct {
+	Version      byte
+	CompressType byte
+	Height       uint32
+}
+
+func (this *SnapshotFileMetadata) Serialize(w io.Writer) error {
+	buf := bytes.NewBuffer([]byte(SNAPSHOT_FILE_MAGIC))
+	buf.WriteByte(this.Version)
+	buf.WriteByte(this.CompressType)
+	serialization.WriteUint32(buf, this.Height)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (this *SnapshotFileMetadata) Deserialize(r io.Reader) error {
+	magic := make([]byte, len(SNAPSHOT_FILE_MAGIC))
+	_, err := io.ReadFull(r, magic)
+	if err != nil {
+		return err
+	}
+	if string(magic) != SNAPSHOT_FILE_MAGIC {
+		return fmt.Errorf("not a snapshot file")
+	}
+	this.Version, err = serialization.ReadByte(r)
+	if err != nil {
+		return err
+	}
+	if this.Version != SNAPSHOT_FILE_VERSION {
+		return fmt.Errorf("unsupported snapshot file version %d", this.Version)
+	}
+	this.CompressType, err = serialization.ReadByte(r)
+	if err != nil {
+		return err
+	}
+	if this.CompressType != COMPRESS_TYPE_ZLIB {
+		return fmt.Errorf("unknown compress type")
+	}
+	this.Height, err = serialization.ReadUint32(r)
+	return err
+}
+
+//SnapshotFileWriter write the state snapshot exported from the ledger to a snapshot file
+type SnapshotFileWriter struct {
+	file     *os.File
+	checksum hash.Hash
+	buf      *bufio.Writer
+	zlib     *zlib.Writer
+}
+
+func NewSnapshotFileWriter(file *os.File, height uint32) (*SnapshotFileWriter, error) {
+	this := &SnapshotFileWriter{file: file, checksum: sha256.New()}
+	this.buf = bufio.NewWriter(io.MultiWriter(file, this.checksum))
+	metadata := &SnapshotFileMetadata{Version: SNAPSHOT_FILE_VERSION, CompressType: COMPRESS_TYPE_ZLIB, Height: height}
+	err := metadata.Serialize(this.buf)
+	if err != nil {
+		return nil, err
+	}
+	this.zlib = zlib.NewWriter(this.buf)
+	return this, nil
+}
+
+func (this *SnapshotFileWriter) writeRecord(sink *common.ZeroCopySink) error {
+	return serialization.WriteVarBytes(this.zlib, sink.Bytes())
+}
+
+func (this *SnapshotFileWriter) WriteManifest(manifest *scom.SnapshotManifest, blocks []*types.Block) error {
+	sink := common.NewZeroCopySink(nil)
+	manifest.Serialization(sink)
+	sink.WriteVarUint(uint64(len(blocks)))
+	for _, block := range blocks {
+		sink.WriteVarBytes(block.ToArray())
+	}
+	return this.writeRecord(sink)
+}
+
+func (this *SnapshotFileWriter) WriteHeaders(blockHashes []common.Uint256, txRoots []common.Uint256) error {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(blockHashes)))
+	for i := range blockHashes {
+		sink.WriteHash(blockHashes[i])
+		sink.WriteHash(txRoots[i])
+	}
+	return this.writeRecord(sink)
+}
+
+func (this *SnapshotFileWriter) WriteStates(kvs []*scom.SnapshotKV) error {
+	if len(kvs) == 0 {
+		return nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(kvs)))
+	for _, kv := range kvs {
+		kv.Serialization(sink)
+	}
+	return this.writeRecord(sink)
+}
+
+//Close write the end of states and the checksum, the file is not closed
+func (this *SnapshotFileWriter) Close() error {
+	err := serialization.WriteVarBytes(this.zlib, nil)
+	if err != nil {
+		return err
+	}
+	err = this.zlib.Close()
+	if err != nil {
+		return err
+	}
+	err = this.buf.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = this.file.Write(this.checksum.Sum(nil))
+	return err
+}
+
+//VerifySnapshotFileChecksum check the checksum at the end of the snapshot file
+func VerifySnapshotFileChecksum(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size() - SNAPSHOT_CHECKSUM_SIZE
+	if size < 0 {
+		return fmt.Errorf("snapshot file is truncated")
+	}
+	checksum := sha256.New()
+	_, err = io.Copy(checksum, io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return err
+	}
+	expected := make([]byte, SNAPSHOT_CHECKSUM_SIZE)
+	_, err = file.ReadAt(expected, size)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, checksum.Sum(nil)) {
+		return fmt.Errorf("snapshot file checksum mismatch")
+	}
+	return nil
+}
+
+//SnapshotFileReader read the state snapshot from a snapshot file
+type SnapshotFileReader struct {
+	Metadata *SnapshotFileMetadata
+	zlib     io.ReadCloser
+}
+
+func NewSnapshotFileReader(r io.Reader) (*SnapshotFileReader, error) {
+	reader := bufio.NewReader(r)
+	metadata := &SnapshotFileMetadata{}
+	err := metadata.Deserialize(reader)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot file metadata deserialize error:%s", err)
+	}
+	zlibReader, err := zlib.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("zlib.NewReader error %s", err)
+	}
+	return &SnapshotFileReader{Metadata: metadata, zlib: zlibReader}, nil
+}
+
+func (this *SnapshotFileReader) readRecord() (*common.ZeroCopySource, error) {
+	data, err := serialization.ReadVarBytes(this.zlib)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewZeroCopySource(data), nil
+}
+
+func (this *SnapshotFileReader) ReadManifest() (*scom.SnapshotManifest, []*types.Block, error) {
+	source, err := this.readRecord()
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest := &scom.SnapshotManifest{}
+	err = manifest.Deserialization(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest.Height != this.Metadata.Height {
+		return nil, nil, fmt.Errorf("snapshot height %d mismatch with metadata height %d", manifest.Height,
+			this.Metadata.Height)
+	}
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	var blocks []*types.Block
+	for i := uint64(0); i < count; i++ {
+		raw, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		block, err := types.BlockFromRawBytes(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return manifest, blocks, nil
+}
+
+//ReadHeaders return the block hashes and transaction roots from the genesis block to the snapshot block
+func (this *SnapshotFileReader) ReadHeaders() ([]common.Uint256, []common.Uint256, error) {
+	total := uint64(this.Metadata.Height) + 1
+	blockHashes := make([]common.Uint256, 0, total)
+	txRoots := make([]common.Uint256, 0, total)
+	for uint64(len(blockHashes)) < total {
+		source, err := this.readRecord()
+		if err != nil {
+			return nil, nil, err
+		}
+		count, _, irregular, eof := source.NextVarUint()
+		if irregular || eof || count == 0 || uint64(len(blockHashes))+count > total {
+			return nil, nil, fmt.Errorf("invalid headers count")
+		}
+		for i := uint64(0); i < count; i++ {
+			blockHash, eof := source.NextHash()
+			if eof {
+				return nil, nil, io.ErrUnexpectedEOF
+			}
+			txRoot, eof := source.NextHash()
+			if eof {
+				return nil, nil, io.ErrUnexpectedEOF
+			}
+			blockHashes = append(blockHashes, blockHash)
+			txRoots = append(txRoots, txRoot)
+		}
+	}
+	return blockHashes, txRoots, nil
+}
+
+//ReadStates return the next chunk of states in key order, nil if all the states are read
+func (this *SnapshotFileReader) ReadStates() ([]*scom.SnapshotKV, error) {
+	source, err := this.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	if source.Len() == 0 {
+		return nil, nil
+	}
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var kvs []*scom.SnapshotKV
+	for i := uint64(0); i < count; i++ {
+		kv := &scom.SnapshotKV{}
+		err := kv.Deserialization(source)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, nil
+}
+
+func (this *SnapshotFileReader) Close() error {
+	return this.zlib.Close()
+}
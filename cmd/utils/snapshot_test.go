@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "snapshot")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	manifest := &scom.SnapshotManifest{
+		Height:     2,
+		BlockHash:  common.Uint256{1},
+		KeyCount:   3,
+		Bookkeeper: &states.BookkeeperState{},
+	}
+	blockHashes := []common.Uint256{{0}, {1}, {2}}
+	txRoots := []common.Uint256{{3}, {4}, {5}}
+	kvs := []*scom.SnapshotKV{
+		{Key: []byte{byte(scom.ST_CONTRACT), 1}, Value: []byte{1}},
+		{Key: []byte{byte(scom.ST_STORAGE), 1}, Value: []byte{2}},
+		{Key: []byte{byte(scom.ST_STORAGE), 2}, Value: []byte{3}},
+	}
+	writer, err := NewSnapshotFileWriter(file, manifest.Height)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.WriteManifest(manifest, nil))
+	assert.Nil(t, writer.WriteHeaders(blockHashes[:2], txRoots[:2]))
+	assert.Nil(t, writer.WriteHeaders(blockHashes[2:], txRoots[2:]))
+	assert.Nil(t, writer.WriteStates(kvs[:1]))
+	assert.Nil(t, writer.WriteStates(kvs[1:]))
+	assert.Nil(t, writer.Close())
+
+	assert.Nil(t, VerifySnapshotFileChecksum(file))
+	_, err = file.Seek(0, 0)
+	assert.Nil(t, err)
+	reader, err := NewSnapshotFileReader(file)
+	assert.Nil(t, err)
+	defer reader.Close()
+	assert.Equal(t, manifest.Height, reader.Metadata.Height)
+	m, blocks, err := reader.ReadManifest()
+	assert.Nil(t, err)
+	assert.Equal(t, manifest.Hash(), m.Hash())
+	assert.Equal(t, 0, len(blocks))
+	hashes, roots, err := reader.ReadHeaders()
+	assert.Nil(t, err)
+	assert.Equal(t, blockHashes, hashes)
+	assert.Equal(t, txRoots, roots)
+	var imported []*scom.SnapshotKV
+	for {
+		chunk, err := reader.ReadStates()
+		assert.Nil(t, err)
+		if chunk == nil {
+			break
+		}
+		imported = append(imported, chunk...)
+	}
+	assert.Equal(t, kvs, imported)
+
+	info, err := file.Stat()
+	assert.Nil(t, err)
+	_, err = file.WriteAt([]byte{0}, info.Size()/2)
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifySnapshotFileChecksum(file))
+}
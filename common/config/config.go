@@ -51,10 +51,12 @@ const (
 	DBFT_MIN_NODE_NUM        = 4 //min node number of dbft consensus
 	SOLO_MIN_NODE_NUM        = 1 //min node number of solo consensus
 	VBFT_MIN_NODE_NUM        = 4 //min node number of vbft consensus
+	SBFT_MIN_NODE_NUM        = 4 //min node number of sbft consensus
 
 	CONSENSUS_TYPE_DBFT = "dbft"
 	CONSENSUS_TYPE_SOLO = "solo"
 	CONSENSUS_TYPE_VBFT = "vbft"
+	CONSENSUS_TYPE_SBFT = "sbft"
 
 	DEFAULT_LOG_LEVEL                       = log.InfoLog
 	DEFAULT_ETH_RPC_PORT                    = 20339
@@ -81,6 +83,7 @@ const (
 
 	DEFAULT_DATA_DIR      = "./Chain/"
 	DEFAULT_RESERVED_FILE = "./peers.rsv"
+	DEFAULT_DB_ENGINE     = "leveldb"
 
 	//DEFAULT_ETH_BLOCK_GAS_LIMIT = 800000000
 	DEFAULT_ETH_TX_MAX_GAS_LIMIT = 6000000
@@ -250,6 +253,50 @@ func GetTrackDestroyedContractHeight() uint32 {
 	}
 }
 
+func GetEthTypedTxHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_ETH_TYPED_TX_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_ETH_TYPED_TX_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetStorageFindHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_STORAGE_FIND_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_STORAGE_FIND_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetSubmitEvidenceHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_SUBMIT_EVIDENCE_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_SUBMIT_EVIDENCE_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetRotatePeerKeyHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_ROTATE_PEER_KEY_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_ROTATE_PEER_KEY_POLARIS
+	default:
+		return 0
+	}
+}
+
 // the end of unbound timestamp offset from genesis block's timestamp
 func GetGovUnboundDeadline() (uint32, uint64) {
 	count := uint64(0)
@@ -351,6 +398,7 @@ var PolarisConfig = &GenesisConfig{
 	},
 	DBFT: &DBFTConfig{},
 	SOLO: &SOLOConfig{},
+	SBFT: &SBFTConfig{},
 }
 
 var MainNetConfig = &GenesisConfig{
@@ -414,6 +462,7 @@ var MainNetConfig = &GenesisConfig{
 	},
 	DBFT: &DBFTConfig{},
 	SOLO: &SOLOConfig{},
+	SBFT: &SBFTConfig{},
 }
 
 var DefConfig = NewOntologyConfig()
@@ -424,6 +473,7 @@ type GenesisConfig struct {
 	VBFT          *VBFTConfig
 	DBFT          *DBFTConfig
 	SOLO          *SOLOConfig
+	SBFT          *SBFTConfig
 }
 
 func NewGenesisConfig() *GenesisConfig {
@@ -433,6 +483,7 @@ func NewGenesisConfig() *GenesisConfig {
 		VBFT:          &VBFTConfig{},
 		DBFT:          &DBFTConfig{},
 		SOLO:          &SOLOConfig{},
+		SBFT:          &SBFTConfig{},
 	}
 }
 
@@ -626,6 +677,14 @@ type SOLOConfig struct {
 	Bookkeepers  []string
 }
 
+//SBFTConfig is the genesis config of the hotstuff style sbft consensus, the leader of a view proposes
+//a block at most every GenBlockTime seconds and a view is abandoned after ViewTimeout seconds
+type SBFTConfig struct {
+	GenBlockTime uint
+	ViewTimeout  uint
+	Bookkeepers  []string
+}
+
 type CommonConfig struct {
 	LogLevel       uint
 	NodeType       string
@@ -636,7 +695,16 @@ type CommonConfig struct {
 	DataDir        string
 	ETHTxGasLimit  uint64
 	//NGasLimit        uint64
-	WasmVerifyMethod VerifyMethod
+	WasmVerifyMethod         VerifyMethod
+	EnableEthLogBloom        bool
+	EnableArchiveState       bool
+	EnableAddressIndex       bool
+	EnableContractEventIndex bool
+	EnableSnapshotServe      bool
+	EnableParallelExec       bool
+	EnableStateUndo          bool
+	StateGCHistory           uint32
+	DBEngine                 string
 }
 
 type ConsensusConfig struct {
@@ -666,6 +734,8 @@ type P2PNodeConfig struct {
 	MaxConnOutBound           uint
 	MaxConnInBoundForSingleIP uint
 	EVMChainId                uint32
+	EnableSnapshotSync        bool
+	SnapshotSyncManifest      string
 }
 
 type RpcConfig struct {
@@ -673,6 +743,11 @@ type RpcConfig struct {
 	HttpJsonPort      uint
 	HttpLocalPort     uint
 	EthJsonPort       uint
+	EthWsPort         uint     // 0 to disable the eth websocket server
+	EthWsOrigins      []string // origins allowed to connect the eth websocket server, localhost if empty, * for any
+	EthDebugApi       bool     // serve the debug namespace on the eth json rpc servers
+	HttpRateLimit     uint     // requests per second of each method from each ip, 0 to disable
+	HttpRateBurst     uint
 }
 
 type RestfulConfig struct {
@@ -681,12 +756,15 @@ type RestfulConfig struct {
 	HttpMaxConnections uint
 	HttpCertPath       string
 	HttpKeyPath        string
+	HttpRateLimit      uint // requests per second of each api from each ip, 0 to disable
+	HttpRateBurst      uint
 }
 
 type GraphQLConfig struct {
 	EnableGraphQL  bool
 	GraphQLPort    uint
 	MaxConnections uint
+	WsOrigins      []string // origins allowed to connect the subscription websocket, localhost if empty, * for any
 }
 
 type WebSocketConfig struct {
@@ -718,6 +796,7 @@ func NewOntologyConfig() *OntologyConfig {
 			DataDir:          DEFAULT_DATA_DIR,
 			WasmVerifyMethod: InterpVerifyMethod,
 			ETHTxGasLimit:    DEFAULT_ETH_TX_MAX_GAS_LIMIT,
+			DBEngine:         DEFAULT_DB_ENGINE,
 		},
 		Consensus: &ConsensusConfig{
 			EnableConsensus: true,
@@ -745,6 +824,7 @@ func NewOntologyConfig() *OntologyConfig {
 			EnableHttpJsonRpc: true,
 			HttpJsonPort:      DEFAULT_RPC_PORT,
 			HttpLocalPort:     DEFAULT_RPC_LOCAL_PORT,
+			EthJsonPort:       DEFAULT_ETH_RPC_PORT,
 		},
 		Restful: &RestfulConfig{
 			EnableHttpRestful: true,
@@ -772,6 +852,8 @@ func (this *OntologyConfig) GetBookkeepers() ([]keypair.PublicKey, error) {
 		bookKeepers = this.Genesis.DBFT.Bookkeepers
 	case CONSENSUS_TYPE_SOLO:
 		bookKeepers = this.Genesis.SOLO.Bookkeepers
+	case CONSENSUS_TYPE_SBFT:
+		bookKeepers = this.Genesis.SBFT.Bookkeepers
 	default:
 		return nil, fmt.Errorf("Does not support %s consensus", this.Genesis.ConsensusType)
 	}
@@ -822,6 +904,8 @@ func (this *OntologyConfig) getDefNetworkIDFromGenesisConfig(genCfg *GenesisConf
 		configData, err = json.Marshal(genCfg.VBFT)
 	case CONSENSUS_TYPE_DBFT:
 		configData, err = json.Marshal(genCfg.DBFT)
+	case CONSENSUS_TYPE_SBFT:
+		configData, err = json.Marshal(genCfg.SBFT)
 	case CONSENSUS_TYPE_SOLO:
 		return NETWORK_ID_SOLO_NET, nil
 	default:
@@ -103,3 +103,19 @@ const BLOCKHEIGHT_NEW_PEER_COST_POLARIS = 13400000
 
 const BLOCKHEIGHT_TRACK_DESTROYED_CONTRACT_MAINNET = 11600000
 const BLOCKHEIGHT_TRACK_DESTROYED_CONTRACT_POLARIS = 14100000
+
+//eip-2930 and eip-1559 eth transaction height, not activated yet on main net and polaris
+const BLOCKHEIGHT_ETH_TYPED_TX_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_ETH_TYPED_TX_POLARIS = 0xFFFFFFFF
+
+//range and reverse storage find of neovm and wasm contracts, not activated yet on main net and polaris
+const BLOCKHEIGHT_STORAGE_FIND_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_STORAGE_FIND_POLARIS = 0xFFFFFFFF
+
+//submitEvidence of governance contract, not activated yet on main net and polaris
+const BLOCKHEIGHT_SUBMIT_EVIDENCE_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_SUBMIT_EVIDENCE_POLARIS = 0xFFFFFFFF
+
+//rotatePeerKey of governance contract, not activated yet on main net and polaris
+const BLOCKHEIGHT_ROTATE_PEER_KEY_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_ROTATE_PEER_KEY_POLARIS = 0xFFFFFFFF
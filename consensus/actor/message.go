@@ -23,6 +23,40 @@ import "github.com/ontio/ontology/core/types"
 type StartConsensus struct{}
 type StopConsensus struct{}
 
+//GetConsensusStatus queries the round state of the consensus service, only answered by vbft
+type GetConsensusStatus struct{}
+
+//RoundStatus is the number of consensus messages in the msg pool of vbft for a block
+type RoundStatus struct {
+	BlockNum     uint32
+	Proposals    int
+	Endorsements int
+	Commits      int
+}
+
+type PeerStatus struct {
+	Index             uint32
+	PubKey            string
+	Connected         bool
+	CommittedBlockNum uint32
+	HeartbeatAge      float64 //seconds since the last handshake or heartbeat, -1 if never heard of
+}
+
+//ConsensusStatus is the snapshot of the server answered to GetConsensusStatus
+type ConsensusStatus struct {
+	Index             uint32
+	State             string
+	CurrentBlockNum   uint32
+	CommittedBlockNum uint32
+	ChainConfigView   uint32
+	ProposerIndex     uint32 //leader of the current round, math.MaxUint32 before the round is configured
+	Rounds            []*RoundStatus
+	TimerEvents       map[string]uint64
+	CatchConsensus    uint64
+	RestartSyncing    uint64
+	Peers             []*PeerStatus
+}
+
 //internal Message
 type TimeOut struct{}
 type BlockCompleted struct {
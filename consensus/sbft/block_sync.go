@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"time"
+
+	"github.com/ontio/ontology/common/log"
+)
+
+//syncRoot moves the root of the block tree to the ledger when blocks were saved by the p2p block sync
+func (this *SbftService) syncRoot() {
+	height := this.ledger.GetCurrentBlockHeight()
+	if height <= this.tree.root.height() {
+		return
+	}
+	block, err := this.ledger.GetBlockByHeight(height)
+	if err != nil {
+		log.Errorf("sbft get block %d error: %s", height, err)
+		return
+	}
+	if node := this.tree.get(block.Hash()); node != nil {
+		this.tree.setRoot(node)
+	} else {
+		this.tree = newBlockTree(&blockNode{block: block, view: blockView(block.Header)})
+	}
+	if this.tree.get(this.highQC.BlockHash) == nil || this.highQC.Height < height {
+		this.highQC = committedQC(this.tree.root)
+	}
+	this.enterView(this.tree.root.view + 1)
+}
+
+//requestSync asks the bookkeeper for the blocks the node misses, at most one request is sent every block
+//interval unless the previous response was full
+func (this *SbftService) requestSync(index uint16) {
+	if int(index) == this.index || time.Since(this.lastSync) < this.genBlockTime {
+		return
+	}
+	this.lastSync = time.Now()
+	this.sendTo(index, &SyncRequest{StartHeight: this.ledger.GetCurrentBlockHeight() + 1})
+}
+
+func (this *SbftService) onSyncRequest(index uint16, req *SyncRequest) {
+	start := req.StartHeight
+	if start == 0 {
+		start = 1
+	}
+	height := this.ledger.GetCurrentBlockHeight()
+	resp := &SyncResponse{}
+	for h := start; h <= height && len(resp.Blocks) < MAX_SYNC_BLOCKS; h++ {
+		block, err := this.ledger.GetBlockByHeight(h)
+		if err != nil {
+			log.Errorf("sbft get block %d error: %s", h, err)
+			return
+		}
+		resp.Blocks = append(resp.Blocks, block)
+	}
+	if start+uint32(len(resp.Blocks)) > height {
+		if node := this.tree.get(this.highQC.BlockHash); node != nil {
+			branch, _ := this.tree.branch(node)
+			for _, b := range branch {
+				if b.qc == nil || len(resp.PendingBlocks) == MAX_SYNC_BLOCKS {
+					break
+				}
+				resp.PendingBlocks = append(resp.PendingBlocks, b.block)
+				resp.PendingQCs = append(resp.PendingQCs, b.qc)
+			}
+		}
+	}
+	this.sendTo(index, resp)
+}
+
+func (this *SbftService) onSyncResponse(index uint16, resp *SyncResponse) {
+	for _, block := range resp.Blocks {
+		if block.Header.Height != this.ledger.GetCurrentBlockHeight()+1 {
+			continue
+		}
+		//the ledger checks the signatures of the bookkeepers on the header
+		result, err := this.ledger.ExecuteBlock(block)
+		if err != nil {
+			log.Errorf("sbft execute synced block %d error: %s", block.Header.Height, err)
+			return
+		}
+		if err := this.ledger.SubmitBlock(block, nil, result); err != nil {
+			log.Errorf("sbft submit synced block %d error: %s", block.Header.Height, err)
+			return
+		}
+		this.incrValidator.AddBlock(block)
+	}
+	if len(resp.Blocks) > 0 {
+		log.Infof("sbft synced blocks to %d from %d", this.ledger.GetCurrentBlockHeight(), index)
+	}
+	this.syncRoot()
+
+	for i, block := range resp.PendingBlocks {
+		qc := resp.PendingQCs[i]
+		if err := checkQCHeader(qc, block.Header); err != nil {
+			log.Warnf("sbft invalid pending block from %d: %s", index, err)
+			return
+		}
+		if qc.Height <= this.tree.root.height() {
+			continue
+		}
+		err := this.verifyCertSigs(qc.Signers, qc.Sigs, func(int) []byte {
+			return qc.BlockHash[:]
+		})
+		if err != nil {
+			log.Warnf("sbft invalid pending qc from %d: %s", index, err)
+			return
+		}
+		if this.tree.get(qc.BlockHash) == nil {
+			if this.tree.get(block.Header.PrevBlockHash) == nil {
+				continue
+			}
+			this.tree.add(&blockNode{block: block, view: qc.View})
+		}
+		this.processQC(qc)
+	}
+
+	if len(resp.Blocks) == MAX_SYNC_BLOCKS {
+		this.lastSync = time.Time{}
+		this.requestSync(index)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+type blockNode struct {
+	block *types.Block
+	view  uint64
+	qc    *QuorumCert //certifies this block, nil until a qc is seen
+}
+
+func (this *blockNode) hash() common.Uint256 {
+	return this.block.Hash()
+}
+
+func (this *blockNode) height() uint32 {
+	return this.block.Header.Height
+}
+
+//blockTree keeps the proposed blocks which extend the last committed block
+type blockTree struct {
+	root  *blockNode
+	nodes map[common.Uint256]*blockNode
+}
+
+func newBlockTree(root *blockNode) *blockTree {
+	tree := &blockTree{
+		root:  root,
+		nodes: make(map[common.Uint256]*blockNode),
+	}
+	tree.nodes[root.hash()] = root
+	return tree
+}
+
+func (this *blockTree) get(hash common.Uint256) *blockNode {
+	return this.nodes[hash]
+}
+
+func (this *blockTree) parent(node *blockNode) *blockNode {
+	if node.height() <= this.root.height() {
+		return nil
+	}
+	return this.nodes[node.block.Header.PrevBlockHash]
+}
+
+func (this *blockTree) add(node *blockNode) {
+	this.nodes[node.hash()] = node
+}
+
+//branch returns the uncommitted blocks from the child of the root up to node, false if node does not
+//extend the root
+func (this *blockTree) branch(node *blockNode) ([]*blockNode, bool) {
+	var branch []*blockNode
+	rootHash := this.root.hash()
+	for node == nil || node.hash() != rootHash {
+		if node == nil || node.height() <= this.root.height() {
+			return nil, false
+		}
+		branch = append(branch, node)
+		node = this.nodes[node.block.Header.PrevBlockHash]
+	}
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch, true
+}
+
+//setRoot moves the root forward to a committed block and drops the blocks which do not extend it
+func (this *blockTree) setRoot(root *blockNode) {
+	this.root = root
+	nodes := make(map[common.Uint256]*blockNode)
+	nodes[root.hash()] = root
+	for hash, node := range this.nodes {
+		if _, ok := this.branch(node); ok && node.height() > root.height() {
+			nodes[hash] = node
+		}
+	}
+	this.nodes = nodes
+}
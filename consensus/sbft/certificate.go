@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+)
+
+type voteKey struct {
+	view      uint64
+	height    uint32
+	blockHash common.Uint256
+}
+
+type voteSet struct {
+	signers []uint16
+	sigs    [][]byte
+	done    bool
+}
+
+func (this *voteSet) add(signer uint16, sig []byte) bool {
+	for _, s := range this.signers {
+		if s == signer {
+			return false
+		}
+	}
+	this.signers = append(this.signers, signer)
+	this.sigs = append(this.sigs, sig)
+	return true
+}
+
+type timeoutSet struct {
+	signers     []uint16
+	sigs        [][]byte
+	highQCViews []uint64
+	done        bool
+}
+
+func (this *timeoutSet) add(signer uint16, highQCView uint64, sig []byte) bool {
+	for _, s := range this.signers {
+		if s == signer {
+			return false
+		}
+	}
+	this.signers = append(this.signers, signer)
+	this.sigs = append(this.sigs, sig)
+	this.highQCViews = append(this.highQCViews, highQCView)
+	return true
+}
+
+//blockView returns the view a block is proposed in, the genesis block is in view 0
+func blockView(header *types.Header) uint64 {
+	if header.Height == 0 {
+		return 0
+	}
+	return header.ConsensusData
+}
+
+//committedQC makes the qc of a committed block, committed blocks are final so it needs no signatures
+func committedQC(node *blockNode) *QuorumCert {
+	if node.qc != nil {
+		return node.qc
+	}
+	return &QuorumCert{
+		View:      node.view,
+		Height:    node.height(),
+		BlockHash: node.hash(),
+	}
+}
+
+//quorum is the number of signatures a certificate needs, it is the multi-signature threshold the ledger
+//requires of block headers
+func (this *SbftService) quorum() int {
+	n := len(this.bookkeepers)
+	return n - (n-1)/3
+}
+
+func (this *SbftService) leader(view uint64) uint16 {
+	return uint16(view % uint64(len(this.bookkeepers)))
+}
+
+func (this *SbftService) isLeader(view uint64) bool {
+	return this.index >= 0 && int(this.leader(view)) == this.index
+}
+
+//checkQCHeader checks that qc certifies the header, votes only sign the block hash so the view and height
+//of a qc are bound through the header
+func checkQCHeader(qc *QuorumCert, header *types.Header) error {
+	if header.Hash() != qc.BlockHash || header.Height != qc.Height || blockView(header) != qc.View {
+		return fmt.Errorf("qc of view %d height %d does not match block %s", qc.View, qc.Height,
+			qc.BlockHash.ToHexString())
+	}
+	return nil
+}
+
+//verifyQC checks a qc against its block, known is false if the block is neither committed nor in the tree
+func (this *SbftService) verifyQC(qc *QuorumCert) (known bool, err error) {
+	if qc.Height <= this.ledger.GetCurrentBlockHeight() {
+		header, err := this.ledger.GetHeaderByHeight(qc.Height)
+		if err != nil {
+			return false, err
+		}
+		return true, checkQCHeader(qc, header)
+	}
+	node := this.tree.get(qc.BlockHash)
+	if node == nil {
+		return false, nil
+	}
+	if err := checkQCHeader(qc, node.block.Header); err != nil {
+		return true, err
+	}
+	return true, this.verifyCertSigs(qc.Signers, qc.Sigs, func(int) []byte {
+		return qc.BlockHash[:]
+	})
+}
+
+func (this *SbftService) verifyTC(tc *TimeoutCert) error {
+	return this.verifyCertSigs(tc.Signers, tc.Sigs, func(i int) []byte {
+		digest := TimeoutDigest(tc.View, tc.HighQCViews[i])
+		return digest[:]
+	})
+}
+
+func (this *SbftService) verifyCertSigs(signers []uint16, sigs [][]byte, data func(i int) []byte) error {
+	if len(signers) < this.quorum() {
+		return fmt.Errorf("certificate has %d signers, need %d", len(signers), this.quorum())
+	}
+	seen := make(map[uint16]bool)
+	for i, signer := range signers {
+		if int(signer) >= len(this.bookkeepers) || seen[signer] {
+			return fmt.Errorf("invalid certificate signer %d", signer)
+		}
+		seen[signer] = true
+		if err := signature.Verify(this.bookkeepers[signer], data(i), sigs[i]); err != nil {
+			return fmt.Errorf("invalid signature of certificate signer %d: %s", signer, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,480 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+type ConsensusMessageType byte
+
+const (
+	ProposalMsg     ConsensusMessageType = 0x01
+	VoteMsg         ConsensusMessageType = 0x02
+	TimeoutMsg      ConsensusMessageType = 0x03
+	SyncRequestMsg  ConsensusMessageType = 0x04
+	SyncResponseMsg ConsensusMessageType = 0x05
+)
+
+const (
+	MAX_CERT_SIGNERS = 1024 //max signatures a quorum or timeout certificate can carry
+	MAX_SYNC_BLOCKS  = 16   //max committed blocks returned by one sync response
+)
+
+type ConsensusMessage interface {
+	Type() ConsensusMessageType
+	Serialization(sink *common.ZeroCopySink)
+	Deserialization(source *common.ZeroCopySource) error
+}
+
+//SerializeMessage encodes a consensus message into the data field of a consensus payload
+func SerializeMessage(msg ConsensusMessage) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(msg.Type()))
+	msg.Serialization(sink)
+	return sink.Bytes()
+}
+
+//DeserializeMessage decodes the data field of a consensus payload
+func DeserializeMessage(data []byte) (ConsensusMessage, error) {
+	source := common.NewZeroCopySource(data)
+	msgType, eof := source.NextByte()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var msg ConsensusMessage
+	switch ConsensusMessageType(msgType) {
+	case ProposalMsg:
+		msg = &Proposal{}
+	case VoteMsg:
+		msg = &Vote{}
+	case TimeoutMsg:
+		msg = &Timeout{}
+	case SyncRequestMsg:
+		msg = &SyncRequest{}
+	case SyncResponseMsg:
+		msg = &SyncResponse{}
+	default:
+		return nil, fmt.Errorf("unknown sbft message type: %d", msgType)
+	}
+	if err := msg.Deserialization(source); err != nil {
+		return nil, err
+	}
+	if source.Len() != 0 {
+		return nil, errors.New("sbft message has trailing bytes")
+	}
+	return msg, nil
+}
+
+//QuorumCert proves that a quorum of validators voted for a block, the signatures are made over the block hash
+//so they can be used as the SigData of the committed header
+type QuorumCert struct {
+	View      uint64
+	Height    uint32
+	BlockHash common.Uint256
+	Signers   []uint16
+	Sigs      [][]byte
+}
+
+func (this *QuorumCert) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.View)
+	sink.WriteUint32(this.Height)
+	sink.WriteHash(this.BlockHash)
+	serializeSigs(sink, this.Signers, this.Sigs)
+}
+
+func (this *QuorumCert) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.View, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Height, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	var err error
+	this.Signers, this.Sigs, err = deserializeSigs(source)
+	return err
+}
+
+//TimeoutCert proves that a quorum of validators gave up the view, each signer reports the view of its highest qc
+type TimeoutCert struct {
+	View        uint64
+	HighQCViews []uint64
+	Signers     []uint16
+	Sigs        [][]byte
+}
+
+//MaxHighQCView returns the highest qc view reported by the signers of the certificate
+func (this *TimeoutCert) MaxHighQCView() uint64 {
+	var max uint64
+	for _, view := range this.HighQCViews {
+		if view > max {
+			max = view
+		}
+	}
+	return max
+}
+
+func (this *TimeoutCert) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.View)
+	sink.WriteVarUint(uint64(len(this.HighQCViews)))
+	for _, view := range this.HighQCViews {
+		sink.WriteUint64(view)
+	}
+	serializeSigs(sink, this.Signers, this.Sigs)
+}
+
+func (this *TimeoutCert) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.View, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if num > MAX_CERT_SIGNERS {
+		return fmt.Errorf("too many timeout signers: %d", num)
+	}
+	this.HighQCViews = make([]uint64, 0, num)
+	for i := uint64(0); i < num; i++ {
+		view, eof := source.NextUint64()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		this.HighQCViews = append(this.HighQCViews, view)
+	}
+	var err error
+	this.Signers, this.Sigs, err = deserializeSigs(source)
+	if err != nil {
+		return err
+	}
+	if len(this.Signers) != len(this.HighQCViews) {
+		return errors.New("mismatched timeout signers and qc views")
+	}
+	return nil
+}
+
+//TimeoutDigest is the data a validator signs when it gives up view with the given highest qc view
+func TimeoutDigest(view, highQCView uint64) common.Uint256 {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString("sbft-timeout")
+	sink.WriteUint64(view)
+	sink.WriteUint64(highQCView)
+	return sha256.Sum256(sink.Bytes())
+}
+
+//Proposal is broadcast by the leader of a view, it extends the block certified by Justify. TC is set when
+//the previous view ended without a qc
+type Proposal struct {
+	View    uint64
+	Block   *types.Block
+	Justify *QuorumCert
+	TC      *TimeoutCert
+}
+
+func (this *Proposal) Type() ConsensusMessageType {
+	return ProposalMsg
+}
+
+func (this *Proposal) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.View)
+	this.Block.Serialization(sink)
+	this.Justify.Serialization(sink)
+	serializeTC(sink, this.TC)
+}
+
+func (this *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.View, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Block = &types.Block{}
+	if err := this.Block.Deserialization(source); err != nil {
+		return err
+	}
+	this.Justify = &QuorumCert{}
+	if err := this.Justify.Deserialization(source); err != nil {
+		return err
+	}
+	var err error
+	this.TC, err = deserializeTC(source)
+	return err
+}
+
+//Vote is sent to the leader of the next view, the signature is made over the block hash
+type Vote struct {
+	View      uint64
+	Height    uint32
+	BlockHash common.Uint256
+	Signature []byte
+}
+
+func (this *Vote) Type() ConsensusMessageType {
+	return VoteMsg
+}
+
+func (this *Vote) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.View)
+	sink.WriteUint32(this.Height)
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarBytes(this.Signature)
+}
+
+func (this *Vote) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	this.View, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Height, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Signature, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//Timeout is broadcast when a validator gives up a view, it carries the highest qc and the last tc of the
+//sender so that lagging validators can catch up with the view
+type Timeout struct {
+	View      uint64
+	HighQC    *QuorumCert
+	TC        *TimeoutCert
+	Signature []byte
+}
+
+func (this *Timeout) Type() ConsensusMessageType {
+	return TimeoutMsg
+}
+
+func (this *Timeout) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.View)
+	this.HighQC.Serialization(sink)
+	serializeTC(sink, this.TC)
+	sink.WriteVarBytes(this.Signature)
+}
+
+func (this *Timeout) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	this.View, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.HighQC = &QuorumCert{}
+	if err := this.HighQC.Deserialization(source); err != nil {
+		return err
+	}
+	var err error
+	this.TC, err = deserializeTC(source)
+	if err != nil {
+		return err
+	}
+	this.Signature, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//SyncRequest asks a validator for the committed blocks from StartHeight
+type SyncRequest struct {
+	StartHeight uint32
+}
+
+func (this *SyncRequest) Type() ConsensusMessageType {
+	return SyncRequestMsg
+}
+
+func (this *SyncRequest) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.StartHeight)
+}
+
+func (this *SyncRequest) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.StartHeight, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//SyncResponse carries committed blocks with their signatures, followed by the certified but not yet
+//committed blocks of the responder together with their qcs
+type SyncResponse struct {
+	Blocks        []*types.Block
+	PendingBlocks []*types.Block
+	PendingQCs    []*QuorumCert
+}
+
+func (this *SyncResponse) Type() ConsensusMessageType {
+	return SyncResponseMsg
+}
+
+func (this *SyncResponse) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Blocks)))
+	for _, block := range this.Blocks {
+		block.Serialization(sink)
+	}
+	sink.WriteVarUint(uint64(len(this.PendingBlocks)))
+	for i, block := range this.PendingBlocks {
+		block.Serialization(sink)
+		this.PendingQCs[i].Serialization(sink)
+	}
+}
+
+func (this *SyncResponse) Deserialization(source *common.ZeroCopySource) error {
+	num, err := nextSyncCount(source)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < num; i++ {
+		block := &types.Block{}
+		if err := block.Deserialization(source); err != nil {
+			return err
+		}
+		this.Blocks = append(this.Blocks, block)
+	}
+	num, err = nextSyncCount(source)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < num; i++ {
+		block := &types.Block{}
+		if err := block.Deserialization(source); err != nil {
+			return err
+		}
+		qc := &QuorumCert{}
+		if err := qc.Deserialization(source); err != nil {
+			return err
+		}
+		this.PendingBlocks = append(this.PendingBlocks, block)
+		this.PendingQCs = append(this.PendingQCs, qc)
+	}
+	return nil
+}
+
+func nextSyncCount(source *common.ZeroCopySource) (uint64, error) {
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return 0, common.ErrIrregularData
+	}
+	if eof {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if num > MAX_SYNC_BLOCKS {
+		return 0, fmt.Errorf("too many sync blocks: %d", num)
+	}
+	return num, nil
+}
+
+func serializeSigs(sink *common.ZeroCopySink, signers []uint16, sigs [][]byte) {
+	sink.WriteVarUint(uint64(len(signers)))
+	for i, signer := range signers {
+		sink.WriteUint16(signer)
+		sink.WriteVarBytes(sigs[i])
+	}
+}
+
+func deserializeSigs(source *common.ZeroCopySource) ([]uint16, [][]byte, error) {
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return nil, nil, common.ErrIrregularData
+	}
+	if eof {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	if num > MAX_CERT_SIGNERS {
+		return nil, nil, fmt.Errorf("too many certificate signers: %d", num)
+	}
+	signers := make([]uint16, 0, num)
+	sigs := make([][]byte, 0, num)
+	for i := uint64(0); i < num; i++ {
+		signer, eof := source.NextUint16()
+		if eof {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		sig, _, irregular, eof := source.NextVarBytes()
+		if irregular {
+			return nil, nil, common.ErrIrregularData
+		}
+		if eof {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		signers = append(signers, signer)
+		sigs = append(sigs, sig)
+	}
+	return signers, sigs, nil
+}
+
+func serializeTC(sink *common.ZeroCopySink, tc *TimeoutCert) {
+	sink.WriteBool(tc != nil)
+	if tc != nil {
+		tc.Serialization(sink)
+	}
+}
+
+func deserializeTC(source *common.ZeroCopySource) (*TimeoutCert, error) {
+	hasTC, irregular, eof := source.NextBool()
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if !hasTC {
+		return nil, nil
+	}
+	tc := &TimeoutCert{}
+	if err := tc.Deserialization(source); err != nil {
+		return nil, err
+	}
+	return tc, nil
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBlock(height uint32, view uint64, prevHash common.Uint256) *types.Block {
+	return &types.Block{
+		Header: &types.Header{
+			PrevBlockHash:    prevHash,
+			TransactionsRoot: common.ComputeMerkleRoot(nil),
+			Timestamp:        uint32(height) + 1,
+			Height:           height,
+			ConsensusData:    view,
+		},
+	}
+}
+
+func newTestQC(block *types.Block) *QuorumCert {
+	return &QuorumCert{
+		View:      block.Header.ConsensusData,
+		Height:    block.Header.Height,
+		BlockHash: block.Hash(),
+		Signers:   []uint16{0, 2, 3},
+		Sigs:      [][]byte{{1}, {2}, {3}},
+	}
+}
+
+func TestMessageSerialization(t *testing.T) {
+	parent := newTestBlock(9, 11, common.Uint256{1})
+	block := newTestBlock(10, 13, parent.Hash())
+	tc := &TimeoutCert{
+		View:        12,
+		HighQCViews: []uint64{11, 10, 11},
+		Signers:     []uint16{1, 2, 3},
+		Sigs:        [][]byte{{4}, {5}, {6}},
+	}
+	msgs := []ConsensusMessage{
+		&Proposal{View: 13, Block: block, Justify: newTestQC(parent), TC: tc},
+		&Proposal{View: 12, Block: block, Justify: newTestQC(parent)},
+		&Vote{View: 13, Height: 10, BlockHash: block.Hash(), Signature: []byte{7}},
+		&Timeout{View: 13, HighQC: newTestQC(parent), TC: tc, Signature: []byte{8}},
+		&SyncRequest{StartHeight: 5},
+		&SyncResponse{
+			Blocks:        []*types.Block{parent},
+			PendingBlocks: []*types.Block{block},
+			PendingQCs:    []*QuorumCert{newTestQC(block)},
+		},
+	}
+	for _, msg := range msgs {
+		data := SerializeMessage(msg)
+		decoded, err := DeserializeMessage(data)
+		assert.Nil(t, err)
+		assert.Equal(t, msg.Type(), decoded.Type())
+		assert.Equal(t, data, SerializeMessage(decoded))
+
+		_, err = DeserializeMessage(append(data, 0))
+		assert.NotNil(t, err)
+		_, err = DeserializeMessage(data[:len(data)-1])
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, uint64(11), tc.MaxHighQCView())
+}
+
+func TestBlockTree(t *testing.T) {
+	root := &blockNode{block: newTestBlock(0, 0, common.Uint256{})}
+	tree := newBlockTree(root)
+	b1 := &blockNode{block: newTestBlock(1, 1, root.hash()), view: 1}
+	b2 := &blockNode{block: newTestBlock(2, 2, b1.hash()), view: 2}
+	fork := &blockNode{block: newTestBlock(2, 3, b1.hash()), view: 3}
+	orphan := &blockNode{block: newTestBlock(3, 4, common.Uint256{9}), view: 4}
+	for _, node := range []*blockNode{b1, b2, fork, orphan} {
+		tree.add(node)
+	}
+
+	branch, ok := tree.branch(b2)
+	assert.True(t, ok)
+	assert.Equal(t, []*blockNode{b1, b2}, branch)
+	_, ok = tree.branch(orphan)
+	assert.False(t, ok)
+	assert.Equal(t, b1, tree.parent(b2))
+
+	tree.setRoot(b1)
+	assert.Equal(t, b2, tree.get(b2.hash()))
+	assert.Equal(t, fork, tree.get(fork.hash()))
+	assert.Nil(t, tree.get(root.hash()))
+	assert.Nil(t, tree.get(orphan.hash()))
+
+	tree.setRoot(b2)
+	assert.Nil(t, tree.get(fork.hash()))
+	branch, ok = tree.branch(b2)
+	assert.True(t, ok)
+	assert.Equal(t, 0, len(branch))
+}
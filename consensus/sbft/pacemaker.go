@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/signature"
+)
+
+//enterView moves the node forward to view, the leader of the view proposes after the block interval
+func (this *SbftService) enterView(view uint64) {
+	if view <= this.view {
+		return
+	}
+	log.Debugf("sbft enter view %d, leader %d", view, this.leader(view))
+	this.view = view
+	for key := range this.votes {
+		if key.view+1 < view {
+			delete(this.votes, key)
+		}
+	}
+	for v := range this.timeouts {
+		if v < view {
+			delete(this.timeouts, v)
+		}
+	}
+	this.viewTimer = this.schedule(this.viewTimer, this.viewTimeout, &viewTimeout{view: view})
+	if this.isLeader(view) {
+		this.proposeTimer = this.schedule(this.proposeTimer, this.genBlockTime, &proposeTimeout{view: view})
+	}
+}
+
+//onViewTimeout gives up the view, the timeout is broadcast again every view timeout until the view changes
+func (this *SbftService) onViewTimeout(view uint64) {
+	if view != this.view || this.index < 0 {
+		return
+	}
+	log.Infof("sbft view %d timeout, high qc view %d height %d", view, this.highQC.View, this.highQC.Height)
+	this.timeoutView = view
+	if this.lastVotedView < view {
+		this.lastVotedView = view
+	}
+	digest := TimeoutDigest(view, this.highQC.View)
+	sig, err := signature.Sign(this.Account, digest[:])
+	if err != nil {
+		log.Errorf("sbft sign timeout error: %s", err)
+		return
+	}
+	timeout := &Timeout{
+		View:      view,
+		HighQC:    this.highQC,
+		TC:        this.lastTC,
+		Signature: sig,
+	}
+	this.viewTimer = this.schedule(this.viewTimer, this.viewTimeout, &viewTimeout{view: view})
+	this.broadcast(timeout)
+	this.addTimeout(uint16(this.index), timeout)
+}
+
+func (this *SbftService) onTimeout(index uint16, timeout *Timeout) {
+	digest := TimeoutDigest(timeout.View, timeout.HighQC.View)
+	if err := signature.Verify(this.bookkeepers[index], digest[:], timeout.Signature); err != nil {
+		log.Warnf("sbft invalid timeout signature from %d: %s", index, err)
+		return
+	}
+	if timeout.TC != nil && (this.lastTC == nil || timeout.TC.View > this.lastTC.View) {
+		if err := this.verifyTC(timeout.TC); err != nil {
+			log.Warnf("sbft invalid tc in timeout from %d: %s", index, err)
+			return
+		}
+		this.processTC(timeout.TC)
+	}
+	known, err := this.verifyQC(timeout.HighQC)
+	if err != nil {
+		log.Warnf("sbft invalid high qc in timeout from %d: %s", index, err)
+		return
+	}
+	if !known {
+		//the claimed view of the high qc can only be checked with its block
+		this.requestSync(index)
+		return
+	}
+	this.processQC(timeout.HighQC)
+	if timeout.View < this.view {
+		return
+	}
+	this.addTimeout(index, timeout)
+}
+
+func (this *SbftService) addTimeout(index uint16, timeout *Timeout) {
+	set := this.timeouts[timeout.View]
+	if set == nil {
+		set = &timeoutSet{}
+		this.timeouts[timeout.View] = set
+	}
+	if !set.add(index, timeout.HighQC.View, timeout.Signature) {
+		return
+	}
+	//join the view change once an honest node is known to have given up the view
+	if timeout.View == this.view && this.timeoutView < timeout.View &&
+		len(set.signers) > len(this.bookkeepers)-this.quorum() {
+		this.onViewTimeout(timeout.View)
+	}
+	if set.done || len(set.signers) < this.quorum() {
+		return
+	}
+	set.done = true
+	tc := &TimeoutCert{
+		View:        timeout.View,
+		HighQCViews: append([]uint64{}, set.highQCViews...),
+		Signers:     append([]uint16{}, set.signers...),
+		Sigs:        append([][]byte{}, set.sigs...),
+	}
+	this.processTC(tc)
+}
+
+func (this *SbftService) processTC(tc *TimeoutCert) {
+	if this.lastTC == nil || tc.View > this.lastTC.View {
+		this.lastTC = tc
+	}
+	this.enterView(tc.View + 1)
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+)
+
+func (this *SbftService) onProposeTimeout(view uint64) {
+	if view != this.view || !this.isLeader(view) || this.proposedView >= view {
+		return
+	}
+	justify := this.highQC
+	var tc *TimeoutCert
+	if justify.View+1 != view {
+		if this.lastTC == nil || this.lastTC.View+1 != view {
+			return
+		}
+		tc = this.lastTC
+	}
+	parent := this.tree.get(justify.BlockHash)
+	if parent == nil {
+		//the votes arrived before the proposal they certify, wait for it
+		this.proposeTimer = this.schedule(this.proposeTimer, this.genBlockTime, &proposeTimeout{view: view})
+		return
+	}
+	block, err := this.makeBlock(parent, view)
+	if err != nil {
+		log.Errorf("sbft make block of view %d error: %s", view, err)
+		return
+	}
+	proposal := &Proposal{
+		View:    view,
+		Block:   block,
+		Justify: justify,
+		TC:      tc,
+	}
+	log.Infof("sbft propose block %d in view %d, txs %d", block.Header.Height, view, len(block.Transactions))
+	this.proposedView = view
+	this.broadcast(proposal)
+	this.tree.add(&blockNode{block: block, view: view})
+	this.vote(proposal)
+}
+
+func (this *SbftService) makeBlock(parent *blockNode, view uint64) (*types.Block, error) {
+	validHeight := this.validHeight()
+	branchTxs := this.branchTxs(parent)
+	var txs []*types.Transaction
+	var txHashes []common.Uint256
+	nonceCtx := make(map[common.Address]uint64)
+	for _, entry := range this.poolActor.GetTxnPool(true, validHeight) {
+		hash := entry.Tx.Hash()
+		if _, ok := branchTxs[hash]; ok {
+			continue
+		}
+		if err := this.incrValidator.Verify(entry.Tx, validHeight, nonceCtx); err != nil {
+			log.Debugf("sbft increment verify failed: %s", err)
+			continue
+		}
+		txs = append(txs, entry.Tx)
+		txHashes = append(txHashes, hash)
+	}
+	txRoot := common.ComputeMerkleRoot(txHashes)
+
+	timestamp := uint32(time.Now().Unix())
+	if prev := parent.block.Header.Timestamp; timestamp <= prev {
+		timestamp = prev + 1
+	}
+	header := &types.Header{
+		Version:          ContextVersion,
+		PrevBlockHash:    parent.hash(),
+		TransactionsRoot: txRoot,
+		BlockRoot:        this.blockRoot(parent, txRoot),
+		Timestamp:        timestamp,
+		Height:           parent.height() + 1,
+		ConsensusData:    view,
+		NextBookkeeper:   this.nextBookkeeper,
+	}
+	return &types.Block{
+		Header:       header,
+		Transactions: txs,
+	}, nil
+}
+
+func (this *SbftService) onProposal(index uint16, proposal *Proposal) {
+	header := proposal.Block.Header
+	justify := proposal.Justify
+	if index != this.leader(proposal.View) {
+		log.Warnf("sbft proposal of view %d from %d which is not the leader", proposal.View, index)
+		return
+	}
+	if blockView(header) != proposal.View || justify.View >= proposal.View ||
+		header.Height != justify.Height+1 || header.PrevBlockHash != justify.BlockHash {
+		log.Warnf("sbft proposal of view %d from %d does not extend its qc", proposal.View, index)
+		return
+	}
+	if proposal.TC != nil {
+		if err := this.verifyTC(proposal.TC); err != nil {
+			log.Warnf("sbft invalid tc in proposal of view %d: %s", proposal.View, err)
+			return
+		}
+		this.processTC(proposal.TC)
+	}
+	known, err := this.verifyQC(justify)
+	if err != nil {
+		log.Warnf("sbft invalid qc in proposal of view %d: %s", proposal.View, err)
+		return
+	}
+	if !known {
+		log.Infof("sbft proposal of view %d extends unknown block %d, start syncing", proposal.View, justify.Height)
+		this.requestSync(index)
+		return
+	}
+	this.processQC(justify)
+	//a proposal of a past view is still kept, the next leader may have certified it before it arrived here
+	parent := this.tree.get(justify.BlockHash)
+	if parent == nil || this.tree.get(proposal.Block.Hash()) != nil {
+		return
+	}
+	if err := this.verifyBlock(parent, proposal.Block); err != nil {
+		log.Warnf("sbft invalid block in proposal of view %d: %s", proposal.View, err)
+		return
+	}
+	txs := proposal.Block.Transactions
+	if len(txs) == 0 {
+		this.onProposalVerified(index, proposal, nil)
+		return
+	}
+	pool, pid, validHeight := this.poolActor, this.pid, this.validHeight()
+	go func() {
+		err := pool.VerifyBlock(txs, validHeight)
+		pid.Tell(&verifiedProposal{index: index, proposal: proposal, err: err})
+	}()
+}
+
+func (this *SbftService) onProposalVerified(index uint16, proposal *Proposal, err error) {
+	if err != nil {
+		log.Warnf("sbft verify txs of proposal of view %d from %d failed: %s", proposal.View, index, err)
+		return
+	}
+	//the tree may have moved on while the transactions were verified
+	if this.tree.get(proposal.Justify.BlockHash) == nil {
+		return
+	}
+	node := &blockNode{block: proposal.Block, view: proposal.View}
+	this.tree.add(node)
+	if this.highQC.BlockHash == node.hash() {
+		node.qc = this.highQC
+	}
+	this.vote(proposal)
+}
+
+//vote follows the safety rule: a node votes once per view for a proposal which extends the qc of the previous
+//view, or after a timeout certificate for a qc at least as high as any reported in the certificate
+func (this *SbftService) vote(proposal *Proposal) {
+	view := proposal.View
+	if this.index < 0 || view != this.view || view <= this.lastVotedView {
+		return
+	}
+	justify := proposal.Justify
+	if justify.View+1 != view {
+		tc := proposal.TC
+		if tc == nil || tc.View+1 != view || justify.View < tc.MaxHighQCView() {
+			log.Warnf("sbft proposal of view %d breaks the safety rule, qc view %d", view, justify.View)
+			return
+		}
+	}
+	hash := proposal.Block.Hash()
+	sig, err := signature.Sign(this.Account, hash[:])
+	if err != nil {
+		log.Errorf("sbft sign vote error: %s", err)
+		return
+	}
+	this.lastVotedView = view
+	vote := &Vote{
+		View:      view,
+		Height:    proposal.Block.Header.Height,
+		BlockHash: hash,
+		Signature: sig,
+	}
+	next := this.leader(view + 1)
+	if int(next) == this.index {
+		this.onVote(next, vote)
+		return
+	}
+	this.sendTo(next, vote)
+}
+
+func (this *SbftService) onVote(index uint16, vote *Vote) {
+	if !this.isLeader(vote.View+1) || vote.View+1 < this.view {
+		return
+	}
+	if err := signature.Verify(this.bookkeepers[index], vote.BlockHash[:], vote.Signature); err != nil {
+		log.Warnf("sbft invalid vote signature from %d: %s", index, err)
+		return
+	}
+	key := voteKey{view: vote.View, height: vote.Height, blockHash: vote.BlockHash}
+	set := this.votes[key]
+	if set == nil {
+		set = &voteSet{}
+		this.votes[key] = set
+	}
+	if !set.add(index, vote.Signature) || set.done || len(set.signers) < this.quorum() {
+		return
+	}
+	set.done = true
+	this.processQC(&QuorumCert{
+		View:      vote.View,
+		Height:    vote.Height,
+		BlockHash: vote.BlockHash,
+		Signers:   append([]uint16{}, set.signers...),
+		Sigs:      append([][]byte{}, set.sigs...),
+	})
+}
+
+//processQC records a verified qc, it commits the parent of the certified block when they are proposed in
+//consecutive views
+func (this *SbftService) processQC(qc *QuorumCert) {
+	if node := this.tree.get(qc.BlockHash); node != nil && node != this.tree.root {
+		if node.qc == nil {
+			node.qc = qc
+		}
+		parent := this.tree.parent(node)
+		if parent != nil && parent != this.tree.root && parent.view+1 == node.view {
+			this.commit(parent)
+		}
+	}
+	if qc.View > this.highQC.View {
+		this.highQC = qc
+	}
+	this.enterView(qc.View + 1)
+}
+
+func (this *SbftService) commit(node *blockNode) {
+	branch, ok := this.tree.branch(node)
+	if !ok {
+		return
+	}
+	for _, b := range branch {
+		if err := this.submitBlock(b.block, b.qc); err != nil {
+			log.Errorf("sbft commit block %d error: %s", b.height(), err)
+			return
+		}
+		this.tree.setRoot(b)
+	}
+}
+
+func (this *SbftService) submitBlock(block *types.Block, qc *QuorumCert) error {
+	if block.Header.Height <= this.ledger.GetCurrentBlockHeight() {
+		//saved by block sync already
+		return nil
+	}
+	if qc == nil {
+		return errors.New("block is not certified")
+	}
+	block.Header.Bookkeepers = this.bookkeepers
+	block.Header.SigData = qc.Sigs
+	result, err := this.ledger.ExecuteBlock(block)
+	if err != nil {
+		return fmt.Errorf("execute block error: %s", err)
+	}
+	if err := this.ledger.SubmitBlock(block, nil, result); err != nil {
+		return fmt.Errorf("submit block error: %s", err)
+	}
+	this.incrValidator.AddBlock(block)
+	log.Infof("sbft commit block %d in view %d, txs %d", block.Header.Height, blockView(block.Header),
+		len(block.Transactions))
+	return nil
+}
+
+func (this *SbftService) verifyBlock(parent *blockNode, block *types.Block) error {
+	header := block.Header
+	if header.Version != ContextVersion {
+		return fmt.Errorf("unknown block version %d", header.Version)
+	}
+	if header.Timestamp <= parent.block.Header.Timestamp {
+		return errors.New("block timestamp is incorrect")
+	}
+	if header.NextBookkeeper != this.nextBookkeeper {
+		return errors.New("next bookkeeper of block is incorrect")
+	}
+	branchTxs := this.branchTxs(parent)
+	txHashes := make([]common.Uint256, 0, len(block.Transactions))
+	seen := make(map[common.Uint256]bool)
+	for _, tx := range block.Transactions {
+		hash := tx.Hash()
+		if _, ok := branchTxs[hash]; ok || seen[hash] {
+			return fmt.Errorf("duplicated transaction %s", hash.ToHexString())
+		}
+		seen[hash] = true
+		txHashes = append(txHashes, hash)
+	}
+	txRoot := common.ComputeMerkleRoot(txHashes)
+	if txRoot != header.TransactionsRoot {
+		return errors.New("transactions root of block is incorrect")
+	}
+	if this.blockRoot(parent, txRoot) != header.BlockRoot {
+		return errors.New("block root of block is incorrect")
+	}
+	validHeight := this.validHeight()
+	nonceCtx := make(map[common.Address]uint64)
+	for _, tx := range block.Transactions {
+		if err := this.incrValidator.Verify(tx, validHeight, nonceCtx); err != nil {
+			return fmt.Errorf("increment verify failed: %s", err)
+		}
+	}
+	return nil
+}
+
+//blockRoot computes the block root of a new block extending parent, the tx roots of the uncommitted
+//ancestors are appended to the ledger
+func (this *SbftService) blockRoot(parent *blockNode, txRoot common.Uint256) common.Uint256 {
+	branch, _ := this.tree.branch(parent)
+	txRoots := make([]common.Uint256, 0, len(branch)+1)
+	for _, node := range branch {
+		txRoots = append(txRoots, node.block.Header.TransactionsRoot)
+	}
+	txRoots = append(txRoots, txRoot)
+	return this.ledger.GetBlockRootWithNewTxRoots(this.tree.root.height()+1, txRoots)
+}
+
+func (this *SbftService) branchTxs(parent *blockNode) map[common.Uint256]struct{} {
+	txs := make(map[common.Uint256]struct{})
+	branch, _ := this.tree.branch(parent)
+	for _, node := range branch {
+		for _, tx := range node.block.Transactions {
+			txs[tx.Hash()] = struct{}{}
+		}
+	}
+	return txs
+}
+
+func (this *SbftService) validHeight() uint32 {
+	height := this.ledger.GetCurrentBlockHeight()
+	start, end := this.incrValidator.BlockRange()
+	if height+1 == end {
+		return start
+	}
+	this.incrValidator.Clean()
+	return height
+}
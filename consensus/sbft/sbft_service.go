@@ -16,15 +16,311 @@
  * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
  */
 
+/*
+Package sbft implements a hotstuff style pipelined bft consensus. The leader of view v is the bookkeeper
+v % n, it proposes a block extending the block certified by the highest quorum certificate it knows,
+and the votes for the proposal are sent to the leader of view v+1 only, which aggregates them into the
+qc carried by the next proposal. A block is committed once it and its child of the next view are both
+certified, so a block is final two rounds after it is proposed. Views without progress are abandoned
+with timeout messages which aggregate into timeout certificates.
+*/
 package sbft
 
+import (
+	"reflect"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	p2pcommon "github.com/ontio/ontology/p2pserver/common"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	txpool "github.com/ontio/ontology/txnpool/common"
+	"github.com/ontio/ontology/validator/increment"
+)
+
+const ContextVersion uint32 = 0
+
+//txPool is the part of the transaction pool used by the service
+type txPool interface {
+	GetTxnPool(byCount bool, height uint32) []*txpool.VerifiedTx
+	VerifyBlock(txs []*types.Transaction, height uint32) error
+}
+
+type viewTimeout struct {
+	view uint64
+}
+
+type proposeTimeout struct {
+	view uint64
+}
+
+type verifiedProposal struct {
+	index    uint16
+	proposal *Proposal
+	err      error
+}
+
 type SbftService struct {
+	Account        *account.Account
+	bookkeepers    []keypair.PublicKey
+	index          int //index of the account in bookkeepers, -1 if the node only follows the chain
+	nextBookkeeper common.Address
+	peers          map[uint16]p2pcommon.PeerId
+	genBlockTime   time.Duration
+	viewTimeout    time.Duration
+	started        bool
+	ledger         *ledger.Ledger
+	incrValidator  *increment.IncrementValidator
+	poolActor      txPool
+	p2p            p2p.P2P
+	pid            *actor.PID
+
+	view          uint64
+	lastVotedView uint64
+	timeoutView   uint64
+	proposedView  uint64
+	highQC        *QuorumCert
+	lastTC        *TimeoutCert
+	tree          *blockTree
+	votes         map[voteKey]*voteSet
+	timeouts      map[uint64]*timeoutSet
+	viewTimer     *time.Timer
+	proposeTimer  *time.Timer
+	lastSync      time.Time
+}
+
+func NewSbftService(bkAccount *account.Account, txpool *actor.PID, p2p p2p.P2P) (*SbftService, error) {
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.DefConfig.Genesis.SBFT
+	genBlockTime := time.Duration(cfg.GenBlockTime) * time.Second
+	if genBlockTime == 0 {
+		genBlockTime = config.DEFAULT_GEN_BLOCK_TIME * time.Second
+	}
+	viewTimeout := time.Duration(cfg.ViewTimeout) * time.Second
+	if viewTimeout <= genBlockTime {
+		viewTimeout = 3 * genBlockTime
+	}
+	service, err := newSbftService(bkAccount, bookkeepers, &actorTypes.TxPoolActor{Pool: txpool}, ledger.DefLedger,
+		p2p, genBlockTime, viewTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	props := actor.FromProducer(func() actor.Actor {
+		return service
+	})
+	service.pid, err = actor.SpawnNamed(props, "consensus_sbft")
+	return service, err
+}
+
+func newSbftService(bkAccount *account.Account, bookkeepers []keypair.PublicKey, pool txPool, ledger *ledger.Ledger,
+	p2p p2p.P2P, genBlockTime, viewTimeout time.Duration) (*SbftService, error) {
+	nextBookkeeper, err := types.AddressFromBookkeepers(bookkeepers)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, pubKey := range bookkeepers {
+		if keypair.ComparePublicKey(pubKey, bkAccount.PublicKey) {
+			index = i
+		}
+	}
+	return &SbftService{
+		Account:        bkAccount,
+		bookkeepers:    bookkeepers,
+		index:          index,
+		nextBookkeeper: nextBookkeeper,
+		peers:          make(map[uint16]p2pcommon.PeerId),
+		genBlockTime:   genBlockTime,
+		viewTimeout:    viewTimeout,
+		ledger:         ledger,
+		incrValidator:  increment.NewIncrementValidator(20),
+		poolActor:      pool,
+		p2p:            p2p,
+	}, nil
+}
+
+func (this *SbftService) Receive(context actor.Context) {
+	if _, ok := context.Message().(*actorTypes.StartConsensus); !this.started && !ok {
+		return
+	}
+
+	switch msg := context.Message().(type) {
+	case *actor.Restarting:
+		log.Warn("sbft actor restarting")
+	case *actor.Stopping:
+		log.Warn("sbft actor stopping")
+	case *actor.Stopped:
+		log.Warn("sbft actor stopped")
+	case *actor.Started:
+		log.Warn("sbft actor started")
+	case *actor.Restart:
+		log.Warn("sbft actor restart")
+	case *actorTypes.StartConsensus:
+		this.start()
+	case *actorTypes.StopConsensus:
+		this.halt()
+	case *viewTimeout:
+		this.syncRoot()
+		this.onViewTimeout(msg.view)
+	case *proposeTimeout:
+		this.syncRoot()
+		this.onProposeTimeout(msg.view)
+	case *verifiedProposal:
+		this.onProposalVerified(msg.index, msg.proposal, msg.err)
+	case *p2pmsg.ConsensusPayload:
+		this.onConsensusPayload(msg)
+	default:
+		log.Info("sbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+	}
 }
 
-func (ss *SbftService) Halt() error {
+func (this *SbftService) GetPID() *actor.PID {
+	return this.pid
+}
+
+func (this *SbftService) Start() error {
+	this.pid.Tell(&actorTypes.StartConsensus{})
 	return nil
 }
 
-func (ds *SbftService) Start() error {
+func (this *SbftService) Halt() error {
+	this.pid.Tell(&actorTypes.StopConsensus{})
 	return nil
 }
+
+func (this *SbftService) start() {
+	if this.started {
+		return
+	}
+	if this.index < 0 {
+		log.Info("sbft: you aren't bookkeeper, only follow the chain")
+	}
+	height := this.ledger.GetCurrentBlockHeight()
+	block, err := this.ledger.GetBlockByHeight(height)
+	if err != nil {
+		log.Errorf("sbft start: get block %d error: %s", height, err)
+		return
+	}
+	root := &blockNode{block: block, view: blockView(block.Header)}
+	this.tree = newBlockTree(root)
+	this.highQC = committedQC(root)
+	this.lastTC = nil
+	this.votes = make(map[voteKey]*voteSet)
+	this.timeouts = make(map[uint64]*timeoutSet)
+	this.view = 0
+	this.started = true
+	log.Infof("sbft start at block %d view %d, bookkeeper index %d", height, root.view, this.index)
+	this.enterView(root.view + 1)
+}
+
+func (this *SbftService) halt() {
+	if !this.started {
+		return
+	}
+	log.Info("sbft stop")
+	this.started = false
+	if this.viewTimer != nil {
+		this.viewTimer.Stop()
+	}
+	if this.proposeTimer != nil {
+		this.proposeTimer.Stop()
+	}
+	this.incrValidator.Clean()
+}
+
+func (this *SbftService) onConsensusPayload(payload *p2pmsg.ConsensusPayload) {
+	//the signature of the payload has been checked by the p2p consensus handler
+	if payload.Version != ContextVersion {
+		return
+	}
+	index := payload.BookkeeperIndex
+	if int(index) >= len(this.bookkeepers) || int(index) == this.index {
+		return
+	}
+	if !keypair.ComparePublicKey(payload.Owner, this.bookkeepers[index]) {
+		log.Warnf("sbft: payload owner is not bookkeeper %d", index)
+		return
+	}
+	msg, err := DeserializeMessage(payload.Data)
+	if err != nil {
+		log.Errorf("sbft: DeserializeMessage from %d failed: %s", index, err)
+		return
+	}
+	this.peers[index] = payload.PeerId
+	this.syncRoot()
+
+	switch m := msg.(type) {
+	case *Proposal:
+		this.onProposal(index, m)
+	case *Vote:
+		this.onVote(index, m)
+	case *Timeout:
+		this.onTimeout(index, m)
+	case *SyncRequest:
+		this.onSyncRequest(index, m)
+	case *SyncResponse:
+		this.onSyncResponse(index, m)
+	}
+}
+
+func (this *SbftService) newPayload(msg ConsensusMessage) *p2pmsg.ConsensusPayload {
+	root := this.tree.root
+	payload := &p2pmsg.ConsensusPayload{
+		Version:         ContextVersion,
+		PrevHash:        root.hash(),
+		Height:          root.height() + 1,
+		BookkeeperIndex: uint16(this.index),
+		Timestamp:       uint32(time.Now().Unix()),
+		Data:            SerializeMessage(msg),
+		Owner:           this.Account.PublicKey,
+	}
+	sink := common.NewZeroCopySink(nil)
+	payload.SerializationUnsigned(sink)
+	payload.Signature, _ = signature.Sign(this.Account, sink.Bytes())
+	return payload
+}
+
+func (this *SbftService) broadcast(msg ConsensusMessage) {
+	if this.index < 0 {
+		return
+	}
+	this.p2p.Broadcast(msgpack.NewConsensus(this.newPayload(msg)))
+}
+
+//sendTo sends msg to the bookkeeper, it falls back to broadcast until the peer of the bookkeeper is known
+func (this *SbftService) sendTo(index uint16, msg ConsensusMessage) {
+	if this.index < 0 {
+		return
+	}
+	peerId, ok := this.peers[index]
+	if !ok {
+		this.broadcast(msg)
+		return
+	}
+	this.p2p.SendTo(peerId, msgpack.NewConsensus(this.newPayload(msg)))
+}
+
+//schedule tells msg to the service after d, the returned timer replaces the previous one
+func (this *SbftService) schedule(timer *time.Timer, d time.Duration, msg interface{}) *time.Timer {
+	if timer != nil {
+		timer.Stop()
+	}
+	pid := this.pid
+	return time.AfterFunc(d, func() {
+		pid.Tell(msg)
+	})
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/types"
+	p2pcommon "github.com/ontio/ontology/p2pserver/common"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/mock"
+	"github.com/ontio/ontology/p2pserver/net/netserver"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
+	txpool "github.com/ontio/ontology/txnpool/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testGenBlockTime = 100 * time.Millisecond
+	testViewTimeout  = time.Second
+)
+
+type emptyPool struct{}
+
+func (self emptyPool) GetTxnPool(byCount bool, height uint32) []*txpool.VerifiedTx {
+	return nil
+}
+
+func (self emptyPool) VerifyBlock(txs []*types.Transaction, height uint32) error {
+	return nil
+}
+
+//consensusProtocol delivers the consensus messages of the simulated network to the service of the node
+type consensusProtocol struct {
+	service *SbftService
+}
+
+func (self *consensusProtocol) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage) {}
+
+func (self *consensusProtocol) HandlePeerMessage(ctx *p2p.Context, msg msgTypes.Message) {
+	if m, ok := msg.(*msgTypes.Consensus); ok {
+		if err := m.Cons.Verify(); err != nil {
+			return
+		}
+		m.Cons.PeerId = ctx.Sender().GetID()
+		self.service.GetPID().Tell(&m.Cons)
+	}
+}
+
+type testNode struct {
+	ledger  *ledger.Ledger
+	net     *netserver.NetServer
+	service *SbftService
+}
+
+func (self *testNode) height() uint32 {
+	return self.ledger.GetCurrentBlockHeight()
+}
+
+type testNetwork struct {
+	dir   string
+	nodes []*testNode
+}
+
+func newTestNetwork(t *testing.T, n int) *testNetwork {
+	log.InitLog(log.WarnLog, log.Stdout)
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SBFT
+
+	var accounts []*account.Account
+	var bookkeepers []keypair.PublicKey
+	for i := 0; i < n; i++ {
+		acc := account.NewAccount("")
+		accounts = append(accounts, acc)
+		bookkeepers = append(bookkeepers, acc.PublicKey)
+	}
+	keypair.SortPublicKeys(bookkeepers)
+	config.DefConfig.Genesis.SBFT.Bookkeepers = nil
+	for _, pubKey := range bookkeepers {
+		config.DefConfig.Genesis.SBFT.Bookkeepers = append(config.DefConfig.Genesis.SBFT.Bookkeepers,
+			hex.EncodeToString(keypair.SerializePublicKey(pubKey)))
+	}
+
+	dir, err := ioutil.TempDir("", "sbft")
+	require.Nil(t, err)
+	network := &testNetwork{dir: dir}
+	simNet := mock.NewNetwork()
+	for i, acc := range accounts {
+		block, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+		require.Nil(t, err)
+		db, err := ledger.InitLedger(filepath.Join(dir, fmt.Sprint(i)), 0, bookkeepers, block)
+		require.Nil(t, err)
+
+		keyId := p2pcommon.RandPeerKeyId()
+		info := peer.NewPeerInfo(keyId.Id, 0, 0, true, 0, 0, 0, "1.10", "")
+		logger := p2pcommon.LoggerWithContext(p2pcommon.NewGlobalLoggerWrapper(), fmt.Sprintf("node %d: ", i))
+		proto := &consensusProtocol{}
+		net := mock.NewNode(keyId, "", info, proto, simNet, nil, p2p.AllAddrFilter(), logger)
+		service, err := newSbftService(acc, bookkeepers, emptyPool{}, db, net, testGenBlockTime, testViewTimeout)
+		require.Nil(t, err)
+		service.pid = actor.Spawn(actor.FromProducer(func() actor.Actor {
+			return service
+		}))
+		proto.service = service
+		go net.Start()
+		network.nodes = append(network.nodes, &testNode{ledger: db, net: net, service: service})
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			simNet.AllowConnect(network.nodes[i].net.GetID(), network.nodes[j].net.GetID())
+			go network.nodes[i].net.Connect(network.nodes[j].net.GetHostInfo().Addr)
+		}
+	}
+	waitFor(t, 10*time.Second, "all nodes connected", func() bool {
+		for _, node := range network.nodes {
+			if node.net.GetConnectionCnt() != uint32(n-1) {
+				return false
+			}
+		}
+		return true
+	})
+	return network
+}
+
+func (self *testNetwork) close() {
+	for _, node := range self.nodes {
+		node.service.Halt()
+		node.net.Stop()
+	}
+	time.Sleep(testGenBlockTime)
+	for _, node := range self.nodes {
+		node.ledger.Close()
+	}
+	os.RemoveAll(self.dir)
+}
+
+func (self *testNetwork) start(indexes ...int) {
+	for _, i := range indexes {
+		self.nodes[i].service.Start()
+	}
+}
+
+//checkConsistent checks that the nodes committed the same blocks up to the lowest height among them
+func (self *testNetwork) checkConsistent(t *testing.T, indexes ...int) {
+	height := self.nodes[indexes[0]].height()
+	for _, i := range indexes {
+		if h := self.nodes[i].height(); h < height {
+			height = h
+		}
+	}
+	for h := uint32(1); h <= height; h++ {
+		expect := self.nodes[indexes[0]].ledger.GetBlockHash(h)
+		for _, i := range indexes[1:] {
+			require.Equal(t, expect, self.nodes[i].ledger.GetBlockHash(h), "block %d of node %d", h, i)
+		}
+	}
+}
+
+func (self *testNetwork) waitHeight(t *testing.T, height uint32, timeout time.Duration, indexes ...int) {
+	waitFor(t, timeout, fmt.Sprintf("nodes %v reach block %d", indexes, height), func() bool {
+		for _, i := range indexes {
+			if self.nodes[i].height() < height {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestSbftCommit(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.close()
+
+	network.start(0, 1, 2, 3)
+	network.waitHeight(t, 8, 30*time.Second, 0, 1, 2, 3)
+	network.checkConsistent(t, 0, 1, 2, 3)
+
+	block, err := network.nodes[0].ledger.GetBlockByHeight(8)
+	require.Nil(t, err)
+	require.True(t, len(block.Header.SigData) >= network.nodes[0].service.quorum())
+}
+
+func TestSbftLeaderCrash(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.close()
+
+	//views led by node 2 can only end with a timeout certificate
+	network.start(0, 1, 3)
+	network.waitHeight(t, 6, 30*time.Second, 0, 1, 3)
+	network.checkConsistent(t, 0, 1, 3)
+	require.Equal(t, uint32(0), network.nodes[2].height())
+}
+
+func TestSbftNoQuorum(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.close()
+
+	network.start(0, 1)
+	time.Sleep(3 * testViewTimeout)
+	require.Equal(t, uint32(0), network.nodes[0].height())
+	require.Equal(t, uint32(0), network.nodes[1].height())
+
+	network.start(2)
+	network.waitHeight(t, 4, 30*time.Second, 0, 1, 2)
+	network.checkConsistent(t, 0, 1, 2)
+}
+
+func TestSbftCatchUp(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.close()
+
+	network.start(0, 1, 2)
+	network.waitHeight(t, 12, 60*time.Second, 0, 1, 2)
+
+	network.start(3)
+	height := network.nodes[0].height()
+	network.waitHeight(t, height+4, 30*time.Second, 0, 1, 2, 3)
+	network.checkConsistent(t, 0, 1, 2, 3)
+}
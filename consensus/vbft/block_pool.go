@@ -246,6 +246,10 @@ func (pool *BlockPool) setProposalEndorsed(proposal *blockProposalMsg, forEmpty
 			return nil
 		} else {
 			if c.EndorsedProposal.Block.getProposer() == proposal.Block.getProposer() {
+				// never endorse two versions of a proposal, the conflicting endorsements are slashable
+				if c.EndorsedProposal.Block.Block.Hash() != proposal.Block.Block.Hash() {
+					return fmt.Errorf("blk %d had endorsed another proposal of %d", blkNum, proposal.Block.getProposer())
+				}
 				return nil
 			}
 			return fmt.Errorf("blk %d had endorsed for %d, skip %d", blkNum,
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import "time"
+
+//clockTimer is a function scheduled on a clock, *time.Timer satisfies it
+type clockTimer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+//clock is the time source of the server. All consensus timers, peer tickers and block timestamps go through it,
+//so that a simulation can drive several servers with a virtual clock.
+type clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) clockTimer
+}
+
+type systemClock struct{}
+
+func (self systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (self systemClock) AfterFunc(d time.Duration, f func()) clockTimer {
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	gover "github.com/ontio/ontology/smartcontract/service/native/governance"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+//gas limit of the evidence transaction, submitEvidence is a single native invoke
+const EVIDENCE_GAS_LIMIT = 2 * config.DEFAULT_MIN_GAS_LIMIT
+
+type evidenceKind uint8
+
+const (
+	proposalEvidence evidenceKind = iota
+	endorseEvidence
+	emptyEndorseEvidence
+)
+
+type evidenceKey struct {
+	blkNum uint32
+	peer   uint32
+	kind   evidenceKind
+}
+
+//EvidencePool keeps the first proposal and endorsements of each peer for the recent blocks, and reports the
+//peers that signed a conflicting one.
+//The msgs signed by the node itself are checked against the sign log instead.
+type EvidencePool struct {
+	lock       sync.Mutex
+	server     *Server
+	historyLen uint32
+	proposals  map[evidenceKey]*blockProposalMsg
+	endorses   map[evidenceKey]*blockEndorseMsg
+	fetched    map[evidenceKey]bool // proposals fetched for the endorsements of an unknown version
+	reported   map[evidenceKey]bool
+}
+
+func newEvidencePool(server *Server, historyLen uint32) *EvidencePool {
+	return &EvidencePool{
+		server:     server,
+		historyLen: historyLen,
+		proposals:  make(map[evidenceKey]*blockProposalMsg),
+		endorses:   make(map[evidenceKey]*blockEndorseMsg),
+		fetched:    make(map[evidenceKey]bool),
+		reported:   make(map[evidenceKey]bool),
+	}
+}
+
+func sameProposal(p1, p2 *blockProposalMsg) bool {
+	if p1.Block.Block.Hash() != p2.Block.Block.Hash() {
+		return false
+	}
+	if p1.Block.EmptyBlock == nil || p2.Block.EmptyBlock == nil {
+		return p1.Block.EmptyBlock == p2.Block.EmptyBlock
+	}
+	return p1.Block.EmptyBlock.Hash() == p2.Block.EmptyBlock.Hash()
+}
+
+//onProposal returns the evidence if the proposer has signed another proposal for the block
+func (pool *EvidencePool) onProposal(msg *blockProposalMsg) *gover.EvidenceParam {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	key := evidenceKey{blkNum: msg.GetBlockNum(), peer: msg.Block.getProposer(), kind: proposalEvidence}
+	first, present := pool.proposals[key]
+	if !present {
+		pool.proposals[key] = msg
+		return nil
+	}
+	if pool.reported[key] || sameProposal(first, msg) {
+		return nil
+	}
+	if first.Block.EmptyBlock == nil || msg.Block.EmptyBlock == nil {
+		return nil
+	}
+	pool.reported[key] = true
+	return &gover.EvidenceParam{
+		Type:     gover.EVIDENCE_PROPOSAL,
+		BlockNum: key.blkNum,
+		Proposals: [2]*gover.ProposalEvidence{
+			proposalToEvidence(first),
+			proposalToEvidence(msg),
+		},
+	}
+}
+
+func proposalToEvidence(msg *blockProposalMsg) *gover.ProposalEvidence {
+	return &gover.ProposalEvidence{
+		Block:      proposerHeader(msg.Block.Block.Header),
+		EmptyBlock: proposerHeader(msg.Block.EmptyBlock.Header),
+	}
+}
+
+//proposerHeader copies the header with the proposer sig only
+func proposerHeader(header *types.Header) *types.Header {
+	h := *header
+	h.Bookkeepers = nil
+	h.SigData = [][]byte{header.SigData[0]}
+	return &h
+}
+
+//onEndorse returns the evidence if the endorser has signed another vote for the block, and whether the endorsed
+//proposal differs from the known proposal of the proposer, which may be the other half of a proposal evidence
+func (pool *EvidencePool) onEndorse(msg *blockEndorseMsg) (*gover.EvidenceParam, bool) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	fetch := false
+	proposalKey := evidenceKey{blkNum: msg.BlockNum, peer: msg.EndorsedProposer, kind: proposalEvidence}
+	if p, present := pool.proposals[proposalKey]; present && !pool.fetched[proposalKey] {
+		hash := p.Block.Block.Hash()
+		if msg.EndorseForEmpty && p.Block.EmptyBlock != nil {
+			hash = p.Block.EmptyBlock.Hash()
+		}
+		if hash != msg.EndorsedBlockHash {
+			pool.fetched[proposalKey] = true
+			fetch = true
+		}
+	}
+
+	if msg.EndorseVoteSig == nil {
+		return nil, fetch
+	}
+	key := evidenceKey{blkNum: msg.BlockNum, peer: msg.Endorser, kind: endorseEvidence}
+	if msg.EndorseForEmpty {
+		key.kind = emptyEndorseEvidence
+	}
+	first, present := pool.endorses[key]
+	if !present {
+		pool.endorses[key] = msg
+		return nil, fetch
+	}
+	if pool.reported[key] || first.EndorsedBlockHash == msg.EndorsedBlockHash {
+		return nil, fetch
+	}
+	pool.reported[key] = true
+	return &gover.EvidenceParam{
+		Type:     gover.EVIDENCE_ENDORSE,
+		BlockNum: key.blkNum,
+		Endorses: [2]*gover.EndorseEvidence{
+			{BlockHash: first.EndorsedBlockHash, ForEmpty: first.EndorseForEmpty, Sig: first.EndorseVoteSig},
+			{BlockHash: msg.EndorsedBlockHash, ForEmpty: msg.EndorseForEmpty, Sig: msg.EndorseVoteSig},
+		},
+	}, fetch
+}
+
+func (pool *EvidencePool) onBlockSealed(blockNum uint32) {
+	if blockNum <= pool.historyLen {
+		return
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for key := range pool.proposals {
+		if key.blkNum < blockNum-pool.historyLen {
+			delete(pool.proposals, key)
+		}
+	}
+	for key := range pool.endorses {
+		if key.blkNum < blockNum-pool.historyLen {
+			delete(pool.endorses, key)
+		}
+	}
+	for key := range pool.fetched {
+		if key.blkNum < blockNum-pool.historyLen {
+			delete(pool.fetched, key)
+		}
+	}
+	for key := range pool.reported {
+		if key.blkNum < blockNum-pool.historyLen {
+			delete(pool.reported, key)
+		}
+	}
+}
+
+//checkEvidence looks for equivocation in the consensus msg received from peerIdx, the msg has been verified with
+//the key of peerIdx
+func (self *Server) checkEvidence(peerIdx uint32, msg ConsensusMsg) {
+	// the evidence can not be submitted before the governance method is activated
+	blkNum := msg.GetBlockNum()
+	if blkNum < config.GetSubmitEvidenceHeight() {
+		return
+	}
+	// the other version of a msg may arrive after the block is sealed, the pool keeps the msgs of the recent blocks
+	if blkNum+self.msgHistoryDuration <= self.GetCommittedBlockNo() || blkNum > self.GetCurrentBlockNo()+self.msgHistoryDuration {
+		return
+	}
+
+	var evidence *gover.EvidenceParam
+	var peer uint32
+	switch pMsg := msg.(type) {
+	case *blockProposalMsg:
+		peer = pMsg.Block.getProposer()
+		if peer == self.Index {
+			return
+		}
+		evidence = self.evidencePool.onProposal(pMsg)
+	case *blockEndorseMsg:
+		if pMsg.Endorser != peerIdx || peerIdx == self.Index {
+			return
+		}
+		peer = pMsg.Endorser
+		var fetch bool
+		evidence, fetch = self.evidencePool.onEndorse(pMsg)
+		if fetch && pMsg.EndorsedProposer != self.Index {
+			// the endorser has another version of the proposal, get it to have the proposer's sig on both
+			self.fetchProposal(blkNum, pMsg.EndorsedProposer)
+		}
+	}
+	if evidence == nil {
+		return
+	}
+
+	log.Warnf("server %d detected equivocation of peer %d, blk %d, type %d", self.Index, peer, blkNum, evidence.Type)
+	if err := self.submitEvidence(peer, evidence); err != nil {
+		log.Errorf("server %d failed to submit evidence of peer %d, blk %d: %s", self.Index, peer, blkNum, err)
+	}
+}
+
+//submitEvidence broadcasts a governance submitEvidence transaction paid by the node account
+func (self *Server) submitEvidence(peer uint32, evidence *gover.EvidenceParam) error {
+	pk := self.peerPool.GetPeerPubKey(peer)
+	if pk == nil {
+		return fmt.Errorf("failed to get peer %d pubkey", peer)
+	}
+	evidence.PeerPubkey = vconfig.PubkeyID(pk)
+
+	tx, err := self.createEvidenceTransaction(evidence)
+	if err != nil {
+		return err
+	}
+	log.Infof("server %d broadcast evidence tx %s", self.Index, tx.Hash().ToHexString())
+	go self.p2p.Broadcast(msgpack.NewTxn(tx))
+	return nil
+}
+
+func (self *Server) createEvidenceTransaction(evidence *gover.EvidenceParam) (*types.Transaction, error) {
+	mutable := utils.BuildNativeTransaction(nutils.GovernanceContractAddress, gover.SUBMIT_EVIDENCE,
+		common.SerializeToBytes(evidence))
+	mutable.GasPrice = config.DefConfig.Common.GasPrice
+	mutable.GasLimit = EVIDENCE_GAS_LIMIT
+	mutable.Payer = self.account.Address
+	mutable.Nonce = rand.Uint32()
+
+	txHash := mutable.Hash()
+	sig, err := signature.Sign(self.account, txHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign evidence tx: %s", err)
+	}
+	mutable.Sigs = append(mutable.Sigs, types.Sig{
+		PubKeys: []keypair.PublicKey{self.account.PublicKey},
+		M:       1,
+		SigData: [][]byte{sig},
+	})
+	return mutable.IntoImmutable()
+}
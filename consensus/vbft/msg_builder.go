@@ -21,15 +21,15 @@ package vbft
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
-	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/signature"
 	"github.com/ontio/ontology/core/types"
+	gover "github.com/ontio/ontology/smartcontract/service/native/governance"
 )
 
 type ConsensusMsgPayload struct {
@@ -196,7 +196,7 @@ func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, tx
 	}
 
 	txRoot := common.ComputeMerkleRoot(txHash)
-	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoots(lastBlock.Block.Header.Height, []common.Uint256{lastBlock.Block.Header.TransactionsRoot, txRoot})
+	blockRoot := self.ledger.GetBlockRootWithNewTxRoots(lastBlock.Block.Header.Height, []common.Uint256{lastBlock.Block.Header.TransactionsRoot, txRoot})
 
 	blkHeader := &types.Header{
 		PrevBlockHash:    prevBlkHash,
@@ -251,7 +251,7 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 	if prevBlk == nil {
 		return nil, fmt.Errorf("failed to get prevBlock (%d)", blkNum-1)
 	}
-	blocktimestamp := uint32(time.Now().Unix())
+	blocktimestamp := uint32(self.clock.Now().Unix())
 	if prevBlk.Block.Header.Timestamp >= blocktimestamp {
 		blocktimestamp = prevBlk.Block.Header.Timestamp + 1
 	}
@@ -296,6 +296,10 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 	if err != nil {
 		return nil, fmt.Errorf("failed to crossChainMsgHash :%s,blkNum:%d", err, (blkNum - 1))
 	}
+	// the block sigs are released only if the node has not signed another proposal for the block
+	if err := self.signLog.sign(signProposal, blkNum, self.account.PublicKey, proposalHash(blk.Hash(), emptyBlk.Hash())); err != nil {
+		return nil, err
+	}
 	msg := &blockProposalMsg{
 		Block: &Block{
 			Block:              blk,
@@ -328,10 +332,22 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 		proposerSig = proposal.EmptyBlockProposerSig
 		blkHash = proposal.Block.EmptyBlock.Hash()
 	}
+	kind := signEndorse
+	if forEmpty {
+		kind = signEmptyEndorse
+	}
+	if err := self.signLog.sign(kind, proposal.Block.getBlockNum(), self.account.PublicKey, blkHash); err != nil {
+		return nil, err
+	}
 	endorserSig, err = signature.Sign(self.account, blkHash[:])
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, err: %s", blkHash, err)
 	}
+	vote := gover.EndorseVoteHash(config.DefConfig.P2PNode.NetworkId, proposal.Block.getBlockNum(), blkHash, forEmpty)
+	voteSig, err := signature.Sign(self.account, vote[:])
+	if err != nil {
+		return nil, fmt.Errorf("endorser failed to sign vote. hash:%x, err: %s", vote, err)
+	}
 
 	msg := &blockEndorseMsg{
 		Endorser:          self.Index,
@@ -341,6 +357,7 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 		EndorseForEmpty:   forEmpty,
 		ProposerSig:       proposerSig,
 		EndorserSig:       endorserSig,
+		EndorseVoteSig:    voteSig,
 	}
 	if proposal.Block.CrossChainMsg != nil {
 		hash := proposal.Block.CrossChainMsg.Hash()
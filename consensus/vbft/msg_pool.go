@@ -20,9 +20,11 @@ package vbft
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/ontio/ontology/common"
+	actorTypes "github.com/ontio/ontology/consensus/actor"
 )
 
 var errDropFarFutureMsg = errors.New("msg pool dropped msg for far future")
@@ -209,6 +211,25 @@ func (pool *MsgPool) GetBlockSubmitMsgNums(blocknum uint32) []ConsensusMsg {
 	return msg
 }
 
+func (pool *MsgPool) getRoundStatus() []*actorTypes.RoundStatus {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	rounds := make([]*actorTypes.RoundStatus, 0, len(pool.rounds))
+	for blkNum, round := range pool.rounds {
+		rounds = append(rounds, &actorTypes.RoundStatus{
+			BlockNum:     blkNum,
+			Proposals:    len(round.msgs[BlockProposalMessage]),
+			Endorsements: len(round.msgs[BlockEndorseMessage]),
+			Commits:      len(round.msgs[BlockCommitMessage]),
+		})
+	}
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].BlockNum < rounds[j].BlockNum
+	})
+	return rounds
+}
+
 func (pool *MsgPool) onBlockSealed(blockNum uint32) {
 	if blockNum <= pool.historyLen {
 		return
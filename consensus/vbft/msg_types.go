@@ -27,8 +27,10 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-crypto/signature"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/serialization"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	gover "github.com/ontio/ontology/smartcontract/service/native/governance"
 )
 
 type MsgType uint8
@@ -158,6 +160,7 @@ type blockEndorseMsg struct {
 	EndorserSig              []byte          `json:"endorser_sig"`
 	CrossChainMsgHash        common.Uint256  `json:"cross_chain_msg_hash"`
 	CrossChainMsgEndorserSig []byte          `json:"cross_chain_msg_endorser_sig"`
+	EndorseVoteSig           []byte          `json:"endorse_vote_sig"` // sig on the endorse vote hash, as equivocation evidence
 }
 
 func (msg *blockEndorseMsg) Type() MsgType {
@@ -183,6 +186,17 @@ func (msg *blockEndorseMsg) Verify(pub keypair.PublicKey) error {
 			return fmt.Errorf("endorse failed to verify cross chain endorse sig")
 		}
 	}
+	if msg.EndorseVoteSig != nil {
+		vSig, err := signature.Deserialize(msg.EndorseVoteSig)
+		if err != nil {
+			return fmt.Errorf("endorse deserialize vote sig: %s", err)
+		}
+		vote := gover.EndorseVoteHash(config.DefConfig.P2PNode.NetworkId, msg.BlockNum, msg.EndorsedBlockHash,
+			msg.EndorseForEmpty)
+		if !signature.Verify(pub, vote[:], vSig) {
+			return fmt.Errorf("endorse failed to verify vote sig")
+		}
+	}
 	return nil
 }
 
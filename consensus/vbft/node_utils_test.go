@@ -69,6 +69,7 @@ func constructServer() *Server {
 		config:                   chainconfig,
 		chainStore:               chainstore,
 		currentParticipantConfig: blockparticipantconfig,
+		clock:                    systemClock{},
 	}
 	return server
 }
@@ -20,10 +20,12 @@ package vbft
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
+	actorTypes "github.com/ontio/ontology/consensus/actor"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/p2pserver/common"
 )
@@ -103,6 +105,30 @@ func (pool *PeerPool) addPeer(config *vconfig.PeerConfig) error {
 	return nil
 }
 
+//rotatePeer replaces the key of the peer at config.Index, the old key and the p2p id of its node are dropped
+func (pool *PeerPool) rotatePeer(config *vconfig.PeerConfig) error {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	peerPK, err := vconfig.Pubkey(config.ID)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal peer pubkey: %s", err)
+	}
+	if old, present := pool.configs[config.Index]; present {
+		delete(pool.IDMap, old.ID)
+	}
+	delete(pool.P2pMap, config.Index)
+	pool.configs[config.Index] = config
+	pool.IDMap[config.ID] = config.Index
+	pool.peers[config.Index] = &Peer{
+		Index:          config.Index,
+		PubKey:         peerPK,
+		LastUpdateTime: time.Unix(0, 0),
+		connected:      false,
+	}
+	return nil
+}
+
 func (pool *PeerPool) getActivePeerCount() int {
 	pool.lock.RLock()
 	defer pool.lock.RUnlock()
@@ -156,15 +182,16 @@ func (pool *PeerPool) peerDisconnected(peerIdx uint32) {
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
 
-	var lastUpdateTime time.Time
-	if p, present := pool.peers[peerIdx]; present {
-		lastUpdateTime = p.LastUpdateTime
+	// the pool may have been cleaned by server stop
+	p, present := pool.peers[peerIdx]
+	if !present {
+		return
 	}
 
 	pool.peers[peerIdx] = &Peer{
 		Index:          peerIdx,
-		PubKey:         pool.peers[peerIdx].PubKey,
-		LastUpdateTime: lastUpdateTime,
+		PubKey:         p.PubKey,
+		LastUpdateTime: p.LastUpdateTime,
 		connected:      false,
 	}
 }
@@ -216,6 +243,36 @@ func (pool *PeerPool) getNeighbours() []*Peer {
 	return peers
 }
 
+func (pool *PeerPool) getPeerStatus() []*actorTypes.PeerStatus {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	peers := make([]*actorTypes.PeerStatus, 0, len(pool.peers))
+	for idx, p := range pool.peers {
+		status := &actorTypes.PeerStatus{
+			Index:        idx,
+			Connected:    p.connected,
+			HeartbeatAge: -1,
+		}
+		if p.PubKey != nil {
+			status.PubKey = vconfig.PubkeyID(p.PubKey)
+		}
+		if p.LatestInfo != nil {
+			status.CommittedBlockNum = p.LatestInfo.CommittedBlockNumber
+		} else if p.handShake != nil {
+			status.CommittedBlockNum = p.handShake.CommittedBlockNumber
+		}
+		if p.LastUpdateTime.Unix() > 0 {
+			status.HeartbeatAge = time.Since(p.LastUpdateTime).Seconds()
+		}
+		peers = append(peers, status)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Index < peers[j].Index
+	})
+	return peers
+}
+
 func (pool *PeerPool) GetPeerIndex(nodeId string) (uint32, bool) {
 	pool.lock.RLock()
 	defer pool.lock.RUnlock()
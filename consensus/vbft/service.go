@@ -22,8 +22,10 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"path/filepath"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
@@ -31,6 +33,7 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	actorTypes "github.com/ontio/ontology/consensus/actor"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
@@ -95,6 +98,7 @@ type Server struct {
 	ledger        *ledger.Ledger
 	incrValidator *increment.IncrementValidator
 	pid           *actor.PID
+	clock         clock
 
 	// some config
 	msgHistoryDuration uint32
@@ -112,13 +116,16 @@ type Server struct {
 	config                   *vconfig.ChainConfig
 	currentParticipantConfig *BlockParticipantConfig
 
-	chainStore *ChainStore // block store
-	msgPool    *MsgPool    // consensus msg pool
-	blockPool  *BlockPool  // received block proposals
-	peerPool   *PeerPool   // consensus peers
-	syncer     *Syncer
-	stateMgr   *StateMgr
-	timer      *EventTimer
+	chainStore   *ChainStore   // block store
+	msgPool      *MsgPool      // consensus msg pool
+	blockPool    *BlockPool    // received block proposals
+	peerPool     *PeerPool     // consensus peers
+	evidencePool *EvidencePool // first msgs of the peers, to detect equivocation
+	signLog      *SignLog      // proposals and endorsements signed by the node
+	syncer       *Syncer
+	stateMgr     *StateMgr
+	timer        *EventTimer
+	stats        serverStats
 
 	msgRecvC   *sync.Map // map[uint32]chan *p2pMsgPayload
 	msgC       chan ConsensusMsg
@@ -131,15 +138,12 @@ type Server struct {
 }
 
 func NewVbftServer(account *account.Account, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
-	server := &Server{
-		msgHistoryDuration: 64,
-		account:            account,
-		poolActor:          &actorTypes.TxPoolActor{Pool: txpool},
-		p2p:                p2p,
-		ledger:             ledger.DefLedger,
-		incrValidator:      increment.NewIncrementValidator(20),
+	signLog, err := OpenSignLog(filepath.Join(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName,
+		SIGN_LOG_DIR))
+	if err != nil {
+		return nil, err
 	}
-	server.stateMgr = newStateMgr(server)
+	server := newServer(account, &actorTypes.TxPoolActor{Pool: txpool}, p2p, ledger.DefLedger, signLog, systemClock{})
 
 	props := actor.FromProducer(func() actor.Actor {
 		return server
@@ -158,6 +162,22 @@ func NewVbftServer(account *account.Account, txpool *actor.PID, p2p p2p.P2P) (*S
 	return server, nil
 }
 
+func newServer(account *account.Account, poolActor *actorTypes.TxPoolActor, p2p p2p.P2P, db *ledger.Ledger,
+	signLog *SignLog, clock clock) *Server {
+	server := &Server{
+		msgHistoryDuration: 64,
+		account:            account,
+		poolActor:          poolActor,
+		p2p:                p2p,
+		ledger:             db,
+		signLog:            signLog,
+		incrValidator:      increment.NewIncrementValidator(20),
+		clock:              clock,
+	}
+	server.stateMgr = newStateMgr(server)
+	return server
+}
+
 func (self *Server) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Restarting:
@@ -174,6 +194,8 @@ func (self *Server) Receive(context actor.Context) {
 		log.Info("vbft actor start consensus")
 	case *actorTypes.StopConsensus:
 		self.stop()
+	case *actorTypes.GetConsensusStatus:
+		context.Respond(self.GetConsensusStatus())
 	case *message.SaveBlockCompleteMsg:
 		log.Infof("vbft actor SaveBlockCompleteMsg receives block complete event. block height=%d, numtx=%d",
 			msg.Block.Header.Height, len(msg.Block.Transactions))
@@ -212,6 +234,9 @@ func (self *Server) handleBlockPersistCompleted(block *types.Block) {
 		return
 	}
 	self.SetCompletedBlockNum(block.Header.Height)
+	if err := self.signLog.prune(block.Header.Height); err != nil {
+		log.Errorf("server %d, prune sign log to block %d: %s", self.Index, block.Header.Height, err)
+	}
 	self.incrValidator.AddBlock(block)
 	if self.nonConsensusNode() {
 		self.blockPool.ReloadFromLedger()
@@ -368,6 +393,11 @@ func (self *Server) updateChainConfig() error {
 	peermap := make(map[uint32]string)
 	for _, p := range self.GetChainConfig().Peers {
 		peermap[p.Index] = p.ID
+		if self.Index == p.Index && pubkey != p.ID {
+			// the key of this peer has been rotated, the node with the new key takes over the index
+			self.Index = math.MaxUint32
+			log.Infof("updateChainConfig rotated index :%d", p.Index)
+		}
 		if self.Index == math.MaxUint32 && pubkey == p.ID {
 			self.Index = p.Index
 			log.Infof("updateChainConfig add index :%d", self.Index)
@@ -381,7 +411,16 @@ func (self *Server) updateChainConfig() error {
 				return fmt.Errorf("peer %d: invalid peer pubkey for VRF", p.Index)
 			}
 
-			if err := self.peerPool.addPeer(p); err != nil {
+			if self.peerPool.GetPeerPubKey(p.Index) != nil {
+				if err := self.peerPool.rotatePeer(p); err != nil {
+					return fmt.Errorf("failed to rotate peer %d: %s", p.Index, err)
+				}
+				log.Infof("updateChainConfig rotate peer index:%v,id:%v", p.Index, p.ID)
+				if self.GetPeerMsgChan(p.Index) != nil {
+					// the processor of the index verifies the msgs with the key in peer pool
+					continue
+				}
+			} else if err := self.peerPool.addPeer(p); err != nil {
 				return fmt.Errorf("failed to add peer %d: %s", p.Index, err)
 			}
 			publickey, err := vconfig.Pubkey(p.ID)
@@ -440,6 +479,7 @@ func (self *Server) initialize() error {
 		return fmt.Errorf("init blockpool: %s", err)
 	}
 	self.msgPool = newMsgPool(self, self.msgHistoryDuration)
+	self.evidencePool = newEvidencePool(self, self.msgHistoryDuration)
 	self.peerPool = NewPeerPool(0, self) // FIXME: maxSize
 	self.timer = NewEventTimer(self)
 	self.syncer = newSyncer(self)
@@ -769,6 +809,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		log.Debugf("dup msg with msg type %d from %d", msg.Type(), peerIdx)
 		return
 	}
+	self.checkEvidence(peerIdx, msg)
 
 	switch msg.Type() {
 	case BlockProposalMessage:
@@ -960,7 +1001,8 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 			return
 		}
 		var pmsg *blockProposalMsg
-		if self.Index == pMsg.ProposerID || pMsg.BlockNum == self.GetCurrentBlockNo() {
+		// the proposals of the sealed blocks are fetched as evidence of an equivocating proposer
+		if self.Index == pMsg.ProposerID || pMsg.BlockNum <= self.GetCurrentBlockNo() {
 			pMsgs := self.msgPool.GetProposalMsgs(pMsg.BlockNum)
 			for _, msg := range pMsgs {
 				p := msg.(*blockProposalMsg)
@@ -1144,7 +1186,7 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 
 	prevBlockTimestamp := blk.Block.Header.Timestamp
 	currentBlockTimestamp := msg.Block.Block.Header.Timestamp
-	if currentBlockTimestamp <= prevBlockTimestamp || currentBlockTimestamp > uint32(time.Now().Add(time.Minute*10).Unix()) {
+	if currentBlockTimestamp <= prevBlockTimestamp || currentBlockTimestamp > uint32(self.clock.Now().Add(time.Minute*10).Unix()) {
 		log.Errorf("BlockPrposalMessage check  blocknum:%d,prevBlockTimestamp:%d,currentBlockTimestamp:%d", msg.GetBlockNum(), prevBlockTimestamp, currentBlockTimestamp)
 		self.msgPool.DropMsg(msg)
 		return
@@ -1674,6 +1716,7 @@ func (self *Server) timerLoop() {
 }
 
 func (self *Server) processTimerEvent(evt *TimerEvent) error {
+	self.stats.onTimerEvent(evt.evtType)
 	switch evt.evtType {
 	case EventProposalBackoff:
 		// 1. if endorsed, return
@@ -2104,6 +2147,7 @@ func (self *Server) sealBlock(block *Block, empty bool, sigdata bool) error {
 	// notify other modules that block sealed
 	self.timer.onBlockSealed(sealedBlkNum)
 	self.msgPool.onBlockSealed(sealedBlkNum)
+	self.evidencePool.onBlockSealed(sealedBlkNum)
 	self.blockPool.onBlockSealed(sealedBlkNum)
 
 	_, h := self.blockPool.getSealedBlock(sealedBlkNum)
@@ -2176,7 +2220,7 @@ func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 
 //checkUpdateChainConfig query leveldb check is force update
 func (self *Server) checkUpdateChainConfig(blkNum uint32) bool {
-	force, err := isUpdate(self.blockPool.getExecWriteSet(blkNum-1), self.GetChainConfig().View)
+	force, err := isUpdate(self.ledger, self.blockPool.getExecWriteSet(blkNum-1), self.GetChainConfig().View)
 	if err != nil {
 		log.Errorf("checkUpdateChainConfig err:%s", err)
 		return false
@@ -2217,6 +2261,13 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 			self.Index, blkNum, self.GetCurrentBlockNo())
 	}
 
+	// a second proposal for the block is slashable, whether or not it is for empty
+	for _, msg := range self.msgPool.GetProposalMsgs(blkNum) {
+		if p, ok := msg.(*blockProposalMsg); ok && p.Block.getProposer() == self.Index {
+			return fmt.Errorf("server %d has proposed for block %d", self.Index, blkNum)
+		}
+	}
+
 	validHeight := self.validHeight(blkNum)
 	sysTxs := make([]*types.Transaction, 0)
 	userTxs := make([]*types.Transaction, 0)
@@ -2224,7 +2275,7 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	//check need upate chainconfig
 	var cfg *vconfig.ChainConfig
 	if self.checkNeedUpdateChainConfig(blkNum) || self.checkUpdateChainConfig(blkNum) {
-		chainconfig, err := getChainConfig(self.blockPool.getExecWriteSet(blkNum-1), blkNum)
+		chainconfig, err := getChainConfig(self.ledger, self.blockPool.getExecWriteSet(blkNum-1), blkNum)
 		if err != nil {
 			return fmt.Errorf("getChainConfig failed:%s", err)
 		}
@@ -2405,6 +2456,7 @@ func (self *Server) catchConsensus(blkNum uint32) error {
 	if !self.isEndorser(blkNum, self.Index) && !self.isCommitter(blkNum, self.Index) {
 		return nil
 	}
+	atomic.AddUint64(&self.stats.catchConsensus, 1)
 
 	proposals := make(map[uint32]*blockProposalMsg)
 	pMsgs := self.msgPool.GetProposalMsgs(blkNum)
@@ -2533,6 +2585,7 @@ func (self *Server) hasBlockConsensused() bool {
 }
 
 func (self *Server) restartSyncing() {
+	atomic.AddUint64(&self.stats.restartSyncing, 1)
 
 	// send sync request to self.sync, go syncing-state immediately
 	// stop all bft timers
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+//dir of the sign log in the ledger dir of the network
+const SIGN_LOG_DIR = "vbftsign"
+
+type signKind byte
+
+const (
+	signProposal signKind = iota
+	signEndorse
+	signEmptyEndorse
+)
+
+//SignLog persists the proposals and endorsements signed by the node. Two versions of them for the same block are
+//evidence for slashing, so a node restarted in the middle of a round checks the log before it signs again.
+type SignLog struct {
+	lock  sync.Mutex
+	store *leveldbstore.LevelDBStore
+}
+
+func OpenSignLog(path string) (*SignLog, error) {
+	store, err := leveldbstore.NewLevelDBStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("open sign log %s: %s", path, err)
+	}
+	return &SignLog{store: store}, nil
+}
+
+func (self *SignLog) Close() error {
+	return self.store.Close()
+}
+
+func signLogKey(kind signKind, blkNum uint32, signer keypair.PublicKey) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(kind)
+	binary.BigEndian.PutUint32(key[1:], blkNum)
+	return append(key, keypair.SerializePublicKey(signer)...)
+}
+
+//proposalHash identifies a proposal by its block and empty block
+func proposalHash(blkHash, emptyBlkHash common.Uint256) common.Uint256 {
+	return sha256.Sum256(append(blkHash[:], emptyBlkHash[:]...))
+}
+
+//sign records that the signer signs hash for the block, it fails if the signer has signed another hash of the kind
+func (self *SignLog) sign(kind signKind, blkNum uint32, signer keypair.PublicKey, hash common.Uint256) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	key := signLogKey(kind, blkNum, signer)
+	signed, err := self.store.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return fmt.Errorf("get sign log of block %d: %s", blkNum, err)
+	}
+	if signed != nil {
+		if !bytes.Equal(signed, hash[:]) {
+			return fmt.Errorf("signed another version of kind %d for block %d", kind, blkNum)
+		}
+		return nil
+	}
+	if err := self.store.Put(key, hash[:]); err != nil {
+		return fmt.Errorf("put sign log of block %d: %s", blkNum, err)
+	}
+	return nil
+}
+
+//prune removes the records of the blocks up to blkNum, the node never signs a msg of a block in its ledger
+func (self *SignLog) prune(blkNum uint32) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.store.NewBatch()
+	for _, kind := range []signKind{signProposal, signEndorse, signEmptyEndorse} {
+		iter := self.store.NewIterator([]byte{byte(kind)})
+		for iter.Next() {
+			if binary.BigEndian.Uint32(iter.Key()[1:5]) > blkNum {
+				break
+			}
+			self.store.BatchDelete(iter.Key())
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return fmt.Errorf("iterate sign log: %s", err)
+		}
+	}
+	return self.store.BatchCommit()
+}
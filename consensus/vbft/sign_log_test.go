@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+)
+
+func TestSignLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vbft_sign_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	signLog, err := OpenSignLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, other := account.NewAccount("").PublicKey, account.NewAccount("").PublicKey
+	if err := signLog.sign(signProposal, 10, signer, common.Uint256{1}); err != nil {
+		t.Fatalf("sign proposal: %s", err)
+	}
+	if err := signLog.sign(signEndorse, 10, signer, common.Uint256{2}); err != nil {
+		t.Fatalf("sign endorse: %s", err)
+	}
+	if err := signLog.sign(signEmptyEndorse, 10, signer, common.Uint256{3}); err != nil {
+		t.Fatalf("sign empty endorse: %s", err)
+	}
+	if err := signLog.sign(signProposal, 10, other, common.Uint256{4}); err != nil {
+		t.Fatalf("sign proposal with another key: %s", err)
+	}
+	if err := signLog.sign(signProposal, 11, signer, common.Uint256{5}); err != nil {
+		t.Fatalf("sign proposal of the next block: %s", err)
+	}
+
+	// the log survives a restart
+	signLog.Close()
+	if signLog, err = OpenSignLog(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer signLog.Close()
+	if err := signLog.sign(signEndorse, 10, signer, common.Uint256{2}); err != nil {
+		t.Fatalf("sign the same endorse again: %s", err)
+	}
+	if err := signLog.sign(signEndorse, 10, signer, common.Uint256{6}); err == nil {
+		t.Fatalf("signed another endorse for block 10")
+	}
+	if err := signLog.sign(signProposal, 10, signer, common.Uint256{6}); err == nil {
+		t.Fatalf("signed another proposal for block 10")
+	}
+
+	if err := signLog.prune(10); err != nil {
+		t.Fatalf("prune: %s", err)
+	}
+	if err := signLog.sign(signProposal, 10, signer, common.Uint256{6}); err != nil {
+		t.Fatalf("sign proposal of pruned block: %s", err)
+	}
+	if err := signLog.sign(signProposal, 11, signer, common.Uint256{6}); err == nil {
+		t.Fatalf("signed another proposal for block 11 after prune")
+	}
+}
@@ -0,0 +1,326 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/core/validation"
+	ontErrors "github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/smartcontract/event"
+	gover "github.com/ontio/ontology/smartcontract/service/native/governance"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const simNodes = 7
+
+func TestSimClock(t *testing.T) {
+	clock := newSimClock()
+	node := clock.newNodeClock()
+	fired := make(chan int, 4)
+	node.AfterFunc(2*time.Second, func() { fired <- 2 })
+	t1 := node.AfterFunc(time.Second, func() { fired <- 1 })
+	t3 := node.AfterFunc(3*time.Second, func() { fired <- 3 })
+	t1.Reset(4 * time.Second)
+
+	clock.advance(simEpoch.Add(10 * time.Second))
+	if now := clock.Now(); !now.Equal(simEpoch.Add(2 * time.Second)) {
+		t.Fatalf("clock advanced to %s, expect the first due timer", now.Sub(simEpoch))
+	}
+	if v := <-fired; v != 2 {
+		t.Fatalf("timer %d fired first", v)
+	}
+	if !t3.Stop() || t3.Stop() {
+		t.Fatalf("stop of a pending timer")
+	}
+	clock.stop(node)
+	clock.advance(simEpoch.Add(10 * time.Second))
+	if !clock.Now().Equal(simEpoch.Add(10 * time.Second)) {
+		t.Fatalf("clock not advanced to the limit")
+	}
+	if node.AfterFunc(0, func() { fired <- 0 }).Stop() {
+		t.Fatalf("timer scheduled on a stopped clock")
+	}
+	select {
+	case v := <-fired:
+		t.Fatalf("timer %d fired after stop", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestSimulationCommit(t *testing.T) {
+	sim := newSimulation(t, simNodes, 1)
+	defer sim.close()
+
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(5*time.Minute, 5, 0, 1, 2, 3, 4, 5, 6)
+}
+
+func TestSimulationLossyNetwork(t *testing.T) {
+	sim := newSimulation(t, simNodes, 2)
+	defer sim.close()
+
+	sim.addFault(dropRate(10))
+	sim.addFault(delayMsgs(0, 500*time.Millisecond))
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(10*time.Minute, 5, 0, 1, 2, 3, 4, 5, 6)
+}
+
+func TestSimulationCrashRestart(t *testing.T) {
+	sim := newSimulation(t, simNodes, 3)
+	defer sim.close()
+
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(5*time.Minute, 3, 0, 1, 2, 3, 4, 5, 6)
+
+	//the network tolerates c = 2 crashed nodes
+	sim.crash(0)
+	sim.crash(1)
+	height := sim.height(2)
+	sim.runUntilHeight(10*time.Minute, height+3, 2, 3, 4, 5, 6)
+
+	sim.restart(0)
+	sim.restart(1)
+	height = sim.height(2)
+	sim.runUntilHeight(10*time.Minute, height+3, 0, 1, 2, 3, 4, 5, 6)
+	//the restarted nodes keep the msgs they signed before the crash in the sign log
+	if txs := sim.evidenceTxs(); len(txs) != 0 {
+		t.Fatalf("%d evidence txs against honest nodes", len(txs))
+	}
+}
+
+func TestSimulationPartition(t *testing.T) {
+	sim := newSimulation(t, simNodes, 4)
+	defer sim.close()
+
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(5*time.Minute, 2, 0, 1, 2, 3, 4, 5, 6)
+
+	sim.addFault(partition(5, 6))
+	height := sim.height(0)
+	sim.runUntilHeight(10*time.Minute, height+3, 0, 1, 2, 3, 4)
+
+	sim.clearFaults()
+	height = sim.height(0)
+	sim.runUntilHeight(10*time.Minute, height+3, 0, 1, 2, 3, 4, 5, 6)
+}
+
+func TestSimulationSilentProposer(t *testing.T) {
+	sim := newSimulation(t, simNodes, 5)
+	defer sim.close()
+
+	//the rounds led by node 2 have to be taken over by the second proposers
+	sim.addFault(dropMsgs(2, BlockProposalMessage))
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(10*time.Minute, 8, 0, 1, 2, 3, 4, 5, 6)
+}
+
+//vbft counts the endorsements and commits of a round per proposer, so honest nodes that received different versions
+//of the proposal of an equivocating proposer seal different blocks. The simulation has to report that fork.
+func TestSimulationEquivocatingProposer(t *testing.T) {
+	sim := newSimulation(t, simNodes, 6)
+	defer sim.close()
+
+	sim.equivocate(0)
+	sim.equivocate(1)
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntil(10*time.Minute, "a fork", func() bool {
+		return len(sim.forks()) > 0
+	})
+}
+
+//the honest nodes that received the two versions of a proposal, directly or through the proposal fetch triggered by
+//the endorsements of the other version, broadcast the evidence
+func TestSimulationEvidence(t *testing.T) {
+	sim := newSimulation(t, simNodes, 6)
+	defer sim.close()
+
+	sim.equivocate(0)
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntil(10*time.Minute, "an evidence", func() bool {
+		return len(sim.evidenceTxs()) > 0
+	})
+
+	equivocator := sim.nodes[0].server
+	for _, tx := range sim.evidenceTxs() {
+		evidence, err := decodeEvidenceTx(tx)
+		if err != nil {
+			t.Fatalf("invalid evidence tx: %s", err)
+		}
+		if evidence.Type != gover.EVIDENCE_PROPOSAL || evidence.PeerPubkey != vconfig.PubkeyID(sim.nodes[0].acc.PublicKey) {
+			t.Fatalf("evidence of type %d against %s", evidence.Type, evidence.PeerPubkey)
+		}
+		prevBlockHash := sim.nodes[1].ledger.GetBlockHash(evidence.BlockNum - 1)
+		if err := evidence.Verify(equivocator.Index, config.DefConfig.P2PNode.NetworkId, prevBlockHash); err != nil {
+			t.Fatalf("verify evidence of block %d: %s", evidence.BlockNum, err)
+		}
+		if tx.Payer == sim.nodes[0].acc.Address || validation.VerifyTransaction(tx) != ontErrors.ErrNoError {
+			t.Fatalf("evidence tx of block %d not signed by an honest node", evidence.BlockNum)
+		}
+	}
+}
+
+//the admin rotates the key of a consensus peer, the node with the old key leaves consensus on the next block and the
+//node restarted with the new key takes over its index
+func TestSimulationRotatePeerKey(t *testing.T) {
+	sim := newSimulation(t, simNodes, 8)
+	defer sim.close()
+
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(5*time.Minute, 3, 0, 1, 2, 3, 4, 5, 6)
+
+	rotated := sim.nodes[6]
+	index := sim.liveServer(rotated).Index
+	oldID := vconfig.PubkeyID(rotated.acc.PublicKey)
+	newAcc := account.NewAccount("")
+	newID := vconfig.PubkeyID(newAcc.PublicKey)
+	tx, err := sim.rotatePeerKeyTx(oldID, newID)
+	if err != nil {
+		t.Fatalf("build rotatePeerKey tx: %s", err)
+	}
+	sim.submit(tx)
+	sim.runUntil(5*time.Minute, "the rotatePeerKey tx sealed", func() bool {
+		sealed, _ := sim.nodes[0].ledger.IsContainTransaction(tx.Hash())
+		return sealed
+	})
+	notify, err := sim.nodes[0].ledger.GetEventNotifyByTx(tx.Hash())
+	if err != nil || notify == nil || notify.State != event.CONTRACT_STATE_SUCCESS {
+		t.Fatalf("rotatePeerKey tx failed: %v, %+v", err, notify)
+	}
+	blackList, err := sim.nodes[0].ledger.GetStorageItem(nutils.GovernanceContractAddress,
+		append([]byte(gover.BLACK_LIST), keypair.SerializePublicKey(rotated.acc.PublicKey)...))
+	if err != nil || blackList == nil {
+		t.Fatalf("old key not in black list: %v", err)
+	}
+
+	height := sim.height(0)
+	sim.runUntilHeight(5*time.Minute, height+2, 0, 1, 2, 3, 4, 5, 6)
+	if idx := sim.liveServer(rotated).Index; idx != math.MaxUint32 {
+		t.Fatalf("node with the old key still at index %d", idx)
+	}
+	for _, node := range sim.nodes {
+		pool := sim.liveServer(node).peerPool
+		if idx, present := pool.GetPeerIndex(newID); !present || idx != index {
+			t.Fatalf("node %d: new key at index %d, present %v", node.index, idx, present)
+		}
+		if _, present := pool.GetPeerIndex(oldID); present {
+			t.Fatalf("node %d: old key still in peer pool", node.index)
+		}
+	}
+
+	// the node restarted with the new key signs the blocks at its index
+	sim.rotate(6, newAcc)
+	sim.crash(5)
+	height = sim.height(0)
+	sim.runUntil(10*time.Minute, "a block signed by the new key", func() bool {
+		for blkNum := height + 1; blkNum <= sim.height(0); blkNum++ {
+			header, err := sim.nodes[0].ledger.GetHeaderByHeight(blkNum)
+			if err != nil {
+				t.Fatalf("get header %d: %s", blkNum, err)
+			}
+			for _, pk := range header.Bookkeepers {
+				if vconfig.PubkeyID(pk) == newID {
+					return true
+				}
+			}
+		}
+		return false
+	})
+	if idx := sim.liveServer(rotated).Index; idx != index {
+		t.Fatalf("node with the new key at index %d", idx)
+	}
+}
+
+//rotatePeerKeyTx builds the governance rotatePeerKey tx, signed by the admin which is the multisig of the genesis
+//bookkeepers
+func (self *simulation) rotatePeerKeyTx(peerPubkey, newPeerPubkey string) (*types.Transaction, error) {
+	param := &gover.RotatePeerKeyParam{PeerPubkey: peerPubkey, NewPeerPubkey: newPeerPubkey}
+	code, err := utils.BuildNativeInvokeCode(nutils.GovernanceContractAddress, 0, gover.ROTATE_PEER_KEY,
+		[]interface{}{param})
+	if err != nil {
+		return nil, err
+	}
+	var keys []keypair.PublicKey
+	for _, node := range self.nodes {
+		keys = append(keys, node.acc.PublicKey)
+	}
+	m := (5*len(keys) + 6) / 7
+	admin, err := types.AddressFromMultiPubKeys(keys, m)
+	if err != nil {
+		return nil, err
+	}
+
+	mutable := utils.NewInvokeTransaction(code)
+	mutable.GasLimit = 20 * config.DEFAULT_MIN_GAS_LIMIT
+	mutable.Payer = admin
+	hash := mutable.Hash()
+	sigs := make([][]byte, 0, m)
+	for _, node := range self.nodes[:m] {
+		sig, err := signature.Sign(node.acc, hash[:])
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	mutable.Sigs = []types.Sig{{PubKeys: keys, M: uint16(m), SigData: sigs}}
+	return mutable.IntoImmutable()
+}
+
+//decodeEvidenceTx gets the evidence from the args of the native invoke, the first push of the code
+func decodeEvidenceTx(tx *types.Transaction) (*gover.EvidenceParam, error) {
+	invoke, ok := tx.Payload.(*payload.InvokeCode)
+	if !ok || len(invoke.Code) < 5 {
+		return nil, fmt.Errorf("not a native invoke")
+	}
+	code := invoke.Code
+	var size, offset int
+	switch op := code[0]; {
+	case op <= 75:
+		size, offset = int(op), 1
+	case op == 0x4c:
+		size, offset = int(code[1]), 2
+	case op == 0x4d:
+		size, offset = int(binary.LittleEndian.Uint16(code[1:])), 3
+	case op == 0x4e:
+		size, offset = int(binary.LittleEndian.Uint32(code[1:])), 5
+	default:
+		return nil, fmt.Errorf("invalid push op %x", op)
+	}
+	if offset+size > len(code) {
+		return nil, fmt.Errorf("invalid push size %d", size)
+	}
+	evidence := new(gover.EvidenceParam)
+	if err := evidence.Deserialization(common.NewZeroCopySource(code[offset : offset+size])); err != nil {
+		return nil, err
+	}
+	return evidence, nil
+}
@@ -0,0 +1,839 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+//
+// In-process simulation of a vbft network.
+//
+// Every node runs a real Server on its own ledger, the consensus messages travel over p2pserver/mock. All timers of
+// the servers are scheduled on a virtual clock which the simulation advances step by step, giving the goroutines of
+// the servers a short real time to settle between two steps. Messages pass through a scriptable fault layer that can
+// drop, delay and reorder them, nodes can be crashed and restarted, and a node can be turned into an equivocating
+// proposer. After every step the blocks sealed by the nodes are checked so that no two different blocks are ever
+// sealed at the same height.
+//
+// Fault decisions are drawn from a random source derived from the seed and the identity of the message (sender,
+// receiver, type, block number and sequence), so a seed reproduces the same fault pattern independently of the order
+// in which the goroutines hand their messages to the network.
+//
+
+import (
+	"container/heap"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology-eventbus/eventhub"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/events"
+	p2pcommon "github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/message/msg_pack"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/mock"
+	"github.com/ontio/ontology/p2pserver/net/netserver"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
+	txpool "github.com/ontio/ontology/txnpool/common"
+)
+
+const (
+	simTick   = 100 * time.Millisecond // max virtual time of a step
+	simSettle = 2 * time.Millisecond   // real time given to the servers after a step
+)
+
+// the virtual clock starts at a fixed time, so that block timestamps only depend on the schedule
+var simEpoch = time.Unix(1600000000, 0)
+
+///////////////////////////////////////////////////////////
+//
+// virtual clock
+//
+///////////////////////////////////////////////////////////
+
+type simTimer struct {
+	clock *nodeClock
+	f     func()
+	due   time.Time
+	seq   uint64
+	index int // index in the timer queue, -1 if not scheduled
+}
+
+type simTimerQueue []*simTimer
+
+func (tq simTimerQueue) Len() int {
+	return len(tq)
+}
+
+func (tq simTimerQueue) Less(i, j int) bool {
+	if tq[i].due.Equal(tq[j].due) {
+		return tq[i].seq < tq[j].seq
+	}
+	return tq[i].due.Before(tq[j].due)
+}
+
+func (tq simTimerQueue) Swap(i, j int) {
+	tq[i], tq[j] = tq[j], tq[i]
+	tq[i].index = i
+	tq[j].index = j
+}
+
+func (tq *simTimerQueue) Push(x interface{}) {
+	item := x.(*simTimer)
+	item.index = len(*tq)
+	*tq = append(*tq, item)
+}
+
+func (tq *simTimerQueue) Pop() interface{} {
+	old := *tq
+	n := len(old)
+	item := old[n-1]
+	item.index = -1
+	*tq = old[0 : n-1]
+	return item
+}
+
+//simClock is the virtual time shared by all nodes of a simulation
+type simClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	seq    uint64
+	timers simTimerQueue
+}
+
+func newSimClock() *simClock {
+	return &simClock{now: simEpoch}
+}
+
+func (self *simClock) Now() time.Time {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.now
+}
+
+//newNodeClock returns the clock of a server instance, all its timers are dropped when the instance crashes
+func (self *simClock) newNodeClock() *nodeClock {
+	return &nodeClock{sim: self}
+}
+
+//schedule should be called with lock held
+func (self *simClock) schedule(t *simTimer, d time.Duration) {
+	self.seq++
+	t.due = self.now.Add(d)
+	t.seq = self.seq
+	heap.Push(&self.timers, t)
+}
+
+//advance moves the virtual time to the next due timer, but at most to limit, and fires all timers due at that time
+func (self *simClock) advance(limit time.Time) {
+	self.lock.Lock()
+	now := limit
+	if len(self.timers) > 0 && self.timers[0].due.Before(limit) {
+		now = self.timers[0].due
+	}
+	if now.After(self.now) {
+		self.now = now
+	}
+	var fired []*simTimer
+	for len(self.timers) > 0 && !self.timers[0].due.After(self.now) {
+		fired = append(fired, heap.Pop(&self.timers).(*simTimer))
+	}
+	self.lock.Unlock()
+
+	//same as time.AfterFunc, every function runs in its own goroutine
+	for _, t := range fired {
+		go t.f()
+	}
+}
+
+func (self *simClock) stop(clock *nodeClock) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	clock.stopped = true
+	var timers simTimerQueue
+	for _, t := range self.timers {
+		if t.clock != clock {
+			timers = append(timers, t)
+		} else {
+			t.index = -1
+		}
+	}
+	self.timers = self.timers[:0]
+	for _, t := range timers {
+		heap.Push(&self.timers, t)
+	}
+}
+
+type nodeClock struct {
+	sim     *simClock
+	stopped bool // protected by sim.lock
+}
+
+func (self *nodeClock) Now() time.Time {
+	return self.sim.Now()
+}
+
+func (self *nodeClock) AfterFunc(d time.Duration, f func()) clockTimer {
+	t := &simTimer{clock: self, f: f, index: -1}
+	t.Reset(d)
+	return t
+}
+
+func (self *simTimer) Stop() bool {
+	sim := self.clock.sim
+	sim.lock.Lock()
+	defer sim.lock.Unlock()
+
+	if self.index < 0 {
+		return false
+	}
+	heap.Remove(&sim.timers, self.index)
+	return true
+}
+
+func (self *simTimer) Reset(d time.Duration) bool {
+	sim := self.clock.sim
+	sim.lock.Lock()
+	defer sim.lock.Unlock()
+
+	active := self.index >= 0
+	if active {
+		heap.Remove(&sim.timers, self.index)
+	}
+	if !self.clock.stopped {
+		sim.schedule(self, d)
+	}
+	return active
+}
+
+///////////////////////////////////////////////////////////
+//
+// faults
+//
+///////////////////////////////////////////////////////////
+
+//simMessage is a consensus message on its way from one node to another
+type simMessage struct {
+	from, to int
+	msg      ConsensusMsg
+}
+
+//simFault decides whether a message is dropped and how long it is delayed, rnd is private to the message
+type simFault func(m *simMessage, rnd *rand.Rand) (drop bool, delay time.Duration)
+
+//partition drops all messages between the nodes of group and the other nodes
+func partition(group ...int) simFault {
+	inGroup := make(map[int]bool)
+	for _, i := range group {
+		inGroup[i] = true
+	}
+	return func(m *simMessage, rnd *rand.Rand) (bool, time.Duration) {
+		return inGroup[m.from] != inGroup[m.to], 0
+	}
+}
+
+//dropRate drops percent of all messages
+func dropRate(percent int) simFault {
+	return func(m *simMessage, rnd *rand.Rand) (bool, time.Duration) {
+		return rnd.Intn(100) < percent, 0
+	}
+}
+
+//dropMsgs drops all messages of type msgType sent by node from, -1 matches every sender
+func dropMsgs(from int, msgType MsgType) simFault {
+	return func(m *simMessage, rnd *rand.Rand) (bool, time.Duration) {
+		return (from < 0 || m.from == from) && m.msg.Type() == msgType, 0
+	}
+}
+
+//delayMsgs delays every message by a random duration in [min, max), which also reorders them
+func delayMsgs(min, max time.Duration) simFault {
+	return func(m *simMessage, rnd *rand.Rand) (bool, time.Duration) {
+		return false, min + time.Duration(rnd.Int63n(int64(max-min)))
+	}
+}
+
+///////////////////////////////////////////////////////////
+//
+// network
+//
+///////////////////////////////////////////////////////////
+
+//simProtocol hands the consensus messages received by the mock p2p node to the current server of the node
+type simProtocol struct {
+	sim  *simulation
+	node *simNode
+}
+
+func (self *simProtocol) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage) {}
+
+func (self *simProtocol) HandlePeerMessage(ctx *p2p.Context, msg p2pmsg.Message) {
+	m, ok := msg.(*p2pmsg.Consensus)
+	if !ok {
+		return
+	}
+	defer atomic.AddInt64(&self.sim.inflight, -1)
+	if err := m.Cons.Verify(); err != nil {
+		return
+	}
+	m.Cons.PeerId = ctx.Sender().GetID()
+	if server := self.sim.liveServer(self.node); server != nil {
+		server.GetPID().Tell(&m.Cons)
+	}
+}
+
+//simP2P is the p2p network of one server instance, it routes the messages of the server through the fault layer
+type simP2P struct {
+	*netserver.NetServer
+	sim   *simulation
+	node  *simNode
+	clock *nodeClock
+}
+
+func (self *simP2P) SendTo(id p2pcommon.PeerId, msg p2pmsg.Message) {
+	for _, to := range self.sim.nodes {
+		if to.net.GetID() == id {
+			self.sim.send(self, to, msg)
+		}
+	}
+}
+
+func (self *simP2P) Broadcast(msg p2pmsg.Message) {
+	for _, to := range self.sim.nodes {
+		if to != self.node {
+			self.sim.send(self, to, msg)
+		}
+	}
+}
+
+type simNode struct {
+	index   int
+	acc     *account.Account
+	ledger  *ledger.Ledger
+	signLog *SignLog
+	net     *netserver.NetServer
+
+	// protected by simulation.lock
+	server      *Server
+	clock       *nodeClock
+	crashed     bool
+	equivocate  bool
+	checkedSeal uint32
+}
+
+type sealRecord struct {
+	node int
+	hash common.Uint256
+}
+
+type simulation struct {
+	t     *testing.T
+	seed  int64
+	dir   string
+	clock *simClock
+	net   *nodeClock // clock of the delayed deliveries
+	pool  *actorTypes.TxPoolActor
+	nodes []*simNode
+
+	inflight int64 // messages handed to the mock network but not received yet
+
+	lock         sync.Mutex
+	faults       []simFault
+	msgSeq       map[string]uint64
+	equivocation map[uint32]p2pmsg.Message // conflicting proposal per block
+	sealed       map[uint32]sealRecord
+	violations   []string
+	evidence     []*types.Transaction // evidence txs broadcast by the servers
+	pending      []*types.Transaction // txs served by the tx pool until a node seals them
+}
+
+//newSimulation creates n consensus nodes sharing one genesis block, the nodes are connected but not started
+func newSimulation(t *testing.T, n int, seed int64) *simulation {
+	log.InitLog(log.FatalLog, log.Stdout)
+
+	var accounts []*account.Account
+	var bookkeepers []keypair.PublicKey
+	vbftCfg := *config.PolarisConfig.VBFT
+	vbftCfg.N, vbftCfg.K, vbftCfg.C, vbftCfg.L = uint32(n), uint32(n), uint32((n-1)/3), uint32(16*n)
+	vbftCfg.BlockMsgDelay, vbftCfg.HashMsgDelay = 5000, 5000
+	vbftCfg.Peers = nil
+	for i := 0; i < n; i++ {
+		acc := account.NewAccount("")
+		accounts = append(accounts, acc)
+		bookkeepers = append(bookkeepers, acc.PublicKey)
+		vbftCfg.Peers = append(vbftCfg.Peers, &config.VBFTPeerStakeInfo{
+			Index:      uint32(i + 1),
+			PeerPubkey: hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)),
+			Address:    acc.Address.ToBase58(),
+			InitPos:    10000,
+		})
+	}
+	keypair.SortPublicKeys(bookkeepers)
+	// the governance methods not activated on main net yet are active from genesis
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_VBFT
+	config.DefConfig.Genesis.VBFT = &vbftCfg
+
+	dir, err := ioutil.TempDir("", "vbft_sim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := newSimClock()
+	sim := &simulation{
+		t:            t,
+		seed:         seed,
+		dir:          dir,
+		clock:        clock,
+		net:          clock.newNodeClock(),
+		msgSeq:       make(map[string]uint64),
+		equivocation: make(map[uint32]p2pmsg.Message),
+		sealed:       make(map[uint32]sealRecord),
+	}
+	pool := actor.Spawn(actor.FromFunc(func(ctx actor.Context) {
+		switch ctx.Message().(type) {
+		case *txpool.GetTxnPoolReq:
+			ctx.Respond(&txpool.GetTxnPoolRsp{TxnPool: sim.pendingTxs()})
+		case *txpool.VerifyBlockReq:
+			ctx.Respond(&txpool.VerifyBlockRsp{})
+		}
+	}))
+	sim.pool = &actorTypes.TxPoolActor{Pool: pool}
+
+	mockNet := mock.NewNetwork()
+	for i, acc := range accounts {
+		block, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, err := ledger.InitLedger(filepath.Join(dir, fmt.Sprint(i)), 0, bookkeepers, block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signLog, err := OpenSignLog(filepath.Join(dir, fmt.Sprint(i), SIGN_LOG_DIR))
+		if err != nil {
+			t.Fatal(err)
+		}
+		node := &simNode{index: i, acc: acc, ledger: db, signLog: signLog}
+		keyId := p2pcommon.RandPeerKeyId()
+		info := peer.NewPeerInfo(keyId.Id, 0, 0, true, 0, 0, 0, "1.10", "")
+		logger := p2pcommon.LoggerWithContext(p2pcommon.NewGlobalLoggerWrapper(), fmt.Sprintf("node %d: ", i))
+		node.net = mock.NewNode(keyId, "", info, &simProtocol{sim: sim, node: node}, mockNet, nil,
+			p2p.AllAddrFilter(), logger)
+		go node.net.Start()
+		sim.nodes = append(sim.nodes, node)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			mockNet.AllowConnect(sim.nodes[i].net.GetID(), sim.nodes[j].net.GetID())
+			go sim.nodes[i].net.Connect(sim.nodes[j].net.GetHostInfo().Addr)
+		}
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for _, node := range sim.nodes {
+		for node.net.GetConnectionCnt() != uint32(n-1) {
+			if time.Now().After(deadline) {
+				t.Fatalf("node %d connected to %d peers", node.index, node.net.GetConnectionCnt())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return sim
+}
+
+func (self *simulation) close() {
+	for _, node := range self.nodes {
+		if self.liveServer(node) != nil {
+			self.crash(node.index)
+		}
+		node.net.Stop()
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, node := range self.nodes {
+		node.ledger.Close()
+		node.signLog.Close()
+	}
+	os.RemoveAll(self.dir)
+}
+
+//start starts a new server instance on the ledger of each node
+func (self *simulation) start(indexes ...int) {
+	for _, i := range indexes {
+		node := self.nodes[i]
+		clock := self.clock.newNodeClock()
+		net := &simP2P{NetServer: node.net, sim: self, node: node, clock: clock}
+		server := newServer(node.acc, self.pool, net, node.ledger, node.signLog, clock)
+		server.pid = actor.Spawn(actor.FromProducer(func() actor.Actor {
+			return server
+		}))
+		server.sub = events.NewActorSubscriber(server.pid, eventhub.GlobalEventHub)
+		if err := server.initialize(); err != nil {
+			self.t.Fatalf("node %d initialize: %s", i, err)
+		}
+		self.lock.Lock()
+		node.server, node.clock, node.crashed, node.checkedSeal = server, clock, false, 0
+		self.lock.Unlock()
+		if err := server.Start(); err != nil {
+			self.t.Fatalf("node %d start: %s", i, err)
+		}
+	}
+}
+
+//crash stops the server of the node, its timers never fire again and its messages are lost
+func (self *simulation) crash(i int) {
+	node := self.nodes[i]
+	self.lock.Lock()
+	node.crashed = true
+	server := node.server
+	self.lock.Unlock()
+	self.clock.stop(node.clock)
+	server.Halt()
+}
+
+//restart crashes the node if needed and starts a new server on the ledger it left
+func (self *simulation) restart(i int) {
+	if self.liveServer(self.nodes[i]) != nil {
+		self.crash(i)
+	}
+	self.start(i)
+}
+
+//rotate restarts the node with a new account on the ledger it left
+func (self *simulation) rotate(i int, acc *account.Account) {
+	if self.liveServer(self.nodes[i]) != nil {
+		self.crash(i)
+	}
+	self.nodes[i].acc = acc
+	self.start(i)
+}
+
+//equivocate makes the node send a conflicting version of each of its proposals to half of the peers
+func (self *simulation) equivocate(i int) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.nodes[i].equivocate = true
+}
+
+func (self *simulation) addFault(fault simFault) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.faults = append(self.faults, fault)
+}
+
+func (self *simulation) clearFaults() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.faults = nil
+}
+
+func (self *simulation) liveServer(node *simNode) *Server {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if node.server == nil || node.crashed {
+		return nil
+	}
+	return node.server
+}
+
+//send passes a message of the server instance owning net through the fault layer
+func (self *simulation) send(net *simP2P, to *simNode, msg p2pmsg.Message) {
+	if trn, ok := msg.(*p2pmsg.Trn); ok {
+		// the servers only broadcast evidence txs, which are recorded once instead of being delivered
+		self.lock.Lock()
+		defer self.lock.Unlock()
+		for _, tx := range self.evidence {
+			if tx == trn.Txn {
+				return
+			}
+		}
+		self.evidence = append(self.evidence, trn.Txn)
+		return
+	}
+	cons, ok := msg.(*p2pmsg.Consensus)
+	if !ok {
+		return
+	}
+	m, err := DeserializeVbftMsg(cons.Cons.Data)
+	if err != nil {
+		self.t.Errorf("node %d sent invalid consensus msg: %s", net.node.index, err)
+		return
+	}
+	from := net.node
+
+	self.lock.Lock()
+	if from.crashed || from.clock != net.clock || to.crashed {
+		self.lock.Unlock()
+		return
+	}
+	equivocate := from.equivocate && m.Type() == BlockProposalMessage && to.index%2 == 1
+	key := fmt.Sprintf("%d-%d-%d-%d", from.index, to.index, m.Type(), m.GetBlockNum())
+	self.msgSeq[key]++
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s-%d", key, self.msgSeq[key])
+	rnd := rand.New(rand.NewSource(self.seed ^ int64(h.Sum64())))
+	drop, delay := false, time.Duration(0)
+	for _, fault := range self.faults {
+		d, t := fault(&simMessage{from: from.index, to: to.index, msg: m}, rnd)
+		drop = drop || d
+		delay += t
+	}
+	self.lock.Unlock()
+
+	if drop {
+		return
+	}
+	if equivocate {
+		if msg, err = self.conflictingProposal(from, m.(*blockProposalMsg)); err != nil {
+			self.t.Errorf("node %d equivocate: %s", from.index, err)
+			return
+		}
+	}
+	deliver := func() {
+		if self.liveServer(to) == nil {
+			return
+		}
+		atomic.AddInt64(&self.inflight, 1)
+		from.net.SendTo(to.net.GetID(), msg)
+	}
+	if delay > 0 {
+		self.net.AfterFunc(delay, deliver)
+	} else {
+		deliver()
+	}
+}
+
+//conflictingProposal builds a second proposal of the node for the same block, signed with the key of the node
+func (self *simulation) conflictingProposal(node *simNode, proposal *blockProposalMsg) (p2pmsg.Message, error) {
+	blkNum := proposal.GetBlockNum()
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if msg, present := self.equivocation[blkNum]; present {
+		return msg, nil
+	}
+
+	header := proposal.Block.Block.Header
+	blk, err := node.server.constructBlock(blkNum, header.PrevBlockHash, proposal.Block.Block.Transactions,
+		header.ConsensusPayload, header.Timestamp+1)
+	if err != nil {
+		return nil, err
+	}
+	conflict := &blockProposalMsg{
+		Block: &Block{
+			Block:              blk,
+			Info:               proposal.Block.Info,
+			PrevExecMerkleRoot: proposal.Block.PrevExecMerkleRoot,
+			CrossChainMsg:      proposal.Block.CrossChainMsg,
+		},
+	}
+	if empty := proposal.Block.EmptyBlock; empty != nil {
+		conflict.Block.EmptyBlock, err = node.server.constructBlock(blkNum, empty.Header.PrevBlockHash,
+			empty.Transactions, empty.Header.ConsensusPayload, empty.Header.Timestamp+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := SerializeVbftMsg(conflict)
+	if err != nil {
+		return nil, err
+	}
+	payload := &p2pmsg.ConsensusPayload{
+		Data:  data,
+		Owner: node.acc.PublicKey,
+	}
+	sink := common.NewZeroCopySink(nil)
+	payload.SerializationUnsigned(sink)
+	payload.Signature, err = signature.Sign(node.acc, sink.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	msg := msgpack.NewConsensus(payload)
+	self.equivocation[blkNum] = msg
+	return msg, nil
+}
+
+///////////////////////////////////////////////////////////
+//
+// scheduling and invariants
+//
+///////////////////////////////////////////////////////////
+
+//step advances the virtual clock by at most simTick, lets the servers settle and checks the sealed blocks
+func (self *simulation) step() {
+	self.clock.advance(self.clock.Now().Add(simTick))
+	time.Sleep(simSettle)
+	deadline := time.Now().Add(50 * simSettle)
+	for atomic.LoadInt64(&self.inflight) > 0 && time.Now().Before(deadline) {
+		time.Sleep(simSettle)
+	}
+	self.checkSealed()
+}
+
+//run advances the virtual clock by d
+func (self *simulation) run(d time.Duration) {
+	end := self.clock.Now().Add(d)
+	for self.clock.Now().Before(end) {
+		self.step()
+	}
+	self.checkSafety()
+}
+
+//runUntil advances the virtual clock until cond holds, the test fails if it does not within d
+func (self *simulation) runUntil(d time.Duration, what string, cond func() bool) {
+	end := self.clock.Now().Add(d)
+	for !cond() {
+		if !self.clock.Now().Before(end) {
+			self.checkSafety()
+			self.t.Fatalf("%s not reached in %s of virtual time, heights %v", what, d, self.heights())
+		}
+		self.step()
+	}
+}
+
+//runUntilHeight runs until all the given nodes committed the block at height
+func (self *simulation) runUntilHeight(d time.Duration, height uint32, indexes ...int) {
+	self.runUntil(d, fmt.Sprintf("block %d on nodes %v", height, indexes), func() bool {
+		for _, i := range indexes {
+			if self.height(i) < height {
+				return false
+			}
+		}
+		return true
+	})
+	self.checkSafety()
+}
+
+func (self *simulation) height(i int) uint32 {
+	return self.nodes[i].ledger.GetCurrentBlockHeight()
+}
+
+func (self *simulation) heights() []uint32 {
+	var heights []uint32
+	for i := range self.nodes {
+		heights = append(heights, self.height(i))
+	}
+	return heights
+}
+
+//checkSealed records the blocks sealed by the live servers, a different block at a recorded height is a violation
+func (self *simulation) checkSealed() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, node := range self.nodes {
+		if node.server == nil || node.crashed {
+			continue
+		}
+		committed := node.server.GetCommittedBlockNo()
+		for blkNum := node.checkedSeal + 1; blkNum <= committed; blkNum++ {
+			blk, hash := node.server.blockPool.getSealedBlock(blkNum)
+			if blk == nil {
+				break
+			}
+			node.checkedSeal = blkNum
+			record, present := self.sealed[blkNum]
+			if !present {
+				self.sealed[blkNum] = sealRecord{node: node.index, hash: hash}
+			} else if record.hash != hash {
+				self.violations = append(self.violations, fmt.Sprintf("block %d sealed as %s by node %d and %s by node %d",
+					blkNum, record.hash.ToHexString(), record.node, hash.ToHexString(), node.index))
+			}
+		}
+	}
+}
+
+//forks returns the heights at which two different blocks were sealed, on the servers or on the ledgers
+func (self *simulation) forks() []string {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	forks := append([]string{}, self.violations...)
+	for _, node := range self.nodes {
+		for blkNum := uint32(1); blkNum <= node.ledger.GetCurrentBlockHeight(); blkNum++ {
+			hash := node.ledger.GetBlockHash(blkNum)
+			record, present := self.sealed[blkNum]
+			if !present {
+				self.sealed[blkNum] = sealRecord{node: node.index, hash: hash}
+			} else if record.hash != hash {
+				forks = append(forks, fmt.Sprintf("block %d saved as %s by node %d and %s by node %d",
+					blkNum, record.hash.ToHexString(), record.node, hash.ToHexString(), node.index))
+			}
+		}
+	}
+	return forks
+}
+
+//submit adds the tx to the tx pool of the servers
+func (self *simulation) submit(tx *types.Transaction) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.pending = append(self.pending, tx)
+}
+
+func (self *simulation) pendingTxs() []*txpool.VerifiedTx {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	txs := make([]*txpool.VerifiedTx, 0)
+	for _, tx := range self.pending {
+		sealed := false
+		for _, node := range self.nodes {
+			if ok, _ := node.ledger.IsContainTransaction(tx.Hash()); ok {
+				sealed = true
+			}
+		}
+		if !sealed {
+			txs = append(txs, &txpool.VerifiedTx{Tx: tx})
+		}
+	}
+	return txs
+}
+
+//evidenceTxs returns the evidence txs broadcast so far
+func (self *simulation) evidenceTxs() []*types.Transaction {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return append([]*types.Transaction{}, self.evidence...)
+}
+
+//checkSafety fails the test if two different blocks were sealed at one height
+func (self *simulation) checkSafety() {
+	forks := self.forks()
+	for _, fork := range forks {
+		self.t.Error(fork)
+	}
+	if len(forks) > 0 {
+		self.t.FailNow()
+	}
+}
@@ -90,7 +90,7 @@ type StateMgr struct {
 	StateEventC      chan *StateEvent
 	peers            map[uint32]*PeerState
 
-	liveTicker             *time.Timer
+	liveTicker             clockTimer
 	lastTickChainHeight    uint32
 	lastBlockSyncReqHeight uint32
 }
@@ -116,7 +116,7 @@ func (self *StateMgr) setState(newstate ServerState) {
 
 func (self *StateMgr) run() {
 	liveTimeout := time.Duration(atomic.LoadInt64(&peerHandshakeTimeout) * 5)
-	self.liveTicker = time.AfterFunc(liveTimeout, func() {
+	self.liveTicker = self.server.clock.AfterFunc(liveTimeout, func() {
 		self.StateEventC <- &StateEvent{
 			Type:     LiveTick,
 			blockNum: self.server.GetCommittedBlockNo(),
@@ -367,7 +367,7 @@ func (self *StateMgr) setSyncedReady() error {
 	if prevState <= SyncReady {
 		log.Infof("server %d start sync ready", self.server.Index)
 		blkNum := self.server.GetCurrentBlockNo()
-		time.AfterFunc(self.syncReadyTimeout, func() {
+		self.server.clock.AfterFunc(self.syncReadyTimeout, func() {
 			self.StateEventC <- &StateEvent{
 				Type:     SyncReadyTimeout,
 				blockNum: blkNum,
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	actorTypes "github.com/ontio/ontology/consensus/actor"
+)
+
+var serverStateNames = []string{
+	Init:             "Init",
+	LocalConfigured:  "LocalConfigured",
+	Configured:       "Configured",
+	Syncing:          "Syncing",
+	WaitNetworkReady: "WaitNetworkReady",
+	SyncReady:        "SyncReady",
+	Synced:           "Synced",
+	SyncingCheck:     "SyncingCheck",
+}
+
+func (state ServerState) String() string {
+	if int(state) < len(serverStateNames) {
+		return serverStateNames[state]
+	}
+	return fmt.Sprintf("ServerState(%d)", uint32(state))
+}
+
+var timerEventNames = []string{
+	EventProposeBlockTimeout:      "ProposeBlockTimeout",
+	EventProposalBackoff:          "ProposalBackoff",
+	EventRandomBackoff:            "RandomBackoff",
+	EventPropose2ndBlockTimeout:   "Propose2ndBlockTimeout",
+	EventEndorseBlockTimeout:      "EndorseBlockTimeout",
+	EventEndorseEmptyBlockTimeout: "EndorseEmptyBlockTimeout",
+	EventCommitBlockTimeout:       "CommitBlockTimeout",
+	EventPeerHeartbeat:            "PeerHeartbeat",
+	EventTxPool:                   "TxPool",
+	EventTxBlockTimeout:           "TxBlockTimeout",
+}
+
+func (evtType TimerEventType) String() string {
+	if evtType >= 0 && int(evtType) < len(timerEventNames) {
+		return timerEventNames[evtType]
+	}
+	return fmt.Sprintf("TimerEvent(%d)", int(evtType))
+}
+
+//serverStats counts the server events which leave no trace in the pools, updated atomically
+type serverStats struct {
+	timerEvents    [EventMax]uint64
+	catchConsensus uint64
+	restartSyncing uint64
+}
+
+func (self *serverStats) onTimerEvent(evtType TimerEventType) {
+	if evtType >= 0 && evtType < EventMax {
+		atomic.AddUint64(&self.timerEvents[evtType], 1)
+	}
+}
+
+func (self *Server) GetConsensusStatus() *actorTypes.ConsensusStatus {
+	status := &actorTypes.ConsensusStatus{
+		Index:             self.Index,
+		State:             self.getState().String(),
+		CurrentBlockNum:   self.GetCurrentBlockNo(),
+		CommittedBlockNum: self.GetCommittedBlockNo(),
+		ProposerIndex:     math.MaxUint32,
+		TimerEvents:       make(map[string]uint64),
+		CatchConsensus:    atomic.LoadUint64(&self.stats.catchConsensus),
+		RestartSyncing:    atomic.LoadUint64(&self.stats.restartSyncing),
+	}
+
+	self.metaLock.RLock()
+	if self.config != nil {
+		status.ChainConfigView = self.config.View
+	}
+	if cfg := self.currentParticipantConfig; cfg != nil && cfg.BlockNum == status.CurrentBlockNum && len(cfg.Proposers) > 0 {
+		status.ProposerIndex = cfg.Proposers[0]
+	}
+	self.metaLock.RUnlock()
+
+	for evtType := TimerEventType(0); evtType < EventMax; evtType++ {
+		status.TimerEvents[evtType.String()] = atomic.LoadUint64(&self.stats.timerEvents[evtType])
+	}
+	if self.msgPool != nil {
+		status.Rounds = self.msgPool.getRoundStatus()
+	}
+	if self.peerPool != nil {
+		status.Peers = self.peerPool.getPeerStatus()
+	}
+	return status
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	actorTypes "github.com/ontio/ontology/consensus/actor"
+)
+
+func TestStatusNames(t *testing.T) {
+	if Synced.String() != "Synced" || SyncingCheck.String() != "SyncingCheck" || ServerState(100).String() != "ServerState(100)" {
+		t.Fatalf("invalid server state names")
+	}
+	for evtType := TimerEventType(0); evtType < EventMax; evtType++ {
+		if evtType.String() == "" {
+			t.Fatalf("timer event %d has no name", evtType)
+		}
+	}
+	if EventMax.String() != "TimerEvent(10)" {
+		t.Fatalf("invalid timer event name %s", EventMax.String())
+	}
+}
+
+func TestConsensusStatus(t *testing.T) {
+	sim := newSimulation(t, simNodes, 7)
+	defer sim.close()
+
+	sim.start(0, 1, 2, 3, 4, 5, 6)
+	sim.runUntilHeight(5*time.Minute, 3, 0, 1, 2, 3, 4, 5, 6)
+	sim.crash(6)
+	height := sim.height(0)
+	sim.runUntilHeight(5*time.Minute, height+2, 0, 1, 2, 3, 4, 5)
+
+	server := sim.liveServer(sim.nodes[0])
+	result, err := server.GetPID().RequestFuture(&actorTypes.GetConsensusStatus{}, 5*time.Second).Result()
+	if err != nil {
+		t.Fatalf("query consensus status: %s", err)
+	}
+	status := result.(*actorTypes.ConsensusStatus)
+	if status.Index != server.Index || status.State != Synced.String() {
+		t.Fatalf("server %d in state %s", status.Index, status.State)
+	}
+	if status.CommittedBlockNum < height+2 || status.CurrentBlockNum != status.CommittedBlockNum+1 {
+		t.Fatalf("current block %d, committed block %d", status.CurrentBlockNum, status.CommittedBlockNum)
+	}
+	if status.ProposerIndex == math.MaxUint32 {
+		t.Fatalf("no proposer for block %d", status.CurrentBlockNum)
+	}
+
+	var committed bool
+	for _, round := range status.Rounds {
+		if round.BlockNum == status.CommittedBlockNum {
+			committed = round.Proposals > 0 && round.Endorsements > 0 && round.Commits > 0
+		}
+	}
+	if !committed {
+		t.Fatalf("no msgs of committed block %d in %v", status.CommittedBlockNum, status.Rounds)
+	}
+	if status.TimerEvents[EventPeerHeartbeat.String()] == 0 {
+		t.Fatalf("no heartbeat timer fired: %v", status.TimerEvents)
+	}
+
+	crashed := sim.nodes[6].server.Index
+	if len(status.Peers) != simNodes {
+		t.Fatalf("%d peers in status", len(status.Peers))
+	}
+	for _, peer := range status.Peers {
+		if peer.Index == server.Index {
+			continue
+		}
+		if !peer.Connected || peer.HeartbeatAge < 0 || peer.PubKey == "" {
+			t.Fatalf("invalid status of peer %d: %+v", peer.Index, peer)
+		}
+		//the p2p connection survives the crash, but the heartbeats stop
+		if (peer.CommittedBlockNum <= height) != (peer.Index == crashed) {
+			t.Fatalf("peer %d committed block %d, crash at %d", peer.Index, peer.CommittedBlockNum, height)
+		}
+	}
+}
@@ -53,3 +53,18 @@ func InitLedger(dataDir string, stateHashHeight uint32, defaultBookkeeper []keyp
 		LedgerStore: ldgStore,
 	}, nil
 }
+
+//RollbackLedger rolls the ledger in dataDir back to the block at height, the ledger must not be opened
+func RollbackLedger(dataDir string, stateHashHeight, height uint32, progress func(height uint32)) error {
+	return ledgerstore.RollbackLedgerStore(dataDir, stateHashHeight, height, progress)
+}
+
+//MigrateLedger copies the ledger in srcDir to dstDir stored with engine, the ledger must not be opened
+func MigrateLedger(srcDir, dstDir, engine string, progress func(store string, keys uint64)) error {
+	return ledgerstore.MigrateLedgerStore(srcDir, dstDir, engine, progress)
+}
+
+//VerifyLedger verifies the integrity of the ledger in dataDir block by block, the ledger must not be opened
+func VerifyLedger(dataDir string, stateHashHeight uint32, progress func(check string, height uint32)) (*ledgerstore.VerifyReport, error) {
+	return ledgerstore.VerifyLedgerStore(dataDir, stateHashHeight, progress)
+}
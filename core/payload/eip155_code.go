@@ -23,8 +23,11 @@ import (
 	"github.com/ontio/ontology/common"
 )
 
+//EIP155Code is the payload of eth transaction. EIPTx is the legacy transaction, for typed transaction it is
+//the unsigned legacy view of TypedTx used for execution.
 type EIP155Code struct {
-	EIPTx *types.Transaction
+	EIPTx   *types.Transaction
+	TypedTx *EthTypedTx
 }
 
 func (self *EIP155Code) Deserialization(source *common.ZeroCopySource) error {
@@ -32,6 +35,15 @@ func (self *EIP155Code) Deserialization(source *common.ZeroCopySource) error {
 	if err != nil {
 		return err
 	}
+	if IsEthTypedTx(code) {
+		typed, err := DecodeEthTypedTx(code)
+		if err != nil {
+			return err
+		}
+		self.TypedTx = typed
+		self.EIPTx = typed.AsLegacyTx()
+		return nil
+	}
 	tx := new(types.Transaction)
 	err = rlp.DecodeBytes(code, tx)
 	if err != nil {
@@ -43,6 +55,10 @@ func (self *EIP155Code) Deserialization(source *common.ZeroCopySource) error {
 }
 
 func (self *EIP155Code) Serialization(sink *common.ZeroCopySink) {
+	if self.TypedTx != nil {
+		sink.WriteVarBytes(self.TypedTx.MarshalBinary())
+		return
+	}
 	bts, err := rlp.EncodeToBytes(self.EIPTx)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EIP-2718 transaction types
+const (
+	AccessListTxType byte = 0x01 // EIP-2930
+	DynamicFeeTxType byte = 0x02 // EIP-1559
+)
+
+// AccessTuple is the element type of an EIP-2930 access list
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+type AccessList []AccessTuple
+
+//EthTypedTx is an EIP-2718 typed eth transaction. GasPrice is only set for access list transaction, GasTipCap
+//and GasFeeCap are only set for dynamic fee transaction. V is the y parity of the signature.
+type EthTypedTx struct {
+	Type       byte
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
+}
+
+type accessListTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
+}
+
+type dynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
+}
+
+//IsEthTypedTx checks whether the encoded eth transaction is a typed envelope, the rlp list of legacy
+//transaction always starts with a byte not less than 0xc0
+func IsEthTypedTx(data []byte) bool {
+	return len(data) > 0 && data[0] <= 0x7f
+}
+
+//DecodeEthTypedTx decodes the typed envelope type || rlp(payload)
+func DecodeEthTypedTx(data []byte) (*EthTypedTx, error) {
+	if !IsEthTypedTx(data) {
+		return nil, errors.New("not a typed eth transaction")
+	}
+	tx := &EthTypedTx{Type: data[0]}
+	switch tx.Type {
+	case AccessListTxType:
+		inner := new(accessListTx)
+		if err := rlp.DecodeBytes(data[1:], inner); err != nil {
+			return nil, err
+		}
+		tx.ChainID, tx.Nonce, tx.GasPrice, tx.Gas = inner.ChainID, inner.Nonce, inner.GasPrice, inner.Gas
+		tx.To, tx.Value, tx.Data, tx.AccessList = inner.To, inner.Value, inner.Data, inner.AccessList
+		tx.V, tx.R, tx.S = inner.V, inner.R, inner.S
+	case DynamicFeeTxType:
+		inner := new(dynamicFeeTx)
+		if err := rlp.DecodeBytes(data[1:], inner); err != nil {
+			return nil, err
+		}
+		if inner.GasTipCap.Cmp(inner.GasFeeCap) > 0 {
+			return nil, fmt.Errorf("max priority fee per gas %d higher than max fee per gas %d",
+				inner.GasTipCap, inner.GasFeeCap)
+		}
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas = inner.ChainID, inner.Nonce, inner.GasTipCap,
+			inner.GasFeeCap, inner.Gas
+		tx.To, tx.Value, tx.Data, tx.AccessList = inner.To, inner.Value, inner.Data, inner.AccessList
+		tx.V, tx.R, tx.S = inner.V, inner.R, inner.S
+	default:
+		return nil, fmt.Errorf("unsupported eth transaction type %d", tx.Type)
+	}
+	return tx, nil
+}
+
+func (tx *EthTypedTx) fields(withSig bool) []interface{} {
+	var fields []interface{}
+	switch tx.Type {
+	case AccessListTxType:
+		fields = []interface{}{tx.ChainID, tx.Nonce, tx.GasPrice, tx.Gas, tx.to(), tx.Value, tx.Data, tx.accessList()}
+	default:
+		fields = []interface{}{tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.to(), tx.Value, tx.Data,
+			tx.accessList()}
+	}
+	if withSig {
+		fields = append(fields, tx.V, tx.R, tx.S)
+	}
+	return fields
+}
+
+// empty byte string encodes the contract creation
+func (tx *EthTypedTx) to() interface{} {
+	if tx.To == nil {
+		return []byte{}
+	}
+	return tx.To
+}
+
+// nil access list must be encoded as empty list
+func (tx *EthTypedTx) accessList() AccessList {
+	if tx.AccessList == nil {
+		return AccessList{}
+	}
+	return tx.AccessList
+}
+
+func (tx *EthTypedTx) encode(withSig bool) []byte {
+	payload, err := rlp.EncodeToBytes(tx.fields(withSig))
+	if err != nil {
+		panic(err)
+	}
+	return append([]byte{tx.Type}, payload...)
+}
+
+//MarshalBinary returns the typed envelope of the signed transaction
+func (tx *EthTypedTx) MarshalBinary() []byte {
+	return tx.encode(true)
+}
+
+//Hash returns the transaction hash, which is the keccak256 of the typed envelope
+func (tx *EthTypedTx) Hash() common.Hash {
+	return crypto.Keccak256Hash(tx.encode(true))
+}
+
+//SigHash returns the hash to be signed by the sender
+func (tx *EthTypedTx) SigHash() common.Hash {
+	return crypto.Keccak256Hash(tx.encode(false))
+}
+
+//Sender recovers the address of the sender from the signature
+func (tx *EthTypedTx) Sender() (common.Address, error) {
+	if tx.V.BitLen() > 1 {
+		return common.Address{}, fmt.Errorf("invalid signature y parity %d", tx.V)
+	}
+	v := byte(tx.V.Uint64())
+	if !crypto.ValidateSignatureValues(v, tx.R, tx.S, true) {
+		return common.Address{}, errors.New("invalid transaction v, r, s values")
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig[32-len(tx.R.Bytes()):32], tx.R.Bytes())
+	copy(sig[64-len(tx.S.Bytes()):64], tx.S.Bytes())
+	sig[64] = v
+	sigHash := tx.SigHash()
+	pub, err := crypto.Ecrecover(sigHash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(pub) == 0 || pub[0] != 4 {
+		return common.Address{}, errors.New("invalid public key")
+	}
+	return common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]), nil
+}
+
+//EffectiveGasPrice returns the gas price charged per gas. There is no base fee in ontology, so the dynamic fee
+//transaction pays its priority fee, which is bounded by the fee cap on decoding.
+func (tx *EthTypedTx) EffectiveGasPrice() *big.Int {
+	if tx.Type == DynamicFeeTxType {
+		return new(big.Int).Set(tx.GasTipCap)
+	}
+	return new(big.Int).Set(tx.GasPrice)
+}
+
+//AsLegacyTx returns the unsigned legacy transaction of the same message with the effective gas price,
+//which is used to execute the typed transaction. The sender must be taken from the signature of typed transaction.
+func (tx *EthTypedTx) AsLegacyTx() *types.Transaction {
+	if tx.To == nil {
+		return types.NewContractCreation(tx.Nonce, tx.Value, tx.Gas, tx.EffectiveGasPrice(), tx.Data)
+	}
+	return types.NewTransaction(tx.Nonce, *tx.To, tx.Value, tx.Gas, tx.EffectiveGasPrice(), tx.Data)
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"io"
+
+	"github.com/ontio/ontology/common"
+)
+
+//AddressTx is a transaction involving an address, either as signer, payer or party of a transfer
+type AddressTx struct {
+	TxHash  common.Uint256
+	Height  uint32
+	TxIndex uint32
+}
+
+//AddressTransfer is an ONT or ONG transfer involving an address
+type AddressTransfer struct {
+	TxHash   common.Uint256
+	Height   uint32
+	TxIndex  uint32
+	Contract common.Address
+	From     common.Address
+	To       common.Address
+	Amount   uint64
+}
+
+func (self *AddressTransfer) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(self.TxHash)
+	sink.WriteUint32(self.Height)
+	sink.WriteUint32(self.TxIndex)
+	sink.WriteAddress(self.Contract)
+	sink.WriteAddress(self.From)
+	sink.WriteAddress(self.To)
+	sink.WriteUint64(self.Amount)
+}
+
+func (self *AddressTransfer) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	self.TxHash, eof = source.NextHash()
+	self.Height, eof = source.NextUint32()
+	self.TxIndex, eof = source.NextUint32()
+	self.Contract, eof = source.NextAddress()
+	self.From, eof = source.NextAddress()
+	self.To, eof = source.NextAddress()
+	self.Amount, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"io"
+
+	"github.com/ontio/ontology/common"
+)
+
+//ContractEvent is a notify of contract in the contract event index, states are json encoded as in the event notify
+type ContractEvent struct {
+	TxHash     common.Uint256
+	Height     uint32
+	TxIndex    uint32
+	EventIndex uint32
+	Contract   common.Address
+	Name       string
+	States     []byte
+}
+
+//ContractEventCursor is the position of an event in the contract event index, a query continues from it
+type ContractEventCursor struct {
+	Height     uint32
+	TxIndex    uint32
+	EventIndex uint32
+}
+
+func (self *ContractEvent) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(self.TxHash)
+	sink.WriteUint32(self.Height)
+	sink.WriteUint32(self.TxIndex)
+	sink.WriteUint32(self.EventIndex)
+	sink.WriteAddress(self.Contract)
+	sink.WriteString(self.Name)
+	sink.WriteVarBytes(self.States)
+}
+
+func (self *ContractEvent) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	self.TxHash, eof = source.NextHash()
+	self.Height, eof = source.NextUint32()
+	self.TxIndex, eof = source.NextUint32()
+	self.EventIndex, eof = source.NextUint32()
+	self.Contract, eof = source.NextAddress()
+	var irregular bool
+	self.Name, _, irregular, eof = source.NextString()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	self.States, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
@@ -27,12 +27,14 @@ const (
 	DATA_HEADER                            = 0x01 //Block hash => block header+txhashes key prefix
 	DATA_TRANSACTION                       = 0x02 //Transction hash => transaction key prefix
 	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
+	DATA_STATE_UNDO                        = 0x25 // block height => value of the state keys before the block
 
 	// Transaction
 	ST_BOOKKEEPER DataEntryPrefix = 0x03 //BookKeeper state key prefix
 	ST_CONTRACT   DataEntryPrefix = 0x04 //Smart contract deploy code key prefix
 	ST_STORAGE    DataEntryPrefix = 0x05 //Smart contract storage key prefix
 	ST_DESTROYED  DataEntryPrefix = 0x06 // record destroyed smart contract: prefix+address -> height
+	ST_ARCHIVE    DataEntryPrefix = 0x07 // archive state: prefix+len(key)+key+height -> value of key before the block
 
 	// eth state
 	ST_ETH_CODE    DataEntryPrefix = 0x30 // eth contract code:hash -> bytes
@@ -47,8 +49,20 @@ const (
 	SYS_BLOCK_MERKLE_TREE    DataEntryPrefix = 0x13 // Block merkle tree root key prefix
 	SYS_STATE_MERKLE_TREE    DataEntryPrefix = 0x20 // state merkle tree root key prefix
 	SYS_CROSS_CHAIN_MSG      DataEntryPrefix = 0x22 // state merkle tree root key prefix
+	SYS_ARCHIVE_HEIGHT       DataEntryPrefix = 0x23 // first and last block height of archive state
+	SYS_STATE_GC_HEIGHT      DataEntryPrefix = 0x26 // last block height whose state merkle root is removed by state gc
 
 	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
+	EVENT_BLOOM  DataEntryPrefix = 0x15 //Block height => bloom of evm logs key prefix
+
+	// address index
+	IX_ADDRESS_TX       DataEntryPrefix = 0x16 // prefix+address+height+txIndex -> tx hash
+	IX_ADDRESS_TRANSFER DataEntryPrefix = 0x17 // prefix+address+height+txIndex+eventIndex -> transfer
+	SYS_ADDRESS_INDEX   DataEntryPrefix = 0x24 // last block height of address index
+
+	// contract event index
+	IX_CONTRACT_EVENT       DataEntryPrefix = 0x18 // prefix+contract+height+txIndex+eventIndex -> contract event
+	IX_CONTRACT_EVENT_BLOCK DataEntryPrefix = 0x19 // prefix+height -> contracts with events indexed in the block
 
 	DATA_BLOCK_PRUNE_HEIGHT DataEntryPrefix = 0x80 //  last pruned block height, genesis block can not be pruned
 )
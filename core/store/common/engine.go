@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Storage engines of persist store
+const (
+	ENGINE_LEVELDB = "leveldb"
+	ENGINE_PEBBLE  = "pebble"
+)
+
+//EngineOpener open the engine store at dir
+type EngineOpener func(dir string) (EngineStore, error)
+
+var (
+	engineLock sync.RWMutex
+	engines    = make(map[string]EngineOpener)
+)
+
+//RegisterEngine register the opener of storage engine, usually in the init of the engine package
+func RegisterEngine(name string, opener EngineOpener) {
+	engineLock.Lock()
+	defer engineLock.Unlock()
+	if _, ok := engines[name]; ok {
+		panic(fmt.Sprintf("storage engine %s registered twice", name))
+	}
+	engines[name] = opener
+}
+
+//HasEngine return whether the storage engine is available in this build
+func HasEngine(name string) bool {
+	engineLock.RLock()
+	defer engineLock.RUnlock()
+	_, ok := engines[name]
+	return ok
+}
+
+//Engines return the names of storage engines available in this build
+func Engines() []string {
+	engineLock.RLock()
+	defer engineLock.RUnlock()
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//OpenEngineStore open the store at dir with the storage engine
+func OpenEngineStore(engine, dir string) (EngineStore, error) {
+	engineLock.RLock()
+	opener, ok := engines[engine]
+	engineLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("storage engine %s is not available, supported: %s", engine,
+			strings.Join(Engines(), ", "))
+	}
+	return opener(dir)
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/merkle"
+)
+
+//SNAPSHOT_CHUNK_SIZE is the size of the key values in a chunk of snapshot states, the last chunk may be smaller
+const SNAPSHOT_CHUNK_SIZE = 4 * 1024 * 1024
+
+//StateHashPrefixes are the key prefixes of the states hashed into the total state hash, in key order
+var StateHashPrefixes = []DataEntryPrefix{ST_CONTRACT, ST_STORAGE, ST_DESTROYED, ST_ETH_CODE, ST_ETH_ACCOUNT}
+
+//IsStateHashKey return whether the key belongs to the states hashed into the total state hash
+func IsStateHashKey(key []byte) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, prefix := range StateHashPrefixes {
+		if key[0] == byte(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//SnapshotManifest describe the states of a snapshot at a block height, which is served to the nodes doing fast sync
+type SnapshotManifest struct {
+	Height          uint32
+	BlockHash       common.Uint256
+	TotalStateHash  common.Uint256 // hash of all the key values with StateHashPrefixes at the height
+	KeyCount        uint64
+	WriteSetHash    common.Uint256 // write set hash of the block
+	StateRoot       common.Uint256 // state merkle root of the block
+	StateTreeSize   uint32         // compact state merkle tree of the block
+	StateTreeHashes []common.Uint256
+	CrossStates     []common.Uint256 // cross chain states of the block
+	Bookkeeper      *states.BookkeeperState
+	ChunkHashes     []common.Uint256 // hash of every chunk of the states in key order, see SnapshotChunkHash
+}
+
+func (self *SnapshotManifest) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(self.Height)
+	sink.WriteHash(self.BlockHash)
+	sink.WriteHash(self.TotalStateHash)
+	sink.WriteUint64(self.KeyCount)
+	sink.WriteHash(self.WriteSetHash)
+	sink.WriteHash(self.StateRoot)
+	sink.WriteUint32(self.StateTreeSize)
+	sink.WriteVarUint(uint64(len(self.StateTreeHashes)))
+	for _, h := range self.StateTreeHashes {
+		sink.WriteHash(h)
+	}
+	sink.WriteVarUint(uint64(len(self.CrossStates)))
+	for _, h := range self.CrossStates {
+		sink.WriteHash(h)
+	}
+	self.Bookkeeper.Serialization(sink)
+	sink.WriteVarUint(uint64(len(self.ChunkHashes)))
+	for _, h := range self.ChunkHashes {
+		sink.WriteHash(h)
+	}
+}
+
+func (self *SnapshotManifest) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	self.Height, eof = source.NextUint32()
+	self.BlockHash, eof = source.NextHash()
+	self.TotalStateHash, eof = source.NextHash()
+	self.KeyCount, eof = source.NextUint64()
+	self.WriteSetHash, eof = source.NextHash()
+	self.StateRoot, eof = source.NextHash()
+	self.StateTreeSize, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	hashes, err := nextHashList(source)
+	if err != nil {
+		return err
+	}
+	self.StateTreeHashes = hashes
+	hashes, err = nextHashList(source)
+	if err != nil {
+		return err
+	}
+	self.CrossStates = hashes
+	self.Bookkeeper = new(states.BookkeeperState)
+	if err := self.Bookkeeper.Deserialization(source); err != nil {
+		return err
+	}
+	hashes, err = nextHashList(source)
+	if err != nil {
+		return err
+	}
+	self.ChunkHashes = hashes
+	return nil
+}
+
+func nextHashList(source *common.ZeroCopySource) ([]common.Uint256, error) {
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	if eof || count > source.Len()/common.UINT256_SIZE {
+		return nil, io.ErrUnexpectedEOF
+	}
+	hashes := make([]common.Uint256, 0, count)
+	for i := uint64(0); i < count; i++ {
+		h, _ := source.NextHash()
+		hashes = append(hashes, h)
+	}
+	return hashes, nil
+}
+
+//Hash return the hash of the manifest, nodes serving the same snapshot return the same hash
+func (self *SnapshotManifest) Hash() common.Uint256 {
+	sink := common.NewZeroCopySink(nil)
+	self.Serialization(sink)
+	return sha256.Sum256(sink.Bytes())
+}
+
+//Verify check the state merkle tree of the manifest is consistent with its state root
+func (self *SnapshotManifest) Verify(stateHashCheckHeight uint32) error {
+	if self.Height < stateHashCheckHeight {
+		return fmt.Errorf("snapshot height %d is lower than state hash check height %d", self.Height, stateHashCheckHeight)
+	}
+	if self.StateTreeSize != self.Height-stateHashCheckHeight+1 {
+		return fmt.Errorf("state merkle tree size %d is inconsistent with height %d", self.StateTreeSize, self.Height)
+	}
+	tree := merkle.NewTree(self.StateTreeSize, self.StateTreeHashes, nil)
+	if tree.Root() != self.StateRoot {
+		return fmt.Errorf("state merkle tree root mismatch, expected: %s, got: %s",
+			self.StateRoot.ToHexString(), tree.Root().ToHexString())
+	}
+	if self.Bookkeeper == nil {
+		return fmt.Errorf("missing bookkeeper state")
+	}
+	if len(self.ChunkHashes) == 0 {
+		return fmt.Errorf("missing state chunk hashes")
+	}
+	return nil
+}
+
+//SnapshotKV is a key value pair of snapshot states
+type SnapshotKV struct {
+	Key   []byte
+	Value []byte
+}
+
+func (self *SnapshotKV) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(self.Key)
+	sink.WriteVarBytes(self.Value)
+}
+
+func (self *SnapshotKV) Deserialization(source *common.ZeroCopySource) error {
+	var irregular, eof bool
+	self.Key, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	self.Value, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//SnapshotChunkHash return the hash of a chunk of snapshot states and the start key of the next chunk, empty if it is
+//the last chunk. The chunks are cut every SNAPSHOT_CHUNK_SIZE bytes, so the chunk hashes of the manifest pin the
+//content of every chunk served by peers.
+func SnapshotChunkHash(kvs []*SnapshotKV, next []byte) common.Uint256 {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(kvs)))
+	for _, kv := range kvs {
+		kv.Serialization(sink)
+	}
+	sink.WriteVarBytes(next)
+	return sha256.Sum256(sink.Bytes())
+}
+
+//StateHasher accumulate the total state hash of key values written in key order
+type StateHasher struct {
+	hasher  hash.Hash
+	lastKey []byte
+	count   uint64
+}
+
+func NewStateHasher() *StateHasher {
+	return &StateHasher{hasher: sha256.New()}
+}
+
+//Write add the key value to the hash, the keys must be states keys in strictly ascending order
+func (self *StateHasher) Write(key, value []byte) error {
+	if !IsStateHashKey(key) {
+		return fmt.Errorf("unexpected state key %x", key)
+	}
+	if self.lastKey != nil && bytes.Compare(key, self.lastKey) <= 0 {
+		return fmt.Errorf("state key %x is not in ascending order", key)
+	}
+	self.lastKey = append(self.lastKey[:0], key...)
+	self.hasher.Write(key)
+	self.hasher.Write(value)
+	self.count++
+	return nil
+}
+
+//CheckKeys check the key values can be written in order without error
+func (self *StateHasher) CheckKeys(kvs []*SnapshotKV) error {
+	last := self.lastKey
+	for _, kv := range kvs {
+		if !IsStateHashKey(kv.Key) {
+			return fmt.Errorf("unexpected state key %x", kv.Key)
+		}
+		if last != nil && bytes.Compare(kv.Key, last) <= 0 {
+			return fmt.Errorf("state key %x is not in ascending order", kv.Key)
+		}
+		last = kv.Key
+	}
+	return nil
+}
+
+//Count return the number of key values written
+func (self *StateHasher) Count() uint64 {
+	return self.count
+}
+
+func (self *StateHasher) Sum() (result common.Uint256) {
+	self.hasher.Sum(result[:0])
+	return
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/merkle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotManifest(t *testing.T) {
+	tree := merkle.NewTree(0, nil, nil)
+	for i := 0; i < 5; i++ {
+		tree.AppendHash(common.Uint256{byte(i)})
+	}
+	manifest := &SnapshotManifest{
+		Height:          104,
+		BlockHash:       common.Uint256{1},
+		TotalStateHash:  common.Uint256{2},
+		KeyCount:        10,
+		WriteSetHash:    common.Uint256{4},
+		StateRoot:       tree.Root(),
+		StateTreeSize:   tree.TreeSize(),
+		StateTreeHashes: tree.Hashes(),
+		CrossStates:     []common.Uint256{{5}},
+		Bookkeeper:      &states.BookkeeperState{},
+		ChunkHashes:     []common.Uint256{{7}, {8}},
+	}
+	assert.Nil(t, manifest.Verify(100))
+	assert.NotNil(t, manifest.Verify(99))
+
+	sink := common.NewZeroCopySink(nil)
+	manifest.Serialization(sink)
+	decoded := new(SnapshotManifest)
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, manifest.Hash(), decoded.Hash())
+	assert.Equal(t, manifest.ChunkHashes, decoded.ChunkHashes)
+
+	manifest.StateRoot = common.Uint256{6}
+	assert.NotNil(t, manifest.Verify(100))
+}
+
+func TestSnapshotChunkHash(t *testing.T) {
+	kvs := []*SnapshotKV{{Key: []byte{byte(ST_STORAGE), 1}, Value: []byte{1}}}
+	hash := SnapshotChunkHash(kvs, []byte{byte(ST_STORAGE), 2})
+	assert.NotEqual(t, hash, SnapshotChunkHash(kvs, nil))
+	assert.NotEqual(t, hash, SnapshotChunkHash([]*SnapshotKV{{Key: []byte{byte(ST_STORAGE), 1}, Value: []byte{2}}},
+		[]byte{byte(ST_STORAGE), 2}))
+	assert.Equal(t, hash, SnapshotChunkHash([]*SnapshotKV{{Key: []byte{byte(ST_STORAGE), 1}, Value: []byte{1}}},
+		[]byte{byte(ST_STORAGE), 2}))
+}
+
+func TestStateHasher(t *testing.T) {
+	hasher := NewStateHasher()
+	kvs := []*SnapshotKV{
+		{Key: []byte{byte(ST_CONTRACT), 1}, Value: []byte{1}},
+		{Key: []byte{byte(ST_STORAGE), 1}, Value: []byte{2}},
+	}
+	assert.Nil(t, hasher.CheckKeys(kvs))
+	for _, kv := range kvs {
+		assert.Nil(t, hasher.Write(kv.Key, kv.Value))
+	}
+	assert.Equal(t, uint64(2), hasher.Count())
+
+	assert.NotNil(t, hasher.CheckKeys([]*SnapshotKV{{Key: []byte{byte(ST_CONTRACT), 2}}}))
+	assert.NotNil(t, hasher.CheckKeys([]*SnapshotKV{{Key: []byte{byte(SYS_VERSION)}}}))
+	assert.NotNil(t, hasher.Write([]byte{byte(ST_STORAGE), 1}, nil))
+}
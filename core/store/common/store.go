@@ -29,15 +29,15 @@ var ErrNotFound = errors.New("not found")
 
 //Store iterator for iterate store
 type StoreIterator interface {
-	Next() bool //Next item. If item available return true, otherwise return false
-	//Prev() bool           //previous item. If item available return true, otherwise return false
-	First() bool //First item. If item available return true, otherwise return false
-	//Last() bool           //Last item. If item available return true, otherwise return false
-	//Seek(key []byte) bool //Seek key. If item available return true, otherwise return false
-	Key() []byte   //Return the current item key
-	Value() []byte //Return the current item value
-	Release()      //Close iterator
-	Error() error  // Error returns any accumulated error.
+	Next() bool           //Next item. If item available return true, otherwise return false
+	Prev() bool           //previous item. If item available return true, otherwise return false
+	First() bool          //First item. If item available return true, otherwise return false
+	Last() bool           //Last item. If item available return true, otherwise return false
+	Seek(key []byte) bool //Seek the first item whose key is not less than key. If item available return true, otherwise return false
+	Key() []byte          //Return the current item key
+	Value() []byte        //Return the current item value
+	Release()             //Close iterator
+	Error() error         // Error returns any accumulated error.
 }
 
 //PersistStore of ledger
@@ -54,6 +54,31 @@ type PersistStore interface {
 	NewIterator(prefix []byte) StoreIterator //Return the iterator of store
 }
 
+//StoreSnapshot is a read only view of store at the time it is created
+type StoreSnapshot interface {
+	Get(key []byte) ([]byte, error)                     //Get the value if key in snapshot
+	NewIterator(prefix []byte) StoreIterator            //Return the iterator of snapshot with the key prefix
+	NewRangeIterator(start, limit []byte) StoreIterator //Return the iterator of snapshot over keys in [start, limit), nil limit means no upper bound
+	Release()                                           //Release snapshot
+}
+
+//WriteBatch is a batch of writes committed in one atomic write, independent of the batch started by NewBatch
+type WriteBatch interface {
+	Put(key []byte, value []byte) //Put a key-value pair to batch
+	Delete(key []byte)            //Delete the key in batch
+	Len() int                     //Return the number of writes in batch
+	Reset()                       //Clear batch for reuse
+}
+
+//EngineStore is a PersistStore backed by a storage engine, like leveldb
+type EngineStore interface {
+	PersistStore
+	NewSnapshot() (StoreSnapshot, error) //Return a snapshot of store, which must be released after use
+	NewWriteBatch() WriteBatch           //Return a new empty write batch
+	WriteBatch(batch WriteBatch) error   //Commit the write batch to store
+	CompactRange(prefix []byte) error    //Compact the keys with the prefix, to reclaim the space of deleted keys
+}
+
 //EventStore save event notify
 type EventStore interface {
 	//SaveEventNotifyByTx save event notify gen by smart contract execution
@@ -0,0 +1,326 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+// The address index keeps, for every address, the transactions signed or paid by it and the ONT/ONG
+// transfers from or to it. Height and index are encoded in big endian so the entries of an address are
+// iterated in chain order.
+
+//SaveAddressIndex index the transactions and ONT/ONG transfers of block by the addresses involved
+func (this *EventStore) SaveAddressIndex(block *types.Block, notify []*event.ExecuteNotify) {
+	height := block.Header.Height
+	notifyByTx := make(map[common.Uint256]*event.ExecuteNotify, len(notify))
+	for _, n := range notify {
+		notifyByTx[n.TxHash] = n
+	}
+	for i, tx := range block.Transactions {
+		txIndex := uint32(i)
+		txHash := tx.Hash()
+		addrs := make(map[common.Address]bool)
+		addrs[tx.Payer] = true
+		for _, addr := range tx.GetSignatureAddresses() {
+			addrs[addr] = true
+		}
+		if n := notifyByTx[txHash]; n != nil {
+			for j, info := range n.Notify {
+				transfer := parseTransferNotify(info)
+				if transfer == nil {
+					continue
+				}
+				transfer.TxHash, transfer.Height, transfer.TxIndex = txHash, height, txIndex
+				value := common.SerializeToBytes(transfer)
+				this.store.BatchPut(genAddressTransferKey(transfer.From, height, txIndex, uint32(j)), value)
+				if transfer.To != transfer.From {
+					this.store.BatchPut(genAddressTransferKey(transfer.To, height, txIndex, uint32(j)), value)
+				}
+				addrs[transfer.From] = true
+				addrs[transfer.To] = true
+			}
+		}
+		for addr := range addrs {
+			this.store.BatchPut(genAddressTxKey(addr, height, txIndex), txHash[:])
+		}
+	}
+	this.saveAddressIndexHeight(height)
+}
+
+//GetAddressTransactions return at most limit transactions involving address in chain order, skipping the first offset ones
+func (this *EventStore) GetAddressTransactions(addr common.Address, offset, limit uint32) ([]*scom.AddressTx, error) {
+	txs := make([]*scom.AddressTx, 0)
+	err := this.iterateAddressIndex(genAddressTxPrefix(addr), offset, limit, func(key, value []byte) error {
+		txHash, err := common.Uint256ParseFromBytes(value)
+		if err != nil {
+			return err
+		}
+		pos := key[1+common.ADDR_LEN:]
+		txs = append(txs, &scom.AddressTx{
+			TxHash:  txHash,
+			Height:  binary.BigEndian.Uint32(pos),
+			TxIndex: binary.BigEndian.Uint32(pos[4:]),
+		})
+		return nil
+	})
+	return txs, err
+}
+
+//GetAddressTransfers return at most limit ONT/ONG transfers involving address in chain order, skipping the first offset ones
+func (this *EventStore) GetAddressTransfers(addr common.Address, offset, limit uint32) ([]*scom.AddressTransfer, error) {
+	transfers := make([]*scom.AddressTransfer, 0)
+	err := this.iterateAddressIndex(genAddressTransferPrefix(addr), offset, limit, func(key, value []byte) error {
+		transfer := &scom.AddressTransfer{}
+		if err := transfer.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return err
+		}
+		transfers = append(transfers, transfer)
+		return nil
+	})
+	return transfers, err
+}
+
+func (this *EventStore) iterateAddressIndex(prefix []byte, offset, limit uint32, handle func(key, value []byte) error) error {
+	if limit == 0 {
+		return nil
+	}
+	iter := this.store.NewIterator(prefix)
+	defer iter.Release()
+	for ok := iter.First(); ok; ok = iter.Next() {
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if err := handle(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+		limit--
+		if limit == 0 {
+			break
+		}
+	}
+	return iter.Error()
+}
+
+//RollbackAddressIndex remove the index entries of the blocks after height
+func (this *EventStore) RollbackAddressIndex(height uint32) error {
+	indexHeight, err := this.GetAddressIndexHeight()
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if indexHeight <= height {
+		return nil
+	}
+	// the transfers of a block can not be found without its notify, so the whole index is scanned
+	this.NewBatch()
+	count := 0
+	for _, prefix := range []scom.DataEntryPrefix{scom.IX_ADDRESS_TX, scom.IX_ADDRESS_TRANSFER} {
+		iter := this.store.NewIterator([]byte{byte(prefix)})
+		for iter.Next() {
+			key := iter.Key()
+			if len(key) < 1+common.ADDR_LEN+4 || binary.BigEndian.Uint32(key[1+common.ADDR_LEN:]) <= height {
+				continue
+			}
+			this.store.BatchDelete(append([]byte{}, key...))
+			count++
+			if count%addressIndexBatchSize == 0 {
+				if err := this.CommitTo(); err != nil {
+					iter.Release()
+					return err
+				}
+				this.NewBatch()
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	this.saveAddressIndexHeight(height)
+	return this.CommitTo()
+}
+
+//checkAddressIndex refuse to serve the address index if it misses the blocks saved while it was disabled, they must
+//be indexed by RebuildAddressIndex first. The index is contiguous from the genesis block up to its height, since
+//it is either saved with every block or rebuilt from an indexed height.
+func (this *LedgerStoreImp) checkAddressIndex() error {
+	currHeight := this.GetCurrentBlockHeight()
+	indexHeight, err := this.eventStore.GetAddressIndexHeight()
+	if err == scom.ErrNotFound {
+		return fmt.Errorf("address index is not built for the blocks in DB, run reindex before enabling it")
+	}
+	if err != nil {
+		return fmt.Errorf("GetAddressIndexHeight error %s", err)
+	}
+	if indexHeight < currHeight {
+		return fmt.Errorf("address index height %d is behind current block height %d, run reindex --start-height %d "+
+			"before enabling it", indexHeight, currHeight, indexHeight+1)
+	}
+	return nil
+}
+
+func (this *EventStore) saveAddressIndexHeight(height uint32) {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, height)
+	this.store.BatchPut([]byte{byte(scom.SYS_ADDRESS_INDEX)}, value)
+}
+
+//GetAddressIndexHeight return the height of the last block indexed by address
+func (this *EventStore) GetAddressIndexHeight() (uint32, error) {
+	value, err := this.store.Get([]byte{byte(scom.SYS_ADDRESS_INDEX)})
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid address index height length: %d", len(value))
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// parseTransferNotify return the transfer of ONT/ONG transfer notify, or nil if the notify is not one. The amount is
+// an uint64 in the notify gen by execution and a json number in the notify loaded from store.
+func parseTransferNotify(info *event.NotifyEventInfo) *scom.AddressTransfer {
+	if info.IsEvm || (info.ContractAddress != utils.OntContractAddress && info.ContractAddress != utils.OngContractAddress) {
+		return nil
+	}
+	states, ok := info.States.([]interface{})
+	if !ok || len(states) != 4 {
+		return nil
+	}
+	if name, ok := states[0].(string); !ok || name != ont.TRANSFER_NAME {
+		return nil
+	}
+	from, ok := states[1].(string)
+	if !ok {
+		return nil
+	}
+	to, ok := states[2].(string)
+	if !ok {
+		return nil
+	}
+	transfer := &scom.AddressTransfer{Contract: info.ContractAddress}
+	var err error
+	if transfer.From, err = common.AddressFromBase58(from); err != nil {
+		return nil
+	}
+	if transfer.To, err = common.AddressFromBase58(to); err != nil {
+		return nil
+	}
+	switch amount := states[3].(type) {
+	case uint64:
+		transfer.Amount = amount
+	case json.Number:
+		if transfer.Amount, err = strconv.ParseUint(amount.String(), 10, 64); err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return transfer
+}
+
+func genAddressTxPrefix(addr common.Address) []byte {
+	key := make([]byte, 1, 1+common.ADDR_LEN+8)
+	key[0] = byte(scom.IX_ADDRESS_TX)
+	return append(key, addr[:]...)
+}
+
+func genAddressTxKey(addr common.Address, height, txIndex uint32) []byte {
+	key := genAddressTxPrefix(addr)
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	binary.BigEndian.PutUint32(buf[4:], txIndex)
+	return append(key, buf[:]...)
+}
+
+func genAddressTransferPrefix(addr common.Address) []byte {
+	key := make([]byte, 1, 1+common.ADDR_LEN+12)
+	key[0] = byte(scom.IX_ADDRESS_TRANSFER)
+	return append(key, addr[:]...)
+}
+
+func genAddressTransferKey(addr common.Address, height, txIndex, eventIndex uint32) []byte {
+	key := genAddressTransferPrefix(addr)
+	var buf [12]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	binary.BigEndian.PutUint32(buf[4:], txIndex)
+	binary.BigEndian.PutUint32(buf[8:], eventIndex)
+	return append(key, buf[:]...)
+}
+
+const addressIndexBatchSize = 1000
+
+//RebuildAddressIndex index the blocks from startHeight to current block height by address with the saved event
+//notifies, progress is called after each block is indexed. Blocks must not be pruned and event log is required to
+//index the transfers. The blocks before startHeight must have been indexed, so that the index has no gap.
+func (this *LedgerStoreImp) RebuildAddressIndex(startHeight uint32, progress func(height uint32)) error {
+	if startHeight > 0 {
+		indexHeight, err := this.eventStore.GetAddressIndexHeight()
+		if err != nil && err != scom.ErrNotFound {
+			return fmt.Errorf("GetAddressIndexHeight error:%s", err)
+		}
+		if err == scom.ErrNotFound || indexHeight+1 < startHeight {
+			return fmt.Errorf("the blocks before start height %d are not indexed", startHeight)
+		}
+	}
+	currHeight := this.GetCurrentBlockHeight()
+	this.eventStore.NewBatch()
+	for height := startHeight; height <= currHeight; height++ {
+		block, err := this.GetBlockByHeight(height)
+		if err != nil {
+			return fmt.Errorf("GetBlockByHeight height:%d error:%s", height, err)
+		}
+		notify := make([]*event.ExecuteNotify, 0, len(block.Transactions))
+		for _, tx := range block.Transactions {
+			// amounts are decoded as json number to keep the precision
+			n, err := this.eventStore.getEventNotifyByTx(tx.Hash(), true)
+			if err != nil {
+				if err == scom.ErrNotFound {
+					continue
+				}
+				return fmt.Errorf("getEventNotifyByTx height:%d error:%s", height, err)
+			}
+			notify = append(notify, n)
+		}
+		this.eventStore.SaveAddressIndex(block, notify)
+		if (height-startHeight+1)%addressIndexBatchSize == 0 || height == currHeight {
+			if err := this.eventStore.CommitTo(); err != nil {
+				return fmt.Errorf("eventStore.CommitTo height:%d error:%s", height, err)
+			}
+			this.eventStore.NewBatch()
+		}
+		if progress != nil {
+			progress(height)
+		}
+	}
+	return nil
+}
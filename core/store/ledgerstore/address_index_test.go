@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressIndex(t *testing.T) {
+	eventStore, err := NewEventStore("test/address_index")
+	assert.Nil(t, err)
+	defer eventStore.Close()
+
+	from := common.AddressFromVmCode([]byte("from"))
+	to := common.AddressFromVmCode([]byte("to"))
+	tx, err := transferTx(from, to, 100)
+	assert.Nil(t, err)
+	block := &types.Block{Header: &types.Header{Height: 5}, Transactions: []*types.Transaction{tx}}
+	notify := []*event.ExecuteNotify{{
+		TxHash: tx.Hash(),
+		Notify: []*event.NotifyEventInfo{
+			{ContractAddress: nutils.OntContractAddress, States: []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(100)}},
+			{ContractAddress: nutils.OntIDContractAddress, States: []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(1)}},
+			// notify loaded from store with json number
+			{ContractAddress: nutils.OngContractAddress, States: []interface{}{"transfer", from.ToBase58(), nutils.GovernanceContractAddress.ToBase58(), json.Number("1000000000000000001")}},
+		},
+	}}
+	eventStore.NewBatch()
+	eventStore.SaveAddressIndex(block, notify)
+	assert.Nil(t, eventStore.CommitTo())
+
+	height, err := eventStore.GetAddressIndexHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(5), height)
+
+	for _, addr := range []common.Address{tx.Payer, from, to, nutils.GovernanceContractAddress} {
+		txs, err := eventStore.GetAddressTransactions(addr, 0, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(txs))
+		assert.Equal(t, tx.Hash(), txs[0].TxHash)
+		assert.Equal(t, uint32(5), txs[0].Height)
+	}
+
+	transfers, err := eventStore.GetAddressTransfers(from, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(transfers))
+	assert.Equal(t, nutils.OntContractAddress, transfers[0].Contract)
+	assert.Equal(t, uint64(100), transfers[0].Amount)
+	assert.Equal(t, nutils.OngContractAddress, transfers[1].Contract)
+	assert.Equal(t, uint64(1000000000000000001), transfers[1].Amount)
+
+	page, err := eventStore.GetAddressTransfers(from, 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, transfers[1:], page)
+	page, err = eventStore.GetAddressTransfers(from, 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, transfers[:1], page)
+
+	transfers, err = eventStore.GetAddressTransfers(to, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(transfers))
+}
+
+func TestCheckAddressIndex(t *testing.T) {
+	bookkeepers := []keypair.PublicKey{account.NewAccount("").PublicKey}
+	ledger, err := NewLedgerStore("test/check_address_index", 0)
+	assert.Nil(t, err)
+	defer ledger.Close()
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+
+	// the genesis block is saved without the index
+	assert.NotNil(t, ledger.checkAddressIndex())
+	// the index can not start after a gap
+	assert.NotNil(t, ledger.RebuildAddressIndex(1, func(uint32) {}))
+	assert.Nil(t, ledger.RebuildAddressIndex(0, func(uint32) {}))
+	assert.Nil(t, ledger.checkAddressIndex())
+	assert.Nil(t, ledger.RebuildAddressIndex(1, func(uint32) {}))
+}
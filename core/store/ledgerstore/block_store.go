@@ -27,16 +27,15 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/serialization"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/types"
 )
 
 //Block store save the data of block & transaction
 type BlockStore struct {
-	enableCache bool                       //Is enable lru cache
-	dbDir       string                     //The path of store file
-	cache       *BlockCache                //The cache of block, if have.
-	store       *leveldbstore.LevelDBStore //block store handler
+	enableCache bool             //Is enable lru cache
+	dbDir       string           //The path of store file
+	cache       *BlockCache      //The cache of block, if have.
+	store       scom.EngineStore //block store handler
 }
 
 //NewBlockStore return the block store instance
@@ -50,7 +49,7 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 		}
 	}
 
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openEngineStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -554,3 +553,45 @@ func (this *BlockStore) PruneBlock(hash common.Uint256) []common.Uint256 {
 	this.store.BatchDelete(key)
 	return txHashes
 }
+
+//RollbackBlock remove the block at height, which must be the current block, and make its previous block current
+func (this *BlockStore) RollbackBlock(height uint32) error {
+	if height == 0 {
+		return fmt.Errorf("genesis block can not be rolled back")
+	}
+	hash, err := this.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash error %s", err)
+	}
+	prevHash, err := this.GetBlockHash(height - 1)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash error %s", err)
+	}
+	this.NewBatch()
+	// the index list containing the block is dropped, the hashes before the block are kept by height
+	startHeight := height - height%HEADER_INDEX_BATCH_SIZE
+	indexKey := genHeaderIndexListKey(startHeight)
+	value, err := this.store.Get(indexKey)
+	if err != nil && err != scom.ErrNotFound {
+		return err
+	}
+	if err == nil {
+		source := common.NewZeroCopySource(value)
+		count, eof := source.NextUint32()
+		for i := uint32(0); !eof && i < count && startHeight+i < height; i++ {
+			var blockHash common.Uint256
+			blockHash, eof = source.NextHash()
+			this.SaveBlockHash(startHeight+i, blockHash)
+		}
+		if eof {
+			return fmt.Errorf("invalid header index list of height %d", startHeight)
+		}
+		this.store.BatchDelete(indexKey)
+	}
+	this.PruneBlock(hash)
+	this.store.BatchDelete(genBlockHashKey(height))
+	if err := this.SaveCurrentBlock(height-1, prevHash); err != nil {
+		return err
+	}
+	return this.CommitTo()
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+)
+
+// The contract event index keeps the notifies of successful transactions by contract. Height and index are encoded
+// in big endian so the events of a contract are iterated in chain order, and the contracts indexed in a block are
+// recorded to remove the entries when the block is pruned.
+
+//SaveContractEventIndex index the notifies of block by the contract address
+func (this *EventStore) SaveContractEventIndex(block *types.Block, notify []*event.ExecuteNotify) {
+	height := block.Header.Height
+	notifyByTx := make(map[common.Uint256]*event.ExecuteNotify, len(notify))
+	for _, n := range notify {
+		notifyByTx[n.TxHash] = n
+	}
+	contracts := make([]common.Address, 0)
+	indexed := make(map[common.Address]bool)
+	for i, tx := range block.Transactions {
+		n := notifyByTx[tx.Hash()]
+		if n == nil || n.State != event.CONTRACT_STATE_SUCCESS {
+			continue
+		}
+		for j, info := range n.Notify {
+			states, err := json.Marshal(info.States)
+			if err != nil {
+				log.Errorf("SaveContractEventIndex height:%d tx:%s marshal states error:%s", height,
+					n.TxHash.ToHexString(), err)
+				continue
+			}
+			evt := &scom.ContractEvent{
+				TxHash:     n.TxHash,
+				Height:     height,
+				TxIndex:    uint32(i),
+				EventIndex: uint32(j),
+				Contract:   info.ContractAddress,
+				Name:       getContractEventName(info),
+				States:     states,
+			}
+			this.store.BatchPut(genContractEventKey(evt.Contract, height, evt.TxIndex, evt.EventIndex),
+				common.SerializeToBytes(evt))
+			if !indexed[evt.Contract] {
+				indexed[evt.Contract] = true
+				contracts = append(contracts, evt.Contract)
+			}
+		}
+	}
+	if len(contracts) == 0 {
+		return
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(contracts)))
+	for _, addr := range contracts {
+		sink.WriteAddress(addr)
+	}
+	this.store.BatchPut(genContractEventBlockKey(height), sink.Bytes())
+}
+
+//maxContractEventScan is the max number of index entries scanned by a query, so that a query filtering by name over a
+//long range is bounded
+var maxContractEventScan = 10000
+
+//GetContractEvents return at most limit events of contract from the position from to endHeight in chain order. Only
+//the events whose name equals to name are returned if name is not empty. The position to continue from is returned if
+//the limit or the max entries to scan is reached before endHeight, nil if all the events are returned.
+func (this *EventStore) GetContractEvents(contract common.Address, from scom.ContractEventCursor, endHeight uint32,
+	name string, limit uint32) ([]*scom.ContractEvent, *scom.ContractEventCursor, error) {
+	events := make([]*scom.ContractEvent, 0)
+	if limit == 0 || from.Height > endHeight {
+		return events, nil, nil
+	}
+	prefix := genContractEventPrefix(contract)
+	iter := this.store.NewIterator(prefix)
+	defer iter.Release()
+	var next *scom.ContractEventCursor
+	scanned := 0
+	for ok := iter.Seek(genContractEventKey(contract, from.Height, from.TxIndex, from.EventIndex)); ok; ok = iter.Next() {
+		key := iter.Key()[len(prefix):]
+		if len(key) != 12 {
+			return nil, nil, fmt.Errorf("invalid contract event key %x", iter.Key())
+		}
+		pos := scom.ContractEventCursor{
+			Height:     binary.BigEndian.Uint32(key),
+			TxIndex:    binary.BigEndian.Uint32(key[4:]),
+			EventIndex: binary.BigEndian.Uint32(key[8:]),
+		}
+		if pos.Height > endHeight {
+			break
+		}
+		if uint32(len(events)) == limit || scanned == maxContractEventScan {
+			next = &pos
+			break
+		}
+		scanned++
+		// the states refer to the value, which is reused by iterator
+		evt := &scom.ContractEvent{}
+		if err := evt.Deserialization(common.NewZeroCopySource(append([]byte{}, iter.Value()...))); err != nil {
+			return nil, nil, err
+		}
+		if name != "" && evt.Name != name {
+			continue
+		}
+		events = append(events, evt)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, nil, err
+	}
+	return events, next, nil
+}
+
+//pruneContractEventIndex remove the index entries of the block at height
+func (this *EventStore) pruneContractEventIndex(height uint32) error {
+	blockKey := genContractEventBlockKey(height)
+	data, err := this.store.Get(blockKey)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	source := common.NewZeroCopySource(data)
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	for i := uint64(0); i < count && !eof; i++ {
+		var addr common.Address
+		addr, eof = source.NextAddress()
+		if eof {
+			break
+		}
+		prefix := genContractEventHeightPrefix(addr, height)
+		iter := this.store.NewIterator(prefix)
+		for iter.Next() {
+			this.store.BatchDelete(append([]byte{}, iter.Key()...))
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.store.BatchDelete(blockKey)
+	return nil
+}
+
+// getContractEventName return the conventional name of event, which is the first state of notify if it is a string,
+// or the first topic of evm log.
+func getContractEventName(info *event.NotifyEventInfo) string {
+	if info.IsEvm {
+		evmLog, err := event.NotifyEventInfoToEvmLog(info)
+		if err != nil || len(evmLog.Topics) == 0 {
+			return ""
+		}
+		return evmLog.Topics[0].Hex()
+	}
+	states, ok := info.States.([]interface{})
+	if !ok || len(states) == 0 {
+		return ""
+	}
+	name, _ := states[0].(string)
+	return name
+}
+
+func genContractEventPrefix(contract common.Address) []byte {
+	key := make([]byte, 1, 1+common.ADDR_LEN+12)
+	key[0] = byte(scom.IX_CONTRACT_EVENT)
+	return append(key, contract[:]...)
+}
+
+func genContractEventHeightPrefix(contract common.Address, height uint32) []byte {
+	key := genContractEventPrefix(contract)
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	return append(key, buf[:]...)
+}
+
+func genContractEventKey(contract common.Address, height, txIndex, eventIndex uint32) []byte {
+	key := genContractEventHeightPrefix(contract, height)
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:], txIndex)
+	binary.BigEndian.PutUint32(buf[4:], eventIndex)
+	return append(key, buf[:]...)
+}
+
+func genContractEventBlockKey(height uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(scom.IX_CONTRACT_EVENT_BLOCK)
+	binary.BigEndian.PutUint32(key[1:], height)
+	return key
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractEventIndex(t *testing.T) {
+	eventStore, err := NewEventStore("test/contract_event_index")
+	assert.Nil(t, err)
+	defer eventStore.Close()
+
+	from := common.AddressFromVmCode([]byte("from"))
+	to := common.AddressFromVmCode([]byte("to"))
+	contract := common.AddressFromVmCode([]byte("contract"))
+	for height := uint32(5); height <= 6; height++ {
+		tx1, err := transferTx(from, to, uint64(height))
+		assert.Nil(t, err)
+		tx2, err := transferTx(to, from, uint64(height))
+		assert.Nil(t, err)
+		block := &types.Block{Header: &types.Header{Height: height}, Transactions: []*types.Transaction{tx1, tx2}}
+		notify := []*event.ExecuteNotify{{
+			TxHash: tx1.Hash(),
+			State:  event.CONTRACT_STATE_SUCCESS,
+			Notify: []*event.NotifyEventInfo{
+				{ContractAddress: nutils.OntContractAddress, States: []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(height)}},
+				{ContractAddress: contract, States: []interface{}{"approve", from.ToBase58()}},
+				{ContractAddress: contract, States: []interface{}{"transfer", from.ToBase58()}},
+			},
+		}, {
+			// notify of failed transaction is not indexed
+			TxHash: tx2.Hash(),
+			State:  event.CONTRACT_STATE_FAIL,
+			Notify: []*event.NotifyEventInfo{
+				{ContractAddress: contract, States: []interface{}{"transfer", to.ToBase58()}},
+			},
+		}}
+		eventStore.NewBatch()
+		eventStore.SaveContractEventIndex(block, notify)
+		assert.Nil(t, eventStore.CommitTo())
+	}
+
+	events, next, err := eventStore.GetContractEvents(contract, scom.ContractEventCursor{}, 10, "", 10)
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, 4, len(events))
+	assert.Equal(t, uint32(5), events[0].Height)
+	assert.Equal(t, uint32(1), events[0].EventIndex)
+	assert.Equal(t, "approve", events[0].Name)
+	assert.Equal(t, `["approve","`+from.ToBase58()+`"]`, string(events[0].States))
+	assert.Equal(t, uint32(6), events[3].Height)
+	assert.Equal(t, "transfer", events[3].Name)
+
+	events, next, err = eventStore.GetContractEvents(contract, scom.ContractEventCursor{Height: 6}, 6, "", 10)
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, uint32(6), events[0].Height)
+
+	// continue from the returned position
+	events, next, err = eventStore.GetContractEvents(contract, scom.ContractEventCursor{}, 10, "transfer", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, uint32(5), events[0].Height)
+	assert.Equal(t, &scom.ContractEventCursor{Height: 6, TxIndex: 0, EventIndex: 1}, next)
+	events, next, err = eventStore.GetContractEvents(contract, *next, 10, "transfer", 1)
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, uint32(6), events[0].Height)
+	assert.Equal(t, uint32(2), events[0].EventIndex)
+
+	// the scan stops at the max entries even if no event matches the name
+	maxContractEventScan = 2
+	events, next, err = eventStore.GetContractEvents(contract, scom.ContractEventCursor{}, 10, "unknown", 10)
+	maxContractEventScan = 10000
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+	assert.Equal(t, &scom.ContractEventCursor{Height: 6, TxIndex: 0, EventIndex: 1}, next)
+
+	events, _, err = eventStore.GetContractEvents(nutils.OntContractAddress, scom.ContractEventCursor{}, 10, "transfer", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, uint32(5), events[0].Height)
+
+	eventStore.NewBatch()
+	eventStore.PruneBlock(5, nil)
+	assert.Nil(t, eventStore.CommitTo())
+	events, _, err = eventStore.GetContractEvents(contract, scom.ContractEventCursor{}, 10, "", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, uint32(6), events[0].Height)
+	events, _, err = eventStore.GetContractEvents(nutils.OntContractAddress, scom.ContractEventCursor{}, 10, "", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	_, err = eventStore.store.Get(genContractEventBlockKey(5))
+	assert.Equal(t, scom.ErrNotFound, err)
+}
@@ -24,7 +24,6 @@ import (
 
 	"github.com/ontio/ontology/common"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/types"
 )
 
@@ -34,14 +33,14 @@ const (
 
 //Block store save the data of block & transaction
 type CrossChainStore struct {
-	dbDir string                     //The path of store file
-	store *leveldbstore.LevelDBStore //block store handler
+	dbDir string           //The path of store file
+	store scom.EngineStore //block store handler
 }
 
 //NewCrossChainStore return cross chain store instance
 func NewCrossChainStore(dataDir string) (*CrossChainStore, error) {
 	dbDir := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirCrossChain)
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openEngineStore(dbDir)
 	if err != nil {
 		return nil, fmt.Errorf("NewCrossShardStore error %s", err)
 	}
@@ -78,6 +77,16 @@ func (this *CrossChainStore) GetCrossChainMsg(height uint32) (*types.CrossChainM
 	return msg, nil
 }
 
+//DeleteCrossChainMsg delete the cross chain msg of block at height
+func (this *CrossChainStore) DeleteCrossChainMsg(height uint32) error {
+	return this.store.Delete(this.genCrossChainMsgKey(height))
+}
+
+//Close cross chain store
+func (this *CrossChainStore) Close() error {
+	return this.store.Close()
+}
+
 func (this *CrossChainStore) genCrossChainMsgKey(height uint32) []byte {
 	temp := make([]byte, 5)
 	temp[0] = byte(scom.SYS_CROSS_CHAIN_MSG)
@@ -0,0 +1,276 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ontio/ontology/common"
+	sysconfig "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+)
+
+// The storage engine of a new ledger is selected by config, and recorded in the data dir. A ledger can only be opened
+// with the engine it is created with, MigrateLedgerStore copies it to another engine.
+
+const (
+	DBEngineFile          = "ENGINE" //File recording the storage engine of the ledger stores in data dir
+	migrateBatchSize      = 10000    //Number of keys written in one batch when migrating
+	migrateBatchByteLimit = 16 << 20 //Size of the keys and values written in one batch when migrating
+)
+
+//dirs of the ledger stores and files of the merkle trees in data dir
+func ledgerStoreDirs() []string {
+	return []string{DBDirBlock, DBDirState, DBDirEvent, DBDirCrossChain}
+}
+
+func ledgerStoreFiles() []string {
+	return []string{MerkleTreeStorePath}
+}
+
+func getDBEngine() string {
+	if engine := sysconfig.DefConfig.Common.DBEngine; engine != "" {
+		return engine
+	}
+	return scom.ENGINE_LEVELDB
+}
+
+func openEngineStore(dbDir string) (scom.EngineStore, error) {
+	return scom.OpenEngineStore(getDBEngine(), dbDir)
+}
+
+func dataDirPath(dataDir, name string) string {
+	return fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), name)
+}
+
+//GetDBEngine return the storage engine of the ledger in dataDir, empty if there is no ledger in dataDir
+func GetDBEngine(dataDir string) (string, error) {
+	data, err := ioutil.ReadFile(dataDirPath(dataDir, DBEngineFile))
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	// ledger created before the storage engine is selectable is stored with leveldb
+	if common.FileExisted(dataDirPath(dataDir, DBDirBlock)) {
+		return scom.ENGINE_LEVELDB, nil
+	}
+	return "", nil
+}
+
+func saveDBEngine(dataDir, engine string) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dataDirPath(dataDir, DBEngineFile), []byte(engine), 0644)
+}
+
+//checkDBEngine make sure the ledger in dataDir is stored with the configured storage engine
+func checkDBEngine(dataDir string) error {
+	engine := getDBEngine()
+	if !scom.HasEngine(engine) {
+		return fmt.Errorf("storage engine %s is not available, supported: %s", engine,
+			strings.Join(scom.Engines(), ", "))
+	}
+	curr, err := GetDBEngine(dataDir)
+	if err != nil {
+		return fmt.Errorf("GetDBEngine error %s", err)
+	}
+	if curr == "" {
+		return saveDBEngine(dataDir, engine)
+	}
+	if curr != engine {
+		return fmt.Errorf("ledger in %s is stored with %s instead of the configured %s, "+
+			"use `db migrate` to copy it to %s", dataDir, curr, engine, engine)
+	}
+	return nil
+}
+
+//MigrateLedgerStore copies the ledger in srcDir to dstDir stored with engine, and verifies the copy key by key.
+//Both ledgers must not be opened, and dstDir must not exist or be empty. progress is called after each batch of
+//keys is copied.
+func MigrateLedgerStore(srcDir, dstDir, engine string, progress func(store string, keys uint64)) error {
+	srcEngine, err := GetDBEngine(srcDir)
+	if err != nil {
+		return fmt.Errorf("GetDBEngine error %s", err)
+	}
+	if srcEngine == "" {
+		return fmt.Errorf("no ledger in %s", srcDir)
+	}
+	if !scom.HasEngine(srcEngine) {
+		return fmt.Errorf("storage engine %s of ledger in %s is not available", srcEngine, srcDir)
+	}
+	if !scom.HasEngine(engine) {
+		return fmt.Errorf("storage engine %s is not available, supported: %s", engine,
+			strings.Join(scom.Engines(), ", "))
+	}
+	files, err := ioutil.ReadDir(dstDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(files) > 0 {
+		return fmt.Errorf("target dir %s is not empty", dstDir)
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range ledgerStoreDirs() {
+		if !common.FileExisted(dataDirPath(srcDir, name)) {
+			continue
+		}
+		err := migrateStore(dataDirPath(srcDir, name), srcEngine, dataDirPath(dstDir, name), engine,
+			func(keys uint64) {
+				if progress != nil {
+					progress(name, keys)
+				}
+			})
+		if err != nil {
+			return fmt.Errorf("migrate %s error %s", name, err)
+		}
+	}
+	for _, name := range ledgerStoreFiles() {
+		if !common.FileExisted(dataDirPath(srcDir, name)) {
+			continue
+		}
+		if err := copyFile(dataDirPath(srcDir, name), dataDirPath(dstDir, name)); err != nil {
+			return fmt.Errorf("copy %s error %s", name, err)
+		}
+	}
+	// recorded at last, so an interrupted migration is not taken as a ledger
+	return saveDBEngine(dstDir, engine)
+}
+
+func migrateStore(srcPath, srcEngine, dstPath, dstEngine string, progress func(keys uint64)) error {
+	src, err := scom.OpenEngineStore(srcEngine, srcPath)
+	if err != nil {
+		return fmt.Errorf("open %s error %s", srcPath, err)
+	}
+	defer src.Close()
+	dst, err := scom.OpenEngineStore(dstEngine, dstPath)
+	if err != nil {
+		return fmt.Errorf("open %s error %s", dstPath, err)
+	}
+	defer dst.Close()
+
+	count, err := copyStore(src, dst, progress)
+	if err != nil {
+		return err
+	}
+	log.Infof("%d keys copied from %s to %s", count, srcPath, dstPath)
+	if err := verifyStore(src, dst); err != nil {
+		return fmt.Errorf("verify error %s", err)
+	}
+	return nil
+}
+
+func copyStore(src, dst scom.EngineStore, progress func(keys uint64)) (uint64, error) {
+	iter := src.NewIterator(nil)
+	defer iter.Release()
+	batch := dst.NewWriteBatch()
+	size := 0
+	count := uint64(0)
+	commit := func() error {
+		if err := dst.WriteBatch(batch); err != nil {
+			return err
+		}
+		count += uint64(batch.Len())
+		batch.Reset()
+		size = 0
+		if progress != nil {
+			progress(count)
+		}
+		return nil
+	}
+	for iter.Next() {
+		batch.Put(iter.Key(), iter.Value())
+		size += len(iter.Key()) + len(iter.Value())
+		if batch.Len() >= migrateBatchSize || size >= migrateBatchByteLimit {
+			if err := commit(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return count, err
+	}
+	if batch.Len() > 0 {
+		if err := commit(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+//verifyStore make sure both stores have the same keys and values
+func verifyStore(src, dst scom.EngineStore) error {
+	srcIter := src.NewIterator(nil)
+	defer srcIter.Release()
+	dstIter := dst.NewIterator(nil)
+	defer dstIter.Release()
+	count := uint64(0)
+	for {
+		srcNext, dstNext := srcIter.Next(), dstIter.Next()
+		if !srcNext || !dstNext {
+			if srcNext || dstNext {
+				return fmt.Errorf("key count mismatch after %d keys", count)
+			}
+			break
+		}
+		if !bytes.Equal(srcIter.Key(), dstIter.Key()) {
+			return fmt.Errorf("key %x mismatch %x", dstIter.Key(), srcIter.Key())
+		}
+		if !bytes.Equal(srcIter.Value(), dstIter.Value()) {
+			return fmt.Errorf("value of key %x mismatch", srcIter.Key())
+		}
+		count++
+	}
+	if err := srcIter.Error(); err != nil {
+		return err
+	}
+	return dstIter.Error()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
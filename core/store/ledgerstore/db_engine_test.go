@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateLedgerStore(t *testing.T) {
+	srcDir, dstDir := "test/migrate_src", "test/migrate_dst"
+	ledger, holder := newTestLedger(t, srcDir)
+	for i := 0; i < 2; i++ {
+		block := newTransferBlock(t, ledger, holder, []common.Address{common.AddressFromVmCode([]byte{byte(i)})})
+		result, err := ledger.executeBlock(block)
+		assert.Nil(t, err)
+		assert.Nil(t, ledger.submitBlock(block, nil, result))
+	}
+	height, hash := ledger.GetCurrentBlockHeight(), ledger.GetCurrentBlockHash()
+	states := dumpStateStore(t, ledger)
+	assert.Nil(t, ledger.Close())
+
+	// ledger created before the engine is recorded is leveldb
+	assert.Nil(t, os.Remove(dataDirPath(srcDir, DBEngineFile)))
+	engine, err := GetDBEngine(srcDir)
+	assert.Nil(t, err)
+	assert.Equal(t, scom.ENGINE_LEVELDB, engine)
+
+	assert.NotNil(t, MigrateLedgerStore(srcDir, dstDir, "unknown", nil))
+	assert.NotNil(t, MigrateLedgerStore("test/migrate_none", dstDir, scom.ENGINE_LEVELDB, nil))
+	stores := make(map[string]uint64)
+	err = MigrateLedgerStore(srcDir, dstDir, scom.ENGINE_LEVELDB, func(store string, keys uint64) {
+		stores[store] = keys
+	})
+	assert.Nil(t, err)
+	assert.True(t, stores[DBDirBlock] > 0)
+	assert.True(t, stores[DBDirState] > 0)
+	// target dir is not empty
+	assert.NotNil(t, MigrateLedgerStore(srcDir, dstDir, scom.ENGINE_LEVELDB, nil))
+
+	engine, err = GetDBEngine(dstDir)
+	assert.Nil(t, err)
+	assert.Equal(t, scom.ENGINE_LEVELDB, engine)
+	ledger, err = NewLedgerStore(dstDir, 0)
+	assert.Nil(t, err)
+	// the test blocks have no consensus payload to load the vbft peers from
+	assert.Nil(t, ledger.init())
+	assert.Equal(t, height, ledger.GetCurrentBlockHeight())
+	assert.Equal(t, hash, ledger.GetCurrentBlockHash())
+	assert.Equal(t, states, dumpStateStore(t, ledger))
+	assert.Nil(t, ledger.Close())
+
+	// ledger can not be opened with another engine
+	assert.Nil(t, ioutil.WriteFile(dataDirPath(dstDir, DBEngineFile), []byte(scom.ENGINE_PEBBLE), 0644))
+	_, err = NewLedgerStore(dstDir, 0)
+	assert.NotNil(t, err)
+}
@@ -23,12 +23,13 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 
+	types2 "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/common/serialization"
 	scom "github.com/ontio/ontology/core/store/common"
-	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/smartcontract/event"
 )
 
@@ -38,13 +39,13 @@ var UseNumber = false
 
 //Saving event notifies gen by smart contract execution
 type EventStore struct {
-	dbDir string                     //Store path
-	store *leveldbstore.LevelDBStore //Store handler
+	dbDir string           //Store path
+	store scom.EngineStore //Store handler
 }
 
 //NewEventStore return event store instance
 func NewEventStore(dbDir string) (*EventStore, error) {
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openEngineStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -83,13 +84,17 @@ func (this *EventStore) SaveEventNotifyByBlock(height uint32, txHashs []common.U
 
 //GetEventNotifyByTx return event notify by trasanction hash
 func (this *EventStore) GetEventNotifyByTx(txHash common.Uint256) (*event.ExecuteNotify, error) {
+	return this.getEventNotifyByTx(txHash, UseNumber)
+}
+
+func (this *EventStore) getEventNotifyByTx(txHash common.Uint256, useNumber bool) (*event.ExecuteNotify, error) {
 	key := genEventNotifyByTxKey(txHash)
 	data, err := this.store.Get(key)
 	if err != nil {
 		return nil, err
 	}
 	var notify event.ExecuteNotify
-	if UseNumber {
+	if useNumber {
 		dec := json.NewDecoder(bytes.NewReader(data))
 		dec.UseNumber()
 		if err = dec.Decode(&notify); err != nil {
@@ -130,12 +135,57 @@ func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNo
 	return evtNotifies, nil
 }
 
+//getEventTxHashesByBlock return the transaction hashes saved with the event notify of block
+func (this *EventStore) getEventTxHashesByBlock(height uint32) ([]common.Uint256, error) {
+	data, err := this.store.Get(genEventNotifyByBlockKey(height))
+	if err != nil {
+		return nil, err
+	}
+	source := common.NewZeroCopySource(data)
+	size, eof := source.NextUint32()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	txHashes := make([]common.Uint256, 0, size)
+	for i := uint32(0); i < size; i++ {
+		txHash, eof := source.NextHash()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		txHashes = append(txHashes, txHash)
+	}
+	return txHashes, nil
+}
+
+//SaveBloomByBlock persist the bloom of evm logs generated in block
+func (this *EventStore) SaveBloomByBlock(height uint32, bloom types2.Bloom) {
+	key := genBloomByBlockKey(height)
+	this.store.BatchPut(key, bloom.Bytes())
+}
+
+//GetBloomByBlock return the bloom of evm logs generated in block
+func (this *EventStore) GetBloomByBlock(height uint32) (types2.Bloom, error) {
+	key := genBloomByBlockKey(height)
+	data, err := this.store.Get(key)
+	if err != nil {
+		return types2.Bloom{}, err
+	}
+	if len(data) != types2.BloomByteLength {
+		return types2.Bloom{}, fmt.Errorf("invalid bloom length %d at height %d", len(data), height)
+	}
+	return types2.BytesToBloom(data), nil
+}
+
 func (this *EventStore) PruneBlock(height uint32, hashes []common.Uint256) {
 	key := genEventNotifyByBlockKey(height)
 	this.store.BatchDelete(key)
+	this.store.BatchDelete(genBloomByBlockKey(height))
 	for _, hash := range hashes {
 		this.store.BatchDelete(genEventNotifyByTxKey(hash))
 	}
+	if err := this.pruneContractEventIndex(height); err != nil {
+		log.Errorf("pruneContractEventIndex height:%d error:%s", height, err)
+	}
 }
 
 //CommitTo event store batch to store
@@ -209,3 +259,10 @@ func genEventNotifyByTxKey(txHash common.Uint256) []byte {
 	copy(key[1:], data)
 	return key
 }
+
+func genBloomByBlockKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.EVENT_BLOOM)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"testing"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	types2 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStoreBloom(t *testing.T) {
+	eventStore, err := NewEventStore("test/event")
+	assert.Nil(t, err)
+	defer eventStore.Close()
+
+	addr := common2.HexToAddress("0x1234")
+	topic := common2.HexToHash("0x5678")
+	evmLog := &types.StorageLog{Address: addr, Topics: []common2.Hash{topic}}
+	notify := []*event.ExecuteNotify{{
+		Notify: []*event.NotifyEventInfo{
+			{States: []interface{}{"neovm event"}},
+			{ContractAddress: common.Address(addr), States: hexutil.Bytes(common.SerializeToBytes(evmLog)), IsEvm: true},
+		},
+	}}
+	bloom := genEvmLogsBloom(notify)
+	assert.True(t, types2.BloomLookup(bloom, addr))
+	assert.True(t, types2.BloomLookup(bloom, topic))
+
+	eventStore.NewBatch()
+	eventStore.SaveBloomByBlock(10, bloom)
+	assert.Nil(t, eventStore.CommitTo())
+
+	saved, err := eventStore.GetBloomByBlock(10)
+	assert.Nil(t, err)
+	assert.Equal(t, bloom, saved)
+
+	_, err = eventStore.GetBloomByBlock(11)
+	assert.Equal(t, scom.ErrNotFound, err)
+
+	eventStore.NewBatch()
+	eventStore.PruneBlock(10, nil)
+	assert.Nil(t, eventStore.CommitTo())
+	_, err = eventStore.GetBloomByBlock(10)
+	assert.Equal(t, scom.ErrNotFound, err)
+}
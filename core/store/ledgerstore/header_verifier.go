@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/types"
+)
+
+//headerVerifier follow the headers after the current block with its own copy of the vbft chain configs
+type headerVerifier struct {
+	store      *LedgerStoreImp
+	prevHeader *types.Header
+	peerInfos  map[uint32]map[string]uint32
+}
+
+//NewHeaderVerifier return a verifier of the headers following the current block, see store.HeaderVerifier
+func (this *LedgerStoreImp) NewHeaderVerifier() (store.HeaderVerifier, error) {
+	prevHeader, err := this.GetHeaderByHash(this.GetCurrentBlockHash())
+	if err != nil {
+		return nil, fmt.Errorf("get current header error %s", err)
+	}
+	peerInfos := make(map[uint32]map[string]uint32)
+	this.lock.RLock()
+	for height, peerInfo := range this.vbftPeerInfoMap {
+		peerInfos[height] = peerInfo
+	}
+	this.lock.RUnlock()
+	return &headerVerifier{store: this, prevHeader: prevHeader, peerInfos: peerInfos}, nil
+}
+
+func (self *headerVerifier) Follow(header *types.Header) (int, error) {
+	if header.PrevBlockHash != self.prevHeader.Hash() {
+		return 0, fmt.Errorf("prev block hash of header %d mismatch", header.Height)
+	}
+	m, peerInfo, err := checkHeader(header, self.prevHeader, func(chainConfigHeight uint32) (map[string]uint32, bool) {
+		peerInfo, ok := self.peerInfos[chainConfigHeight]
+		return peerInfo, ok
+	})
+	if err != nil {
+		return 0, err
+	}
+	if peerInfo != nil {
+		self.peerInfos[header.Height] = peerInfo
+	}
+	self.prevHeader = header
+	return m, nil
+}
+
+func (self *headerVerifier) VerifySig(header *types.Header, m int) error {
+	hash := header.Hash()
+	err := signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
+	if err != nil {
+		return err
+	}
+	self.store.lock.Lock()
+	self.store.sigVerified[hash] = true
+	self.store.lock.Unlock()
+	return nil
+}
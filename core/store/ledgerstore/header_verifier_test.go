@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderVerifier(t *testing.T) {
+	bookkeeper := account.NewAccount("")
+	bookkeepers := []keypair.PublicKey{bookkeeper.PublicKey}
+	ledger, err := NewLedgerStore("test/header_verifier", 0)
+	assert.Nil(t, err)
+	defer ledger.Close()
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	// the test headers have no vbft consensus payload, verify them by the next bookkeeper of the previous header
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+
+	verifier, err := ledger.NewHeaderVerifier()
+	assert.Nil(t, err)
+	var blocks []*types.Block
+	prevHash := genesisBlock.Hash()
+	for height := uint32(1); height <= 2; height++ {
+		block := &types.Block{Header: &types.Header{
+			Height:         height,
+			PrevBlockHash:  prevHash,
+			Timestamp:      genesisBlock.Header.Timestamp + height,
+			NextBookkeeper: genesisBlock.Header.NextBookkeeper,
+			Bookkeepers:    bookkeepers,
+		}}
+		block.RebuildMerkleRoot()
+		hash := block.Hash()
+		sig, err := signature.Sign(bookkeeper, hash[:])
+		assert.Nil(t, err)
+		block.Header.SigData = [][]byte{sig}
+		blocks = append(blocks, block)
+		prevHash = hash
+	}
+
+	// the headers must be followed in order
+	_, err = verifier.Follow(blocks[1].Header)
+	assert.NotNil(t, err)
+	m, err := verifier.Follow(blocks[0].Header)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, m)
+	assert.Nil(t, verifier.VerifySig(blocks[0].Header, m))
+	assert.True(t, ledger.sigVerified[blocks[0].Hash()])
+
+	m, err = verifier.Follow(blocks[1].Header)
+	assert.Nil(t, err)
+	forged := *blocks[1].Header
+	forged.SigData = blocks[0].Header.SigData
+	assert.NotNil(t, verifier.VerifySig(&forged, m))
+	assert.False(t, ledger.sigVerified[blocks[1].Hash()])
+
+	blocks[0].Header.BlockRoot = ledger.GetBlockRootWithNewTxRoots(1, []common.Uint256{blocks[0].Header.TransactionsRoot})
+	result, err := ledger.ExecuteBlock(blocks[0])
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.SubmitBlock(blocks[0], nil, result))
+	assert.False(t, ledger.sigVerified[blocks[0].Hash()])
+}
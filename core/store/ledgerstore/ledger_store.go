@@ -60,6 +60,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/service/wasmvm"
 	sstate "github.com/ontio/ontology/smartcontract/states"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 	evm2 "github.com/ontio/ontology/vm/evm"
 	"github.com/ontio/ontology/vm/evm/params"
 	types2 "github.com/ontio/ontology/vm/neovm/types"
@@ -82,6 +83,7 @@ type PrexecuteParam struct {
 	JitMode    bool
 	WasmFactor uint64
 	MinGas     bool
+	Tracer     trace.Tracer // optional, trace the neovm and wasmvm invocation
 }
 
 //LedgerStoreImp is main store struct fo ledger
@@ -96,12 +98,20 @@ type LedgerStoreImp struct {
 	headerCache          map[common.Uint256]*types.Header //BlockHash => Header
 	headerIndex          map[uint32]common.Uint256        //Header index, Mapping header height => block hash
 	vbftPeerInfoMap      map[uint32]map[string]uint32     //key:block height,value:peerInfo
+	sigVerified          map[common.Uint256]bool          //headers whose bookkeeper signatures verified by HeaderVerifier
 	lock                 sync.RWMutex
 	stateHashCheckHeight uint32
 
 	savingBlockSemaphore       chan bool
 	closing                    bool
-	preserveBlockHistoryLength uint32 // block could be pruned if blockHeight + preserveBlockHistoryLength < currHeight , disable prune if equals 0
+	preserveBlockHistoryLength uint32         // block could be pruned if blockHeight + preserveBlockHistoryLength < currHeight , disable prune if equals 0
+	stateGCExit                chan struct{}  // closed to stop state gc, nil if state gc is not enabled
+	stateGCDone                sync.WaitGroup // wait state gc to exit before closing the stores
+
+	snapshotLock   sync.RWMutex
+	snapshots      []*stateSnapshot // state snapshots served to fast sync, the latest at last
+	snapshotClosed bool
+	snapshotImport *snapshotImport // progress of importing a snapshot, nil if not importing
 }
 
 //NewLedgerStore return LedgerStoreImp instance
@@ -110,10 +120,14 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 		headerIndex:          make(map[uint32]common.Uint256),
 		headerCache:          make(map[common.Uint256]*types.Header, 0),
 		vbftPeerInfoMap:      make(map[uint32]map[string]uint32),
+		sigVerified:          make(map[common.Uint256]bool),
 		savingBlockSemaphore: make(chan bool, 1),
 		stateHashCheckHeight: stateHashHeight,
 	}
 
+	if err := checkDBEngine(dataDir); err != nil {
+		return nil, err
+	}
 	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true)
 	if err != nil {
 		return nil, fmt.Errorf("NewBlockStore error %s", err)
@@ -199,6 +213,11 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if err != nil {
 			return fmt.Errorf("init error %s", err)
 		}
+		if sysconfig.DefConfig.Common.EnableAddressIndex {
+			if err := this.checkAddressIndex(); err != nil {
+				return err
+			}
+		}
 	}
 	//load vbft peerInfo
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
@@ -317,6 +336,10 @@ func (this *LedgerStoreImp) recoverStore() error {
 	if err != nil {
 		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
 	}
+	if stateHeight > blockHeight {
+		return fmt.Errorf("state height %d is ahead of block height %d, the snapshot import may be interrupted, "+
+			"please remove the data directory and sync again", stateHeight, blockHeight)
+	}
 	for i := stateHeight; i < blockHeight; i++ {
 		blockHash, err := this.blockStore.GetBlockHash(i)
 		if err != nil {
@@ -336,7 +359,7 @@ func (this *LedgerStoreImp) recoverStore() error {
 		if err != nil {
 			return fmt.Errorf("save to state store height:%d error:%s", i, err)
 		}
-		this.saveBlockToEventStore(block)
+		this.saveBlockToEventStore(block, result.Notify)
 		err = this.eventStore.CommitTo()
 		if err != nil {
 			return fmt.Errorf("eventStore.CommitTo height:%d error %s", i, err)
@@ -452,25 +475,66 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header) error {
 	if prevHeader == nil {
 		return fmt.Errorf("cannot find pre header by blockHash %s", prevHeaderHash.ToHexString())
 	}
+	m, peerInfo, err := checkHeader(header, prevHeader, this.getVbftPeerInfo)
+	if err != nil {
+		return err
+	}
+	hash := header.Hash()
+	if !this.takeSigVerified(hash) {
+		err = signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
+		if err != nil {
+			log.Errorf("VerifyMultiSignature:%s,Bookkeepers:%d,heigh:%d", err, len(header.Bookkeepers), header.Height)
+			return err
+		}
+	}
+	if peerInfo != nil {
+		this.lock.Lock()
+		this.vbftPeerInfoMap[header.Height] = peerInfo
+		this.lock.Unlock()
+	}
+	return nil
+}
 
+func (this *LedgerStoreImp) getVbftPeerInfo(chainConfigHeight uint32) (map[string]uint32, bool) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	vbftPeerInfo, ok := this.vbftPeerInfoMap[chainConfigHeight]
+	return vbftPeerInfo, ok
+}
+
+//takeSigVerified return whether the bookkeeper signatures of the header have been verified by a HeaderVerifier,
+//and forget it
+func (this *LedgerStoreImp) takeSigVerified(hash common.Uint256) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	verified := this.sigVerified[hash]
+	delete(this.sigVerified, hash)
+	return verified
+}
+
+//checkHeader check header following prevHeader except the bookkeeper signatures, and return the number of
+//bookkeeper signatures required and the vbft peers of the new chain config of header if any. The vbft peers of
+//chain configs are looked up by getPeerInfo.
+func checkHeader(header, prevHeader *types.Header,
+	getPeerInfo func(chainConfigHeight uint32) (map[string]uint32, bool)) (int, map[string]uint32, error) {
 	if prevHeader.Height+1 != header.Height {
-		return fmt.Errorf("block height is incorrect")
+		return 0, nil, fmt.Errorf("block height is incorrect")
 	}
 
 	if prevHeader.Timestamp >= header.Timestamp {
-		return fmt.Errorf("block timestamp is incorrect")
+		return 0, nil, fmt.Errorf("block timestamp is incorrect")
 	}
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
 	if consensusType == "vbft" {
 		blkInfo, err := vconfig.VbftBlock(header)
 		if err != nil {
-			return err
+			return 0, nil, err
 		}
 		var chainConfigHeight uint32
 		if blkInfo.NewChainConfig != nil {
 			prevBlockInfo, err := vconfig.VbftBlock(prevHeader)
 			if err != nil {
-				return err
+				return 0, nil, err
 			}
 			if prevBlockInfo.NewChainConfig != nil {
 				chainConfigHeight = prevHeader.Height
@@ -480,16 +544,13 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header) error {
 		} else {
 			chainConfigHeight = blkInfo.LastConfigBlockNum
 		}
-		this.lock.RLock()
-		vbftPeerInfo, ok := this.vbftPeerInfoMap[chainConfigHeight]
+		vbftPeerInfo, ok := getPeerInfo(chainConfigHeight)
 		if !ok {
-			this.lock.RUnlock()
-			return fmt.Errorf("chainconfig height:%d not found", chainConfigHeight)
+			return 0, nil, fmt.Errorf("chainconfig height:%d not found", chainConfigHeight)
 		}
-		this.lock.RUnlock()
 		m := len(vbftPeerInfo) - (len(vbftPeerInfo)*6)/7
 		if len(header.Bookkeepers) < m {
-			return fmt.Errorf("header Bookkeepers %d more than 6/7 len vbftPeerInfo%d", len(header.Bookkeepers), len(vbftPeerInfo))
+			return 0, nil, fmt.Errorf("header Bookkeepers %d more than 6/7 len vbftPeerInfo%d", len(header.Bookkeepers), len(vbftPeerInfo))
 		}
 		for _, bookkeeper := range header.Bookkeepers {
 			pubkey := vconfig.PubkeyID(bookkeeper)
@@ -498,41 +559,26 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header) error {
 				val, _ := json.Marshal(vbftPeerInfo)
 				log.Errorf("verify header error: invalid pubkey :%v, height:%d, current vbftPeerInfo :%s",
 					pubkey, header.Height, string(val))
-				return fmt.Errorf("verify header error: invalid pubkey : %v", pubkey)
+				return 0, nil, fmt.Errorf("verify header error: invalid pubkey : %v", pubkey)
 			}
 		}
-		hash := header.Hash()
-		err = signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
-		if err != nil {
-			log.Errorf("VerifyMultiSignature:%s,Bookkeepers:%d,pubkey:%d,heigh:%d", err, len(header.Bookkeepers), len(vbftPeerInfo), header.Height)
-			return err
-		}
-		if blkInfo.NewChainConfig != nil {
-			peerInfo := make(map[string]uint32)
-			for _, p := range blkInfo.NewChainConfig.Peers {
-				peerInfo[p.ID] = p.Index
-			}
-			this.lock.Lock()
-			this.vbftPeerInfoMap[header.Height] = peerInfo
-			this.lock.Unlock()
-		}
-	} else {
-		address, err := types.AddressFromBookkeepers(header.Bookkeepers)
-		if err != nil {
-			return err
+		if blkInfo.NewChainConfig == nil {
+			return m, nil, nil
 		}
-		if prevHeader.NextBookkeeper != address {
-			return fmt.Errorf("bookkeeper address error")
-		}
-
-		m := len(header.Bookkeepers) - (len(header.Bookkeepers)-1)/3
-		hash := header.Hash()
-		err = signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
-		if err != nil {
-			return err
+		peerInfo := make(map[string]uint32)
+		for _, p := range blkInfo.NewChainConfig.Peers {
+			peerInfo[p.ID] = p.Index
 		}
+		return m, peerInfo, nil
 	}
-	return nil
+	address, err := types.AddressFromBookkeepers(header.Bookkeepers)
+	if err != nil {
+		return 0, nil, err
+	}
+	if prevHeader.NextBookkeeper != address {
+		return 0, nil, fmt.Errorf("bookkeeper address error")
+	}
+	return len(header.Bookkeepers) - (len(header.Bookkeepers)-1)/3, nil, nil
 }
 
 func (this *LedgerStoreImp) verifyCrossChainMsg(crossChainMsg *types.CrossChainMsg, bookkeepers []keypair.PublicKey) error {
@@ -733,28 +779,11 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 			return
 		}
 	}
-	gasTable := make(map[string]uint64)
-	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
-		key := k.(string)
-		val := value.(uint64)
-		gasTable[key] = val
-
-		return true
-	})
-	cache := storage.NewCacheDB(overlay)
-	for i, tx := range block.Transactions {
-		cache.Reset()
-		notify, crossStateHashes, e := this.handleTransaction(overlay, cache, gasTable, block, tx, uint32(i))
-		if e != nil {
-			err = e
-			return
-		}
-		if tx.GasPrice != 0 {
-			notify.GasStepUsed = notify.GasConsumed / tx.GasPrice
-		}
-		notify.TxIndex = uint32(i)
-		result.Notify = append(result.Notify, notify)
-		result.CrossStates = append(result.CrossStates, crossStateHashes...)
+	gasTable := copyGasTable()
+	parallel := sysconfig.DefConfig.Common.EnableParallelExec && block.Header.Height != 0
+	result.Notify, result.CrossStates, _, err = this.handleTransactions(overlay, gasTable, block, parallel)
+	if err != nil {
+		return
 	}
 	result.Hash = overlay.ChangeHash()
 	result.WriteSet = overlay.GetWriteSet()
@@ -782,13 +811,22 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 	return
 }
 
+func copyGasTable() map[string]uint64 {
+	gasTable := make(map[string]uint64)
+	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		key := k.(string)
+		val := value.(uint64)
+		gasTable[key] = val
+
+		return true
+	})
+	return gasTable
+}
+
 func calculateTotalStateHash(overlay *overlaydb.OverlayDB) (result common.Uint256, err error) {
 	stateDiff := sha256.New()
 
-	prefix := []scom.DataEntryPrefix{scom.ST_CONTRACT, scom.ST_STORAGE, scom.ST_DESTROYED, scom.ST_ETH_CODE,
-		scom.ST_ETH_ACCOUNT}
-
-	for _, v := range prefix {
+	for _, v := range scom.StateHashPrefixes {
 		iter := overlay.NewIterator([]byte{byte(v)})
 		err = accumulateHash(stateDiff, iter)
 		iter.Release()
@@ -821,6 +859,15 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 		}
 	}
 
+	if blockHeight > 0 {
+		if sysconfig.DefConfig.Common.EnableStateUndo {
+			if err := this.stateStore.SaveStateUndo(blockHeight, result.WriteSet); err != nil {
+				return fmt.Errorf("SaveStateUndo error %s", err)
+			}
+		}
+		this.stateStore.pruneStateUndo(blockHeight)
+	}
+
 	err := this.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
 	if err != nil {
 		return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
@@ -843,6 +890,13 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 
 	log.Debugf("the state transition hash of block %d is:%s", blockHeight, result.Hash.ToHexString())
 
+	if sysconfig.DefConfig.Common.EnableArchiveState {
+		err = this.stateStore.SaveArchiveState(blockHeight, result.WriteSet)
+		if err != nil {
+			return fmt.Errorf("SaveArchiveState error %s", err)
+		}
+	}
+
 	result.WriteSet.ForEach(func(key, val []byte) {
 		if len(val) == 0 {
 			this.stateStore.BatchDeleteRawKey(key)
@@ -854,7 +908,7 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 	return nil
 }
 
-func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) {
+func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block, notify []*event.ExecuteNotify) {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 	txs := make([]common.Uint256, 0)
@@ -865,9 +919,37 @@ func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) {
 	if len(txs) > 0 {
 		this.eventStore.SaveEventNotifyByBlock(block.Header.Height, txs)
 	}
+	if sysconfig.DefConfig.Common.EnableEventLog && sysconfig.DefConfig.Common.EnableEthLogBloom {
+		this.eventStore.SaveBloomByBlock(blockHeight, genEvmLogsBloom(notify))
+	}
+	if sysconfig.DefConfig.Common.EnableAddressIndex {
+		this.eventStore.SaveAddressIndex(block, notify)
+	}
+	if sysconfig.DefConfig.Common.EnableContractEventIndex {
+		this.eventStore.SaveContractEventIndex(block, notify)
+	}
 	this.eventStore.SaveCurrentBlock(blockHeight, blockHash)
 }
 
+//genEvmLogsBloom return the bloom of all evm logs in the notify list, non evm notify are skipped
+func genEvmLogsBloom(notify []*event.ExecuteNotify) types3.Bloom {
+	var logs []*types3.Log
+	for _, n := range notify {
+		for _, info := range n.Notify {
+			if !info.IsEvm {
+				continue
+			}
+			storageLog, err := event.NotifyEventInfoToEvmLog(info)
+			if err != nil {
+				log.Warnf("genEvmLogsBloom: tx %s decode evm log error %s", n.TxHash.ToHexString(), err)
+				continue
+			}
+			logs = append(logs, &types3.Log{Address: storageLog.Address, Topics: storageLog.Topics})
+		}
+	}
+	return types3.BytesToBloom(types3.LogsBloom(logs))
+}
+
 func (this *LedgerStoreImp) tryGetSavingBlockLock() (hasLocked bool) {
 	select {
 	case this.savingBlockSemaphore <- true:
@@ -944,7 +1026,7 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, crossChainMsg *types
 	if err != nil {
 		return fmt.Errorf("save to state store height:%d error:%s", blockHeight, err)
 	}
-	this.saveBlockToEventStore(block)
+	this.saveBlockToEventStore(block, result.Notify)
 	err = this.blockStore.CommitTo()
 	if err != nil {
 		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
@@ -959,6 +1041,7 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, crossChainMsg *types
 		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
 	}
 	this.setCurrentBlock(blockHeight, blockHash)
+	this.trySnapshotState(block)
 
 	if events.DefActorPublisher != nil {
 		events.DefActorPublisher.Publish(
@@ -1000,15 +1083,16 @@ func (this *LedgerStoreImp) saveBlock(block *types.Block, ccMsg *types.CrossChai
 	return this.submitBlock(block, ccMsg, result)
 }
 
-func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cache *storage.CacheDB, gasTable map[string]uint64,
-	block *types.Block, tx *types.Transaction, txIndex uint32) (*event.ExecuteNotify, []common.Uint256, error) {
+//handleTransaction execute the transaction on the overlay, the ledger is passed to the contracts
+func (this *LedgerStoreImp) handleTransaction(ledger store.LedgerStore, overlay *overlaydb.OverlayDB, cache *storage.CacheDB,
+	gasTable map[string]uint64, block *types.Block, tx *types.Transaction, txIndex uint32) (*event.ExecuteNotify, []common.Uint256, error) {
 	txHash := tx.Hash()
 	notify := &event.ExecuteNotify{TxHash: txHash, State: event.CONTRACT_STATE_FAIL, TxIndex: txIndex}
 	var crossStateHashes []common.Uint256
 	var err error
 	switch tx.TxType {
 	case types.Deploy:
-		err = this.stateStore.HandleDeployTransaction(this, overlay, gasTable, cache, tx, block, notify)
+		err = this.stateStore.HandleDeployTransaction(ledger, overlay, gasTable, cache, tx, block, notify)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1016,7 +1100,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			log.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 	case types.InvokeNeo, types.InvokeWasm:
-		crossStateHashes, err = this.stateStore.HandleInvokeTransaction(this, overlay, gasTable, cache, tx, block, notify)
+		crossStateHashes, err = this.stateStore.HandleInvokeTransaction(ledger, overlay, gasTable, cache, tx, block, notify, nil)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1024,18 +1108,13 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			log.Debugf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 	case types.EIP155:
-		eiptx, err := tx.GetEIP155Tx()
-		if err != nil {
-			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), err.Error())
-		}
-
 		ctx := Eip155Context{
 			BlockHash: block.Hash(),
 			TxIndex:   txIndex,
 			Height:    block.Header.Height,
 			Timestamp: block.Header.Timestamp,
 		}
-		_, err = this.stateStore.HandleEIP155Transaction(this, cache, eiptx, ctx, notify, true)
+		_, err = this.stateStore.HandleEIP155Transaction(ledger, cache, tx, ctx, notify, true)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1218,6 +1297,27 @@ func (this *LedgerStoreImp) GetEventNotifyByBlock(height uint32) ([]*event.Execu
 	return this.eventStore.GetEventNotifyByBlock(height)
 }
 
+//GetBloomByBlock return the bloom of evm logs generated in block. Wrap function of EventStore.GetBloomByBlock
+func (this *LedgerStoreImp) GetBloomByBlock(height uint32) (types3.Bloom, error) {
+	return this.eventStore.GetBloomByBlock(height)
+}
+
+//GetAddressTransactions return the transactions involving address. Wrap function of EventStore.GetAddressTransactions
+func (this *LedgerStoreImp) GetAddressTransactions(addr common.Address, offset, limit uint32) ([]*scom.AddressTx, error) {
+	return this.eventStore.GetAddressTransactions(addr, offset, limit)
+}
+
+//GetAddressTransfers return the ONT/ONG transfers involving address. Wrap function of EventStore.GetAddressTransfers
+func (this *LedgerStoreImp) GetAddressTransfers(addr common.Address, offset, limit uint32) ([]*scom.AddressTransfer, error) {
+	return this.eventStore.GetAddressTransfers(addr, offset, limit)
+}
+
+//GetContractEvents return the events of contract from the position from to endHeight. Wrap function of EventStore.GetContractEvents
+func (this *LedgerStoreImp) GetContractEvents(contract common.Address, from scom.ContractEventCursor, endHeight uint32,
+	name string, limit uint32) ([]*scom.ContractEvent, *scom.ContractEventCursor, error) {
+	return this.eventStore.GetContractEvents(contract, from, endHeight, name, limit)
+}
+
 //PreExecuteContract return the result of smart contract execution without commit to store
 func (this *LedgerStoreImp) PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*sstate.PreExecResult, uint32, error) {
 	if atomic {
@@ -1238,7 +1338,7 @@ func (this *LedgerStoreImp) PreExecuteContractBatch(txes []*types.Transaction, a
 	return results, height, nil
 }
 
-func (this *LedgerStoreImp) PreExecuteEIP155(tx *types3.Transaction, ctx Eip155Context) (*types4.ExecutionResult, *event.ExecuteNotify, error) {
+func (this *LedgerStoreImp) PreExecuteEIP155(tx *types.Transaction, ctx Eip155Context) (*types4.ExecutionResult, *event.ExecuteNotify, error) {
 	overlay := this.stateStore.NewOverlayDB()
 	cache := storage.NewCacheDB(overlay)
 
@@ -1271,7 +1371,6 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 	stf := &sstate.PreExecResult{State: event.CONTRACT_STATE_FAIL, Gas: neovm.MIN_TRANSACTION_GAS, Result: nil}
 
 	if tx.IsEipTx() {
-		invoke := tx.Payload.(*payload.EIP155Code)
 		ctx := Eip155Context{
 			BlockHash: blockHash,
 			TxIndex:   0,
@@ -1279,7 +1378,7 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 			Timestamp: blockTime,
 		}
 
-		result, notify, err := this.PreExecuteEIP155(invoke.EIPTx, ctx)
+		result, notify, err := this.PreExecuteEIP155(tx, ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -1324,6 +1423,7 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 			WasmExecStep: config.DEFAULT_WASM_MAX_STEPCOUNT,
 			JitMode:      preParam.JitMode,
 			PreExec:      true,
+			Tracer:       preParam.Tracer,
 		}
 		//start the smart contract executive function
 		engine, _ := sc.NewExecuteEngine(invoke.Code, tx.TxType)
@@ -1392,8 +1492,29 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 	return this.PreExecuteContractWithParam(tx, param)
 }
 
+//PreExecuteContractWithTracer pre-execute the transaction like PreExecuteContract, the neovm and wasmvm invocation
+//is traced by tracer
+func (this *LedgerStoreImp) PreExecuteContractWithTracer(tx *types.Transaction, tracer trace.Tracer) (*sstate.PreExecResult, error) {
+	param := PrexecuteParam{
+		JitMode:    false,
+		WasmFactor: 0,
+		MinGas:     true,
+		Tracer:     tracer,
+	}
+
+	return this.PreExecuteContractWithParam(tx, param)
+}
+
 func (this *LedgerStoreImp) PreExecuteEip155Tx(msg types3.Message) (*types4.ExecutionResult, error) {
-	height := this.GetCurrentBlockHeight()
+	return this.PreExecuteEip155TxAtHeight(msg, this.GetCurrentBlockHeight())
+}
+
+//PreExecuteEip155TxAtHeight execute the eth message on the state after block at height, as if it is in the next block
+func (this *LedgerStoreImp) PreExecuteEip155TxAtHeight(msg types3.Message, height uint32) (*types4.ExecutionResult, error) {
+	cache, err := this.GetCacheDBAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
 	// use previous block time to make it predictable for easy test
 	blockTime := uint32(time.Now().Unix())
 	if header, err := this.GetHeaderByHeight(height); err == nil {
@@ -1409,7 +1530,6 @@ func (this *LedgerStoreImp) PreExecuteEip155Tx(msg types3.Message) (*types4.Exec
 	config := params.GetChainConfig(sysconfig.DefConfig.P2PNode.EVMChainId)
 	txContext := evm.NewEVMTxContext(msg)
 	blockContext := evm.NewEVMBlockContext(height, blockTime, this)
-	cache := this.GetCacheDB()
 	statedb := storage.NewStateDB(cache, common2.Hash{}, common2.Hash(ctx.BlockHash), ong.OngBalanceHandle{})
 	vmenv := evm2.NewEVM(blockContext, txContext, statedb, config, evm2.Config{})
 	res, err := evm.ApplyMessage(vmenv, msg, common2.Address(utils.GovernanceContractAddress))
@@ -1423,6 +1543,11 @@ func (this *LedgerStoreImp) Close() error {
 	defer this.releaseSavingBlockLock()
 
 	this.closing = true
+	this.releaseStateSnapshots()
+	if this.stateGCExit != nil {
+		close(this.stateGCExit)
+		this.stateGCDone.Wait()
+	}
 
 	err := this.blockStore.Close()
 	if err != nil {
@@ -1436,6 +1561,10 @@ func (this *LedgerStoreImp) Close() error {
 	if err != nil {
 		return fmt.Errorf("stateStore close error %s", err)
 	}
+	err = this.crossChainStore.Close()
+	if err != nil {
+		return fmt.Errorf("crossChainStore close error %s", err)
+	}
 	return nil
 }
 
@@ -1475,3 +1604,101 @@ func (this *LedgerStoreImp) GetCacheDB() *storage.CacheDB {
 	return storage.NewCacheDB(overlay)
 
 }
+
+//GetCacheDBAtHeight return the cache db of the state after block at height was executed.
+//State of past height is only available when archive state is enabled
+func (this *LedgerStoreImp) GetCacheDBAtHeight(height uint32) (*storage.CacheDB, error) {
+	current := this.GetCurrentBlockHeight()
+	if height > current {
+		return nil, fmt.Errorf("height %d is higher than current height %d", height, current)
+	}
+	if height == current {
+		return this.GetCacheDB(), nil
+	}
+	overlay, err := this.stateStore.NewOverlayDBAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewCacheDB(overlay), nil
+}
+
+//GetCacheDBBeforeTx return the cache db of the state right before the transaction was executed, together with
+//the block and index of the transaction. The preceding transactions of the block are replayed on the state of
+//previous block, which requires archive state.
+func (this *LedgerStoreImp) GetCacheDBBeforeTx(txHash common.Uint256) (*storage.CacheDB, *types.Block, uint32, error) {
+	st, err := this.getStateBeforeTx(txHash)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return st.cache, st.block, st.txIndex, nil
+}
+
+//stateBeforeTx is the state right before a transaction of block, with the gas table applied to the block
+type stateBeforeTx struct {
+	overlay  *overlaydb.OverlayDB
+	cache    *storage.CacheDB
+	gasTable map[string]uint64
+	block    *types.Block
+	txIndex  uint32
+}
+
+func (this *LedgerStoreImp) getStateBeforeTx(txHash common.Uint256) (*stateBeforeTx, error) {
+	_, height, err := this.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if height == 0 {
+		return nil, fmt.Errorf("transaction %s is in genesis block", txHash.ToHexString())
+	}
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := this.stateStore.NewOverlayDBAtHeight(height - 1)
+	if err != nil {
+		return nil, err
+	}
+	// the gas table is loaded from the global params before the block as executeBlock does
+	config := &smartcontract.Config{
+		Time:   block.Header.Timestamp,
+		Height: block.Header.Height,
+		Tx:     &types.Transaction{},
+	}
+	gasTable, err := getGasTable(config, storage.NewCacheDB(overlay), this)
+	if err != nil {
+		return nil, err
+	}
+	cache := storage.NewCacheDB(overlay)
+	for i, tx := range block.Transactions {
+		cache.Reset()
+		if tx.Hash() == txHash {
+			return &stateBeforeTx{overlay: overlay, cache: cache, gasTable: gasTable, block: block, txIndex: uint32(i)}, nil
+		}
+		if _, _, err := this.handleTransaction(this, overlay, cache, gasTable, block, tx, uint32(i)); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("transaction %s not found in block %d", txHash.ToHexString(), height)
+}
+
+//TraceTransaction re-execute the neovm or wasmvm invoke transaction on the state right before it with the tracer,
+//return the execute notify of the replay. Archive state is required.
+func (this *LedgerStoreImp) TraceTransaction(txHash common.Uint256, tracer trace.Tracer) (*event.ExecuteNotify, error) {
+	st, err := this.getStateBeforeTx(txHash)
+	if err != nil {
+		return nil, err
+	}
+	tx := st.block.Transactions[st.txIndex]
+	if tx.TxType != types.InvokeNeo && tx.TxType != types.InvokeWasm {
+		return nil, fmt.Errorf("transaction %s is not a neovm or wasmvm invoke transaction", txHash.ToHexString())
+	}
+	notify := &event.ExecuteNotify{TxHash: txHash, State: event.CONTRACT_STATE_FAIL, TxIndex: st.txIndex}
+	_, err = this.stateStore.HandleInvokeTransaction(this, st.overlay, st.gasTable, st.cache, tx, st.block, notify, tracer)
+	if st.overlay.Error() != nil {
+		return nil, st.overlay.Error()
+	}
+	if err != nil {
+		log.Debugf("TraceTransaction tx %s error %s", txHash.ToHexString(), err)
+	}
+	return notify, nil
+}
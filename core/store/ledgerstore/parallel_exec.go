@@ -0,0 +1,340 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//feeBalanceKey is the ong balance key of the governance contract, which receives the gas fee of every transaction
+var feeBalanceKey = append([]byte{byte(scom.ST_STORAGE)}, ont.GenBalanceKey(utils.OngContractAddress,
+	utils.GovernanceContractAddress)...)
+
+//readRecordStore record the keys and the iterator prefixes read from the backend store during transaction execution.
+//The reads of keys written by the transaction itself are served by the overlay and not recorded.
+//The governance ong balance read while crediting the gas fee is not recorded either, its base value is kept instead so
+//that the credit can be merged as a delta.
+type readRecordStore struct {
+	scom.PersistStore
+	keys     [][]byte
+	prefixes [][]byte
+
+	crediting    bool
+	feeRead      bool
+	feeBase      []byte
+	feeDependent bool
+}
+
+func (self *readRecordStore) Get(key []byte) ([]byte, error) {
+	if self.crediting && bytes.Equal(key, feeBalanceKey) {
+		val, err := self.PersistStore.Get(key)
+		if err != nil && err != scom.ErrNotFound {
+			return nil, err
+		}
+		if !self.feeRead {
+			self.feeRead = true
+			self.feeBase = val
+		}
+		return val, err
+	}
+	self.keys = append(self.keys, append([]byte(nil), key...))
+	return self.PersistStore.Get(key)
+}
+
+func (self *readRecordStore) Has(key []byte) (bool, error) {
+	self.keys = append(self.keys, append([]byte(nil), key...))
+	return self.PersistStore.Has(key)
+}
+
+func (self *readRecordStore) NewIterator(prefix []byte) scom.StoreIterator {
+	self.prefixes = append(self.prefixes, append([]byte(nil), prefix...))
+	return self.PersistStore.NewIterator(prefix)
+}
+
+//feeCreditor is implemented by the ledger passed to the speculative executions, the gas fee charging notify it to
+//credit the governance contract in a way that commutes with the other transactions of the block
+type feeCreditor interface {
+	beginFeeCredit()
+	endFeeCredit()
+	feeCreditBalanceHandle(handle storage.OngBalanceHandle) storage.OngBalanceHandle
+}
+
+//feeCreditLedger is the ledger of a speculative execution
+type feeCreditLedger struct {
+	*LedgerStoreImp
+	reads *readRecordStore
+}
+
+func (self *feeCreditLedger) beginFeeCredit() {
+	self.reads.crediting = true
+}
+
+func (self *feeCreditLedger) endFeeCredit() {
+	self.reads.crediting = false
+}
+
+func (self *feeCreditLedger) feeCreditBalanceHandle(handle storage.OngBalanceHandle) storage.OngBalanceHandle {
+	return &feeCreditBalanceHandle{OngBalanceHandle: handle, reads: self.reads}
+}
+
+//feeCreditBalanceHandle credits the ong added to the governance contract by the evm as a fee credit. Any other access
+//to the governance balance makes the transaction depend on its value.
+type feeCreditBalanceHandle struct {
+	storage.OngBalanceHandle
+	reads *readRecordStore
+}
+
+func (self *feeCreditBalanceHandle) SubBalance(cache *storage.CacheDB, addr common.Address, val *big.Int) error {
+	self.checkDependent(addr)
+	return self.OngBalanceHandle.SubBalance(cache, addr, val)
+}
+
+func (self *feeCreditBalanceHandle) AddBalance(cache *storage.CacheDB, addr common.Address, val *big.Int) error {
+	if addr != utils.GovernanceContractAddress {
+		return self.OngBalanceHandle.AddBalance(cache, addr, val)
+	}
+	self.reads.crediting = true
+	defer func() { self.reads.crediting = false }()
+	return self.OngBalanceHandle.AddBalance(cache, addr, val)
+}
+
+func (self *feeCreditBalanceHandle) SetBalance(cache *storage.CacheDB, addr common.Address, val *big.Int) error {
+	self.checkDependent(addr)
+	return self.OngBalanceHandle.SetBalance(cache, addr, val)
+}
+
+func (self *feeCreditBalanceHandle) GetBalance(cache *storage.CacheDB, addr common.Address) (*big.Int, error) {
+	self.checkDependent(addr)
+	return self.OngBalanceHandle.GetBalance(cache, addr)
+}
+
+func (self *feeCreditBalanceHandle) checkDependent(addr common.Address) {
+	if addr == utils.GovernanceContractAddress {
+		self.reads.feeDependent = true
+	}
+}
+
+//decodeFeeBalance decode the ong balance stored under feeBalanceKey
+func decodeFeeBalance(raw []byte) (uint64, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	value, err := states.GetValueFromRawStorageItem(raw)
+	if err != nil {
+		return 0, err
+	}
+	balance, eof := common.NewZeroCopySource(value).NextUint64()
+	if eof {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return balance, nil
+}
+
+//txExecution is the speculative execution result of a transaction against the states before the block
+type txExecution struct {
+	notify      *event.ExecuteNotify
+	crossStates []common.Uint256
+	writeSet    *overlaydb.MemDB
+	reads       *readRecordStore
+	feeCredit   bool
+	feeDelta    uint64
+}
+
+//settleFeeCredit decide whether the write of the governance ong balance is a pure fee credit, which is then excluded
+//from the conflict detection and merged as a delta. Otherwise the balance is handled as a normal read.
+func (self *txExecution) settleFeeCredit() {
+	reads := self.reads
+	if !reads.feeRead {
+		return
+	}
+	written, unknown := self.writeSet.Get(feeBalanceKey)
+	if !reads.feeDependent && !unknown {
+		base, err1 := decodeFeeBalance(reads.feeBase)
+		balance, err2 := decodeFeeBalance(written)
+		if err1 == nil && err2 == nil && balance >= base {
+			self.feeCredit = true
+			self.feeDelta = balance - base
+			return
+		}
+	}
+	reads.keys = append(reads.keys, feeBalanceKey)
+}
+
+//conflicted return whether the transaction read any key written by the transactions before it in the block, in which
+//case the speculative result may differ from the sequential execution
+func (self *txExecution) conflicted(writeSet *overlaydb.MemDB) bool {
+	for _, key := range self.reads.keys {
+		if _, unknown := writeSet.Get(key); !unknown {
+			return true
+		}
+	}
+	for _, prefix := range self.reads.prefixes {
+		iter := writeSet.NewIterator(util.BytesPrefix(prefix))
+		found := iter.First()
+		iter.Release()
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+//mergeTo apply the writes of the transaction to the block overlay, the fee credit is added to the current balance
+func (self *txExecution) mergeTo(overlay *overlaydb.OverlayDB) error {
+	self.writeSet.ForEach(func(key, val []byte) {
+		if self.feeCredit && bytes.Equal(key, feeBalanceKey) {
+			return
+		}
+		if len(val) == 0 {
+			overlay.Delete(key)
+		} else {
+			overlay.Put(key, val)
+		}
+	})
+	if !self.feeCredit || self.feeDelta == 0 {
+		return nil
+	}
+	raw, err := overlay.Get(feeBalanceKey)
+	if err != nil {
+		return err
+	}
+	balance, err := decodeFeeBalance(raw)
+	if err != nil {
+		return fmt.Errorf("decode governance ong balance error: %s", err)
+	}
+	if balance+self.feeDelta < balance {
+		return fmt.Errorf("governance ong balance overflow")
+	}
+	overlay.Put(feeBalanceKey, utils.GenUInt64StorageItem(balance+self.feeDelta).ToArray())
+	return nil
+}
+
+//executeTransactionsParallel speculatively execute all the transactions of the block in parallel against the states
+//before the block. The result of a transaction is nil if the execution failed, it must be re-executed in order.
+func (this *LedgerStoreImp) executeTransactionsParallel(gasTable map[string]uint64, block *types.Block) []*txExecution {
+	executions := make([]*txExecution, len(block.Transactions))
+	workers := runtime.NumCPU()
+	if workers > len(block.Transactions) {
+		workers = len(block.Transactions)
+	}
+	indexes := make(chan int, len(block.Transactions))
+	for i := range block.Transactions {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg := new(sync.WaitGroup)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				executions[i] = this.executeTransactionSpeculative(gasTable, block, uint32(i))
+			}
+		}()
+	}
+	wg.Wait()
+	return executions
+}
+
+func (this *LedgerStoreImp) executeTransactionSpeculative(gasTable map[string]uint64, block *types.Block,
+	txIndex uint32) (execution *txExecution) {
+	tx := block.Transactions[txIndex]
+	defer func() {
+		if r := recover(); r != nil {
+			log.Debugf("speculative execution of tx %s panic: %v", tx.Hash().ToHexString(), r)
+			execution = nil
+		}
+	}()
+	reads := &readRecordStore{PersistStore: this.stateStore.store}
+	overlay := overlaydb.NewOverlayDB(reads)
+	cache := storage.NewCacheDB(overlay)
+	ledger := &feeCreditLedger{LedgerStoreImp: this, reads: reads}
+	notify, crossStates, err := this.handleTransaction(ledger, overlay, cache, gasTable, block, tx, txIndex)
+	if err != nil {
+		log.Debugf("speculative execution of tx %s error: %s", tx.Hash().ToHexString(), err)
+		return nil
+	}
+	execution = &txExecution{notify: notify, crossStates: crossStates, writeSet: overlay.GetWriteSet(), reads: reads}
+	execution.settleFeeCredit()
+	return execution
+}
+
+//handleTransactions execute the transactions of the block on the block overlay, the result is the same as executing the
+//transactions one by one. With parallel execution enabled, the speculative results without conflict are merged in
+//order, and the others are re-executed on the block overlay. The number of re-executed transactions is returned.
+func (this *LedgerStoreImp) handleTransactions(overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block, parallel bool) ([]*event.ExecuteNotify, []common.Uint256, int, error) {
+	var executions []*txExecution
+	if parallel && len(block.Transactions) > 1 {
+		executions = this.executeTransactionsParallel(gasTable, block)
+	}
+	var notifies []*event.ExecuteNotify
+	var crossStates []common.Uint256
+	reExecuted := 0
+	cache := storage.NewCacheDB(overlay)
+	for i, tx := range block.Transactions {
+		var notify *event.ExecuteNotify
+		var crossStateHashes []common.Uint256
+		if executions != nil && executions[i] != nil && !executions[i].conflicted(overlay.GetWriteSet()) {
+			if err := executions[i].mergeTo(overlay); err != nil {
+				return nil, nil, 0, err
+			}
+			notify, crossStateHashes = executions[i].notify, executions[i].crossStates
+		} else {
+			if executions != nil {
+				reExecuted++
+			}
+			cache.Reset()
+			var err error
+			notify, crossStateHashes, err = this.handleTransaction(this, overlay, cache, gasTable, block, tx, uint32(i))
+			if err != nil {
+				return nil, nil, 0, err
+			}
+		}
+		if tx.GasPrice != 0 {
+			notify.GasStepUsed = notify.GasConsumed / tx.GasPrice
+		}
+		notify.TxIndex = uint32(i)
+		notifies = append(notifies, notify)
+		crossStates = append(crossStates, crossStateHashes...)
+	}
+	if executions != nil {
+		log.Debugf("parallel execution of block %d: %d txs, %d re-executed", block.Header.Height,
+			len(block.Transactions), reExecuted)
+	}
+	return notifies, crossStates, reExecuted, nil
+}
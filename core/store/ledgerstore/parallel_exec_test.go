@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	nutils "github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+//executeBlockInBothModes execute the block sequentially and in parallel, and check the results are identical
+func executeBlockInBothModes(t *testing.T, ledger *LedgerStoreImp, block *types.Block) store.ExecuteResult {
+	defer func() { config.DefConfig.Common.EnableParallelExec = false }()
+	config.DefConfig.Common.EnableParallelExec = false
+	sequential, err := ledger.executeBlock(block)
+	assert.Nil(t, err)
+	config.DefConfig.Common.EnableParallelExec = true
+	parallel, err := ledger.executeBlock(block)
+	assert.Nil(t, err)
+
+	assert.Equal(t, sequential.Hash, parallel.Hash)
+	assert.Equal(t, sequential.MerkleRoot, parallel.MerkleRoot)
+	assert.Equal(t, sequential.CrossStatesRoot, parallel.CrossStatesRoot)
+	assert.Equal(t, sequential.CrossStates, parallel.CrossStates)
+	assert.Equal(t, sequential.Notify, parallel.Notify)
+	assert.Equal(t, writeSetPairs(sequential.WriteSet), writeSetPairs(parallel.WriteSet))
+	return sequential
+}
+
+func writeSetPairs(writeSet *overlaydb.MemDB) [][]byte {
+	var pairs [][]byte
+	writeSet.ForEach(func(key, val []byte) {
+		pairs = append(pairs, append([]byte(nil), key...), append([]byte(nil), val...))
+	})
+	return pairs
+}
+
+func commitWriteSet(t *testing.T, ledger *LedgerStoreImp, result store.ExecuteResult) {
+	ledger.stateStore.NewBatch()
+	result.WriteSet.ForEach(func(key, val []byte) {
+		if len(val) == 0 {
+			ledger.stateStore.BatchDeleteRawKey(key)
+		} else {
+			ledger.stateStore.BatchPutRawKeyVal(key, val)
+		}
+	})
+	assert.Nil(t, ledger.stateStore.CommitTo())
+}
+
+func signedTransferTx(t *testing.T, from, to common.Address, amount uint64) *types.Transaction {
+	tx, err := transferTx(from, to, amount)
+	assert.Nil(t, err)
+	tx.SignedAddr = []common.Address{from}
+	return tx
+}
+
+func deployTx(t *testing.T, code []byte) *types.Transaction {
+	mutable, err := utils.NewDeployTransaction(code, "test", "1", "", "", "", payload.NEOVM_TYPE)
+	assert.Nil(t, err)
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+//newTestLedger create the ledger in dir with a genesis block, and return the address holding the ONT and ONG
+func newTestLedger(t *testing.T, dir string) (*LedgerStoreImp, common.Address) {
+	ledger, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	bookkeepers := []keypair.PublicKey{account.NewAccount("").PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+
+	holders, err := config.DefConfig.GetBookkeepers()
+	assert.Nil(t, err)
+	holder := types.AddressFromPubKey(holders[0])
+	if len(holders) > 1 {
+		holder, err = types.AddressFromMultiPubKeys(holders, (5*len(holders)+6)/7)
+		assert.Nil(t, err)
+	}
+	return ledger, holder
+}
+
+func TestParallelExecDeterminism(t *testing.T) {
+	ledger, holder := newTestLedger(t, "test/parallel")
+	defer ledger.Close()
+	genesisBlock, err := ledger.GetBlockByHeight(0)
+	assert.Nil(t, err)
+
+	accounts := make([]common.Address, 16)
+	for i := range accounts {
+		accounts[i] = common.AddressFromVmCode([]byte{byte(i)})
+	}
+	// every transfer from the same holder conflicts with the previous one
+	block := &types.Block{Header: &types.Header{Height: 1, Timestamp: genesisBlock.Header.Timestamp + 1}}
+	for i := 0; i < 8; i++ {
+		block.Transactions = append(block.Transactions, signedTransferTx(t, holder, accounts[i], 1000))
+	}
+	commitWriteSet(t, ledger, executeBlockInBothModes(t, ledger, block))
+
+	block = &types.Block{Header: &types.Header{Height: 2, Timestamp: genesisBlock.Header.Timestamp + 2}}
+	// independent transfers
+	for i := 0; i < 8; i++ {
+		block.Transactions = append(block.Transactions, signedTransferTx(t, accounts[i], accounts[8+i], 100))
+	}
+	// transfers depending on the ones before in the block
+	block.Transactions = append(block.Transactions, signedTransferTx(t, accounts[8], accounts[0], 50))
+	block.Transactions = append(block.Transactions, signedTransferTx(t, accounts[0], accounts[15], 950))
+	// failed transfer with insufficient balance
+	block.Transactions = append(block.Transactions, signedTransferTx(t, accounts[1], accounts[2], 10000))
+	// the second deploy of the same contract does nothing
+	block.Transactions = append(block.Transactions, deployTx(t, []byte{1, 2, 3}), deployTx(t, []byte{4, 5, 6}),
+		deployTx(t, []byte{1, 2, 3}))
+	result := executeBlockInBothModes(t, ledger, block)
+	assert.Equal(t, len(block.Transactions), len(result.Notify))
+	for i, notify := range result.Notify {
+		if i == 10 {
+			assert.Equal(t, event.CONTRACT_STATE_FAIL, notify.State)
+		} else {
+			assert.Equal(t, event.CONTRACT_STATE_SUCCESS, notify.State)
+		}
+	}
+}
+
+func TestParallelExecFeeCredit(t *testing.T) {
+	ledger, _ := newTestLedger(t, "test/parallelfee")
+	defer ledger.Close()
+	genesisBlock, err := ledger.GetBlockByHeight(0)
+	assert.Nil(t, err)
+
+	accounts := make([]common.Address, 32)
+	ledger.stateStore.NewBatch()
+	for i := range accounts {
+		accounts[i] = common.AddressFromVmCode([]byte{byte(i)})
+		key := append([]byte{byte(scom.ST_STORAGE)}, ont.GenBalanceKey(nutils.OngContractAddress, accounts[i])...)
+		ledger.stateStore.BatchPutRawKeyVal(key, nutils.GenUInt64StorageItem(1000000000).ToArray())
+	}
+	assert.Nil(t, ledger.stateStore.CommitTo())
+
+	// independent ong transfers, all paying the gas fee to the governance contract
+	block := &types.Block{Header: &types.Header{Height: 1, Timestamp: genesisBlock.Header.Timestamp + 1}}
+	for i := 0; i < 16; i++ {
+		var sts []ont.State
+		sts = append(sts, ont.State{From: accounts[i], To: accounts[16+i], Value: 100})
+		tx, err := invokeSmartContractTx(500, 30000, 0, nutils.OngContractAddress, "transfer", []interface{}{sts})
+		assert.Nil(t, err)
+		tx.Payer = accounts[i]
+		tx.SignedAddr = []common.Address{accounts[i]}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	notifies, _, reExecuted, err := ledger.handleTransactions(ledger.stateStore.NewOverlayDB(), copyGasTable(), block, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, reExecuted)
+	for _, notify := range notifies {
+		assert.Equal(t, event.CONTRACT_STATE_SUCCESS, notify.State)
+		assert.NotEqual(t, uint64(0), notify.GasConsumed)
+	}
+	executeBlockInBothModes(t, ledger, block)
+}
+
+func TestTxExecutionConflicted(t *testing.T) {
+	writeSet := overlaydb.NewMemDB(0, 0)
+	writeSet.Put([]byte{1, 2, 3}, []byte{1})
+	writeSet.Delete([]byte{2, 1})
+
+	execution := &txExecution{reads: &readRecordStore{keys: [][]byte{{1, 2}, {3}}, prefixes: [][]byte{{1, 3}}}}
+	assert.False(t, execution.conflicted(writeSet))
+	execution.reads.keys = append(execution.reads.keys, []byte{2, 1})
+	assert.True(t, execution.conflicted(writeSet))
+	execution.reads.keys = nil
+	execution.reads.prefixes = append(execution.reads.prefixes, []byte{1, 2})
+	assert.True(t, execution.conflicted(writeSet))
+}
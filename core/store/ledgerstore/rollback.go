@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+)
+
+//RollbackLedgerStore rolls the ledger store in dataDir back to the block at height with the undo logs of the latest
+//blocks, the ledger store must not be opened. Every store is rolled back block by block from its own current block,
+//so an interrupted rollback can be resumed by running it again. progress is called after each block is undone.
+func RollbackLedgerStore(dataDir string, stateHashHeight, height uint32, progress func(height uint32)) error {
+	if err := checkDBEngine(dataDir); err != nil {
+		return err
+	}
+	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), false)
+	if err != nil {
+		return fmt.Errorf("NewBlockStore error %s", err)
+	}
+	defer blockStore.Close()
+	crossChainStore, err := NewCrossChainStore(dataDir)
+	if err != nil {
+		return fmt.Errorf("NewCrossChainStore error %s", err)
+	}
+	defer crossChainStore.Close()
+	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
+	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
+	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewStateStore error %s", err)
+	}
+	defer stateStore.Close()
+	eventStore, err := NewEventStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirEvent))
+	if err != nil {
+		return fmt.Errorf("NewEventStore error %s", err)
+	}
+	defer eventStore.Close()
+
+	_, blockHeight, err := blockStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("blockStore.GetCurrentBlock error %s", err)
+	}
+	_, stateHeight, err := stateStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	_, eventHeight, err := eventStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("eventStore.GetCurrentBlock error %s", err)
+	}
+	if height > blockHeight {
+		return fmt.Errorf("target height %d is higher than current block height %d", height, blockHeight)
+	}
+	pruned, err := blockStore.GetBlockPrunedHeight()
+	if err != nil {
+		return fmt.Errorf("GetBlockPrunedHeight error %s", err)
+	}
+	if pruned > 0 && height <= pruned {
+		return fmt.Errorf("target height %d is not higher than pruned block height %d", height, pruned)
+	}
+	// make sure the whole rollback can be done before any block is undone
+	for h := height + 1; h <= stateHeight; h++ {
+		has, err := stateStore.HasStateUndo(h)
+		if err != nil {
+			return fmt.Errorf("HasStateUndo height:%d error %s", h, err)
+		}
+		if !has {
+			return fmt.Errorf("undo log of height %d not found, at most the latest %d blocks saved with state undo "+
+				"enabled can be rolled back", h, MAX_ROLLBACK_BLOCKS)
+		}
+	}
+
+	top := blockHeight
+	if stateHeight > top {
+		top = stateHeight
+	}
+	if eventHeight > top {
+		top = eventHeight
+	}
+	// the state store is rolled back first, a ledger left with state behind blocks is still valid
+	for h := top; h > height; h-- {
+		if h <= stateHeight {
+			if err := stateStore.UndoBlock(h); err != nil {
+				return fmt.Errorf("undo state of height %d error %s", h, err)
+			}
+		}
+		if h <= eventHeight {
+			if err := rollbackEventStore(eventStore, blockStore, h); err != nil {
+				return fmt.Errorf("rollback event store of height %d error %s", h, err)
+			}
+		}
+		if h <= blockHeight {
+			if err := crossChainStore.DeleteCrossChainMsg(h); err != nil {
+				return fmt.Errorf("DeleteCrossChainMsg height:%d error %s", h, err)
+			}
+			if err := blockStore.RollbackBlock(h); err != nil {
+				return fmt.Errorf("rollback block of height %d error %s", h, err)
+			}
+		}
+		if progress != nil {
+			progress(h)
+		}
+	}
+	if err := eventStore.RollbackAddressIndex(height); err != nil {
+		return fmt.Errorf("RollbackAddressIndex error %s", err)
+	}
+	log.Infof("ledger store rolled back to height %d", height)
+
+	return checkRollbackStores(blockStore, stateStore, eventStore, height)
+}
+
+func rollbackEventStore(eventStore *EventStore, blockStore *BlockStore, height uint32) error {
+	hash, err := blockStore.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash error %s", err)
+	}
+	_, txHashes, err := blockStore.loadHeaderWithTx(hash)
+	if err != nil {
+		return fmt.Errorf("loadHeaderWithTx error %s", err)
+	}
+	prevHash, err := blockStore.GetBlockHash(height - 1)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash error %s", err)
+	}
+	eventStore.NewBatch()
+	eventStore.PruneBlock(height, txHashes)
+	eventStore.SaveCurrentBlock(height-1, prevHash)
+	return eventStore.CommitTo()
+}
+
+// checkRollbackStores checks all stores are at the block of height, and the merkle trees are consistent with it
+func checkRollbackStores(blockStore *BlockStore, stateStore *StateStore, eventStore *EventStore, height uint32) error {
+	hash, err := blockStore.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash height:%d error %s", height, err)
+	}
+	stores := []struct {
+		name       string
+		getCurrent func() (common.Uint256, uint32, error)
+	}{
+		{"block", blockStore.GetCurrentBlock},
+		{"state", stateStore.GetCurrentBlock},
+		{"event", eventStore.GetCurrentBlock},
+	}
+	for _, store := range stores {
+		currHash, currHeight, err := store.getCurrent()
+		if err != nil {
+			return fmt.Errorf("%s store GetCurrentBlock error %s", store.name, err)
+		}
+		if currHeight != height || currHash != hash {
+			return fmt.Errorf("current block %d %s of %s store mismatch, expected %d %s", currHeight,
+				currHash.ToHexString(), store.name, height, hash.ToHexString())
+		}
+	}
+	header, err := blockStore.GetHeader(hash)
+	if err != nil {
+		return fmt.Errorf("GetHeader height:%d error %s", height, err)
+	}
+	blockRoot := header.BlockRoot
+	if height == 0 {
+		blockRoot = header.TransactionsRoot
+	}
+	if err := stateStore.CheckMerkleTrees(blockRoot); err != nil {
+		return err
+	}
+	if _, err := blockStore.GetBlockHash(height + 1); err != scom.ErrNotFound {
+		return fmt.Errorf("block hash of height %d not removed", height+1)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func dumpStateStore(t *testing.T, ledger *LedgerStoreImp) map[string]string {
+	kvs := make(map[string]string)
+	iter := ledger.stateStore.store.NewIterator(nil)
+	for iter.Next() {
+		kvs[string(iter.Key())] = string(iter.Value())
+	}
+	iter.Release()
+	assert.Nil(t, iter.Error())
+	return kvs
+}
+
+func newTransferBlock(t *testing.T, ledger *LedgerStoreImp, from common.Address, to []common.Address) *types.Block {
+	height := ledger.GetCurrentBlockHeight() + 1
+	block := &types.Block{Header: &types.Header{
+		Height:        height,
+		PrevBlockHash: ledger.GetCurrentBlockHash(),
+		Timestamp:     uint32(1600000000 + height),
+	}}
+	for _, addr := range to {
+		block.Transactions = append(block.Transactions, signedTransferTx(t, from, addr, 100))
+	}
+	block.RebuildMerkleRoot()
+	block.Header.BlockRoot = ledger.GetBlockRootWithNewTxRoots(height, []common.Uint256{block.Header.TransactionsRoot})
+	return block
+}
+
+func TestRollbackLedgerStore(t *testing.T) {
+	config.DefConfig.Common.EnableAddressIndex = true
+	defer func() { config.DefConfig.Common.EnableAddressIndex = false }()
+	config.DefConfig.Common.EnableStateUndo = true
+	defer func() { config.DefConfig.Common.EnableStateUndo = false }()
+	dataDir := "test/rollback"
+	ledger, holder := newTestLedger(t, dataDir)
+
+	var blocks []*types.Block
+	var states []map[string]string
+	for i := 0; i < 3; i++ {
+		block := newTransferBlock(t, ledger, holder, []common.Address{
+			common.AddressFromVmCode([]byte{byte(2 * i)}), common.AddressFromVmCode([]byte{byte(2*i + 1)})})
+		result, err := ledger.executeBlock(block)
+		assert.Nil(t, err)
+		assert.Nil(t, ledger.submitBlock(block, nil, result))
+		blocks = append(blocks, block)
+		states = append(states, dumpStateStore(t, ledger))
+	}
+	assert.Nil(t, ledger.Close())
+
+	// can not roll forward
+	assert.NotNil(t, RollbackLedgerStore(dataDir, 0, 4, nil))
+	var undone []uint32
+	assert.Nil(t, RollbackLedgerStore(dataDir, 0, 1, func(height uint32) { undone = append(undone, height) }))
+	assert.Equal(t, []uint32{3, 2}, undone)
+
+	ledger, err := NewLedgerStore(dataDir, 0)
+	assert.Nil(t, err)
+	defer ledger.Close()
+	// the test blocks have no consensus payload to load the vbft peers from
+	assert.Nil(t, ledger.init())
+	assert.Equal(t, uint32(1), ledger.GetCurrentBlockHeight())
+	assert.Equal(t, blocks[0].Hash(), ledger.GetCurrentBlockHash())
+	assert.Equal(t, states[0], dumpStateStore(t, ledger))
+	block, err := ledger.GetBlockByHeight(2)
+	assert.Nil(t, err)
+	assert.Nil(t, block)
+	_, _, err = ledger.GetTransaction(blocks[1].Transactions[0].Hash())
+	assert.NotNil(t, err)
+	txs, err := ledger.GetAddressTransactions(common.AddressFromVmCode([]byte{0}), 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(txs))
+	txs, err = ledger.GetAddressTransactions(common.AddressFromVmCode([]byte{2}), 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(txs))
+
+	// the undone blocks can be saved again to the same state
+	for i := 1; i < 3; i++ {
+		result, err := ledger.executeBlock(blocks[i])
+		assert.Nil(t, err)
+		assert.Nil(t, ledger.submitBlock(blocks[i], nil, result))
+		assert.Equal(t, states[i], dumpStateStore(t, ledger))
+	}
+}
@@ -0,0 +1,581 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ontio/ontology/common"
+	sysconfig "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	vconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+)
+
+const (
+	SNAPSHOT_BLOCK_INTERVAL = uint32(10000) //Block interval of taking the state snapshot served to fast sync
+	SNAPSHOT_KEEP_COUNT     = 2             //Number of the latest state snapshots kept to serve
+	snapshotClearBatchSize  = 10000         //Number of keys deleted in one batch when clearing the states
+	snapshotExportChunkSize = 1024 * 1024   //Size of the states passed to the writer at once when exporting
+)
+
+//stateSnapshot is a read only view of the states at a block height, served to the nodes doing fast sync
+type stateSnapshot struct {
+	manifest *scom.SnapshotManifest
+	blocks   []*types.Block // the snapshot block, followed by the vbft config block it refers to
+	db       scom.StoreSnapshot
+}
+
+//snapshotImport record the progress of importing the states of a snapshot
+type snapshotImport struct {
+	manifest *scom.SnapshotManifest
+	blocks   []*types.Block
+	txRoots  []common.Uint256 // transaction roots from height 1 to the snapshot height, nil if headers are synced
+	hasher   *scom.StateHasher
+}
+
+//getConfigBlockHeight return the height of the vbft config block the header refers to
+func getConfigBlockHeight(header *types.Header) (uint32, error) {
+	info, err := vconfig.VbftBlock(header)
+	if err != nil {
+		return 0, err
+	}
+	if info.NewChainConfig != nil {
+		return header.Height, nil
+	}
+	return info.LastConfigBlockNum, nil
+}
+
+func isVbft() bool {
+	return strings.ToLower(sysconfig.DefConfig.Genesis.ConsensusType) == "vbft"
+}
+
+//trySnapshotState take a state snapshot after the block is committed if snapshot serving is enabled,
+//the total state hash of the snapshot is calculated in background
+func (this *LedgerStoreImp) trySnapshotState(block *types.Block) {
+	height := block.Header.Height
+	if !sysconfig.DefConfig.Common.EnableSnapshotServe || height == 0 || height%SNAPSHOT_BLOCK_INTERVAL != 0 ||
+		height < this.stateHashCheckHeight {
+		return
+	}
+	snapshot, err := this.newStateSnapshot(block)
+	if err != nil {
+		log.Warnf("take state snapshot at height %d error: %s", height, err)
+		return
+	}
+	go this.finishStateSnapshot(snapshot)
+}
+
+func (this *LedgerStoreImp) newStateSnapshot(block *types.Block) (*stateSnapshot, error) {
+	height := block.Header.Height
+	writeSetHash, err := this.stateStore.getWriteSetHash(height)
+	if err != nil {
+		return nil, fmt.Errorf("get write set hash error: %s", err)
+	}
+	crossStates, err := this.stateStore.GetCrossStates(height)
+	if err != nil && err != scom.ErrNotFound {
+		return nil, fmt.Errorf("get cross states error: %s", err)
+	}
+	bookkeeper, err := this.stateStore.GetBookkeeperState()
+	if err != nil {
+		return nil, fmt.Errorf("get bookkeeper state error: %s", err)
+	}
+	blocks := []*types.Block{block}
+	if isVbft() {
+		cfgHeight, err := getConfigBlockHeight(block.Header)
+		if err != nil {
+			return nil, err
+		}
+		if cfgHeight != height {
+			cfgBlock, err := this.GetBlockByHeight(cfgHeight)
+			if err != nil {
+				return nil, fmt.Errorf("get config block %d error: %s", cfgHeight, err)
+			}
+			blocks = append(blocks, cfgBlock)
+		}
+	}
+	db, err := this.stateStore.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	tree := this.stateStore.deltaMerkleTree
+	manifest := &scom.SnapshotManifest{
+		Height:          height,
+		BlockHash:       block.Hash(),
+		WriteSetHash:    writeSetHash,
+		StateRoot:       tree.Root(),
+		StateTreeSize:   tree.TreeSize(),
+		StateTreeHashes: append([]common.Uint256(nil), tree.Hashes()...),
+		CrossStates:     crossStates,
+		Bookkeeper:      bookkeeper,
+	}
+	return &stateSnapshot{manifest: manifest, blocks: blocks, db: db}, nil
+}
+
+func (this *LedgerStoreImp) finishStateSnapshot(snapshot *stateSnapshot) {
+	err := hashStateSnapshot(snapshot)
+	if err != nil {
+		log.Warnf("calculate state snapshot hash at height %d error: %s", snapshot.manifest.Height, err)
+		snapshot.db.Release()
+		return
+	}
+
+	this.snapshotLock.Lock()
+	defer this.snapshotLock.Unlock()
+	if this.snapshotClosed {
+		snapshot.db.Release()
+		return
+	}
+	this.snapshots = append(this.snapshots, snapshot)
+	for len(this.snapshots) > SNAPSHOT_KEEP_COUNT {
+		this.snapshots[0].db.Release()
+		this.snapshots = this.snapshots[1:]
+	}
+	log.Infof("state snapshot at height %d is ready to serve, manifest: %s, total state hash: %s, keys: %d",
+		snapshot.manifest.Height, snapshot.manifest.Hash().ToHexString(),
+		snapshot.manifest.TotalStateHash.ToHexString(), snapshot.manifest.KeyCount)
+}
+
+//hashStateSnapshot fill the total state hash, key count and chunk hashes of the snapshot manifest
+func hashStateSnapshot(snapshot *stateSnapshot) error {
+	hasher := scom.NewStateHasher()
+	var start []byte
+	for {
+		kvs, next, err := readSnapshotChunk(snapshot.db, start, scom.SNAPSHOT_CHUNK_SIZE)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			if err := hasher.Write(kv.Key, kv.Value); err != nil {
+				return err
+			}
+		}
+		snapshot.manifest.ChunkHashes = append(snapshot.manifest.ChunkHashes, scom.SnapshotChunkHash(kvs, next))
+		if len(next) == 0 {
+			break
+		}
+		start = next
+	}
+	snapshot.manifest.TotalStateHash = hasher.Sum()
+	snapshot.manifest.KeyCount = hasher.Count()
+	return nil
+}
+
+func (this *LedgerStoreImp) releaseStateSnapshots() {
+	this.snapshotLock.Lock()
+	defer this.snapshotLock.Unlock()
+	for _, snapshot := range this.snapshots {
+		snapshot.db.Release()
+	}
+	this.snapshots = nil
+	this.snapshotClosed = true
+}
+
+//GetSnapshotManifest return the manifest and blocks of the latest state snapshot served
+func (this *LedgerStoreImp) GetSnapshotManifest() (*scom.SnapshotManifest, []*types.Block, error) {
+	this.snapshotLock.RLock()
+	defer this.snapshotLock.RUnlock()
+	if len(this.snapshots) == 0 {
+		return nil, nil, scom.ErrNotFound
+	}
+	snapshot := this.snapshots[len(this.snapshots)-1]
+	return snapshot.manifest, snapshot.blocks, nil
+}
+
+//GetSnapshotChunk return the chunk of states of snapshot at height in key order starting from the key start, until
+//the size reaches SNAPSHOT_CHUNK_SIZE. The next key to request is returned, nil if all the states are returned.
+func (this *LedgerStoreImp) GetSnapshotChunk(height uint32, start []byte) ([]*scom.SnapshotKV, []byte, error) {
+	this.snapshotLock.RLock()
+	defer this.snapshotLock.RUnlock()
+	var snapshot *stateSnapshot
+	for _, s := range this.snapshots {
+		if s.manifest.Height == height {
+			snapshot = s
+		}
+	}
+	if snapshot == nil {
+		return nil, nil, scom.ErrNotFound
+	}
+	return readSnapshotChunk(snapshot.db, start, scom.SNAPSHOT_CHUNK_SIZE)
+}
+
+func readSnapshotChunk(db scom.StoreSnapshot, start []byte, maxSize int) ([]*scom.SnapshotKV, []byte, error) {
+	var kvs []*scom.SnapshotKV
+	size := 0
+	for _, prefix := range scom.StateHashPrefixes {
+		begin, end := []byte{byte(prefix)}, []byte{byte(prefix) + 1}
+		if bytes.Compare(start, end) >= 0 {
+			continue
+		}
+		if bytes.Compare(start, begin) > 0 {
+			begin = start
+		}
+		iter := db.NewRangeIterator(begin, end)
+		for iter.Next() {
+			if size >= maxSize {
+				next := append([]byte(nil), iter.Key()...)
+				iter.Release()
+				return kvs, next, nil
+			}
+			kv := &scom.SnapshotKV{Key: append([]byte(nil), iter.Key()...), Value: append([]byte(nil), iter.Value()...)}
+			kvs = append(kvs, kv)
+			size += len(kv.Key) + len(kv.Value)
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return kvs, nil, nil
+}
+
+//BeginSnapshotImport prepare the empty ledger to import the states of the snapshot. The headers up to the snapshot
+//height must have been synced, and the blocks are the snapshot block followed by the vbft config block it refers to.
+func (this *LedgerStoreImp) BeginSnapshotImport(manifest *scom.SnapshotManifest, blocks []*types.Block) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	return this.beginSnapshotImport(manifest, blocks, nil)
+}
+
+//BeginSnapshotImportWithHeaders prepare the empty ledger to import the states of a snapshot read from a file, whose
+//headers are not synced. The blockHashes and txRoots are the block hashes and transaction roots from the genesis
+//block to the snapshot block, the txRoots are checked against the block root of the snapshot block.
+func (this *LedgerStoreImp) BeginSnapshotImportWithHeaders(manifest *scom.SnapshotManifest, blocks []*types.Block,
+	blockHashes []common.Uint256, txRoots []common.Uint256) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	height := manifest.Height
+	if height == 0 || uint32(len(blockHashes)) != height+1 || len(txRoots) != len(blockHashes) {
+		return fmt.Errorf("unexpected headers count %d of snapshot at height %d", len(blockHashes), height)
+	}
+	if this.GetCurrentHeaderHeight() != 0 {
+		return fmt.Errorf("snapshot can only be imported to an empty ledger")
+	}
+	if blockHashes[0] != this.GetBlockHash(0) {
+		return fmt.Errorf("genesis block hash %s mismatch with local genesis block %s",
+			blockHashes[0].ToHexString(), this.GetBlockHash(0).ToHexString())
+	}
+	this.lock.Lock()
+	for h := uint32(1); h <= height; h++ {
+		this.headerIndex[h] = blockHashes[h]
+	}
+	this.lock.Unlock()
+	err := this.beginSnapshotImport(manifest, blocks, txRoots[1:])
+	if err != nil {
+		this.lock.Lock()
+		for h := uint32(1); h <= height; h++ {
+			delete(this.headerIndex, h)
+		}
+		this.lock.Unlock()
+	}
+	return err
+}
+
+func (this *LedgerStoreImp) beginSnapshotImport(manifest *scom.SnapshotManifest, blocks []*types.Block,
+	txRoots []common.Uint256) error {
+	if this.closing {
+		return fmt.Errorf("ledger is closing")
+	}
+	if sysconfig.DefConfig.Common.EnableArchiveState {
+		return fmt.Errorf("snapshot import is not supported when archive state is enabled")
+	}
+	// the blocks before the snapshot are not in the ledger, the address index would silently miss them
+	if sysconfig.DefConfig.Common.EnableAddressIndex {
+		return fmt.Errorf("snapshot import is not supported when address index is enabled")
+	}
+	if this.GetCurrentBlockHeight() != 0 {
+		return fmt.Errorf("snapshot can only be imported to an empty ledger")
+	}
+	if manifest.Height == 0 {
+		return fmt.Errorf("invalid snapshot height 0")
+	}
+	if err := manifest.Verify(this.stateHashCheckHeight); err != nil {
+		return err
+	}
+	if this.GetBlockHash(manifest.Height) != manifest.BlockHash {
+		return fmt.Errorf("snapshot block hash %s mismatch with header at height %d",
+			manifest.BlockHash.ToHexString(), manifest.Height)
+	}
+	if err := this.verifySnapshotBlocks(manifest, blocks); err != nil {
+		return err
+	}
+	if err := this.clearStates(); err != nil {
+		return fmt.Errorf("clear states error: %s", err)
+	}
+	this.snapshotImport = &snapshotImport{manifest: manifest, blocks: blocks, txRoots: txRoots, hasher: scom.NewStateHasher()}
+	log.Infof("begin to import state snapshot at height %d", manifest.Height)
+	return nil
+}
+
+func (this *LedgerStoreImp) verifySnapshotBlocks(manifest *scom.SnapshotManifest, blocks []*types.Block) error {
+	if len(blocks) == 0 || blocks[0].Hash() != manifest.BlockHash {
+		return fmt.Errorf("missing snapshot block")
+	}
+	expected := 1
+	if isVbft() {
+		cfgHeight, err := getConfigBlockHeight(blocks[0].Header)
+		if err != nil {
+			return err
+		}
+		if cfgHeight != manifest.Height {
+			expected = 2
+			if len(blocks) < 2 || blocks[1].Header.Height != cfgHeight || blocks[1].Hash() != this.GetBlockHash(cfgHeight) {
+				return fmt.Errorf("missing config block %d of snapshot", cfgHeight)
+			}
+		}
+	}
+	if len(blocks) != expected {
+		return fmt.Errorf("unexpected snapshot blocks count %d", len(blocks))
+	}
+	return nil
+}
+
+//clearStates remove all the states hashed into the total state hash
+func (this *LedgerStoreImp) clearStates() error {
+	for _, prefix := range scom.StateHashPrefixes {
+		for {
+			this.stateStore.NewBatch()
+			count := 0
+			iter := this.stateStore.store.NewIterator([]byte{byte(prefix)})
+			for count < snapshotClearBatchSize && iter.Next() {
+				this.stateStore.BatchDeleteRawKey(iter.Key())
+				count++
+			}
+			iter.Release()
+			if err := iter.Error(); err != nil {
+				return err
+			}
+			if err := this.stateStore.CommitTo(); err != nil {
+				return err
+			}
+			if count < snapshotClearBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+//ImportSnapshotChunk write the states of a chunk to the ledger, the chunks must be imported in key order
+func (this *LedgerStoreImp) ImportSnapshotChunk(kvs []*scom.SnapshotKV) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	imp := this.snapshotImport
+	if imp == nil {
+		return fmt.Errorf("no snapshot import in progress")
+	}
+	if err := imp.hasher.CheckKeys(kvs); err != nil {
+		return err
+	}
+	this.stateStore.NewBatch()
+	for _, kv := range kvs {
+		_ = imp.hasher.Write(kv.Key, kv.Value)
+		this.stateStore.BatchPutRawKeyVal(kv.Key, kv.Value)
+	}
+	return this.stateStore.CommitTo()
+}
+
+//FinishSnapshotImport verify the imported states against the total state hash of the snapshot, then save the
+//snapshot blocks and make the snapshot height the current block height. The blocks before are treated as pruned.
+func (this *LedgerStoreImp) FinishSnapshotImport() error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	imp := this.snapshotImport
+	if imp == nil {
+		return fmt.Errorf("no snapshot import in progress")
+	}
+	this.snapshotImport = nil
+	manifest := imp.manifest
+	height := manifest.Height
+	if sum := imp.hasher.Sum(); sum != manifest.TotalStateHash || imp.hasher.Count() != manifest.KeyCount {
+		return fmt.Errorf("total state hash mismatch, expected: %s, got: %s", manifest.TotalStateHash.ToHexString(),
+			sum.ToHexString())
+	}
+
+	txRoots := imp.txRoots
+	if txRoots == nil {
+		txRoots = make([]common.Uint256, 0, height)
+		for h := uint32(1); h <= height; h++ {
+			header, err := this.GetHeaderByHeight(h)
+			if err != nil {
+				return fmt.Errorf("get header %d error: %s", h, err)
+			}
+			txRoots = append(txRoots, header.TransactionsRoot)
+		}
+	}
+	snapshotHeader := imp.blocks[0].Header
+	if root := this.stateStore.GetBlockRootWithNewTxRoots(txRoots); root != snapshotHeader.BlockRoot {
+		return fmt.Errorf("block root mismatch, expected: %s, got: %s", snapshotHeader.BlockRoot.ToHexString(),
+			root.ToHexString())
+	}
+
+	// state store is committed first, so an interrupted import is detected by the state height ahead of block height
+	err := this.stateStore.SaveBookkeeperState(manifest.Bookkeeper)
+	if err != nil {
+		return fmt.Errorf("SaveBookkeeperState error %s", err)
+	}
+	this.stateStore.NewBatch()
+	this.stateStore.AddBlockMerkleTreeRoots(txRoots)
+	this.stateStore.SetStateMerkleTree(height, manifest.WriteSetHash, manifest.StateTreeSize, manifest.StateTreeHashes)
+	err = this.stateStore.SaveCrossStates(height, manifest.CrossStates)
+	if err != nil {
+		return fmt.Errorf("SaveCrossStates error %s", err)
+	}
+	err = this.stateStore.SaveCurrentBlock(height, manifest.BlockHash)
+	if err != nil {
+		return fmt.Errorf("SaveCurrentBlock error %s", err)
+	}
+	err = this.stateStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("stateStore.CommitTo error %s", err)
+	}
+
+	this.eventStore.NewBatch()
+	this.eventStore.SaveCurrentBlock(height, manifest.BlockHash)
+	err = this.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo error %s", err)
+	}
+
+	storeCount, err := this.saveSnapshotHeaderIndex(height)
+	if err != nil {
+		return err
+	}
+	this.blockStore.NewBatch()
+	for _, block := range imp.blocks {
+		err = this.blockStore.SaveBlock(block)
+		if err != nil {
+			return fmt.Errorf("SaveBlock height %d error %s", block.Header.Height, err)
+		}
+	}
+	for h := storeCount; h <= height; h++ {
+		this.blockStore.SaveBlockHash(h, this.GetBlockHash(h))
+	}
+	this.blockStore.SaveBlockPrunedHeight(height - 1)
+	err = this.blockStore.SaveCurrentBlock(height, manifest.BlockHash)
+	if err != nil {
+		return fmt.Errorf("SaveCurrentBlock error %s", err)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+
+	this.lock.Lock()
+	this.storedIndexCount = storeCount
+	for h := uint32(1); h <= height; h++ {
+		delete(this.headerCache, this.headerIndex[h])
+	}
+	this.lock.Unlock()
+	this.setCurrentBlock(height, manifest.BlockHash)
+	log.Infof("state snapshot at height %d imported", height)
+	return nil
+}
+
+//saveSnapshotHeaderIndex save the header index list up to height, return the count of header index saved
+func (this *LedgerStoreImp) saveSnapshotHeaderIndex(height uint32) (uint32, error) {
+	this.lock.RLock()
+	storeCount := this.storedIndexCount
+	this.lock.RUnlock()
+	for batch := 0; height-storeCount >= HEADER_INDEX_BATCH_SIZE; batch++ {
+		if batch%100 == 0 {
+			this.blockStore.NewBatch()
+		}
+		headerList := make([]common.Uint256, HEADER_INDEX_BATCH_SIZE)
+		for i := uint32(0); i < HEADER_INDEX_BATCH_SIZE; i++ {
+			headerList[i] = this.GetBlockHash(storeCount + i)
+		}
+		this.blockStore.SaveHeaderIndexList(storeCount, headerList)
+		storeCount += HEADER_INDEX_BATCH_SIZE
+		if batch%100 == 99 || height-storeCount < HEADER_INDEX_BATCH_SIZE {
+			if err := this.blockStore.CommitTo(); err != nil {
+				return 0, fmt.Errorf("blockStore.CommitTo error %s", err)
+			}
+		}
+	}
+	return storeCount, nil
+}
+
+//ExportSnapshot write the state snapshot at the current block height to the writer, in the order of the manifest,
+//the block hashes and transaction roots from the genesis block, and the states in key order
+func (this *LedgerStoreImp) ExportSnapshot(writer store.SnapshotWriter) error {
+	this.getSavingBlockLock()
+	height := this.GetCurrentBlockHeight()
+	if height == 0 || height < this.stateHashCheckHeight {
+		this.releaseSavingBlockLock()
+		return fmt.Errorf("state merkle tree is not available at height %d", height)
+	}
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		this.releaseSavingBlockLock()
+		return fmt.Errorf("get block %d error: %s", height, err)
+	}
+	snapshot, err := this.newStateSnapshot(block)
+	this.releaseSavingBlockLock()
+	if err != nil {
+		return err
+	}
+	defer snapshot.db.Release()
+	if err := hashStateSnapshot(snapshot); err != nil {
+		return fmt.Errorf("calculate total state hash error: %s", err)
+	}
+	if err := writer.WriteManifest(snapshot.manifest, snapshot.blocks); err != nil {
+		return err
+	}
+
+	for start := uint32(0); start <= height; start += HEADER_INDEX_BATCH_SIZE {
+		end := start + HEADER_INDEX_BATCH_SIZE - 1
+		if end > height {
+			end = height
+		}
+		blockHashes := make([]common.Uint256, 0, end-start+1)
+		txRoots := make([]common.Uint256, 0, end-start+1)
+		for h := start; h <= end; h++ {
+			blockHash := this.GetBlockHash(h)
+			header, err := this.blockStore.GetHeader(blockHash)
+			if err != nil {
+				return fmt.Errorf("get header %d error: %s", h, err)
+			}
+			blockHashes = append(blockHashes, blockHash)
+			txRoots = append(txRoots, header.TransactionsRoot)
+		}
+		if err := writer.WriteHeaders(blockHashes, txRoots); err != nil {
+			return err
+		}
+	}
+
+	var start []byte
+	for {
+		kvs, next, err := readSnapshotChunk(snapshot.db, start, snapshotExportChunkSize)
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteStates(kvs); err != nil {
+			return err
+		}
+		if next == nil {
+			return nil
+		}
+		start = next
+	}
+}
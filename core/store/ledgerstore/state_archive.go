@@ -0,0 +1,254 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+)
+
+// Archive state keeps, for every key written by a block, the value the key had before the block.
+// The value of a key at height h is the pre-image recorded by the first block after h which wrote
+// the key, or the current value if no block after h touched it.
+
+var errArchiveReadOnly = errors.New("archive state is read only")
+
+//SaveArchiveState records the current value of the keys in write set before they are overwritten by block at height.
+//Must be called in the same batch as the write set is committed.
+func (self *StateStore) SaveArchiveState(height uint32, writeSet *overlaydb.MemDB) error {
+	start, last, err := self.GetArchiveHeight()
+	if err != nil && err != scom.ErrNotFound {
+		return err
+	}
+	// archive restarts from this block if it is new or some blocks were saved without archive
+	if err == scom.ErrNotFound || last+1 != height {
+		start = height
+	}
+
+	var getErr error
+	writeSet.ForEach(func(key, _ []byte) {
+		if getErr != nil {
+			return
+		}
+		prev, err := self.store.Get(key)
+		if err != nil && err != scom.ErrNotFound {
+			getErr = err
+			return
+		}
+		self.store.BatchPut(genArchiveKey(key, height), prev)
+	})
+	if getErr != nil {
+		return getErr
+	}
+
+	value := make([]byte, 8)
+	binary.LittleEndian.PutUint32(value, start)
+	binary.LittleEndian.PutUint32(value[4:], height)
+	self.store.BatchPut(genArchiveHeightKey(), value)
+	return nil
+}
+
+//GetArchiveHeight return the first and last block height whose state changes are archived
+func (self *StateStore) GetArchiveHeight() (start, last uint32, err error) {
+	value, err := self.store.Get(genArchiveHeightKey())
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(value) != 8 {
+		return 0, 0, fmt.Errorf("invalid archive height length: %d", len(value))
+	}
+	return binary.LittleEndian.Uint32(value), binary.LittleEndian.Uint32(value[4:]), nil
+}
+
+//NewOverlayDBAtHeight return a read only overlay db of the state after block at height was executed
+func (self *StateStore) NewOverlayDBAtHeight(height uint32) (*overlaydb.OverlayDB, error) {
+	start, last, err := self.GetArchiveHeight()
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, fmt.Errorf("state of height %d is not archived, archive state is not enabled", height)
+		}
+		return nil, err
+	}
+	// the pre-image of the first archived block is the state of previous height
+	if (start > 0 && height < start-1) || height > last {
+		return nil, fmt.Errorf("state of height %d is not archived, available from %d", height, start)
+	}
+	return overlaydb.NewOverlayDB(&archiveStore{store: self.store, height: height}), nil
+}
+
+func genArchiveHeightKey() []byte {
+	return []byte{byte(scom.SYS_ARCHIVE_HEIGHT)}
+}
+
+// the length of key is encoded so that the prefix of one key never covers the versions of another
+func genArchiveKeyPrefix(key []byte) []byte {
+	prefix := make([]byte, 5, 5+len(key)+4)
+	prefix[0] = byte(scom.ST_ARCHIVE)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(key)))
+	return append(prefix, key...)
+}
+
+// height is encoded in big endian to iterate the versions of key in ascending order
+func genArchiveKey(key []byte, height uint32) []byte {
+	archiveKey := genArchiveKeyPrefix(key)
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	return append(archiveKey, buf[:]...)
+}
+
+// archiveStore is a read only view of the state store at a past height
+type archiveStore struct {
+	store  scom.PersistStore
+	height uint32
+}
+
+func (self *archiveStore) Get(key []byte) ([]byte, error) {
+	// current value must be read before the versions, so that a block committed in between is
+	// covered by its own pre-image
+	value, err := self.store.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	if self.height < math.MaxUint32 {
+		// the pre-image of the first version after height is the value at height
+		iter := self.store.NewIterator(genArchiveKeyPrefix(key))
+		defer iter.Release()
+		if iter.Seek(genArchiveKey(key, self.height+1)) {
+			value = append([]byte(nil), iter.Value()...)
+		}
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+	if len(value) == 0 {
+		return nil, scom.ErrNotFound
+	}
+	return value, nil
+}
+
+func (self *archiveStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// NewIterator iterates the keys existing at current height with their value at the archived height,
+// keys deleted after the archived height are not visited.
+func (self *archiveStore) NewIterator(prefix []byte) scom.StoreIterator {
+	return &archiveIterator{store: self, iter: self.store.NewIterator(prefix)}
+}
+
+func (self *archiveStore) Put(key []byte, value []byte) error {
+	return errArchiveReadOnly
+}
+
+func (self *archiveStore) Delete(key []byte) error {
+	return errArchiveReadOnly
+}
+
+func (self *archiveStore) NewBatch() {}
+
+func (self *archiveStore) BatchPut(key []byte, value []byte) {
+	panic(errArchiveReadOnly)
+}
+
+func (self *archiveStore) BatchDelete(key []byte) {
+	panic(errArchiveReadOnly)
+}
+
+func (self *archiveStore) BatchCommit() error {
+	return errArchiveReadOnly
+}
+
+// the underlying store is owned by the state store
+func (self *archiveStore) Close() error {
+	return nil
+}
+
+type archiveIterator struct {
+	store *archiveStore
+	iter  scom.StoreIterator
+	value []byte
+	err   error
+}
+
+func (self *archiveIterator) First() bool {
+	return self.settle(self.iter.First(), self.iter.Next)
+}
+
+func (self *archiveIterator) Next() bool {
+	return self.settle(self.iter.Next(), self.iter.Next)
+}
+
+func (self *archiveIterator) Last() bool {
+	return self.settle(self.iter.Last(), self.iter.Prev)
+}
+
+func (self *archiveIterator) Prev() bool {
+	return self.settle(self.iter.Prev(), self.iter.Prev)
+}
+
+func (self *archiveIterator) Seek(key []byte) bool {
+	return self.settle(self.iter.Seek(key), self.iter.Next)
+}
+
+// settle skips in the moving direction the keys which did not exist at archived height
+func (self *archiveIterator) settle(ok bool, move func() bool) bool {
+	for ; ok; ok = move() {
+		value, err := self.store.Get(self.iter.Key())
+		if err == nil {
+			self.value = value
+			return true
+		}
+		if err != scom.ErrNotFound {
+			self.err = err
+			return false
+		}
+	}
+	self.value = nil
+	return false
+}
+
+func (self *archiveIterator) Key() []byte {
+	return self.iter.Key()
+}
+
+func (self *archiveIterator) Value() []byte {
+	return self.value
+}
+
+func (self *archiveIterator) Release() {
+	self.iter.Release()
+}
+
+func (self *archiveIterator) Error() error {
+	if self.err != nil {
+		return self.err
+	}
+	return self.iter.Error()
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveState(t *testing.T) {
+	db := NewMemStateStore(0)
+	_, err := db.NewOverlayDBAtHeight(0)
+	assert.NotNil(t, err)
+
+	saveBlock := func(height uint32, kvs map[string]string) {
+		overlay := db.NewOverlayDB()
+		for k, v := range kvs {
+			if v == "" {
+				overlay.Delete([]byte(k))
+			} else {
+				overlay.Put([]byte(k), []byte(v))
+			}
+		}
+		db.NewBatch()
+		assert.Nil(t, db.SaveArchiveState(height, overlay.GetWriteSet()))
+		overlay.CommitTo()
+		assert.Nil(t, db.CommitTo())
+	}
+	saveBlock(10, map[string]string{"a": "a10", "b": "b10"})
+	saveBlock(11, map[string]string{"a": "a11", "ab": "ab11"})
+	saveBlock(12, map[string]string{"b": ""})
+	saveBlock(13, map[string]string{"a": "a13"})
+
+	start, last, err := db.GetArchiveHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(10), start)
+	assert.Equal(t, uint32(13), last)
+
+	expects := map[uint32]map[string]string{
+		9:  {"a": "", "b": "", "ab": ""},
+		10: {"a": "a10", "b": "b10", "ab": ""},
+		11: {"a": "a11", "b": "b10", "ab": "ab11"},
+		12: {"a": "a11", "b": "", "ab": "ab11"},
+		13: {"a": "a13", "b": "", "ab": "ab11"},
+	}
+	for height, kvs := range expects {
+		overlay, err := db.NewOverlayDBAtHeight(height)
+		assert.Nil(t, err)
+		for k, v := range kvs {
+			val, err := overlay.Get([]byte(k))
+			assert.Nil(t, err)
+			assert.Equal(t, v, string(val), "key %s at height %d", k, height)
+		}
+	}
+
+	overlay, err := db.NewOverlayDBAtHeight(12)
+	assert.Nil(t, err)
+	iter := overlay.NewIterator([]byte("a"))
+	var values []string
+	for ok := iter.First(); ok; ok = iter.Next() {
+		values = append(values, string(iter.Value()))
+	}
+	iter.Release()
+	assert.Nil(t, iter.Error())
+	assert.Equal(t, []string{"a11", "ab11"}, values)
+
+	_, err = db.NewOverlayDBAtHeight(8)
+	assert.NotNil(t, err)
+	_, err = db.NewOverlayDBAtHeight(14)
+	assert.NotNil(t, err)
+
+	// archive restarts after blocks saved without archive
+	saveBlock(20, map[string]string{"a": "a20"})
+	start, last, err = db.GetArchiveHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(20), start)
+	assert.Equal(t, uint32(20), last)
+	_, err = db.NewOverlayDBAtHeight(12)
+	assert.NotNil(t, err)
+}
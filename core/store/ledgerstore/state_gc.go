@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	sysconfig "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+)
+
+// State GC removes in background the states no longer needed by the latest blocks: the state merkle roots of pruned
+// blocks. They are not read by block execution, so the state transition hashes are not affected. The storage of
+// destroyed contracts is kept, since governance may revive a destroyed contract at any height and it must then run
+// with its original storage on every node. Archive nodes keep all the states.
+
+const (
+	MIN_STATE_GC_HISTORY_LENGTH = MAX_ROLLBACK_BLOCKS // states needed by a rollback are never removed
+	stateGCInterval             = 10 * time.Minute
+	stateGCBatchSize            = 1000
+)
+
+//StateGCResult is the states removed by a round of state gc
+type StateGCResult struct {
+	Roots int    // state merkle roots removed
+	Keys  int    // keys removed
+	Bytes uint64 // size of the keys and values removed
+}
+
+//EnableStateGC start removing in background the states no longer needed by the latest numBeforeCurr blocks
+func (this *LedgerStoreImp) EnableStateGC(numBeforeCurr uint32) {
+	if sysconfig.DefConfig.Common.EnableArchiveState {
+		log.Warnf("state gc is disabled, archive state keeps all the states")
+		return
+	}
+	if numBeforeCurr < MIN_STATE_GC_HISTORY_LENGTH {
+		numBeforeCurr = MIN_STATE_GC_HISTORY_LENGTH
+	}
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	if this.closing || this.stateGCExit != nil {
+		return
+	}
+	this.stateGCExit = make(chan struct{})
+	this.stateGCDone.Add(1)
+	go this.stateGCLoop(numBeforeCurr, this.stateGCExit)
+}
+
+func (this *LedgerStoreImp) stateGCLoop(numBeforeCurr uint32, exit chan struct{}) {
+	defer this.stateGCDone.Done()
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+	for {
+		select {
+		case <-exit:
+			return
+		case <-timer.C:
+		}
+		start := time.Now()
+		result, err := this.GCState(numBeforeCurr, exit)
+		if err != nil {
+			log.Errorf("state gc error: %s", err)
+		} else if result.Keys > 0 {
+			log.Infof("state gc removed %d state merkle roots, %d bytes reclaimed in %s", result.Roots,
+				result.Bytes, time.Since(start))
+		}
+		timer.Reset(stateGCInterval)
+	}
+}
+
+//GCState remove the states no longer needed by the latest numBeforeCurr blocks, stop early when exit is closed
+func (this *LedgerStoreImp) GCState(numBeforeCurr uint32, exit <-chan struct{}) (*StateGCResult, error) {
+	result := &StateGCResult{}
+	currHeight := this.GetCurrentBlockHeight()
+	if currHeight <= numBeforeCurr {
+		return result, nil
+	}
+	height := currHeight - numBeforeCurr
+	// the state merkle root is sent with block to syncing peers, and can only be removed with block
+	pruned, err := this.blockStore.GetBlockPrunedHeight()
+	if err != nil {
+		return result, fmt.Errorf("GetBlockPrunedHeight error %s", err)
+	}
+	if pruned < height {
+		height = pruned
+	}
+	if err := this.stateStore.gcStateMerkleRoots(height, exit, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// gcStateMerkleRoots remove the state merkle roots of the blocks before height
+func (self *StateStore) gcStateMerkleRoots(height uint32, exit <-chan struct{}, result *StateGCResult) error {
+	start := self.stateHashCheckHeight
+	last, err := self.GetStateGCHeight()
+	if err != nil && err != scom.ErrNotFound {
+		return err
+	}
+	if err == nil && last+1 > start {
+		start = last + 1
+	}
+	for start < height {
+		end := start + stateGCBatchSize
+		if end > height {
+			end = height
+		}
+		batch := self.store.NewWriteBatch()
+		for h := start; h < end; h++ {
+			batch.Delete(self.genStateMerkleRootKey(h))
+		}
+		value := make([]byte, 4)
+		binary.LittleEndian.PutUint32(value, end-1)
+		batch.Put(genStateGCHeightKey(), value)
+		if err := self.store.WriteBatch(batch); err != nil {
+			return err
+		}
+		count := int(end - start)
+		result.Roots += count
+		result.Keys += count
+		result.Bytes += uint64(count * (5 + 2*common.UINT256_SIZE))
+		start = end
+		select {
+		case <-exit:
+			return nil
+		default:
+		}
+	}
+	if result.Roots > 0 {
+		return self.store.CompactRange([]byte{byte(scom.DATA_STATE_MERKLE_ROOT)})
+	}
+	return nil
+}
+
+//GetStateGCHeight return the last block height whose state merkle root is removed by state gc
+func (self *StateStore) GetStateGCHeight() (uint32, error) {
+	value, err := self.store.Get(genStateGCHeightKey())
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid state gc height length: %d", len(value))
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+func genStateGCHeightKey() []byte {
+	return []byte{byte(scom.SYS_STATE_GC_HEIGHT)}
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCState(t *testing.T) {
+	ledger, err := NewLedgerStore("test/gc", 0)
+	assert.Nil(t, err)
+	defer ledger.Close()
+
+	store := ledger.stateStore.store
+	// the storage of a destroyed contract is kept, governance may revive the contract
+	addr := common.AddressFromVmCode([]byte{1})
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(5)
+	assert.Nil(t, store.Put(append([]byte{byte(scom.ST_DESTROYED)}, addr[:]...), sink.Bytes()))
+	storageKey := append(append([]byte{byte(scom.ST_STORAGE)}, addr[:]...), 1)
+	assert.Nil(t, store.Put(storageKey, []byte{1}))
+	for h := uint32(0); h <= 100; h++ {
+		assert.Nil(t, store.Put(ledger.stateStore.genStateMerkleRootKey(h), make([]byte, 2*common.UINT256_SIZE)))
+	}
+	ledger.blockStore.NewBatch()
+	ledger.blockStore.SaveBlockPrunedHeight(30)
+	assert.Nil(t, ledger.blockStore.CommitTo())
+	ledger.currBlockHeight = 100
+
+	result, err := ledger.GCState(20, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, result.Roots)
+	assert.Equal(t, 30, result.Keys)
+	value, err := store.Get(storageKey)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1}, value)
+	_, err = ledger.GetStateMerkleRoot(29)
+	assert.Equal(t, scom.ErrNotFound, err)
+	_, err = ledger.GetStateMerkleRoot(30)
+	assert.Nil(t, err)
+
+	result, err = ledger.GCState(20, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.Keys)
+
+	// state gc exits when the ledger is closed
+	ledger.EnableStateGC(20)
+}
@@ -47,7 +47,7 @@ var (
 //StateStore saving the data of ledger states. Like balance of account, and the execution result of smart contract
 type StateStore struct {
 	dbDir                string                    //Store file path
-	store                scom.PersistStore         //Store handler
+	store                scom.EngineStore          //Store handler
 	merklePath           string                    //Merkle tree store path
 	merkleTree           *merkle.CompactMerkleTree //Merkle tree of block root
 	deltaMerkleTree      *merkle.CompactMerkleTree //Merkle tree of delta state root
@@ -58,7 +58,7 @@ type StateStore struct {
 //NewStateStore return state store instance
 func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
 	var err error
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := openEngineStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +188,18 @@ func (self *StateStore) GetStateMerkleRoot(height uint32) (result common.Uint256
 	return
 }
 
+func (self *StateStore) getWriteSetHash(height uint32) (common.Uint256, error) {
+	value, err := self.store.Get(self.genStateMerkleRootKey(height))
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	hash, eof := common.NewZeroCopySource(value).NextHash()
+	if eof {
+		return common.UINT256_EMPTY, fmt.Errorf("invalid state merkle root of height %d", height)
+	}
+	return hash, nil
+}
+
 func (self *StateStore) AddStateMerkleTreeRoot(blockHeight uint32, writeSetHash common.Uint256) error {
 	if blockHeight < self.stateHashCheckHeight {
 		return nil
@@ -231,6 +243,44 @@ func (self *StateStore) AddBlockMerkleTreeRoot(txRoot common.Uint256) error {
 	return nil
 }
 
+//AddBlockMerkleTreeRoots add tx roots of blocks to the block merkle tree
+func (self *StateStore) AddBlockMerkleTreeRoots(txRoots []common.Uint256) {
+	for _, txRoot := range txRoots {
+		self.merkleTree.AppendHash(txRoot)
+	}
+	key := self.genBlockMerkleTreeKey()
+	treeSize := self.merkleTree.TreeSize()
+	hashes := self.merkleTree.Hashes()
+	value := common.NewZeroCopySink(make([]byte, 0, 4+len(hashes)*common.UINT256_SIZE))
+	value.WriteUint32(treeSize)
+	for _, hash := range hashes {
+		value.WriteHash(hash)
+	}
+	self.store.BatchPut(key, value.Bytes())
+}
+
+//SetStateMerkleTree reset the state merkle tree to the compact tree of block height, used when importing a snapshot
+func (self *StateStore) SetStateMerkleTree(blockHeight uint32, writeSetHash common.Uint256, treeSize uint32,
+	hashes []common.Uint256) {
+	self.deltaMerkleTree = merkle.NewTree(treeSize, hashes, nil)
+	value := common.NewZeroCopySink(make([]byte, 0, 4+len(hashes)*common.UINT256_SIZE))
+	value.WriteUint32(treeSize)
+	for _, hash := range hashes {
+		value.WriteHash(hash)
+	}
+	self.store.BatchPut(self.genStateMerkleTreeKey(), value.Bytes())
+
+	value.Reset()
+	value.WriteHash(writeSetHash)
+	value.WriteHash(self.deltaMerkleTree.Root())
+	self.store.BatchPut(self.genStateMerkleRootKey(blockHeight), value.Bytes())
+}
+
+//NewSnapshot return a read only snapshot of the state store
+func (self *StateStore) NewSnapshot() (scom.StoreSnapshot, error) {
+	return self.store.NewSnapshot()
+}
+
 //GetMerkleProof return merkle proof of block
 func (self *StateStore) GetMerkleProof(proofHeight, rootHeight uint32) ([]common.Uint256, error) {
 	return self.merkleTree.InclusionProof(proofHeight, rootHeight+1)
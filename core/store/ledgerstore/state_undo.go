@@ -0,0 +1,285 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	sysconfig "github.com/ontio/ontology/common/config"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/merkle"
+)
+
+// The undo log of a block keeps the value every key written by the block had before it, so the state
+// store can be rolled back block by block. Only the latest MAX_ROLLBACK_BLOCKS blocks are kept.
+// Saving the undo log costs a read of every key written by the block before it is committed, and the logs
+// take about the size of MAX_ROLLBACK_BLOCKS write sets, so they are only saved if state undo is enabled.
+
+//MAX_ROLLBACK_BLOCKS is the number of latest blocks whose undo log is kept
+const MAX_ROLLBACK_BLOCKS = uint32(10000)
+
+type undoEntry struct {
+	key   []byte
+	value []byte // nil if the key did not exist
+}
+
+//writeSetHash compute the hash of the write set in key order the same way as OverlayDB.ChangeHash, the value of
+//entry is the one written by the block
+func writeSetHash(writeSet []undoEntry) common.Uint256 {
+	stateDiff := sha256.New()
+	for _, entry := range writeSet {
+		stateDiff.Write(entry.key)
+		stateDiff.Write(entry.value)
+	}
+	var hash common.Uint256
+	stateDiff.Sum(hash[:0])
+	return hash
+}
+
+type stateUndo struct {
+	writeSet []undoEntry // keys of the block write set in key order
+	system   []undoEntry // keys maintained by the ledger, like current block and merkle trees
+}
+
+func (self *stateUndo) Serialization(sink *common.ZeroCopySink) {
+	for _, entries := range [][]undoEntry{self.writeSet, self.system} {
+		sink.WriteVarUint(uint64(len(entries)))
+		for _, entry := range entries {
+			sink.WriteVarBytes(entry.key)
+			sink.WriteBool(entry.value != nil)
+			if entry.value != nil {
+				sink.WriteVarBytes(entry.value)
+			}
+		}
+	}
+}
+
+func (self *stateUndo) Deserialization(source *common.ZeroCopySource) error {
+	for _, entries := range []*[]undoEntry{&self.writeSet, &self.system} {
+		n, _, irregular, eof := source.NextVarUint()
+		if irregular || eof {
+			return fmt.Errorf("read undo entry count error")
+		}
+		for i := uint64(0); i < n; i++ {
+			key, _, irregular, eof := source.NextVarBytes()
+			if irregular || eof {
+				return fmt.Errorf("read undo entry key error")
+			}
+			exist, irregular, eof := source.NextBool()
+			if irregular || eof {
+				return fmt.Errorf("read undo entry flag error")
+			}
+			var value []byte
+			if exist {
+				value, _, irregular, eof = source.NextVarBytes()
+				if irregular || eof {
+					return fmt.Errorf("read undo entry value error")
+				}
+				// distinguish empty value from missing key
+				value = append([]byte{}, value...)
+			}
+			*entries = append(*entries, undoEntry{key: key, value: value})
+		}
+	}
+	return nil
+}
+
+//SaveStateUndo records the current value of the keys written by block at height, including the write set and
+//the keys saved by the ledger for the block. Must be called in the same batch as the block is committed.
+func (self *StateStore) SaveStateUndo(height uint32, writeSet *overlaydb.MemDB) error {
+	undo := &stateUndo{}
+	var getErr error
+	writeSet.ForEach(func(key, _ []byte) {
+		if getErr != nil {
+			return
+		}
+		var entry undoEntry
+		entry, getErr = self.getUndoEntry(key)
+		undo.writeSet = append(undo.writeSet, entry)
+	})
+	if getErr != nil {
+		return getErr
+	}
+	systemKeys := [][]byte{
+		self.getCurrentBlockKey(),
+		self.genBlockMerkleTreeKey(),
+		self.genStateMerkleTreeKey(),
+		self.genStateMerkleRootKey(height),
+		self.genCrossStatesKey(height),
+	}
+	if sysconfig.DefConfig.Common.EnableArchiveState {
+		systemKeys = append(systemKeys, genArchiveHeightKey())
+		writeSet.ForEach(func(key, _ []byte) {
+			systemKeys = append(systemKeys, genArchiveKey(key, height))
+		})
+	}
+	for _, key := range systemKeys {
+		entry, err := self.getUndoEntry(key)
+		if err != nil {
+			return err
+		}
+		undo.system = append(undo.system, entry)
+	}
+	self.store.BatchPut(genStateUndoKey(height), common.SerializeToBytes(undo))
+	return nil
+}
+
+//pruneStateUndo removes the undo log out of the latest MAX_ROLLBACK_BLOCKS blocks when block at height is saved,
+//also done when state undo is disabled to clear the logs saved before
+func (self *StateStore) pruneStateUndo(height uint32) {
+	if height > MAX_ROLLBACK_BLOCKS {
+		self.store.BatchDelete(genStateUndoKey(height - MAX_ROLLBACK_BLOCKS))
+	}
+}
+
+func (self *StateStore) getUndoEntry(key []byte) (undoEntry, error) {
+	value, err := self.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return undoEntry{key: key}, nil
+		}
+		return undoEntry{}, err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return undoEntry{key: key, value: value}, nil
+}
+
+func (self *StateStore) getStateUndo(height uint32) (*stateUndo, error) {
+	value, err := self.store.Get(genStateUndoKey(height))
+	if err != nil {
+		return nil, err
+	}
+	undo := &stateUndo{}
+	if err := undo.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, fmt.Errorf("invalid undo log of height %d: %s", height, err)
+	}
+	return undo, nil
+}
+
+//HasStateUndo return whether the undo log of block at height is kept
+func (self *StateStore) HasStateUndo(height uint32) (bool, error) {
+	return self.store.Has(genStateUndoKey(height))
+}
+
+//UndoBlock rolls the state store back from height to height-1 with the undo log of block at height, which must be
+//the current block of the state store. The write set is checked against the saved write set hash before undone.
+func (self *StateStore) UndoBlock(height uint32) error {
+	_, currHeight, err := self.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("GetCurrentBlock error %s", err)
+	}
+	if currHeight != height {
+		return fmt.Errorf("current block height %d of state store is not %d", currHeight, height)
+	}
+	undo, err := self.getStateUndo(height)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return fmt.Errorf("undo log of height %d not found", height)
+		}
+		return err
+	}
+	// the write set hash of the check height is replaced by the state root
+	if height > self.stateHashCheckHeight {
+		if err := self.checkWriteSetHash(height, undo.writeSet); err != nil {
+			return err
+		}
+	}
+
+	self.NewBatch()
+	for _, entries := range [][]undoEntry{undo.writeSet, undo.system} {
+		for _, entry := range entries {
+			if entry.value == nil {
+				self.store.BatchDelete(entry.key)
+			} else {
+				self.store.BatchPut(entry.key, entry.value)
+			}
+		}
+	}
+	self.store.BatchDelete(genStateUndoKey(height))
+	return self.store.BatchCommit()
+}
+
+func (self *StateStore) checkWriteSetHash(height uint32, writeSet []undoEntry) error {
+	expected, err := self.getWriteSetHash(height)
+	if err != nil {
+		return fmt.Errorf("get write set hash of height %d error %s", height, err)
+	}
+	current := make([]undoEntry, 0, len(writeSet))
+	for _, entry := range writeSet {
+		value, err := self.store.Get(entry.key)
+		if err != nil && err != scom.ErrNotFound {
+			return err
+		}
+		current = append(current, undoEntry{key: entry.key, value: value})
+	}
+	if hash := writeSetHash(current); hash != expected {
+		return fmt.Errorf("write set hash of height %d mismatch, expected %s, got %s", height,
+			expected.ToHexString(), hash.ToHexString())
+	}
+	return nil
+}
+
+//CheckMerkleTrees checks the block and state merkle trees are consistent with the current block
+func (self *StateStore) CheckMerkleTrees(blockRoot common.Uint256) error {
+	_, height, err := self.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("GetCurrentBlock error %s", err)
+	}
+	treeSize, hashes, err := self.GetBlockMerkleTree()
+	if err != nil {
+		return fmt.Errorf("GetBlockMerkleTree error %s", err)
+	}
+	if treeSize != height+1 {
+		return fmt.Errorf("block merkle tree size %d is inconsistent with block height %d", treeSize, height)
+	}
+	if root := merkle.NewTree(treeSize, hashes, nil).Root(); root != blockRoot {
+		return fmt.Errorf("block merkle root %s mismatch, expected %s", root.ToHexString(), blockRoot.ToHexString())
+	}
+	if height < self.stateHashCheckHeight {
+		return nil
+	}
+	treeSize, hashes, err = self.GetStateMerkleTree()
+	if err != nil {
+		return fmt.Errorf("GetStateMerkleTree error %s", err)
+	}
+	if treeSize != height-self.stateHashCheckHeight+1 {
+		return fmt.Errorf("state merkle tree size %d is inconsistent with block height %d", treeSize, height)
+	}
+	stateRoot, err := self.GetStateMerkleRoot(height)
+	if err != nil {
+		return fmt.Errorf("GetStateMerkleRoot error %s", err)
+	}
+	if root := merkle.NewTree(treeSize, hashes, nil).Root(); root != stateRoot {
+		return fmt.Errorf("state merkle root %s mismatch, expected %s", root.ToHexString(), stateRoot.ToHexString())
+	}
+	return nil
+}
+
+// height is encoded in big endian to keep the undo logs in block order
+func genStateUndoKey(height uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(scom.DATA_STATE_UNDO)
+	binary.BigEndian.PutUint32(key[1:], height)
+	return key
+}
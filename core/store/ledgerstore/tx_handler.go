@@ -25,7 +25,6 @@ import (
 	"strconv"
 
 	common2 "github.com/ethereum/go-ethereum/common"
-	types2 "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ontio/ontology/common"
 	sysconfig "github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
@@ -47,6 +46,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/service/neovm"
 	"github.com/ontio/ontology/smartcontract/service/wasmvm"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 	"github.com/ontio/ontology/vm/evm"
 	"github.com/ontio/ontology/vm/evm/params"
 )
@@ -82,10 +82,13 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 	)
 
 	if deploy.VmType() == payload.WASMVM_TYPE {
-		_, err = wasmvm.ReadWasmModule(deploy.GetRawCode(), sysconfig.DefConfig.Common.WasmVerifyMethod)
+		module, err := wasmvm.ReadWasmModule(deploy.GetRawCode(), sysconfig.DefConfig.Common.WasmVerifyMethod)
 		if err != nil {
 			return err
 		}
+		if err := wasmvm.CheckHostImports(module, block.Header.Height); err != nil {
+			return err
+		}
 	}
 
 	if tx.GasPrice != 0 {
@@ -152,9 +155,9 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 	return nil
 }
 
-//HandleInvokeTransaction deal with smart contract invoke transaction
+//HandleInvokeTransaction deal with smart contract invoke transaction, the execution is traced if tracer is not nil
 func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay *overlaydb.OverlayDB, gasTable map[string]uint64, cache *storage.CacheDB,
-	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify) ([]common.Uint256, error) {
+	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify, tracer trace.Tracer) ([]common.Uint256, error) {
 	invoke := tx.Payload.(*payload.InvokeCode)
 	code := invoke.Code
 	sysTransFlag := bytes.Compare(code, ninit.COMMIT_DPOS_BYTES) == 0 || block.Header.Height == 0
@@ -234,6 +237,7 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 		Gas:          availableGasLimit - codeLenGasLimit,
 		WasmExecStep: sysconfig.DEFAULT_WASM_MAX_STEPCOUNT,
 		PreExec:      false,
+		Tracer:       tracer,
 	}
 
 	//start the smart contract executive function
@@ -321,6 +325,10 @@ func isBalanceSufficient(payer common.Address, cache *storage.CacheDB, config *s
 
 func chargeCostGas(payer common.Address, gas uint64, config *smartcontract.Config,
 	cache *storage.CacheDB, store store.LedgerStore) ([]*event.NotifyEventInfo, error) {
+	if creditor, ok := store.(feeCreditor); ok {
+		creditor.beginFeeCredit()
+		defer creditor.endFeeCredit()
+	}
 
 	params := genNativeTransferCode(payer, utils.GovernanceContractAddress, gas)
 
@@ -340,6 +348,54 @@ func chargeCostGas(payer common.Address, gas uint64, config *smartcontract.Confi
 }
 
 func refreshGlobalParam(config *smartcontract.Config, cache *storage.CacheDB, store store.LedgerStore) error {
+	params, err := getGasGlobalParams(config, cache, store)
+	if err != nil {
+		return err
+	}
+	neovm.GAS_TABLE.Range(func(key, value interface{}) bool {
+		n, ps := params.GetParam(key.(string))
+		if n != -1 && ps.Value != "" {
+			pu, err := strconv.ParseUint(ps.Value, 10, 64)
+			if err != nil {
+				log.Errorf("[refreshGlobalParam] failed to parse uint %v\n", ps.Value)
+			} else {
+				neovm.GAS_TABLE.Store(key, pu)
+			}
+		}
+		return true
+	})
+	return nil
+}
+
+//getGasTable return the gas table of the block executed on the state of cache without refreshing the global one. The
+//keys of gas table in global params start from the initial gas and are updated by the params in the state, the
+//other keys are never changed.
+func getGasTable(config *smartcontract.Config, cache *storage.CacheDB, store store.LedgerStore) (map[string]uint64, error) {
+	params, err := getGasGlobalParams(config, cache, store)
+	if err != nil {
+		return nil, err
+	}
+	gasTable := copyGasTable()
+	for key, value := range neovm.INIT_GAS_TABLE {
+		gasTable[key] = value
+	}
+	gasTable[sysconfig.WASM_GAS_FACTOR] = sysconfig.DEFAULT_WASM_GAS_FACTOR
+	for _, key := range neovm.GAS_TABLE_KEYS {
+		n, ps := params.GetParam(key)
+		if n != -1 && ps.Value != "" {
+			pu, err := strconv.ParseUint(ps.Value, 10, 64)
+			if err != nil {
+				log.Errorf("[getGasTable] failed to parse uint %v", ps.Value)
+				continue
+			}
+			gasTable[key] = pu
+		}
+	}
+	return gasTable, nil
+}
+
+func getGasGlobalParams(config *smartcontract.Config, cache *storage.CacheDB,
+	store store.LedgerStore) (*global_params.Params, error) {
 	sink := common.NewZeroCopySink(nil)
 	utils.EncodeVarUint(sink, uint64(len(neovm.GAS_TABLE_KEYS)))
 	for _, value := range neovm.GAS_TABLE_KEYS {
@@ -356,25 +412,13 @@ func refreshGlobalParam(config *smartcontract.Config, cache *storage.CacheDB, st
 	service, _ := sc.NewNativeService()
 	result, err := service.NativeCall(utils.ParamContractAddress, "getGlobalParam", sink.Bytes())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	params := new(global_params.Params)
 	if err := params.Deserialization(common.NewZeroCopySource(result)); err != nil {
-		return fmt.Errorf("deserialize global params error:%s", err)
+		return nil, fmt.Errorf("deserialize global params error:%s", err)
 	}
-	neovm.GAS_TABLE.Range(func(key, value interface{}) bool {
-		n, ps := params.GetParam(key.(string))
-		if n != -1 && ps.Value != "" {
-			pu, err := strconv.ParseUint(ps.Value, 10, 64)
-			if err != nil {
-				log.Errorf("[refreshGlobalParam] failed to parse uint %v\n", ps.Value)
-			} else {
-				neovm.GAS_TABLE.Store(key, pu)
-			}
-		}
-		return true
-	})
-	return nil
+	return params, nil
 }
 
 func getBalanceFromNative(config *smartcontract.Config, cache *storage.CacheDB, store store.LedgerStore, address common.Address) (uint64, error) {
@@ -420,10 +464,14 @@ type Eip155Context struct {
 }
 
 func (self *StateStore) HandleEIP155Transaction(store store.LedgerStore, cache *storage.CacheDB,
-	tx *types2.Transaction, ctx Eip155Context, notify *event.ExecuteNotify, checkNonce bool) (*types3.ExecutionResult, error) {
+	tx *types.Transaction, ctx Eip155Context, notify *event.ExecuteNotify, checkNonce bool) (*types3.ExecutionResult, error) {
 	usedGas := uint64(0)
 	config := params.GetChainConfig(sysconfig.DefConfig.P2PNode.EVMChainId)
-	statedb := storage.NewStateDB(cache, tx.Hash(), common2.Hash(ctx.BlockHash), ong.OngBalanceHandle{})
+	var balanceHandle storage.OngBalanceHandle = ong.OngBalanceHandle{}
+	if creditor, ok := store.(feeCreditor); ok {
+		balanceHandle = creditor.feeCreditBalanceHandle(balanceHandle)
+	}
+	statedb := storage.NewStateDB(cache, common2.Hash(tx.Hash()), common2.Hash(ctx.BlockHash), balanceHandle)
 	result, receipt, err := evm2.ApplyTransaction(config, store, statedb, ctx.Height, ctx.Timestamp, tx, &usedGas,
 		utils.GovernanceContractAddress, evm.Config{}, checkNonce)
 
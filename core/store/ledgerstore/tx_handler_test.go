@@ -23,6 +23,16 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSyncMapRange(t *testing.T) {
@@ -75,3 +85,27 @@ func TestSyncMapRW(t *testing.T) {
 func addsync(m *sync.Map, va int) {
 	m.Store("key", va)
 }
+
+func TestGetGasTable(t *testing.T) {
+	bookkeepers := []keypair.PublicKey{account.NewAccount("").PublicKey}
+	ledger, err := NewLedgerStore("test/gas_table", 0)
+	assert.Nil(t, err)
+	defer ledger.Close()
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+
+	// the global gas table refreshed by a later block does not apply to the state of genesis block
+	neovm.GAS_TABLE.Store(neovm.STORAGE_PUT_NAME, uint64(1))
+	neovm.GAS_TABLE.Store(config.WASM_GAS_FACTOR, uint64(1))
+	defer func() {
+		neovm.GAS_TABLE.Store(neovm.STORAGE_PUT_NAME, neovm.STORAGE_PUT_GAS)
+		neovm.GAS_TABLE.Store(config.WASM_GAS_FACTOR, config.DEFAULT_WASM_GAS_FACTOR)
+	}()
+	sconfig := &smartcontract.Config{Height: 1, Tx: &types.Transaction{}}
+	gasTable, err := getGasTable(sconfig, storage.NewCacheDB(ledger.stateStore.NewOverlayDB()), ledger)
+	assert.Nil(t, err)
+	assert.Equal(t, neovm.STORAGE_PUT_GAS, gasTable[neovm.STORAGE_PUT_NAME])
+	assert.Equal(t, config.DEFAULT_WASM_GAS_FACTOR, gasTable[config.WASM_GAS_FACTOR])
+	assert.Equal(t, neovm.RUNTIME_GETGASINFO_GAS, gasTable[neovm.RUNTIME_GETGASINFO])
+}
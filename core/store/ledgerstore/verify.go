@@ -0,0 +1,398 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/merkle"
+)
+
+// The ledger store is verified offline against the data it is built from: the headers must link by hash, the
+// transactions must match the tx roots, the block roots must match the block merkle tree rebuilt from the tx roots,
+// the state merkle roots must match the state merkle tree rebuilt from the write set hashes, the write set hashes
+// of the latest blocks must match the states restored with their undo logs, and the event store must record the
+// transactions of the blocks. The data removed by block pruning and state gc can not be verified.
+
+//MAX_VERIFY_ERRORS is the max number of divergences kept in the verify report
+const MAX_VERIFY_ERRORS = 100
+
+const (
+	VERIFY_CHECK_CURRENT_BLOCK = "current_block"
+	VERIFY_CHECK_BLOCK_HASH    = "block_hash"
+	VERIFY_CHECK_HEADER        = "header"
+	VERIFY_CHECK_TRANSACTION   = "transaction"
+	VERIFY_CHECK_TX_ROOT       = "tx_root"
+	VERIFY_CHECK_BLOCK_ROOT    = "block_root"
+	VERIFY_CHECK_MERKLE_TREE   = "merkle_tree"
+	VERIFY_CHECK_STATE_ROOT    = "state_root"
+	VERIFY_CHECK_WRITE_SET     = "write_set"
+	VERIFY_CHECK_EVENT         = "event"
+)
+
+//VerifyError is a divergence found at a block height
+type VerifyError struct {
+	Height  uint32 `json:"height"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+//VerifyReport is the result of verifying a ledger store
+type VerifyReport struct {
+	BlockHeight          uint32         `json:"block_height"`
+	StateHeight          uint32         `json:"state_height"`
+	EventHeight          uint32         `json:"event_height"`
+	PrunedHeight         uint32         `json:"pruned_height"`
+	StateGCHeight        uint32         `json:"state_gc_height"`
+	StateHashCheckHeight uint32         `json:"state_hash_check_height"`
+	BlocksChecked        uint32         `json:"blocks_checked"`
+	BlockRootsChecked    uint32         `json:"block_roots_checked"`
+	StateRootsChecked    uint32         `json:"state_roots_checked"`
+	WriteSetsChecked     uint32         `json:"write_sets_checked"`
+	EventsChecked        uint32         `json:"events_checked"`
+	OK                   bool           `json:"ok"`
+	FirstDivergentHeight *uint32        `json:"first_divergent_height,omitempty"`
+	ErrorCount           int            `json:"error_count"`
+	Errors               []*VerifyError `json:"errors"`
+}
+
+func (self *VerifyReport) addError(height uint32, check string, format string, a ...interface{}) {
+	self.ErrorCount += 1
+	if self.FirstDivergentHeight == nil || height < *self.FirstDivergentHeight {
+		h := height
+		self.FirstDivergentHeight = &h
+	}
+	if len(self.Errors) < MAX_VERIFY_ERRORS {
+		self.Errors = append(self.Errors, &VerifyError{Height: height, Check: check, Message: fmt.Sprintf(format, a...)})
+	}
+}
+
+//VerifyLedgerStore verifies the ledger store in dataDir block by block, the ledger store must not be opened. The
+//divergences found are reported with the first divergent height, and error is only returned if the stores can not be
+//read. progress is called after the check of each height.
+func VerifyLedgerStore(dataDir string, stateHashHeight uint32, progress func(check string, height uint32)) (*VerifyReport, error) {
+	if err := checkDBEngine(dataDir); err != nil {
+		return nil, err
+	}
+	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), false)
+	if err != nil {
+		return nil, fmt.Errorf("NewBlockStore error %s", err)
+	}
+	defer blockStore.Close()
+	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
+	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
+	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight)
+	if err != nil {
+		return nil, fmt.Errorf("NewStateStore error %s", err)
+	}
+	defer stateStore.Close()
+	eventStore, err := NewEventStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirEvent))
+	if err != nil {
+		return nil, fmt.Errorf("NewEventStore error %s", err)
+	}
+	defer eventStore.Close()
+
+	report := &VerifyReport{StateHashCheckHeight: stateHashHeight, Errors: make([]*VerifyError, 0)}
+	if err := verifyBlocks(blockStore, stateStore, eventStore, report, progress); err != nil {
+		return nil, err
+	}
+	if err := verifyStates(blockStore, stateStore, report, progress); err != nil {
+		return nil, err
+	}
+	report.OK = report.ErrorCount == 0
+	return report, nil
+}
+
+func verifyBlocks(blockStore *BlockStore, stateStore *StateStore, eventStore *EventStore, report *VerifyReport,
+	progress func(check string, height uint32)) error {
+	blockHash, blockHeight, err := blockStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("blockStore.GetCurrentBlock error %s", err)
+	}
+	stateHash, stateHeight, err := stateStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	eventHash, eventHeight, err := eventStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("eventStore.GetCurrentBlock error %s", err)
+	}
+	pruned, err := blockStore.GetBlockPrunedHeight()
+	if err != nil {
+		return fmt.Errorf("GetBlockPrunedHeight error %s", err)
+	}
+	report.BlockHeight, report.StateHeight, report.EventHeight, report.PrunedHeight =
+		blockHeight, stateHeight, eventHeight, pruned
+	// the state and event stores may be behind the block store, they catch up when the ledger is opened
+	if stateHeight > blockHeight {
+		report.addError(blockHeight+1, VERIFY_CHECK_CURRENT_BLOCK, "state store height %d is higher than "+
+			"block store height %d", stateHeight, blockHeight)
+	}
+	if eventHeight > blockHeight {
+		report.addError(blockHeight+1, VERIFY_CHECK_CURRENT_BLOCK, "event store height %d is higher than "+
+			"block store height %d", eventHeight, blockHeight)
+	}
+
+	// the block merkle tree can only be rebuilt if no tx root is pruned
+	var blockTree *merkle.CompactMerkleTree
+	if pruned == 0 {
+		blockTree = merkle.NewTree(0, nil, nil)
+	}
+	var prevHash common.Uint256
+	for height := uint32(0); height <= blockHeight; height++ {
+		hash, err := blockStore.GetBlockHash(height)
+		if err != nil {
+			if err != scom.ErrNotFound {
+				return fmt.Errorf("GetBlockHash height:%d error %s", height, err)
+			}
+			report.addError(height, VERIFY_CHECK_BLOCK_HASH, "block hash not found")
+			blockTree = nil
+			prevHash = common.UINT256_EMPTY
+			continue
+		}
+		if height == blockHeight && hash != blockHash {
+			report.addError(height, VERIFY_CHECK_CURRENT_BLOCK, "current block %s of block store mismatch "+
+				"with block hash %s", blockHash.ToHexString(), hash.ToHexString())
+		}
+		if height == stateHeight && hash != stateHash {
+			report.addError(height, VERIFY_CHECK_CURRENT_BLOCK, "current block %s of state store mismatch "+
+				"with block hash %s", stateHash.ToHexString(), hash.ToHexString())
+		}
+		if height == eventHeight && hash != eventHash {
+			report.addError(height, VERIFY_CHECK_CURRENT_BLOCK, "current block %s of event store mismatch "+
+				"with block hash %s", eventHash.ToHexString(), hash.ToHexString())
+		}
+		if height > 0 && height <= pruned {
+			prevHash = hash
+			report.BlocksChecked += 1
+			if progress != nil {
+				progress("block", height)
+			}
+			continue
+		}
+
+		header, txHashes, err := blockStore.loadHeaderWithTx(hash)
+		if err != nil {
+			report.addError(height, VERIFY_CHECK_HEADER, "load header %s error %s", hash.ToHexString(), err)
+			blockTree = nil
+			prevHash = hash
+			continue
+		}
+		if header.Height != height {
+			report.addError(height, VERIFY_CHECK_HEADER, "header height %d mismatch", header.Height)
+		}
+		if h := header.Hash(); h != hash {
+			report.addError(height, VERIFY_CHECK_HEADER, "header hash %s mismatch with block hash %s",
+				h.ToHexString(), hash.ToHexString())
+		}
+		if height > 0 && prevHash != common.UINT256_EMPTY && header.PrevBlockHash != prevHash {
+			report.addError(height, VERIFY_CHECK_HEADER, "prev block hash %s mismatch with block hash %s",
+				header.PrevBlockHash.ToHexString(), prevHash.ToHexString())
+		}
+		// the hashes are used as workspace to compute the merkle root
+		if root := common.ComputeMerkleRoot(append([]common.Uint256{}, txHashes...)); root != header.TransactionsRoot {
+			report.addError(height, VERIFY_CHECK_TX_ROOT, "tx root %s mismatch with header %s",
+				root.ToHexString(), header.TransactionsRoot.ToHexString())
+		}
+		for _, txHash := range txHashes {
+			tx, txHeight, err := blockStore.loadTransaction(txHash)
+			if err != nil {
+				report.addError(height, VERIFY_CHECK_TRANSACTION, "load transaction %s error %s",
+					txHash.ToHexString(), err)
+				continue
+			}
+			if txHeight != height {
+				report.addError(height, VERIFY_CHECK_TRANSACTION, "transaction %s saved with height %d",
+					txHash.ToHexString(), txHeight)
+			}
+			if h := tx.Hash(); h != txHash {
+				report.addError(height, VERIFY_CHECK_TRANSACTION, "transaction hash %s mismatch with %s",
+					h.ToHexString(), txHash.ToHexString())
+			}
+		}
+		if blockTree != nil {
+			blockTree.AppendHash(header.TransactionsRoot)
+			if height > 0 && blockTree.Root() != header.BlockRoot {
+				report.addError(height, VERIFY_CHECK_BLOCK_ROOT, "block root %s mismatch with header %s",
+					blockTree.Root().ToHexString(), header.BlockRoot.ToHexString())
+			}
+			report.BlockRootsChecked += 1
+		}
+		if height == stateHeight {
+			blockRoot := header.BlockRoot
+			if height == 0 {
+				blockRoot = header.TransactionsRoot
+			}
+			if err := stateStore.CheckMerkleTrees(blockRoot); err != nil {
+				report.addError(height, VERIFY_CHECK_MERKLE_TREE, "%s", err)
+			}
+		}
+		if height <= eventHeight {
+			if err := verifyBlockEvents(eventStore, height, txHashes); err != nil {
+				report.addError(height, VERIFY_CHECK_EVENT, "%s", err)
+			}
+			report.EventsChecked += 1
+		}
+		prevHash = hash
+		report.BlocksChecked += 1
+		if progress != nil {
+			progress("block", height)
+		}
+	}
+	if _, err := blockStore.GetBlockHash(blockHeight + 1); err != scom.ErrNotFound {
+		report.addError(blockHeight+1, VERIFY_CHECK_BLOCK_HASH, "block hash found after current block")
+	}
+	return nil
+}
+
+// verifyBlockEvents checks the event store records the transactions of block, the event notify of the transactions
+// is optional as it is not saved if the event log is disabled
+func verifyBlockEvents(eventStore *EventStore, height uint32, txHashes []common.Uint256) error {
+	eventTxHashes, err := eventStore.getEventTxHashesByBlock(height)
+	if err != nil {
+		if err == scom.ErrNotFound && len(txHashes) == 0 {
+			return nil
+		}
+		return fmt.Errorf("get event transactions error %s", err)
+	}
+	if len(eventTxHashes) != len(txHashes) {
+		return fmt.Errorf("event transaction count %d mismatch with block %d", len(eventTxHashes), len(txHashes))
+	}
+	for i, txHash := range txHashes {
+		if eventTxHashes[i] != txHash {
+			return fmt.Errorf("event transaction %s mismatch with block transaction %s",
+				eventTxHashes[i].ToHexString(), txHash.ToHexString())
+		}
+		notify, err := eventStore.getEventNotifyByTx(txHash, false)
+		if err != nil {
+			if err == scom.ErrNotFound {
+				continue
+			}
+			return fmt.Errorf("get event notify of transaction %s error %s", txHash.ToHexString(), err)
+		}
+		if notify.TxHash != txHash {
+			return fmt.Errorf("event notify of transaction %s has transaction hash %s", txHash.ToHexString(),
+				notify.TxHash.ToHexString())
+		}
+	}
+	return nil
+}
+
+func verifyStates(blockStore *BlockStore, stateStore *StateStore, report *VerifyReport,
+	progress func(check string, height uint32)) error {
+	stateHeight := report.StateHeight
+	if stateHeight < stateStore.stateHashCheckHeight {
+		return nil
+	}
+	gcHeight, err := stateStore.GetStateGCHeight()
+	if err != nil && err != scom.ErrNotFound {
+		return fmt.Errorf("GetStateGCHeight error %s", err)
+	}
+	start := stateStore.stateHashCheckHeight
+	// the state merkle tree can only be rebuilt if no state merkle root is removed
+	var stateTree *merkle.CompactMerkleTree
+	if err == scom.ErrNotFound {
+		stateTree = merkle.NewTree(0, nil, nil)
+	} else {
+		report.StateGCHeight = gcHeight
+		if gcHeight+1 > start {
+			start = gcHeight + 1
+		}
+	}
+	for height := start; height <= stateHeight; height++ {
+		value, err := stateStore.store.Get(stateStore.genStateMerkleRootKey(height))
+		if err != nil {
+			if err != scom.ErrNotFound {
+				return fmt.Errorf("get state merkle root of height %d error %s", height, err)
+			}
+			report.addError(height, VERIFY_CHECK_STATE_ROOT, "state merkle root not found")
+			stateTree = nil
+			continue
+		}
+		source := common.NewZeroCopySource(value)
+		writeSetHash, eof := source.NextHash()
+		root, eof2 := source.NextHash()
+		if eof || eof2 {
+			report.addError(height, VERIFY_CHECK_STATE_ROOT, "invalid state merkle root")
+			stateTree = nil
+			continue
+		}
+		if stateTree != nil {
+			stateTree.AppendHash(writeSetHash)
+			if stateTree.Root() != root {
+				report.addError(height, VERIFY_CHECK_STATE_ROOT, "state merkle root %s mismatch with %s",
+					stateTree.Root().ToHexString(), root.ToHexString())
+			}
+			report.StateRootsChecked += 1
+		}
+		if progress != nil {
+			progress("state", height)
+		}
+	}
+	return verifyWriteSets(stateStore, report, progress)
+}
+
+// verifyWriteSets recomputes the write set hashes of the blocks whose undo log is kept, from the latest block back.
+// The states before each block are restored in memory with its undo log, to be the states after the previous block.
+func verifyWriteSets(stateStore *StateStore, report *VerifyReport, progress func(check string, height uint32)) error {
+	restored := make(map[string][]byte) // key => value before the blocks checked, nil if not exist
+	// the write set hash of the check height is replaced by the state root
+	for height := report.StateHeight; height > stateStore.stateHashCheckHeight; height-- {
+		undo, err := stateStore.getStateUndo(height)
+		if err != nil {
+			if err == scom.ErrNotFound {
+				break
+			}
+			report.addError(height, VERIFY_CHECK_WRITE_SET, "%s", err)
+			break
+		}
+		expected, err := stateStore.getWriteSetHash(height)
+		if err != nil {
+			if err == scom.ErrNotFound {
+				break
+			}
+			return fmt.Errorf("get write set hash of height %d error %s", height, err)
+		}
+		current := make([]undoEntry, 0, len(undo.writeSet))
+		for _, entry := range undo.writeSet {
+			value, ok := restored[string(entry.key)]
+			if !ok {
+				value, err = stateStore.store.Get(entry.key)
+				if err != nil && err != scom.ErrNotFound {
+					return err
+				}
+			}
+			current = append(current, undoEntry{key: entry.key, value: value})
+		}
+		if hash := writeSetHash(current); hash != expected {
+			report.addError(height, VERIFY_CHECK_WRITE_SET, "write set hash %s mismatch with %s",
+				hash.ToHexString(), expected.ToHexString())
+		}
+		for _, entry := range undo.writeSet {
+			restored[string(entry.key)] = entry.value
+		}
+		report.WriteSetsChecked += 1
+		if progress != nil {
+			progress("write_set", height)
+		}
+	}
+	return nil
+}
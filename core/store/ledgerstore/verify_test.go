@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyLedgerStore(t *testing.T) {
+	config.DefConfig.Common.EnableStateUndo = true
+	defer func() { config.DefConfig.Common.EnableStateUndo = false }()
+	dataDir := "test/verify"
+	ledger, holder := newTestLedger(t, dataDir)
+	var blocks []*types.Block
+	for i := 0; i < 3; i++ {
+		block := newTransferBlock(t, ledger, holder, []common.Address{common.AddressFromVmCode([]byte{byte(i)})})
+		result, err := ledger.executeBlock(block)
+		assert.Nil(t, err)
+		assert.Nil(t, ledger.submitBlock(block, nil, result))
+		blocks = append(blocks, block)
+	}
+	assert.Nil(t, ledger.Close())
+
+	var verified []string
+	report, err := VerifyLedgerStore(dataDir, 0, func(check string, height uint32) {
+		verified = append(verified, fmt.Sprintf("%s:%d", check, height))
+	})
+	assert.Nil(t, err)
+	assert.True(t, report.OK, "%v", report.Errors)
+	assert.Nil(t, report.FirstDivergentHeight)
+	assert.Equal(t, uint32(3), report.BlockHeight)
+	assert.Equal(t, uint32(4), report.BlocksChecked)
+	assert.Equal(t, uint32(4), report.BlockRootsChecked)
+	assert.Equal(t, uint32(4), report.StateRootsChecked)
+	assert.Equal(t, uint32(3), report.WriteSetsChecked)
+	assert.Equal(t, uint32(4), report.EventsChecked)
+	assert.Equal(t, []string{"block:0", "block:1", "block:2", "block:3", "state:0", "state:1", "state:2", "state:3",
+		"write_set:3", "write_set:2", "write_set:1"}, verified)
+
+	// remove a transaction of block 2 and change a state written by block 3
+	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), false)
+	assert.Nil(t, err)
+	assert.Nil(t, blockStore.store.Delete(genTransactionKey(blocks[1].Transactions[0].Hash())))
+	assert.Nil(t, blockStore.Close())
+	stateStore, err := NewStateStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState),
+		fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath), 0)
+	assert.Nil(t, err)
+	undo, err := stateStore.getStateUndo(3)
+	assert.Nil(t, err)
+	assert.Nil(t, stateStore.store.Put(undo.writeSet[0].key, []byte("corrupted")))
+	assert.Nil(t, stateStore.Close())
+
+	report, err = VerifyLedgerStore(dataDir, 0, nil)
+	assert.Nil(t, err)
+	assert.False(t, report.OK)
+	assert.Equal(t, uint32(2), *report.FirstDivergentHeight)
+	assert.Equal(t, 2, report.ErrorCount)
+	assert.Equal(t, VERIFY_CHECK_TRANSACTION, report.Errors[0].Check)
+	assert.Equal(t, uint32(2), report.Errors[0].Height)
+	assert.Equal(t, VERIFY_CHECK_WRITE_SET, report.Errors[1].Check)
+	assert.Equal(t, uint32(3), report.Errors[1].Height)
+}
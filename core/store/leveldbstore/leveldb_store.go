@@ -19,6 +19,8 @@
 package leveldbstore
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common/fdlimit"
 	"github.com/ontio/ontology/core/store/common"
 	"github.com/syndtr/goleveldb/leveldb"
@@ -39,6 +41,12 @@ type LevelDBStore struct {
 // too small will lead to high false positive rate.
 const BITSPERKEY = 10
 
+func init() {
+	common.RegisterEngine(common.ENGINE_LEVELDB, func(dir string) (common.EngineStore, error) {
+		return NewLevelDBStore(dir)
+	})
+}
+
 //NewLevelDBStore return LevelDBStore instance
 func NewLevelDBStore(file string) (*LevelDBStore, error) {
 	openFileCache := opt.DefaultOpenFilesCacheCapacity
@@ -144,6 +152,25 @@ func (self *LevelDBStore) BatchCommit() error {
 	return nil
 }
 
+//NewWriteBatch return a new empty leveldb batch
+func (self *LevelDBStore) NewWriteBatch() common.WriteBatch {
+	return new(leveldb.Batch)
+}
+
+//WriteBatch commit the batch in one atomic write, independent of the batch started by NewBatch
+func (self *LevelDBStore) WriteBatch(batch common.WriteBatch) error {
+	b, ok := batch.(*leveldb.Batch)
+	if !ok {
+		return fmt.Errorf("invalid leveldb batch type %T", batch)
+	}
+	return self.db.Write(b, nil)
+}
+
+//CompactRange compact the underlying storage of the keys with the prefix, to reclaim the space of deleted keys
+func (self *LevelDBStore) CompactRange(prefix []byte) error {
+	return self.db.CompactRange(*util.BytesPrefix(prefix))
+}
+
 //Close leveldb
 func (self *LevelDBStore) Close() error {
 	err := self.db.Close()
@@ -157,3 +184,44 @@ func (self *LevelDBStore) NewIterator(prefix []byte) common.StoreIterator {
 
 	return iter
 }
+
+//LevelDBSnapshot is a read only view of leveldb at the time it is created
+type LevelDBSnapshot struct {
+	snapshot *leveldb.Snapshot
+}
+
+//NewSnapshot return a snapshot of the current leveldb, which must be released after use
+func (self *LevelDBStore) NewSnapshot() (common.StoreSnapshot, error) {
+	snapshot, err := self.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBSnapshot{snapshot: snapshot}, nil
+}
+
+//Get the value of a key from the snapshot
+func (self *LevelDBSnapshot) Get(key []byte) ([]byte, error) {
+	dat, err := self.snapshot.Get(key, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return dat, nil
+}
+
+//NewIterator return a iterator of the snapshot with the key prefix
+func (self *LevelDBSnapshot) NewIterator(prefix []byte) common.StoreIterator {
+	return self.snapshot.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+//NewRangeIterator return a iterator of the snapshot over the keys in [start, limit), nil limit means no upper bound
+func (self *LevelDBSnapshot) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	return self.snapshot.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
+//Release the snapshot
+func (self *LevelDBSnapshot) Release() {
+	self.snapshot.Release()
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/ontio/ontology/core/store/common"
 )
 
 var testLevelDB *LevelDBStore
@@ -140,3 +142,51 @@ func TestIterator(t *testing.T) {
 	}
 
 }
+
+func TestSnapshot(t *testing.T) {
+	store := NewMemLevelDBStore()
+	err := store.Put([]byte("snap1"), []byte("v1"))
+	if err != nil {
+		t.Errorf("Put error:%s", err)
+		return
+	}
+	snapshot, err := store.NewSnapshot()
+	if err != nil {
+		t.Errorf("NewSnapshot error:%s", err)
+		return
+	}
+	defer snapshot.Release()
+
+	err = store.Put([]byte("snap1"), []byte("v2"))
+	if err != nil {
+		t.Errorf("Put error:%s", err)
+		return
+	}
+	err = store.Put([]byte("snap2"), []byte("v2"))
+	if err != nil {
+		t.Errorf("Put error:%s", err)
+		return
+	}
+
+	v, err := snapshot.Get([]byte("snap1"))
+	if err != nil || string(v) != "v1" {
+		t.Errorf("snapshot Get %s != v1, error:%v", v, err)
+		return
+	}
+	_, err = snapshot.Get([]byte("snap2"))
+	if err != common.ErrNotFound {
+		t.Errorf("snapshot Get error:%v should be not found", err)
+		return
+	}
+
+	count := 0
+	iter := snapshot.NewRangeIterator([]byte("snap1"), nil)
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	if count != 1 {
+		t.Errorf("snapshot iterate %d keys != 1", count)
+		return
+	}
+}
@@ -30,14 +30,27 @@ const (
 	FromBoth           = iota
 )
 
+type iterDir byte
+
+const (
+	dirSOI      iterDir = iota // before the first key
+	dirEOI                     // after the last key
+	dirForward                 // at a key, reached by moving forward
+	dirBackward                // at a key, reached by moving backward
+)
+
+//JoinIter merges the iterator of the memdb into the iterator of the backend, the keys deleted in memdb are skipped.
+//Like leveldb iterator, a new iterator is before the first key, and an iterator moved past either end can move back
+//from that end.
 type JoinIter struct {
-	backend     common.StoreIterator
-	memdb       common.StoreIterator
-	key, value  []byte
-	keyOrigin   KeyOrigin
-	nextMemEnd  bool
-	nextBackEnd bool
-	cmp         comparer.BasicComparer
+	backend    common.StoreIterator
+	memdb      common.StoreIterator
+	key, value []byte
+	keyOrigin  KeyOrigin
+	memOk      bool
+	backOk     bool
+	dir        iterDir
+	cmp        comparer.BasicComparer
 }
 
 func NewJoinIter(memIter, backendIter common.StoreIterator) *JoinIter {
@@ -49,141 +62,156 @@ func NewJoinIter(memIter, backendIter common.StoreIterator) *JoinIter {
 }
 
 func (iter *JoinIter) First() bool {
-	f := iter.first()
-	if !f {
-		return false
-	}
-	for len(iter.value) == 0 {
-		if !iter.next() {
-			return false
-		}
-	}
-
-	return true
+	iter.memOk = iter.memdb.First()
+	iter.backOk = iter.backend.First()
+	iter.dir = dirForward
+	return iter.settle()
 }
 
-func (iter *JoinIter) first() bool {
-	var bkey, bval, mkey, mval []byte
-	back := iter.backend.First()
-	mem := iter.memdb.First()
-	// check error
-	if iter.Error() != nil {
-		return false
-	}
-	if back {
-		bkey = iter.backend.Key()
-		bval = iter.backend.Value()
-		if !mem {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-			return true
-		}
-		mkey = iter.memdb.Key()
-		mval = iter.memdb.Value()
-		cmp := iter.cmp.Compare(mkey, bkey)
-		if cmp < 1 {
-			iter.key = mkey
-			iter.value = mval
-			if cmp == 0 {
-				iter.keyOrigin = FromBoth
-			} else {
-				iter.keyOrigin = FromMem
-			}
-		} else {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-		}
-		return true
-	} else {
-		if mem {
-			iter.key = iter.memdb.Key()
-			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
-			return true
-		}
-		return false
-	}
+func (iter *JoinIter) Last() bool {
+	iter.memOk = iter.memdb.Last()
+	iter.backOk = iter.backend.Last()
+	iter.dir = dirBackward
+	return iter.settle()
 }
 
-func (iter *JoinIter) Key() []byte {
-	return iter.key
-}
-
-func (iter *JoinIter) Value() []byte {
-	return iter.value
+//Seek moves to the first key which is greater than or equal to key
+func (iter *JoinIter) Seek(key []byte) bool {
+	iter.memOk = iter.memdb.Seek(key)
+	iter.backOk = iter.backend.Seek(key)
+	iter.dir = dirForward
+	return iter.settle()
 }
 
 func (iter *JoinIter) Next() bool {
-	f := iter.next()
-	if !f {
+	switch iter.dir {
+	case dirSOI:
+		return iter.First()
+	case dirEOI:
 		return false
+	case dirBackward:
+		// both iterators are moved to the first key after the current key
+		key := append([]byte(nil), iter.key...)
+		iter.memOk = seekAfter(iter.memdb, key, iter.cmp)
+		iter.backOk = seekAfter(iter.backend, key, iter.cmp)
+		iter.dir = dirForward
+	default:
+		iter.step()
 	}
+	return iter.settle()
+}
 
-	for len(iter.value) == 0 {
-		if !iter.next() {
-			return false
-		}
+func (iter *JoinIter) Prev() bool {
+	switch iter.dir {
+	case dirSOI:
+		return false
+	case dirEOI:
+		return iter.Last()
+	case dirForward:
+		// both iterators are moved to the last key before the current key
+		key := append([]byte(nil), iter.key...)
+		iter.memOk = seekBefore(iter.memdb, key)
+		iter.backOk = seekBefore(iter.backend, key)
+		iter.dir = dirBackward
+	default:
+		iter.step()
 	}
-
-	return true
+	return iter.settle()
 }
 
-func (iter *JoinIter) next() bool {
-	if (iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth) && !iter.nextMemEnd {
-		iter.nextMemEnd = !iter.memdb.Next()
+//seekAfter moves the iterator to the first key greater than key
+func seekAfter(it common.StoreIterator, key []byte, cmp comparer.BasicComparer) bool {
+	ok := it.Seek(key)
+	if ok && cmp.Compare(it.Key(), key) == 0 {
+		ok = it.Next()
 	}
-	if (iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth) && !iter.nextBackEnd {
-		iter.nextBackEnd = !iter.backend.Next()
+	return ok
+}
+
+//seekBefore moves the iterator to the last key less than key
+func seekBefore(it common.StoreIterator, key []byte) bool {
+	if it.Seek(key) {
+		return it.Prev()
 	}
+	return it.Last()
+}
 
-	// check error
-	if iter.Error() != nil {
-		return false
+//step moves the iterators at the current key in the current direction
+func (iter *JoinIter) step() {
+	forward := iter.dir == dirForward
+	if iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth {
+		if forward {
+			iter.memOk = iter.memdb.Next()
+		} else {
+			iter.memOk = iter.memdb.Prev()
+		}
 	}
+	if iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth {
+		if forward {
+			iter.backOk = iter.backend.Next()
+		} else {
+			iter.backOk = iter.backend.Prev()
+		}
+	}
+}
 
-	if iter.nextBackEnd {
-		if iter.nextMemEnd {
+//settle picks the current key from the iterators, and skips the keys deleted in memdb
+func (iter *JoinIter) settle() bool {
+	for {
+		if iter.Error() != nil || !iter.pick() {
 			iter.key = nil
 			iter.value = nil
+			if iter.dir == dirBackward {
+				iter.dir = dirSOI
+			} else {
+				iter.dir = dirEOI
+			}
 			return false
-		} else {
-			iter.key = iter.memdb.Key()
-			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
 		}
-	} else {
-		if iter.nextMemEnd {
-			iter.key = iter.backend.Key()
-			iter.value = iter.backend.Value()
-			iter.keyOrigin = FromBack
-		} else {
-			bkey := iter.backend.Key()
-			mkey := iter.memdb.Key()
-			cmp := iter.cmp.Compare(mkey, bkey)
-			switch cmp {
-			case -1:
-				iter.key = mkey
-				iter.value = iter.memdb.Value()
-				iter.keyOrigin = FromMem
-			case 0:
-				iter.key = mkey
-				iter.value = iter.memdb.Value()
-				iter.keyOrigin = FromBoth
-			case 1:
-				iter.key = bkey
-				iter.value = iter.backend.Value()
-				iter.keyOrigin = FromBack
-			default:
-				panic("unreachable")
-			}
+		if len(iter.value) != 0 {
+			return true
 		}
+		iter.step()
 	}
+}
 
+//pick the smaller key of the iterators when moving forward, or the larger one when moving backward
+func (iter *JoinIter) pick() bool {
+	if !iter.memOk && !iter.backOk {
+		return false
+	}
+	if !iter.memOk {
+		iter.key, iter.value, iter.keyOrigin = iter.backend.Key(), iter.backend.Value(), FromBack
+		return true
+	}
+	if !iter.backOk {
+		iter.key, iter.value, iter.keyOrigin = iter.memdb.Key(), iter.memdb.Value(), FromMem
+		return true
+	}
+	mkey, bkey := iter.memdb.Key(), iter.backend.Key()
+	cmp := iter.cmp.Compare(mkey, bkey)
+	if iter.dir == dirBackward {
+		cmp = -cmp
+	}
+	switch {
+	case cmp < 0:
+		iter.key, iter.value, iter.keyOrigin = mkey, iter.memdb.Value(), FromMem
+	case cmp == 0:
+		iter.key, iter.value, iter.keyOrigin = mkey, iter.memdb.Value(), FromBoth
+	default:
+		iter.key, iter.value, iter.keyOrigin = bkey, iter.backend.Value(), FromBack
+	}
 	return true
 }
 
+func (iter *JoinIter) Key() []byte {
+	return iter.key
+}
+
+func (iter *JoinIter) Value() []byte {
+	return iter.value
+}
+
 func (iter *JoinIter) Release() {
 	iter.memdb.Release()
 	iter.backend.Release()
@@ -18,8 +18,10 @@
 package overlaydb
 
 import (
+	"bytes"
 	"encoding/binary"
 	"math/rand"
+	"sort"
 	"strconv"
 	"testing"
 
@@ -67,6 +69,86 @@ func TestNewOverlayDB(t *testing.T) {
 	}
 }
 
+func TestJoinIterBidirectional(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	store := leveldbstore.NewMemLevelDBStore()
+	N := 200
+	expect := make(map[string][]byte)
+	for i := 0; i < N; i += 1 + r.Intn(3) {
+		val := []byte("back" + strconv.Itoa(i))
+		assert.Nil(t, store.Put(makeKey(i), val))
+		expect[string(makeKey(i))] = val
+	}
+	assert.Nil(t, store.Put([]byte("other"), []byte("other")))
+	overlay := NewOverlayDB(store)
+	for i := 0; i < N/2; i++ {
+		key := makeKey(r.Intn(N))
+		if r.Intn(3) == 0 {
+			overlay.Delete(key)
+			delete(expect, string(key))
+		} else {
+			val := []byte("mem" + strconv.Itoa(i))
+			overlay.Put(key, val)
+			expect[string(key)] = val
+		}
+	}
+	var keys []string
+	for key := range expect {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	iter := overlay.NewIterator([]byte("key"))
+	defer iter.Release()
+	// position in keys, -1 is before the first key and len(keys) is after the last key
+	pos := -1
+	check := func(ok bool) {
+		assert.Equal(t, pos >= 0 && pos < len(keys), ok)
+		if ok {
+			assert.Equal(t, []byte(keys[pos]), iter.Key())
+			assert.Equal(t, expect[keys[pos]], iter.Value())
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		switch r.Intn(5) {
+		case 0:
+			if pos < len(keys) {
+				pos++
+			}
+			check(iter.Next())
+		case 1:
+			if pos >= 0 {
+				pos--
+			}
+			check(iter.Prev())
+		case 2:
+			pos = 0
+			check(iter.First())
+		case 3:
+			pos = len(keys) - 1
+			check(iter.Last())
+		case 4:
+			key := makeKey(r.Intn(N + 10))
+			pos = sort.Search(len(keys), func(i int) bool { return bytes.Compare([]byte(keys[i]), key) >= 0 })
+			check(iter.Seek(key))
+		}
+		assert.Nil(t, iter.Error())
+	}
+
+	// reverse scan from a new iterator
+	var reversed []string
+	iter2 := overlay.NewIterator([]byte("key"))
+	assert.False(t, iter2.Prev())
+	for ok := iter2.Last(); ok; ok = iter2.Prev() {
+		reversed = append(reversed, string(iter2.Key()))
+	}
+	iter2.Release()
+	assert.Equal(t, len(keys), len(reversed))
+	for i := range reversed {
+		assert.Equal(t, keys[len(keys)-1-i], reversed[i])
+	}
+}
+
 func BenchmarkOverlayDBSerialPut(b *testing.B) {
 	store := leveldbstore.NewMemLevelDBStore()
 
@@ -0,0 +1,126 @@
+//go:build pebble
+// +build pebble
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pebblestore
+
+import (
+	"github.com/cockroachdb/pebble"
+)
+
+//Iterator of pebble store. Like leveldb iterator, it is before the first key when created, so the first Next moves
+//it to the first key and Prev returns false.
+type Iterator struct {
+	iter       *pebble.Iterator
+	positioned bool
+	err        error
+}
+
+func newIterator(reader pebble.Reader, start, limit []byte) *Iterator {
+	opts := &pebble.IterOptions{LowerBound: start, UpperBound: limit}
+	return &Iterator{iter: reader.NewIter(opts)}
+}
+
+//Next moves to the next key, return false if there is no more key
+func (self *Iterator) Next() bool {
+	if self.iter == nil {
+		return false
+	}
+	if !self.positioned {
+		return self.First()
+	}
+	return self.iter.Next()
+}
+
+//Prev moves to the previous key, return false if there is no more key
+func (self *Iterator) Prev() bool {
+	if self.iter == nil {
+		return false
+	}
+	if !self.positioned {
+		return false
+	}
+	return self.iter.Prev()
+}
+
+//First moves to the first key
+func (self *Iterator) First() bool {
+	if self.iter == nil {
+		return false
+	}
+	self.positioned = true
+	return self.iter.First()
+}
+
+//Last moves to the last key
+func (self *Iterator) Last() bool {
+	if self.iter == nil {
+		return false
+	}
+	self.positioned = true
+	return self.iter.Last()
+}
+
+//Seek moves to the first key which is greater than or equal to key
+func (self *Iterator) Seek(key []byte) bool {
+	if self.iter == nil {
+		return false
+	}
+	self.positioned = true
+	return self.iter.SeekGE(key)
+}
+
+//Key return the current key, which is only valid until the iterator moves
+func (self *Iterator) Key() []byte {
+	if self.iter == nil || !self.iter.Valid() {
+		return nil
+	}
+	return self.iter.Key()
+}
+
+//Value return the current value, which is only valid until the iterator moves
+func (self *Iterator) Value() []byte {
+	if self.iter == nil || !self.iter.Valid() {
+		return nil
+	}
+	return self.iter.Value()
+}
+
+//Release the iterator
+func (self *Iterator) Release() {
+	if self.iter == nil {
+		return
+	}
+	if err := self.iter.Close(); err != nil && self.err == nil {
+		self.err = err
+	}
+	self.iter = nil
+}
+
+//Error return the error met by the iterator
+func (self *Iterator) Error() error {
+	if self.err != nil {
+		return self.err
+	}
+	if self.iter == nil {
+		return nil
+	}
+	return self.iter.Error()
+}
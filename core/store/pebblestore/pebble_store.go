@@ -0,0 +1,262 @@
+//go:build pebble
+// +build pebble
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pebblestore
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/ethereum/go-ethereum/common/fdlimit"
+	"github.com/ontio/ontology/core/store/common"
+)
+
+// used to compute the size of bloom filter bits array, same as leveldb store.
+const BITSPERKEY = 10
+
+//size of block cache
+const CACHE_SIZE = 128 << 20
+
+func init() {
+	common.RegisterEngine(common.ENGINE_PEBBLE, func(dir string) (common.EngineStore, error) {
+		return NewPebbleStore(dir)
+	})
+}
+
+//Pebble store
+type PebbleStore struct {
+	db    *pebble.DB // Pebble instance
+	batch *pebble.Batch
+}
+
+func newOptions() *pebble.Options {
+	maxOpenFiles := 1000
+	if limit, err := fdlimit.Current(); err == nil && limit/2 < maxOpenFiles {
+		maxOpenFiles = limit / 2
+	}
+	if maxOpenFiles < 16 {
+		maxOpenFiles = 16
+	}
+	opts := &pebble.Options{
+		MaxOpenFiles: maxOpenFiles,
+		Levels:       make([]pebble.LevelOptions, 7),
+	}
+	for i := range opts.Levels {
+		opts.Levels[i].FilterPolicy = bloom.FilterPolicy(BITSPERKEY)
+		opts.Levels[i].FilterType = pebble.TableFilter
+	}
+	return opts
+}
+
+//NewPebbleStore return PebbleStore instance
+func NewPebbleStore(dir string) (*PebbleStore, error) {
+	opts := newOptions()
+	cache := pebble.NewCache(CACHE_SIZE)
+	defer cache.Unref()
+	opts.Cache = cache
+	db, err := pebble.Open(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &PebbleStore{db: db}, nil
+}
+
+//NewMemPebbleStore return PebbleStore instance in memory, for test
+func NewMemPebbleStore() *PebbleStore {
+	opts := newOptions()
+	opts.FS = vfs.NewMem()
+	db, err := pebble.Open("", opts)
+	if err != nil {
+		panic(err)
+	}
+	return &PebbleStore{db: db}
+}
+
+func get(reader pebble.Reader, key []byte) ([]byte, error) {
+	dat, closer, err := reader.Get(key)
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	defer closer.Close()
+	value := make([]byte, len(dat))
+	copy(value, dat)
+	return value, nil
+}
+
+//Put a key-value pair to pebble
+func (self *PebbleStore) Put(key []byte, value []byte) error {
+	return self.db.Set(key, value, pebble.NoSync)
+}
+
+//Get the value of a key from pebble
+func (self *PebbleStore) Get(key []byte) ([]byte, error) {
+	return get(self.db, key)
+}
+
+//Has return whether the key is exist in pebble
+func (self *PebbleStore) Has(key []byte) (bool, error) {
+	_, closer, err := self.db.Get(key)
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, closer.Close()
+}
+
+//Delete the the in pebble
+func (self *PebbleStore) Delete(key []byte) error {
+	return self.db.Delete(key, pebble.NoSync)
+}
+
+//NewBatch start commit batch
+func (self *PebbleStore) NewBatch() {
+	self.batch = self.db.NewBatch()
+}
+
+//BatchPut put a key-value pair to pebble batch
+func (self *PebbleStore) BatchPut(key []byte, value []byte) {
+	self.batch.Set(key, value, nil)
+}
+
+//BatchDelete delete a key to pebble batch
+func (self *PebbleStore) BatchDelete(key []byte) {
+	self.batch.Delete(key, nil)
+}
+
+//BatchCommit commit batch to pebble
+func (self *PebbleStore) BatchCommit() error {
+	err := self.batch.Commit(pebble.NoSync)
+	if err != nil {
+		return err
+	}
+	self.batch.Close()
+	self.batch = nil
+	return nil
+}
+
+//pebbleBatch adapts pebble batch to common.WriteBatch
+type pebbleBatch struct {
+	batch *pebble.Batch
+}
+
+func (self *pebbleBatch) Put(key []byte, value []byte) {
+	self.batch.Set(key, value, nil)
+}
+
+func (self *pebbleBatch) Delete(key []byte) {
+	self.batch.Delete(key, nil)
+}
+
+func (self *pebbleBatch) Len() int {
+	return int(self.batch.Count())
+}
+
+func (self *pebbleBatch) Reset() {
+	self.batch.Reset()
+}
+
+//NewWriteBatch return a new empty pebble batch
+func (self *PebbleStore) NewWriteBatch() common.WriteBatch {
+	return &pebbleBatch{batch: self.db.NewBatch()}
+}
+
+//WriteBatch commit the batch in one atomic write, independent of the batch started by NewBatch
+func (self *PebbleStore) WriteBatch(batch common.WriteBatch) error {
+	b, ok := batch.(*pebbleBatch)
+	if !ok {
+		return fmt.Errorf("invalid pebble batch type %T", batch)
+	}
+	return b.batch.Commit(pebble.NoSync)
+}
+
+//CompactRange compact the underlying storage of the keys with the prefix, to reclaim the space of deleted keys
+func (self *PebbleStore) CompactRange(prefix []byte) error {
+	limit := prefixLimit(prefix)
+	if limit == nil {
+		// pebble requires an upper bound, the keys of ledger stores never start with 0xff
+		limit = []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	}
+	return self.db.Compact(prefix, limit)
+}
+
+//Close pebble
+func (self *PebbleStore) Close() error {
+	if self.batch != nil {
+		self.batch.Close()
+		self.batch = nil
+	}
+	return self.db.Close()
+}
+
+//NewIterator return a iterator of pebble with the key prefix
+func (self *PebbleStore) NewIterator(prefix []byte) common.StoreIterator {
+	return newIterator(self.db, prefix, prefixLimit(prefix))
+}
+
+//PebbleSnapshot is a read only view of pebble at the time it is created
+type PebbleSnapshot struct {
+	snapshot *pebble.Snapshot
+}
+
+//NewSnapshot return a snapshot of the current pebble, which must be released after use
+func (self *PebbleStore) NewSnapshot() (common.StoreSnapshot, error) {
+	return &PebbleSnapshot{snapshot: self.db.NewSnapshot()}, nil
+}
+
+//Get the value of a key from the snapshot
+func (self *PebbleSnapshot) Get(key []byte) ([]byte, error) {
+	return get(self.snapshot, key)
+}
+
+//NewIterator return a iterator of the snapshot with the key prefix
+func (self *PebbleSnapshot) NewIterator(prefix []byte) common.StoreIterator {
+	return newIterator(self.snapshot, prefix, prefixLimit(prefix))
+}
+
+//NewRangeIterator return a iterator of the snapshot over the keys in [start, limit), nil limit means no upper bound
+func (self *PebbleSnapshot) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	return newIterator(self.snapshot, start, limit)
+}
+
+//Release the snapshot
+func (self *PebbleSnapshot) Release() {
+	self.snapshot.Close()
+}
+
+//prefixLimit return the smallest key after all the keys with the prefix, nil if there is no such key
+func prefixLimit(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			limit := make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
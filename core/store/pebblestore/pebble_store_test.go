@@ -0,0 +1,126 @@
+//go:build pebble
+// +build pebble
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pebblestore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPebbleStore(t *testing.T) {
+	store := NewMemPebbleStore()
+	defer store.Close()
+
+	assert.Nil(t, store.Put([]byte("foo"), []byte("bar")))
+	v, err := store.Get([]byte("foo"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("bar"), v)
+	assert.Nil(t, store.Delete([]byte("foo")))
+	has, err := store.Has([]byte("foo"))
+	assert.Nil(t, err)
+	assert.False(t, has)
+	_, err = store.Get([]byte("foo"))
+	assert.Equal(t, common.ErrNotFound, err)
+
+	store.NewBatch()
+	store.BatchPut([]byte("foo1"), []byte("bar1"))
+	store.BatchPut([]byte("foo2"), []byte("bar2"))
+	store.BatchPut([]byte("foo3"), []byte("bar3"))
+	store.BatchPut([]byte("fop"), []byte("bar"))
+	assert.Nil(t, store.BatchCommit())
+
+	batch := store.NewWriteBatch()
+	batch.Delete([]byte("foo3"))
+	assert.Equal(t, 1, batch.Len())
+	assert.Nil(t, store.WriteBatch(batch))
+	has, err = store.Has([]byte("foo3"))
+	assert.Nil(t, err)
+	assert.False(t, has)
+}
+
+func TestPebbleIterator(t *testing.T) {
+	store := NewMemPebbleStore()
+	defer store.Close()
+	for _, key := range []string{"fo", "foo1", "foo2", "foo3", "fop"} {
+		assert.Nil(t, store.Put([]byte(key), []byte(key)))
+	}
+
+	var keys []string
+	iter := store.NewIterator([]byte("foo")).(*Iterator)
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	assert.Equal(t, []string{"foo1", "foo2", "foo3"}, keys)
+
+	keys = nil
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		keys = append(keys, string(iter.Key()))
+	}
+	assert.Equal(t, []string{"foo3", "foo2", "foo1"}, keys)
+
+	assert.True(t, iter.Seek([]byte("foo15")))
+	assert.Equal(t, []byte("foo2"), iter.Key())
+	assert.False(t, iter.Seek([]byte("foo4")))
+	iter.Release()
+	assert.Nil(t, iter.Error())
+
+	iter = store.NewIterator([]byte("foo")).(*Iterator)
+	assert.False(t, iter.Prev())
+	assert.True(t, iter.Next())
+	assert.Equal(t, []byte("foo1"), iter.Key())
+	iter.Release()
+}
+
+func TestPebbleSnapshot(t *testing.T) {
+	store := NewMemPebbleStore()
+	defer store.Close()
+	assert.Nil(t, store.Put([]byte("snap1"), []byte("v1")))
+	snapshot, err := store.NewSnapshot()
+	assert.Nil(t, err)
+	defer snapshot.Release()
+
+	assert.Nil(t, store.Put([]byte("snap1"), []byte("v2")))
+	assert.Nil(t, store.Put([]byte("snap2"), []byte("v2")))
+
+	v, err := snapshot.Get([]byte("snap1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v1"), v)
+	_, err = snapshot.Get([]byte("snap2"))
+	assert.Equal(t, common.ErrNotFound, err)
+
+	count := 0
+	iter := snapshot.NewRangeIterator([]byte("snap1"), nil)
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	assert.Equal(t, 1, count)
+}
+
+func TestPrefixLimit(t *testing.T) {
+	assert.Equal(t, []byte("fop"), prefixLimit([]byte("foo")))
+	assert.Equal(t, []byte{0x02}, prefixLimit([]byte{0x01, 0xff}))
+	assert.Nil(t, prefixLimit([]byte{0xff, 0xff}))
+	assert.Nil(t, prefixLimit(nil))
+}
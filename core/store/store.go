@@ -25,14 +25,34 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/store/overlaydb"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
 	types3 "github.com/ontio/ontology/smartcontract/service/evm/types"
 	cstates "github.com/ontio/ontology/smartcontract/states"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 )
 
+//SnapshotWriter receive the content of the state snapshot exported from the ledger
+type SnapshotWriter interface {
+	WriteManifest(manifest *scom.SnapshotManifest, blocks []*types.Block) error
+	WriteHeaders(blockHashes []common.Uint256, txRoots []common.Uint256) error
+	WriteStates(kvs []*scom.SnapshotKV) error
+}
+
+//HeaderVerifier check the headers following the current block ahead of submitting their blocks, so that the
+//bookkeeper signatures, which do not depend on the ledger state, can be verified in parallel
+type HeaderVerifier interface {
+	//Follow check the header following the previous one in order except the bookkeeper signatures, and return the
+	//number of bookkeeper signatures required by the chain config at its height
+	Follow(header *types.Header) (int, error)
+	//VerifySig verify the bookkeeper signatures of the header checked by Follow, it is safe to call concurrently.
+	//The block of a verified header skips the signature verification when submitted.
+	VerifySig(header *types.Header, m int) error
+}
+
 type ExecuteResult struct {
 	WriteSet        *overlaydb.MemDB
 	Hash            common.Uint256
@@ -50,6 +70,7 @@ type LedgerStore interface {
 	AddBlock(block *types.Block, ccMsg *types.CrossChainMsg, stateMerkleRoot common.Uint256) error
 	ExecuteBlock(b *types.Block) (ExecuteResult, error)                                       // called by consensus
 	SubmitBlock(b *types.Block, crossChainMsg *types.CrossChainMsg, exec ExecuteResult) error // called by consensus
+	NewHeaderVerifier() (HeaderVerifier, error)
 	GetStateMerkleRoot(height uint32) (result common.Uint256, err error)
 	GetCurrentBlockHash() common.Uint256
 	GetCurrentBlockHeight() uint32
@@ -71,9 +92,26 @@ type LedgerStore interface {
 	GetStorageItem(codeHash common.Address, key []byte) ([]byte, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
 	PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*cstates.PreExecResult, uint32, error)
+	PreExecuteContractWithTracer(tx *types.Transaction, tracer trace.Tracer) (*cstates.PreExecResult, error)
+	TraceTransaction(txHash common.Uint256, tracer trace.Tracer) (*event.ExecuteNotify, error)
 	PreExecuteEip155Tx(msg types2.Message) (*types3.ExecutionResult, error)
+	PreExecuteEip155TxAtHeight(msg types2.Message, height uint32) (*types3.ExecutionResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
+	GetBloomByBlock(height uint32) (types2.Bloom, error)
+	GetAddressTransactions(addr common.Address, offset, limit uint32) ([]*scom.AddressTx, error)
+	GetAddressTransfers(addr common.Address, offset, limit uint32) ([]*scom.AddressTransfer, error)
+	RebuildAddressIndex(startHeight uint32, progress func(height uint32)) error
+	GetContractEvents(contract common.Address, from scom.ContractEventCursor, endHeight uint32, name string,
+		limit uint32) ([]*scom.ContractEvent, *scom.ContractEventCursor, error)
+	GetSnapshotManifest() (*scom.SnapshotManifest, []*types.Block, error)
+	GetSnapshotChunk(height uint32, start []byte) ([]*scom.SnapshotKV, []byte, error)
+	BeginSnapshotImport(manifest *scom.SnapshotManifest, blocks []*types.Block) error
+	ImportSnapshotChunk(kvs []*scom.SnapshotKV) error
+	FinishSnapshotImport() error
+	BeginSnapshotImportWithHeaders(manifest *scom.SnapshotManifest, blocks []*types.Block, blockHashes []common.Uint256,
+		txRoots []common.Uint256) error
+	ExportSnapshot(writer SnapshotWriter) error
 	GetEthCode(hash common2.Hash) ([]byte, error)
 	GetEthState(address common2.Address, key common2.Hash) ([]byte, error)
 	GetEthAccount(address common2.Address) (*storage.EthAccount, error)
@@ -82,6 +120,9 @@ type LedgerStore interface {
 	GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error)
 	GetCrossStatesProof(height uint32, key []byte) ([]byte, error)
 	EnableBlockPrune(numBeforeCurr uint32)
+	EnableStateGC(numBeforeCurr uint32)
 	//expose the cache db
 	GetCacheDB() *storage.CacheDB
+	GetCacheDBAtHeight(height uint32) (*storage.CacheDB, error)
+	GetCacheDBBeforeTx(txHash common.Uint256) (*storage.CacheDB, *types.Block, uint32, error)
 }
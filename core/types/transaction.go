@@ -79,10 +79,8 @@ func TransactionFromRawBytes(raw []byte) (*Transaction, error) {
 }
 
 func TransactionFromEIP155(eiptx *types.Transaction) (*Transaction, error) {
-	if CheckChainID {
-		if eiptx.ChainId().Cmp(big.NewInt(int64(config.DefConfig.P2PNode.EVMChainId))) != 0 {
-			return nil, fmt.Errorf("invalid chain id, want: %d, got: %d", config.DefConfig.P2PNode.EVMChainId, eiptx.ChainId())
-		}
+	if err := checkEthChainID(eiptx.ChainId()); err != nil {
+		return nil, err
 	}
 
 	signer := types.NewEIP155Signer(eiptx.ChainId())
@@ -91,8 +89,52 @@ func TransactionFromEIP155(eiptx *types.Transaction) (*Transaction, error) {
 		return nil, fmt.Errorf("error EIP155 get sender:%s", err.Error())
 	}
 
-	addr := common.Address(from)
+	return newEthTransaction(&payload.EIP155Code{EIPTx: eiptx}, common.Address(from),
+		common.Uint256(signer.Hash(eiptx)), common.Uint256(eiptx.Hash()))
+}
 
+//TransactionFromEthTypedTx converts the EIP-2930 or EIP-1559 transaction, GasPrice is set to the effective gas price
+func TransactionFromEthTypedTx(typed *payload.EthTypedTx) (*Transaction, error) {
+	if err := checkEthChainID(typed.ChainID); err != nil {
+		return nil, err
+	}
+
+	from, err := typed.Sender()
+	if err != nil {
+		return nil, fmt.Errorf("error typed eth tx get sender:%s", err.Error())
+	}
+
+	return newEthTransaction(&payload.EIP155Code{EIPTx: typed.AsLegacyTx(), TypedTx: typed}, common.Address(from),
+		common.Uint256(typed.SigHash()), common.Uint256(typed.Hash()))
+}
+
+//TransactionFromEthRawBytes decodes the legacy rlp or typed envelope sent by eth_sendRawTransaction
+func TransactionFromEthRawBytes(data []byte) (*Transaction, error) {
+	if payload.IsEthTypedTx(data) {
+		typed, err := payload.DecodeEthTypedTx(data)
+		if err != nil {
+			return nil, err
+		}
+		return TransactionFromEthTypedTx(typed)
+	}
+	eiptx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, eiptx); err != nil {
+		return nil, err
+	}
+	return TransactionFromEIP155(eiptx)
+}
+
+func checkEthChainID(chainId *big.Int) error {
+	if CheckChainID {
+		if chainId.Cmp(big.NewInt(int64(config.DefConfig.P2PNode.EVMChainId))) != 0 {
+			return fmt.Errorf("invalid chain id, want: %d, got: %d", config.DefConfig.P2PNode.EVMChainId, chainId)
+		}
+	}
+	return nil
+}
+
+func newEthTransaction(pl *payload.EIP155Code, payer common.Address, hashUnsigned, hash common.Uint256) (*Transaction, error) {
+	eiptx := pl.EIPTx
 	if eiptx.Nonce() > uint64(math.MaxUint32) || !eiptx.GasPrice().IsUint64() {
 		return nil, fmt.Errorf("nonce :%d or GasPrice :%d is too big", eiptx.Nonce(), eiptx.GasPrice())
 	}
@@ -103,23 +145,19 @@ func TransactionFromEIP155(eiptx *types.Transaction) (*Transaction, error) {
 		Nonce:                uint32(eiptx.Nonce()),
 		GasPrice:             eiptx.GasPrice().Uint64(),
 		GasLimit:             eiptx.Gas(),
-		Payer:                addr,
-		Payload:              &payload.EIP155Code{EIPTx: eiptx},
-		hashUnsigned:         common.Uint256(signer.Hash(eiptx)),
-		hash:                 common.Uint256(eiptx.Hash()),
-		SignedAddr:           []common.Address{addr},
+		Payer:                payer,
+		Payload:              pl,
+		hashUnsigned:         hashUnsigned,
+		hash:                 hash,
+		SignedAddr:           []common.Address{payer},
 		nonDirectConstracted: true,
 	}
 
-	//raw = version + txtype + rlp(ethtx)
-	raw, err := rlp.EncodeToBytes(eiptx)
-	if err != nil {
-		return nil, fmt.Errorf("error EIP155 EncodeToBytes %s", err.Error())
-	}
+	//raw = version + txtype + rlp(ethtx), or the typed envelope for typed eth tx
 	sink := new(common.ZeroCopySink)
 	sink.WriteByte(retTx.Version)
 	sink.WriteByte(byte(retTx.TxType))
-	sink.WriteVarBytes(raw)
+	pl.Serialization(sink)
 
 	retTx.Raw = sink.Bytes()
 
@@ -130,6 +168,8 @@ func (tx *Transaction) IsEipTx() bool {
 	return tx.TxType == EIP155
 }
 
+//GetEIP155Tx returns the eth transaction, it is an unsigned legacy transaction with the effective gas price
+//if the transaction is typed, whose sender is the Payer.
 func (tx *Transaction) GetEIP155Tx() (*types.Transaction, error) {
 	if tx.TxType == EIP155 {
 		tx := tx.Payload.(*payload.EIP155Code).EIPTx
@@ -138,6 +178,27 @@ func (tx *Transaction) GetEIP155Tx() (*types.Transaction, error) {
 	return nil, fmt.Errorf("not a EIP155 tx")
 }
 
+//GetEthTypedTx returns the EIP-2930 or EIP-1559 transaction, nil is returned for legacy eth transaction
+func (tx *Transaction) GetEthTypedTx() *payload.EthTypedTx {
+	if tx.TxType == EIP155 {
+		return tx.Payload.(*payload.EIP155Code).TypedTx
+	}
+	return nil
+}
+
+//CheckEthTypedTx checks whether the transaction can be executed in the block at height. Typed eth transactions are
+//only accepted from the activation height.
+func (tx *Transaction) CheckEthTypedTx(height uint32) error {
+	typed := tx.GetEthTypedTx()
+	if typed == nil {
+		return nil
+	}
+	if height < config.GetEthTypedTxHeight() {
+		return fmt.Errorf("typed eth transaction is not activated at height %d", height)
+	}
+	return nil
+}
+
 func isEip155TxBytes(source *common.ZeroCopySource) bool {
 	prefix, eof := source.NextBytes(2)
 	if eof {
@@ -165,7 +226,12 @@ func (tx *Transaction) decodeEip155(source *common.ZeroCopySource) error {
 		return err
 	}
 
-	decoded, err := TransactionFromEIP155(pl.EIPTx)
+	var decoded *Transaction
+	if pl.TypedTx != nil {
+		decoded, err = TransactionFromEthTypedTx(pl.TypedTx)
+	} else {
+		decoded, err = TransactionFromEIP155(pl.EIPTx)
+	}
 	if err != nil {
 		return err
 	}
@@ -482,6 +548,10 @@ func (tx *Transaction) Hash() common.Uint256 {
 // and take the chain id of ontology as 0.
 func (tx *Transaction) SigHashForChain(id uint32) common.Uint256 {
 	if tx.IsEipTx() {
+		// chain id is signed in the typed transaction
+		if typed := tx.GetEthTypedTx(); typed != nil {
+			return common.Uint256(typed.SigHash())
+		}
 		eiptx, err := tx.GetEIP155Tx()
 		if err != nil {
 			panic(err)
@@ -103,3 +103,93 @@ func Test_EIP155Tx(t *testing.T) {
 	assert.Equal(t, otx.Version, tx.Version)
 	assert.Equal(t, otx.Raw, tx.Raw)
 }
+
+func signTypedTx(typed *payload.EthTypedTx) ethcomm.Address {
+	privateKey, _ := crypto.HexToECDSA("fad9c8855b740a0b7ed4c221dbad0f33a83a49cad6b3fe8d5817ac83d38b6a19")
+	hash := typed.SigHash()
+	sig, err := crypto.Sign(hash[:], privateKey)
+	Ensure(err)
+	typed.R = new(big.Int).SetBytes(sig[:32])
+	typed.S = new(big.Int).SetBytes(sig[32:64])
+	typed.V = big.NewInt(int64(sig[64]))
+	return crypto.PubkeyToAddress(privateKey.PublicKey)
+}
+
+func Test_EthTypedTx(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+	toAddress := ethcomm.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
+	typed := &payload.EthTypedTx{
+		Type:      payload.DynamicFeeTxType,
+		ChainID:   big.NewInt(1234),
+		Nonce:     1,
+		GasTipCap: big.NewInt(2500),
+		GasFeeCap: big.NewInt(5000),
+		Gas:       21000,
+		To:        &toAddress,
+		Value:     big.NewInt(1000000000),
+		AccessList: payload.AccessList{
+			{Address: toAddress, StorageKeys: []ethcomm.Hash{{0x01}}},
+		},
+	}
+	from := signTypedTx(typed)
+
+	otx, err := TransactionFromEthRawBytes(typed.MarshalBinary())
+	assert.Nil(t, err)
+	assert.Equal(t, common.Address(from), otx.Payer)
+	assert.Equal(t, uint64(2500), otx.GasPrice)
+	assert.Equal(t, uint32(1), otx.Nonce)
+	assert.Equal(t, common.Uint256(typed.Hash()), otx.Hash())
+	eiptx, err := otx.GetEIP155Tx()
+	assert.Nil(t, err)
+	assert.Equal(t, &toAddress, eiptx.To())
+	assert.Equal(t, typed.Value, eiptx.Value())
+
+	tx, err := TransactionFromRawBytes(otx.ToArray())
+	assert.Nil(t, err)
+	assert.Equal(t, otx.Raw, tx.Raw)
+	assert.Equal(t, otx.Hash(), tx.Hash())
+	assert.Equal(t, otx.Payer, tx.Payer)
+	assert.Equal(t, typed.AccessList, tx.GetEthTypedTx().AccessList)
+	assert.Nil(t, tx.CheckEthTypedTx(1))
+
+	// access list transaction of contract creation pays its gas price
+	typed = &payload.EthTypedTx{
+		Type:     payload.AccessListTxType,
+		ChainID:  big.NewInt(1234),
+		GasPrice: big.NewInt(3000),
+		Gas:      100000,
+		Value:    big.NewInt(0),
+		Data:     []byte{0x60, 0x00},
+	}
+	signTypedTx(typed)
+	otx, err = TransactionFromEthRawBytes(typed.MarshalBinary())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3000), otx.GasPrice)
+	eiptx, _ = otx.GetEIP155Tx()
+	assert.Nil(t, eiptx.To())
+	assert.Nil(t, otx.CheckEthTypedTx(1))
+	// typed transaction is executed from the activation height
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	assert.NotNil(t, otx.CheckEthTypedTx(1))
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+
+	typed.ChainID = big.NewInt(1)
+	signTypedTx(typed)
+	_, err = TransactionFromEthRawBytes(typed.MarshalBinary())
+	assert.NotNil(t, err)
+
+	typed = &payload.EthTypedTx{
+		Type:      payload.DynamicFeeTxType,
+		ChainID:   big.NewInt(1234),
+		GasTipCap: big.NewInt(5000),
+		GasFeeCap: big.NewInt(2500),
+		Gas:       21000,
+		To:        &toAddress,
+		Value:     big.NewInt(0),
+	}
+	signTypedTx(typed)
+	_, err = TransactionFromEthRawBytes(typed.MarshalBinary())
+	assert.NotNil(t, err)
+}
@@ -25,6 +25,7 @@ import (
 const (
 	TOPIC_SAVE_BLOCK_COMPLETE = "svblkcmp"
 	TOPIC_SMART_CODE_EVENT    = "scevt"
+	TOPIC_TXPOOL_ADD_TX       = "txpooladdtx"
 )
 
 type SaveBlockCompleteMsg struct {
@@ -35,6 +36,10 @@ type SmartCodeEventMsg struct {
 	Event *types.SmartCodeEvent
 }
 
+type TxPoolAddTxMsg struct {
+	Tx *types.Transaction
+}
+
 type BlockConsensusComplete struct {
 	Block *types.Block
 }
@@ -6,6 +6,7 @@ require (
 	github.com/JohnCGriffin/overflow v0.0.0-20170615021017-4d914c927216
 	github.com/Workiva/go-datastructures v1.0.50
 	github.com/blang/semver v3.5.1+incompatible
+	github.com/cockroachdb/pebble v0.0.0-20201001221639-879f3bfeef07
 	github.com/emirpasic/gods v1.12.0 // indirect
 	github.com/ethereum/go-ethereum v1.9.25
 	github.com/gammazero/workerpool v1.1.2
@@ -27,7 +28,7 @@ require (
 	github.com/prometheus/client_golang v0.9.1
 	github.com/scylladb/go-set v1.0.2
 	github.com/status-im/keycard-go v0.0.0-20190316090335-8537d3370df4
-	github.com/stretchr/testify v1.4.0
+	github.com/stretchr/testify v1.6.1
 	github.com/syndtr/goleveldb v1.0.1-0.20200815110645-5c35d600f0ca
 	github.com/urfave/cli v1.22.1
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
@@ -19,7 +19,13 @@
 package actor
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common/config"
 	cactor "github.com/ontio/ontology/consensus/actor"
 )
 
@@ -44,3 +50,22 @@ func ConsensusSrvHalt() error {
 	}
 	return nil
 }
+
+//GetConsensusStatus from the vbft consensus actor
+func GetConsensusStatus() (*cactor.ConsensusStatus, error) {
+	if consensusSrvPid == nil {
+		return nil, errors.New("consensus not started")
+	}
+	if strings.ToLower(config.DefConfig.Genesis.ConsensusType) != config.CONSENSUS_TYPE_VBFT {
+		return nil, fmt.Errorf("consensus status not supported by %s", config.DefConfig.Genesis.ConsensusType)
+	}
+	result, err := consensusSrvPid.RequestFuture(&cactor.GetConsensusStatus{}, REQ_TIMEOUT*time.Second).Result()
+	if err != nil {
+		return nil, err
+	}
+	status, ok := result.(*cactor.ConsensusStatus)
+	if !ok {
+		return nil, fmt.Errorf("unexpected consensus status %T", result)
+	}
+	return status, nil
+}
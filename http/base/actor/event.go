@@ -27,6 +27,7 @@ import (
 type EventActor struct {
 	blockPersistCompleted func(v interface{})
 	smartCodeEvt          func(v interface{})
+	txPoolAddTx           func(v interface{})
 }
 
 //receive from subscribed actor
@@ -36,17 +37,21 @@ func (t *EventActor) Receive(c actor.Context) {
 		t.blockPersistCompleted(*msg.Block)
 	case *message.SmartCodeEventMsg:
 		t.smartCodeEvt(*msg.Event)
+	case *message.TxPoolAddTxMsg:
+		t.txPoolAddTx(msg.Tx)
 	default:
 	}
 }
 
-//Subscribe save block complete and smartcontract Event
+//Subscribe save block complete, smartcontract Event and tx pool new tx
 func SubscribeEvent(topic string, handler func(v interface{})) {
 	var props = actor.FromProducer(func() actor.Actor {
 		if topic == message.TOPIC_SAVE_BLOCK_COMPLETE {
 			return &EventActor{blockPersistCompleted: handler}
 		} else if topic == message.TOPIC_SMART_CODE_EVENT {
 			return &EventActor{smartCodeEvt: handler}
+		} else if topic == message.TOPIC_TXPOOL_ADD_TX {
+			return &EventActor{txPoolAddTx: handler}
 		} else {
 			return &EventActor{}
 		}
@@ -19,16 +19,26 @@
 package actor
 
 import (
+	"fmt"
+
 	common2 "github.com/ethereum/go-ethereum/common"
 	types2 "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
+	evm2 "github.com/ontio/ontology/smartcontract/service/evm"
 	types3 "github.com/ontio/ontology/smartcontract/service/evm/types"
+	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
 	cstate "github.com/ontio/ontology/smartcontract/states"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
+	"github.com/ontio/ontology/vm/evm"
+	"github.com/ontio/ontology/vm/evm/params"
 )
 
 const (
@@ -41,6 +51,11 @@ func GetHeaderByHeight(height uint32) (*types.Header, error) {
 	return ledger.DefLedger.GetHeaderByHeight(height)
 }
 
+//GetHeaderByHash from ledger
+func GetHeaderByHash(hash common.Uint256) (*types.Header, error) {
+	return ledger.DefLedger.GetHeaderByHash(hash)
+}
+
 //GetBlockByHeight from ledger
 func GetBlockByHeight(height uint32) (*types.Block, error) {
 	return ledger.DefLedger.GetBlockByHeight(height)
@@ -98,6 +113,16 @@ func PreExecuteContractBatch(tx []*types.Transaction, atomic bool) ([]*cstate.Pr
 	return ledger.DefLedger.PreExecuteContractBatch(tx, atomic)
 }
 
+//PreExecuteContractWithTracer from ledger
+func PreExecuteContractWithTracer(tx *types.Transaction, tracer trace.Tracer) (*cstate.PreExecResult, error) {
+	return ledger.DefLedger.PreExecuteContractWithTracer(tx, tracer)
+}
+
+//TraceTransaction re-execute the neovm or wasmvm invoke transaction on the state right before it with the tracer
+func TraceTransaction(txHash common.Uint256, tracer trace.Tracer) (*event.ExecuteNotify, error) {
+	return ledger.DefLedger.TraceTransaction(txHash, tracer)
+}
+
 //GetEventNotifyByTxHash from ledger
 func GetEventNotifyByTxHash(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByTx(txHash)
@@ -108,6 +133,27 @@ func GetEventNotifyByHeight(height uint32) ([]*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByBlock(height)
 }
 
+//GetBloomByHeight from ledger
+func GetBloomByHeight(height uint32) (types2.Bloom, error) {
+	return ledger.DefLedger.GetBloomByBlock(height)
+}
+
+//GetAddressTransactions from ledger
+func GetAddressTransactions(addr common.Address, offset, limit uint32) ([]*scom.AddressTx, error) {
+	return ledger.DefLedger.GetAddressTransactions(addr, offset, limit)
+}
+
+//GetAddressTransfers from ledger
+func GetAddressTransfers(addr common.Address, offset, limit uint32) ([]*scom.AddressTransfer, error) {
+	return ledger.DefLedger.GetAddressTransfers(addr, offset, limit)
+}
+
+//GetContractEvents from ledger
+func GetContractEvents(contract common.Address, from scom.ContractEventCursor, endHeight uint32, name string,
+	limit uint32) ([]*scom.ContractEvent, *scom.ContractEventCursor, error) {
+	return ledger.DefLedger.GetContractEvents(contract, from, endHeight, name, limit)
+}
+
 //GetMerkleProof from ledger
 func GetMerkleProof(proofHeight uint32, rootHeight uint32) ([]common.Uint256, error) {
 	return ledger.DefLedger.GetMerkleProof(proofHeight, rootHeight)
@@ -121,6 +167,7 @@ func GetCrossStatesProof(height uint32, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetCrossStatesProof(height, key)
 }
 
+
 func GetEthAccount(address common2.Address) (*storage.EthAccount, error) {
 	return ledger.DefLedger.GetEthAccount(address)
 }
@@ -137,3 +184,51 @@ func PreExecuteEip155Tx(msg types2.Message) (*types3.ExecutionResult, error) {
 	res, err := ledger.DefLedger.PreExecuteEip155Tx(msg)
 	return res, err
 }
+
+func PreExecuteEip155TxAtHeight(msg types2.Message, height uint32) (*types3.ExecutionResult, error) {
+	return ledger.DefLedger.PreExecuteEip155TxAtHeight(msg, height)
+}
+
+//GetEthStateDBAtHeight return a read only eth state db of the state after block at height
+func GetEthStateDBAtHeight(height uint32) (*storage.StateDB, error) {
+	cache, err := ledger.DefLedger.GetCacheDBAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewStateDB(cache, common2.Hash{}, common2.Hash{}, ong.OngBalanceHandle{}), nil
+}
+
+//TraceEip155Tx re-execute the eth transaction on the state right before it with the tracer
+func TraceEip155Tx(txHash common.Uint256, tracer evm.Tracer) (*types3.ExecutionResult, error) {
+	cache, block, txIndex, err := ledger.DefLedger.GetCacheDBBeforeTx(txHash)
+	if err != nil {
+		return nil, err
+	}
+	tx := block.Transactions[txIndex]
+	if !tx.IsEipTx() {
+		return nil, fmt.Errorf("tx %s is not an eth transaction", txHash.ToHexString())
+	}
+	usedGas := uint64(0)
+	chainConfig := params.GetChainConfig(config.DefConfig.P2PNode.EVMChainId)
+	statedb := storage.NewStateDB(cache, common2.Hash(tx.Hash()), common2.Hash(block.Hash()), ong.OngBalanceHandle{})
+	result, _, err := evm2.ApplyTransaction(chainConfig, ledger.DefLedger, statedb, block.Header.Height,
+		block.Header.Timestamp, tx, &usedGas, utils.GovernanceContractAddress, evm.Config{Debug: true, Tracer: tracer}, true)
+	return result, err
+}
+
+//TraceEip155Call execute the eth message with the tracer on the state after block at height, as if it is in the next block
+func TraceEip155Call(msg types2.Message, height uint32, tracer evm.Tracer) (*types3.ExecutionResult, error) {
+	cache, err := ledger.DefLedger.GetCacheDBAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	header, err := ledger.DefLedger.GetHeaderByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig := params.GetChainConfig(config.DefConfig.P2PNode.EVMChainId)
+	blockContext := evm2.NewEVMBlockContext(height, header.Timestamp+1, ledger.DefLedger)
+	statedb := storage.NewStateDB(cache, common2.Hash{}, common2.Hash(header.Hash()), ong.OngBalanceHandle{})
+	vmenv := evm.NewEVM(blockContext, evm2.NewEVMTxContext(msg), statedb, chainConfig, evm.Config{Debug: true, Tracer: tracer})
+	return evm2.ApplyMessage(vmenv, msg, common2.Address(utils.GovernanceContractAddress))
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	bactor "github.com/ontio/ontology/http/base/actor"
+)
+
+type AddressTxInfo struct {
+	TxHash  string
+	Height  uint32
+	TxIndex uint32
+}
+
+type AddressTransferInfo struct {
+	TxHash          string
+	Height          uint32
+	TxIndex         uint32
+	ContractAddress string
+	From            string
+	To              string
+	Amount          string
+}
+
+//GetAddressTransactions return the transactions involving address from the address index
+func GetAddressTransactions(address common.Address, offset, limit uint32) ([]*AddressTxInfo, error) {
+	if limit > MAX_ADDRESS_INDEX_LIMIT {
+		limit = MAX_ADDRESS_INDEX_LIMIT
+	}
+	txs, err := bactor.GetAddressTransactions(address, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*AddressTxInfo, 0, len(txs))
+	for _, tx := range txs {
+		infos = append(infos, &AddressTxInfo{
+			TxHash:  tx.TxHash.ToHexString(),
+			Height:  tx.Height,
+			TxIndex: tx.TxIndex,
+		})
+	}
+	return infos, nil
+}
+
+//GetAddressTransfers return the ONT/ONG transfers involving address from the address index
+func GetAddressTransfers(address common.Address, offset, limit uint32) ([]*AddressTransferInfo, error) {
+	if limit > MAX_ADDRESS_INDEX_LIMIT {
+		limit = MAX_ADDRESS_INDEX_LIMIT
+	}
+	transfers, err := bactor.GetAddressTransfers(address, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*AddressTransferInfo, 0, len(transfers))
+	for _, transfer := range transfers {
+		infos = append(infos, &AddressTransferInfo{
+			TxHash:          transfer.TxHash.ToHexString(),
+			Height:          transfer.Height,
+			TxIndex:         transfer.TxIndex,
+			ContractAddress: transfer.Contract.ToHexString(),
+			From:            transfer.From.ToBase58(),
+			To:              transfer.To.ToBase58(),
+			Amount:          fmt.Sprintf("%d", transfer.Amount),
+		})
+	}
+	return infos, nil
+}
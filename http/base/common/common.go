@@ -22,8 +22,10 @@ package common
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -39,14 +41,19 @@ import (
 	bactor "github.com/ontio/ontology/http/base/actor"
 	common2 "github.com/ontio/ontology/p2pserver/common"
 	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/governance"
 	"github.com/ontio/ontology/smartcontract/service/native/ont"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
 	cstate "github.com/ontio/ontology/smartcontract/states"
+	"github.com/ontio/ontology/smartcontract/trace"
 	"github.com/ontio/ontology/vm/neovm"
 )
 
 const MAX_SEARCH_HEIGHT uint32 = 100
 const MAX_REQUEST_BODY_SIZE = 1 << 20
+const MAX_BATCH_SIZE = 100
+const MAX_ADDRESS_INDEX_LIMIT uint32 = 100
+const MAX_CONTRACT_EVENT_LIMIT uint32 = 100
 
 type BalanceOfRsp struct {
 	Ont    string `json:"ont"`
@@ -95,6 +102,17 @@ type PreExecuteResult struct {
 	Gas    uint64
 	Result interface{}
 	Notify []NotifyEventInfo
+	Trace  *trace.Frame `json:",omitempty"`
+}
+
+type TraceResult struct {
+	Notify ExecuteNotify
+	Trace  *trace.Frame
+}
+
+type TraceErrorResult struct {
+	Error string
+	Trace *trace.Frame
 }
 
 type NotifyEventInfo struct {
@@ -216,7 +234,23 @@ func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
 	for _, v := range obj.Notify {
 		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
 	}
-	return PreExecuteResult{obj.State, obj.Gas, obj.Result, evts}
+	return PreExecuteResult{State: obj.State, Gas: obj.Gas, Result: obj.Result, Notify: evts}
+}
+
+//ParseTraceConfig parse the trace config of json rpc param, the json object is decoded into trace.Config
+func ParseTraceConfig(param interface{}) (*trace.Config, error) {
+	cfg := &trace.Config{}
+	if param == nil {
+		return cfg, nil
+	}
+	raw, err := json.Marshal(param)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
 func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
@@ -364,6 +398,37 @@ func GetGrantOng(addr common.Address) (string, error) {
 	return fmt.Sprintf("%v", boundong), nil
 }
 
+//GetPeerPool return the current governance view and the peers in peer pool ordered by index
+func GetPeerPool() (uint32, []*governance.PeerPoolItem, error) {
+	value, err := ledger.DefLedger.GetStorageItem(utils.GovernanceContractAddress, []byte(governance.GOVERNANCE_VIEW))
+	if err != nil {
+		return 0, nil, fmt.Errorf("get governance view error:%s", err)
+	}
+	view := new(governance.GovernanceView)
+	if err := view.Deserialize(bytes.NewBuffer(value)); err != nil {
+		return 0, nil, fmt.Errorf("deserialize governance view error:%s", err)
+	}
+	key := append([]byte(governance.PEER_POOL), governance.GetUint32Bytes(view.View)...)
+	value, err = ledger.DefLedger.GetStorageItem(utils.GovernanceContractAddress, key)
+	if err != nil {
+		return 0, nil, fmt.Errorf("get peer pool error:%s", err)
+	}
+	peerPoolMap := &governance.PeerPoolMap{
+		PeerPoolMap: make(map[string]*governance.PeerPoolItem),
+	}
+	if err := peerPoolMap.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return 0, nil, fmt.Errorf("deserialize peer pool error:%s", err)
+	}
+	peers := make([]*governance.PeerPoolItem, 0, len(peerPoolMap.PeerPoolMap))
+	for _, peer := range peerPoolMap.PeerPoolMap {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Index < peers[j].Index
+	})
+	return view.View, peers, nil
+}
+
 func GetAllowance(asset string, from, to common.Address) (string, error) {
 	var contractAddr common.Address
 	switch strings.ToLower(asset) {
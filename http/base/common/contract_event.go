@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	bactor "github.com/ontio/ontology/http/base/actor"
+)
+
+type ContractEventInfo struct {
+	TxHash          string
+	Height          uint32
+	TxIndex         uint32
+	EventIndex      uint32
+	ContractAddress string
+	EventName       string
+	States          json.RawMessage
+}
+
+//ContractEventsInfo is a page of the events of contract, Cursor continues the query if not empty
+type ContractEventsInfo struct {
+	Events []*ContractEventInfo
+	Cursor string
+}
+
+//GetContractEvents return the events of contract from the position from to endHeight from the contract event index
+func GetContractEvents(contract common.Address, from scom.ContractEventCursor, endHeight uint32, name string,
+	limit uint32) (*ContractEventsInfo, error) {
+	if limit > MAX_CONTRACT_EVENT_LIMIT {
+		limit = MAX_CONTRACT_EVENT_LIMIT
+	}
+	events, next, err := bactor.GetContractEvents(contract, from, endHeight, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*ContractEventInfo, 0, len(events))
+	for _, evt := range events {
+		infos = append(infos, &ContractEventInfo{
+			TxHash:          evt.TxHash.ToHexString(),
+			Height:          evt.Height,
+			TxIndex:         evt.TxIndex,
+			EventIndex:      evt.EventIndex,
+			ContractAddress: evt.Contract.ToHexString(),
+			EventName:       evt.Name,
+			States:          evt.States,
+		})
+	}
+	result := &ContractEventsInfo{Events: infos}
+	if next != nil {
+		result.Cursor = FormatContractEventCursor(next)
+	}
+	return result, nil
+}
+
+//FormatContractEventCursor encode the position in hex string, which is decoded by GetContractEventStart
+func FormatContractEventCursor(cursor *scom.ContractEventCursor) string {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint32(buf, cursor.Height)
+	binary.BigEndian.PutUint32(buf[4:], cursor.TxIndex)
+	binary.BigEndian.PutUint32(buf[8:], cursor.EventIndex)
+	return hex.EncodeToString(buf)
+}
+
+//GetContractEventStart return the position to query the events between startHeight and endHeight from, which is the
+//cursor returned by the previous query if it is not empty
+func GetContractEventStart(startHeight, endHeight uint32, cursor string) (scom.ContractEventCursor, error) {
+	if startHeight > endHeight {
+		return scom.ContractEventCursor{}, fmt.Errorf("start height %d larger than end height %d", startHeight, endHeight)
+	}
+	if cursor == "" {
+		return scom.ContractEventCursor{Height: startHeight}, nil
+	}
+	buf, err := hex.DecodeString(cursor)
+	if err != nil {
+		return scom.ContractEventCursor{}, err
+	}
+	if len(buf) != 12 {
+		return scom.ContractEventCursor{}, fmt.Errorf("invalid cursor length %d", len(buf))
+	}
+	from := scom.ContractEventCursor{
+		Height:     binary.BigEndian.Uint32(buf),
+		TxIndex:    binary.BigEndian.Uint32(buf[4:]),
+		EventIndex: binary.BigEndian.Uint32(buf[8:]),
+	}
+	if from.Height < startHeight || from.Height > endHeight {
+		return scom.ContractEventCursor{}, fmt.Errorf("cursor height %d out of range", from.Height)
+	}
+	return from, nil
+}
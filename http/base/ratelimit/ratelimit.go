@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package ratelimit provides per ip and per method token bucket limiter for http servers
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idle buckets are refilled to burst, they are swept at this interval to bound the memory
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+//Limiter limits the requests of each method from each ip by token bucket, the bucket is refilled with rate
+//tokens per second up to burst. A nil Limiter allows all requests.
+type Limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time // zero until the first request, which sweeps the empty map
+	now       func() time.Time
+}
+
+//NewLimiter return a limiter of rate requests per second, nil is returned if rate is 0. burst defaults to
+//the requests of one second.
+func NewLimiter(rate float64, burst uint) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst == 0 {
+		burst = uint(math.Ceil(rate))
+	}
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+//Allow consumes a token of the method bucket of ip, return false if the bucket is empty
+func (self *Limiter) Allow(ip, method string) bool {
+	if self == nil {
+		return true
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	now := self.now()
+	if now.Sub(self.lastSweep) >= sweepInterval {
+		self.sweep(now)
+	}
+	key := ip + "/" + method
+	b, ok := self.buckets[key]
+	if !ok {
+		b = &bucket{tokens: self.burst, last: now}
+		self.buckets[key] = b
+	}
+	self.refill(b, now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens -= 1
+	return true
+}
+
+func (self *Limiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * self.rate
+		if b.tokens > self.burst {
+			b.tokens = self.burst
+		}
+	}
+	b.last = now
+}
+
+// sweep deletes the full buckets, which are the same as new ones
+func (self *Limiter) sweep(now time.Time) {
+	for key, b := range self.buckets {
+		self.refill(b, now)
+		if b.tokens >= self.burst {
+			delete(self.buckets, key)
+		}
+	}
+	self.lastSweep = now
+}
+
+//RemoteIP return the ip of the client, the port is stripped from remote address
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
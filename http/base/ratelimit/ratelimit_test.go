@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter := NewLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("127.0.0.1", "getblock"))
+	}
+	assert.False(t, limiter.Allow("127.0.0.1", "getblock"))
+	// buckets are separated by ip and method
+	assert.True(t, limiter.Allow("127.0.0.1", "getblockcount"))
+	assert.True(t, limiter.Allow("127.0.0.2", "getblock"))
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, limiter.Allow("127.0.0.1", "getblock"))
+	assert.False(t, limiter.Allow("127.0.0.1", "getblock"))
+
+	// refilled up to burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("127.0.0.1", "getblock"))
+	}
+	assert.False(t, limiter.Allow("127.0.0.1", "getblock"))
+	assert.Equal(t, 1, len(limiter.buckets))
+
+	var disabled *Limiter
+	assert.Nil(t, NewLimiter(0, 10))
+	assert.True(t, disabled.Allow("127.0.0.1", "getblock"))
+}
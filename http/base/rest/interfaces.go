@@ -590,3 +590,105 @@ func GetMemPoolTxState(cmd map[string]interface{}) map[string]interface{} {
 	resp["Result"] = bcomn.TXNEntryInfo{attrs}
 	return resp
 }
+
+//get the transactions involving address from address index
+func GetAddressTransactions(cmd map[string]interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableAddressIndex {
+		return ResponsePack(berr.INVALID_METHOD)
+	}
+	address, offset, limit, ok := parseAddressIndexParams(cmd)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	txs, err := bcomn.GetAddressTransactions(address, offset, limit)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp := ResponsePack(berr.SUCCESS)
+	resp["Result"] = txs
+	return resp
+}
+
+//get the ONT/ONG transfers involving address from address index
+func GetAddressTransfers(cmd map[string]interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableAddressIndex {
+		return ResponsePack(berr.INVALID_METHOD)
+	}
+	address, offset, limit, ok := parseAddressIndexParams(cmd)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	transfers, err := bcomn.GetAddressTransfers(address, offset, limit)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp := ResponsePack(berr.SUCCESS)
+	resp["Result"] = transfers
+	return resp
+}
+
+// offset and limit are optional, the limit defaults to the max page size
+func parseAddressIndexParams(cmd map[string]interface{}) (common.Address, uint32, uint32, bool) {
+	addrBase58, ok := cmd["Addr"].(string)
+	if !ok {
+		return common.ADDRESS_EMPTY, 0, 0, false
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return common.ADDRESS_EMPTY, 0, 0, false
+	}
+	offset, limit := uint64(0), uint64(bcomn.MAX_ADDRESS_INDEX_LIMIT)
+	if param, ok := cmd["Offset"].(string); ok && len(param) > 0 {
+		if offset, err = strconv.ParseUint(param, 10, 32); err != nil {
+			return common.ADDRESS_EMPTY, 0, 0, false
+		}
+	}
+	if param, ok := cmd["Limit"].(string); ok && len(param) > 0 {
+		if limit, err = strconv.ParseUint(param, 10, 32); err != nil {
+			return common.ADDRESS_EMPTY, 0, 0, false
+		}
+	}
+	return address, uint32(offset), uint32(limit), true
+}
+
+//get the events of contract between heights from contract event index
+func GetContractEvents(cmd map[string]interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableContractEventIndex {
+		return ResponsePack(berr.INVALID_METHOD)
+	}
+	str, ok := cmd["Contract"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	// start height and end height are required, limit is optional
+	values := []uint64{0, 0, uint64(bcomn.MAX_CONTRACT_EVENT_LIMIT)}
+	for i, key := range []string{"StartHeight", "EndHeight", "Limit"} {
+		param, ok := cmd[key].(string)
+		if !ok || len(param) == 0 {
+			if i < 2 {
+				return ResponsePack(berr.INVALID_PARAMS)
+			}
+			continue
+		}
+		if values[i], err = strconv.ParseUint(param, 10, 32); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	cursor, _ := cmd["Cursor"].(string)
+	from, err := bcomn.GetContractEventStart(uint32(values[0]), uint32(values[1]), cursor)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	name, _ := cmd["EventName"].(string)
+	events, err := bcomn.GetContractEvents(contract, from, uint32(values[1]), name, uint32(values[2]))
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp := ResponsePack(berr.SUCCESS)
+	resp["Result"] = events
+	return resp
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
+	"github.com/ontio/ontology/http/base/ratelimit"
 )
 
 var (
@@ -58,6 +59,7 @@ type ServeMux struct {
 	sync.RWMutex
 	m               map[string]func([]interface{}) map[string]interface{}
 	defaultFunction func(http.ResponseWriter, *http.Request)
+	limiter         *ratelimit.Limiter
 }
 
 //a function to register functions to be called for specific rpc calls
@@ -67,6 +69,14 @@ func HandleFunc(pattern string, handler func([]interface{}) map[string]interface
 	mainMux.m[pattern] = handler
 }
 
+//SetRateLimit limits the requests of each method from each ip served by Handle to rate per second with burst, 0 rate
+//disables the limit. The requests served by HandleLocal are not limited.
+func SetRateLimit(rate float64, burst uint) {
+	mainMux.Lock()
+	defer mainMux.Unlock()
+	mainMux.limiter = ratelimit.NewLimiter(rate, burst)
+}
+
 //a function to be called if the request is not a HTTP JSON RPC call
 func SetDefaultFunc(def func(http.ResponseWriter, *http.Request)) {
 	mainMux.defaultFunction = def
@@ -75,6 +85,18 @@ func SetDefaultFunc(def func(http.ResponseWriter, *http.Request)) {
 // this is the function that should be called in order to answer an rpc call
 // should be registered like "http.HandleFunc("/", httpjsonrpc.Handle)"
 func Handle(w http.ResponseWriter, r *http.Request) {
+	mainMux.RLock()
+	limiter := mainMux.limiter
+	mainMux.RUnlock()
+	handle(w, r, limiter)
+}
+
+//HandleLocal answers the rpc call of local rpc server without the rate limit of Handle
+func HandleLocal(w http.ResponseWriter, r *http.Request) {
+	handle(w, r, nil)
+}
+
+func handle(w http.ResponseWriter, r *http.Request, limiter *ratelimit.Limiter) {
 	if r.Method == "OPTIONS" {
 		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
 		w.Header().Set("content-type", "application/json;charset=utf-8")
@@ -103,59 +125,112 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 		mainMux.RUnlock()
 		return
 	}
-	var request JReq
 	defer r.Body.Close()
-	decoder := json.NewDecoder(io.LimitReader(r.Body, common.MAX_REQUEST_BODY_SIZE))
-	err := decoder.Decode(&request)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, common.MAX_REQUEST_BODY_SIZE))
 	if err != nil {
-		log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+		log.Error("HTTP JSON RPC Handle - read body: ", err)
 		return
 	}
-	if request.Method == "" {
-		log.Error("HTTP JSON RPC Handle - method is not string: ")
+	ip := ratelimit.RemoteIP(r)
+	var response interface{}
+	if isBatch(body) {
+		var requests []jsoniter.RawMessage
+		if err := json.Unmarshal(body, &requests); err != nil {
+			log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+			return
+		}
+		if len(requests) == 0 || len(requests) > common.MAX_BATCH_SIZE {
+			log.Warnf("HTTP JSON RPC Handle - invalid batch size %d", len(requests))
+			response = errorResponse(berr.INVALID_PARAMS, -32600, "Invalid Request",
+				fmt.Sprintf("batch size should be in [1, %d]", common.MAX_BATCH_SIZE), nil)
+		} else {
+			responses := make([]map[string]interface{}, 0, len(requests))
+			for _, raw := range requests {
+				var request JReq
+				if err := json.Unmarshal(raw, &request); err != nil || request.Method == "" {
+					responses = append(responses, errorResponse(berr.INVALID_PARAMS, -32600, "Invalid Request",
+						"The JSON sent is not a valid request object", nil))
+					continue
+				}
+				responses = append(responses, handleRequest(&request, ip, limiter))
+			}
+			response = responses
+		}
+	} else {
+		var request JReq
+		err = json.Unmarshal(body, &request)
+		if err != nil {
+			log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+			return
+		}
+		if request.Method == "" {
+			log.Error("HTTP JSON RPC Handle - method is not string: ")
+			return
+		}
+		response = handleRequest(&request, ip, limiter)
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
 		return
 	}
+	w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("content-type", "application/json;charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(data)
+}
+
+// a batch is a json array of requests
+func isBatch(body []byte) bool {
+	for _, c := range body {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// handleRequest calls the function of the request method if it is not limited
+func handleRequest(request *JReq, ip string, limiter *ratelimit.Limiter) map[string]interface{} {
 	//get the corresponding function
 	mainMux.RLock()
 	function, ok := mainMux.m[request.Method]
 	mainMux.RUnlock()
-	if ok {
-		response := function(request.Params)
-		data, err := json.Marshal(map[string]interface{}{
-			"jsonrpc": "2.0",
-			"error":   response["error"],
-			"desc":    response["desc"],
-			"result":  response["result"],
-			"id":      request.ID,
-		})
-		if err != nil {
-			log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-			return
-		}
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Write(data)
-	} else {
+	if !ok {
 		//if the function does not exist
 		log.Warn("HTTP JSON RPC Handle - No function to call for ", request.Method)
-		data, err := json.Marshal(map[string]interface{}{
-			"error": berr.INVALID_METHOD,
-			"result": map[string]interface{}{
-				"code":    -32601,
-				"message": "Method not found",
-				"data":    "The called method was not found on the server",
-			},
-			"id": request.ID,
-		})
-		if err != nil {
-			log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-			return
-		}
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Write(data)
+		return errorResponse(berr.INVALID_METHOD, -32601, "Method not found",
+			"The called method was not found on the server", request.ID)
+	}
+	if !limiter.Allow(ip, request.Method) {
+		log.Debugf("HTTP JSON RPC Handle - %s exceeds rate limit of %s", ip, request.Method)
+		return errorResponse(berr.SERVICE_CEILING, -32005, "Limit exceeded",
+			"The request rate of the method exceeds the limit", request.ID)
+	}
+	response := function(request.Params)
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"error":   response["error"],
+		"desc":    response["desc"],
+		"result":  response["result"],
+		"id":      request.ID,
+	}
+}
+
+func errorResponse(errCode int64, code int, message, data string, id interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"error": errCode,
+		"result": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"data":    data,
+		},
+		"id": id,
 	}
 }
 
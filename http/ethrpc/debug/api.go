@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package debug
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	types2 "github.com/ontio/ontology/http/ethrpc/types"
+	utils2 "github.com/ontio/ontology/http/ethrpc/utils"
+	types3 "github.com/ontio/ontology/smartcontract/service/evm/types"
+	"github.com/ontio/ontology/vm/evm"
+)
+
+const callTracer = "callTracer"
+
+// TraceConfig holds extra parameters to trace functions, the struct logger is used if Tracer is not set
+type TraceConfig struct {
+	*evm.LogConfig
+	Tracer *string
+}
+
+// ExecutionResult groups all structured logs emitted by the EVM
+// while replaying a transaction in debug mode as well as transaction
+// execution status, the amount of gas used and the return value
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes stores a structured log emitted by the EVM while replaying a
+// transaction in debug mode
+type StructLogRes struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     uint64             `json:"gas"`
+	GasCost uint64             `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   string             `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+type DebugAPI struct{}
+
+func NewDebugAPI() *DebugAPI {
+	return &DebugAPI{}
+}
+
+// TraceTransaction re-executes the eth transaction on the state right before it, archive state is required.
+// The value transfers are reported by the call tracer as NATIVE calls to the ong contract, while the gas fee
+// transfer to the governance contract is settled outside of the EVM and is not traced.
+func (api *DebugAPI) TraceTransaction(hash common.Hash, traceConfig *TraceConfig) (interface{}, error) {
+	log.Debugf("debug_traceTransaction hash %v", hash.Hex())
+	tracer, err := newTracer(traceConfig)
+	if err != nil {
+		return nil, err
+	}
+	res, err := bactor.TraceEip155Tx(utils2.EthToOntHash(hash), tracer)
+	if err != nil {
+		return nil, err
+	}
+	return traceResult(tracer, res)
+}
+
+// TraceCall executes the call on the state of the block number, past block number requires archive state
+func (api *DebugAPI) TraceCall(args types2.CallArgs, blockNumber types2.BlockNumber, traceConfig *TraceConfig) (interface{}, error) {
+	log.Debugf("debug_traceCall args %v, block number %v", args, blockNumber)
+	height, _, err := utils2.ResolveStateHeight(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	tracer, err := newTracer(traceConfig)
+	if err != nil {
+		return nil, err
+	}
+	res, err := bactor.TraceEip155Call(args.AsMessage(config.DEFAULT_ETH_TX_MAX_GAS_LIMIT), height, tracer)
+	if err != nil {
+		return nil, err
+	}
+	return traceResult(tracer, res)
+}
+
+func newTracer(traceConfig *TraceConfig) (evm.Tracer, error) {
+	if traceConfig == nil || traceConfig.Tracer == nil {
+		var logConfig *evm.LogConfig
+		if traceConfig != nil {
+			logConfig = traceConfig.LogConfig
+		}
+		return evm.NewStructLogger(logConfig), nil
+	}
+	if *traceConfig.Tracer != callTracer {
+		return nil, fmt.Errorf("tracer %s is not supported", *traceConfig.Tracer)
+	}
+	return evm.NewCallTracer(), nil
+}
+
+func traceResult(tracer evm.Tracer, res *types3.ExecutionResult) (interface{}, error) {
+	switch tracer := tracer.(type) {
+	case *evm.StructLogger:
+		returnVal := fmt.Sprintf("%x", res.Return())
+		if len(res.Revert()) > 0 {
+			returnVal = fmt.Sprintf("%x", res.Revert())
+		}
+		return &ExecutionResult{
+			Gas:         res.UsedGas,
+			Failed:      res.Failed(),
+			ReturnValue: returnVal,
+			StructLogs:  FormatLogs(tracer.StructLogs()),
+		}, nil
+	case *evm.CallTracer:
+		return tracer.Result(), nil
+	default:
+		return nil, fmt.Errorf("unexpected tracer type %T", tracer)
+	}
+}
+
+// FormatLogs formats EVM returned structured logs for json output
+func FormatLogs(logs []evm.StructLog) []StructLogRes {
+	formatted := make([]StructLogRes, len(logs))
+	for index, trace := range logs {
+		formatted[index] = StructLogRes{
+			Pc:      trace.Pc,
+			Op:      trace.Op.String(),
+			Gas:     trace.Gas,
+			GasCost: trace.GasCost,
+			Depth:   trace.Depth,
+			Error:   trace.ErrorString(),
+		}
+		if trace.Stack != nil {
+			stack := make([]string, len(trace.Stack))
+			for i, stackValue := range trace.Stack {
+				stack[i] = fmt.Sprintf("%x", math.PaddedBigBytes(stackValue, 32))
+			}
+			formatted[index].Stack = &stack
+		}
+		if trace.Memory != nil {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
+			}
+			formatted[index].Memory = &memory
+		}
+		if trace.Storage != nil {
+			storage := make(map[string]string)
+			for i, storageValue := range trace.Storage {
+				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
+			}
+			formatted[index].Storage = &storage
+		}
+	}
+	return formatted
+}
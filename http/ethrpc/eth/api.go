@@ -22,12 +22,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	oComm "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
@@ -51,12 +51,13 @@ const (
 	eth65           = 65
 	ProtocolVersion = eth65
 	RPCGasCap       = config.DEFAULT_ETH_TX_MAX_GAS_LIMIT
+	maxFeeHistory   = 1024
 )
 
 type TxPoolService interface {
 	Nonce(addr oComm.Address) uint64
-	PendingEIPTransactions() []*types.Transaction
-	PendingTransactionsByHash(target common.Hash) *types.Transaction
+	PendingEIPTransactions() []*otypes.Transaction
+	PendingTransactionsByHash(target common.Hash) *otypes.Transaction
 }
 
 type EthereumAPI struct {
@@ -78,12 +79,50 @@ func (api *EthereumAPI) BlockNumber() (hexutil.Uint64, error) {
 	return hexutil.Uint64(height), nil
 }
 
-func (api *EthereumAPI) GetBalance(address common.Address, _ rpc.BlockNumberOrHash) (*hexutil.Big, error) {
-	log.Debugf("eth_getBalance address %v", address.Hex())
+func (api *EthereumAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	log.Debugf("eth_getBalance address %v, block %v", address.Hex(), blockNrOrHash)
+	height, latest, err := resolveBlockNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if !latest {
+		statedb, err := bactor.GetEthStateDBAtHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		balance := statedb.GetBalance(address)
+		if err := statedb.DbErr(); err != nil {
+			return nil, err
+		}
+		return (*hexutil.Big)(balance), nil
+	}
 	balance, err := getOngBalance(address)
 	return (*hexutil.Big)(big.NewInt(int64(balance))), err
 }
 
+// resolveBlockNumberOrHash return the height of the queried state, latest is true if current state is queried
+func resolveBlockNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (uint32, bool, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header, err := bactor.GetHeaderByHash(utils2.EthToOntHash(hash))
+		if err != nil && err != common2.ErrNotFound {
+			return 0, false, err
+		}
+		if header == nil {
+			return 0, false, fmt.Errorf("block %s not found", hash.Hex())
+		}
+		return utils2.ResolveStateHeight(types2.BlockNumber(header.Height))
+	}
+	number, ok := blockNrOrHash.Number()
+	if !ok || number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return bactor.GetCurrentBlockHeight(), true, nil
+	}
+	current := bactor.GetCurrentBlockHeight()
+	if number.Int64() >= int64(current) {
+		return current, true, nil
+	}
+	return uint32(number), false, nil
+}
+
 func getOngBalance(address common.Address) (uint64, error) {
 	balances, _, err := hComm.GetContractBalance(0, []oComm.Address{utils.OngContractAddress}, oComm.Address(address), true)
 	if err != nil {
@@ -131,12 +170,121 @@ func (api *EthereumAPI) GasPrice() *hexutil.Big {
 	return (*hexutil.Big)(new(big.Int).SetUint64(gasPrice))
 }
 
+// MaxPriorityFeePerGas returns the suggested priority fee of dynamic fee transaction, since there is no base fee
+// in ontology, the whole gas price is the priority fee
+func (api *EthereumAPI) MaxPriorityFeePerGas() (*hexutil.Big, error) {
+	log.Debug("eth_maxPriorityFeePerGas")
+	gasPrice, _, err := hComm.GetGasPrice()
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(new(big.Int).SetUint64(gasPrice)), nil
+}
+
+// FeeHistory returns the fee history of at most blockCount blocks up to lastBlock. Base fees and gas used ratios
+// are always zero since ontology has neither base fee nor block gas limit, the rewards are the gas prices of the
+// transactions at the percentiles of block gas, where transactions are sorted by gas price and weighted by gas limit.
+func (api *EthereumAPI) FeeHistory(blockCount types2.DecimalOrHex, lastBlock types2.BlockNumber,
+	rewardPercentiles []float64) (*types2.FeeHistoryResult, error) {
+	log.Debugf("eth_feeHistory blockCount %d, lastBlock %v, rewardPercentiles %v", blockCount, lastBlock,
+		rewardPercentiles)
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid reward percentile: %f", p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, fmt.Errorf("invalid reward percentile: #%d:%f > #%d:%f", i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+	last, _, err := utils2.ResolveStateHeight(lastBlock)
+	if err != nil {
+		return nil, err
+	}
+	count := uint64(blockCount)
+	if count > maxFeeHistory {
+		count = maxFeeHistory
+	}
+	if count > uint64(last)+1 {
+		count = uint64(last) + 1
+	}
+	oldest := last + 1 - uint32(count)
+	result := &types2.FeeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(new(big.Int).SetUint64(uint64(oldest))),
+		BaseFee:      make([]*hexutil.Big, count+1),
+		GasUsedRatio: make([]float64, count),
+	}
+	for i := range result.BaseFee {
+		result.BaseFee[i] = (*hexutil.Big)(new(big.Int))
+	}
+	if len(rewardPercentiles) == 0 {
+		return result, nil
+	}
+	result.Reward = make([][]*hexutil.Big, count)
+	for i := range result.Reward {
+		block, err := bactor.GetBlockByHeight(oldest + uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block: %v not found", oldest+uint32(i))
+		}
+		result.Reward[i] = blockRewards(block.Transactions, rewardPercentiles)
+	}
+	return result, nil
+}
+
+// blockRewards returns the gas prices at the percentiles of the block gas, zero is returned for empty block
+func blockRewards(txs []*otypes.Transaction, percentiles []float64) []*hexutil.Big {
+	rewards := make([]*hexutil.Big, len(percentiles))
+	if len(txs) == 0 {
+		for i := range rewards {
+			rewards[i] = (*hexutil.Big)(new(big.Int))
+		}
+		return rewards
+	}
+	sorted := make([]*otypes.Transaction, len(txs))
+	copy(sorted, txs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GasPrice < sorted[j].GasPrice
+	})
+	var totalGas uint64
+	for _, tx := range sorted {
+		totalGas += tx.GasLimit
+	}
+	txIndex := 0
+	sumGas := sorted[0].GasLimit
+	for i, p := range percentiles {
+		threshold := uint64(float64(totalGas) * p / 100)
+		for sumGas < threshold && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGas += sorted[txIndex].GasLimit
+		}
+		rewards[i] = (*hexutil.Big)(new(big.Int).SetUint64(sorted[txIndex].GasPrice))
+	}
+	return rewards
+}
+
 func (api *EthereumAPI) Accounts() ([]common.Address, error) {
 	return nil, fmt.Errorf("eth_accounts is not supported")
 }
 
 func (api *EthereumAPI) GetStorageAt(address common.Address, key string, blockNum types2.BlockNumber) (hexutil.Bytes, error) {
 	log.Debugf("eth_getStorageAt address %v, key %s, blockNum %v", address.Hex(), key, blockNum)
+	height, latest, err := utils2.ResolveStateHeight(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if !latest {
+		statedb, err := bactor.GetEthStateDBAtHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		value := statedb.GetState(address, common.HexToHash(key))
+		if err := statedb.DbErr(); err != nil {
+			return nil, err
+		}
+		return value.Bytes(), nil
+	}
 	return bactor.GetEthStorage(address, common.HexToHash(key))
 }
 
@@ -191,6 +339,21 @@ func (api *EthereumAPI) GetUncleCountByBlockNumber(number int64) hexutil.Uint {
 
 func (api *EthereumAPI) GetCode(address common.Address, blockNumber types2.BlockNumber) (hexutil.Bytes, error) {
 	log.Debugf("eth_getCode address %s, blockNumber %v", address.Hex(), blockNumber)
+	height, latest, err := utils2.ResolveStateHeight(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if !latest {
+		statedb, err := bactor.GetEthStateDBAtHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		code := statedb.GetCode(address)
+		if err := statedb.DbErr(); err != nil {
+			return nil, err
+		}
+		return code, nil
+	}
 	account, err := bactor.GetEthAccount(address)
 	if err != nil {
 		return nil, err
@@ -258,12 +421,7 @@ func (api *EthereumAPI) SendTransaction(args types2.SendTxArgs) (common.Hash, er
 
 func (api *EthereumAPI) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
 	log.Debugf("eth_sendRawTransaction data %v", data.String())
-	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(data, tx); err != nil {
-		return common.Hash{}, err
-	}
-
-	eip155tx, err := otypes.TransactionFromEIP155(tx)
+	eip155tx, err := otypes.TransactionFromEthRawBytes(data)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -279,8 +437,17 @@ func (api *EthereumAPI) SendRawTransaction(data hexutil.Bytes) (common.Hash, err
 
 func (api *EthereumAPI) Call(args types2.CallArgs, blockNumber types2.BlockNumber, _ *map[common.Address]types2.Account) (hexutil.Bytes, error) {
 	log.Debugf("eth_call args %v ,block number %v ", args, blockNumber)
+	height, latest, err := utils2.ResolveStateHeight(blockNumber)
+	if err != nil {
+		return nil, err
+	}
 	msg := args.AsMessage(RPCGasCap)
-	res, err := bactor.PreExecuteEip155Tx(msg)
+	var res *types3.ExecutionResult
+	if latest {
+		res, err = bactor.PreExecuteEip155Tx(msg)
+	} else {
+		res, err = bactor.PreExecuteEip155TxAtHeight(msg, height)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -387,6 +554,10 @@ func (api *EthereumAPI) EstimateGas(args types2.CallArgs) (hexutil.Uint64, error
 			return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
 		}
 	}
+	// the access list only adds its intrinsic gas, it does not change the execution
+	if args.AccessList != nil {
+		hi += evm.AccessListGas(*args.AccessList)
+	}
 	return hexutil.Uint64(hi), nil
 }
 
@@ -514,10 +685,9 @@ func generateRecipient(notify *event.ExecuteNotify) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	signer := types.NewEIP155Signer(big.NewInt(int64(utils2.GetChainId())))
-	from, err := signer.Sender(eip155Tx)
-	if err != nil {
-		return nil, err
+	txType := hexutil.Uint64(0)
+	if typed := tx.GetEthTypedTx(); typed != nil {
+		txType = hexutil.Uint64(typed.Type)
 	}
 	receipt := map[string]interface{}{
 		// Consensus fields: These fields are defined by the Yellow Paper
@@ -539,8 +709,11 @@ func generateRecipient(notify *event.ExecuteNotify) (interface{}, error) {
 		"transactionIndex": hexutil.Uint64(notify.TxIndex),
 
 		// sender and receiver (contract or EOA) addresses
-		"from": from,
+		"from": common.Address(tx.Payer),
 		"to":   eip155Tx.To(),
+
+		"type":              txType,
+		"effectiveGasPrice": (*hexutil.Big)(new(big.Int).SetUint64(tx.GasPrice)),
 	}
 	if logs == nil {
 		receipt["logs"] = [][]*types.Log{}
@@ -558,7 +731,7 @@ func (api *EthereumAPI) PendingTransactions() ([]*types2.Transaction, error) {
 	pendingTxs := api.txpool.PendingEIPTransactions()
 	var rpcTxs []*types2.Transaction
 	for _, v2 := range pendingTxs {
-		tx, err := utils2.NewTransaction(v2, v2.Hash(), common.Hash{}, 0, 0)
+		tx, err := utils2.NewTransaction(v2, common.Hash{}, 0, 0)
 		if err != nil {
 			return nil, nil
 		}
@@ -573,7 +746,7 @@ func (api *EthereumAPI) PendingTransactionsByHash(target common.Hash) (*types2.T
 	if ethTx == nil {
 		return nil, fmt.Errorf("tx: %v not found", target.String())
 	}
-	return utils2.NewTransaction(ethTx, ethTx.Hash(), common.Hash{}, 0, 0)
+	return utils2.NewTransaction(ethTx, common.Hash{}, 0, 0)
 }
 
 func (api *EthereumAPI) GetUncleByBlockHashAndIndex(_ common.Hash, _ hexutil.Uint) map[string]interface{} {
@@ -584,6 +757,8 @@ func (api *EthereumAPI) GetUncleByBlockNumberAndIndex(_ hexutil.Uint, _ hexutil.
 	return nil
 }
 
+// GetProof is not supported, the state merkle root only commits to the block write sets, so the state of a block
+// can not be proved.
 func (api *EthereumAPI) GetProof(address common.Address, storageKeys []string, block types2.BlockNumber) (*types2.AccountResult, error) {
 	return nil, fmt.Errorf("eth_getProof is not supported")
 }
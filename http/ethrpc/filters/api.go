@@ -0,0 +1,374 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ontio/ontology/common/log"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	types2 "github.com/ontio/ontology/http/ethrpc/types"
+	utils2 "github.com/ontio/ontology/http/ethrpc/utils"
+)
+
+const (
+	// filters not polled within deadline are uninstalled
+	deadline = 5 * time.Minute
+	// MaxLogsBlockRange limits the number of blocks a single log query can scan
+	MaxLogsBlockRange = 100000
+)
+
+type filterType byte
+
+const (
+	LogsFilter filterType = iota
+	BlocksFilter
+)
+
+// filter is a helper struct that holds meta information over the filter type
+// and the last height that has been delivered to the client.
+type filter struct {
+	typ        filterType
+	deadline   *time.Timer // filter is inactive when deadline triggers
+	crit       types2.FilterCriteria
+	lastHeight uint32
+}
+
+// PublicFilterAPI offers support to create and manage filters. This will allow external clients to retrieve various
+// information related to the ontology protocol such as blocks and evm logs.
+type PublicFilterAPI struct {
+	events    *EventSystem
+	filtersMu sync.Mutex
+	filters   map[rpc.ID]*filter
+}
+
+// NewPublicFilterAPI returns a new PublicFilterAPI instance.
+func NewPublicFilterAPI() *PublicFilterAPI {
+	api := &PublicFilterAPI{
+		events:  NewEventSystem(),
+		filters: make(map[rpc.ID]*filter),
+	}
+	go api.timeoutLoop()
+
+	return api
+}
+
+// timeoutLoop runs every 5 minutes and deletes filters that have not been recently used.
+func (api *PublicFilterAPI) timeoutLoop() {
+	ticker := time.NewTicker(deadline)
+	defer ticker.Stop()
+	for {
+		<-ticker.C
+		api.filtersMu.Lock()
+		for id, f := range api.filters {
+			select {
+			case <-f.deadline.C:
+				delete(api.filters, id)
+			default:
+				continue
+			}
+		}
+		api.filtersMu.Unlock()
+	}
+}
+
+// NewPendingTransactions creates a subscription that is triggered each time a transaction
+// enters the transaction pool.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	pendingTxSub := api.events.SubscribePendingTxs()
+	go func() {
+		defer pendingTxSub.Unsubscribe()
+		for {
+			select {
+			case hashes := <-pendingTxSub.hashes:
+				for _, h := range hashes {
+					notifier.Notify(rpcSub.ID, h)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewHeads send a notification each time a new block is saved to the ledger.
+func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	headersSub := api.events.SubscribeNewHeads()
+	go func() {
+		defer headersSub.Unsubscribe()
+		for {
+			select {
+			case h := <-headersSub.blocks:
+				notifier.Notify(rpcSub.ID, h)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// Logs creates a subscription that fires for all new evm logs that match the given filter criteria.
+func (api *PublicFilterAPI) Logs(ctx context.Context, crit types2.FilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit.BlockHash != nil || crit.FromBlock != nil || crit.ToBlock != nil {
+		return &rpc.Subscription{}, errors.New("block range is not supported by logs subscription")
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	logsSub := api.events.SubscribeLogs()
+	go func() {
+		defer logsSub.Unsubscribe()
+		for {
+			select {
+			case logs := <-logsSub.logs:
+				for _, log := range filterLogs(logs, crit.Addresses, crit.Topics) {
+					notifier.Notify(rpcSub.ID, log)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
+// It is part of the filter package since polling goes with eth_getFilterChanges.
+func (api *PublicFilterAPI) NewBlockFilter() rpc.ID {
+	log.Debug("eth_newBlockFilter")
+	id := rpc.NewID()
+	api.filtersMu.Lock()
+	api.filters[id] = &filter{
+		typ:        BlocksFilter,
+		deadline:   time.NewTimer(deadline),
+		lastHeight: bactor.GetCurrentBlockHeight(),
+	}
+	api.filtersMu.Unlock()
+	return id
+}
+
+// NewFilter creates a new filter and returns the filter id. It can be
+// used to retrieve logs when the state changes. This method cannot be
+// used to fetch logs that are already stored in the ledger.
+func (api *PublicFilterAPI) NewFilter(crit types2.FilterCriteria) (rpc.ID, error) {
+	log.Debugf("eth_newFilter crit %v", crit)
+	if crit.BlockHash != nil {
+		return "", errors.New("block hash is not supported by eth_newFilter")
+	}
+	id := rpc.NewID()
+	api.filtersMu.Lock()
+	api.filters[id] = &filter{
+		typ:        LogsFilter,
+		deadline:   time.NewTimer(deadline),
+		crit:       crit,
+		lastHeight: bactor.GetCurrentBlockHeight(),
+	}
+	api.filtersMu.Unlock()
+	return id, nil
+}
+
+// GetLogs returns logs matching the given argument that are stored within the ledger.
+func (api *PublicFilterAPI) GetLogs(crit types2.FilterCriteria) ([]*types.Log, error) {
+	log.Debugf("eth_getLogs crit %v", crit)
+	var f *Filter
+	if crit.BlockHash != nil {
+		f = NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics)
+	} else {
+		current := bactor.GetCurrentBlockHeight()
+		begin := resolveHeight(crit.FromBlock, current)
+		end := resolveHeight(crit.ToBlock, current)
+		if err := checkRange(begin, end); err != nil {
+			return nil, err
+		}
+		f = NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
+	}
+	logs, err := f.Logs()
+	if err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), nil
+}
+
+// UninstallFilter removes the filter with the given filter id.
+func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
+	log.Debugf("eth_uninstallFilter id %v", id)
+	api.filtersMu.Lock()
+	f, found := api.filters[id]
+	if found {
+		delete(api.filters, id)
+	}
+	api.filtersMu.Unlock()
+	if found {
+		f.deadline.Stop()
+	}
+	return found
+}
+
+// GetFilterLogs returns the logs for the filter with the given id.
+// If the filter could not be found an empty array of logs is returned.
+func (api *PublicFilterAPI) GetFilterLogs(id rpc.ID) ([]*types.Log, error) {
+	log.Debugf("eth_getFilterLogs id %v", id)
+	api.filtersMu.Lock()
+	f, found := api.filters[id]
+	api.filtersMu.Unlock()
+
+	if !found || f.typ != LogsFilter {
+		return nil, fmt.Errorf("filter not found")
+	}
+	current := bactor.GetCurrentBlockHeight()
+	begin := resolveHeight(f.crit.FromBlock, current)
+	end := resolveHeight(f.crit.ToBlock, current)
+	if err := checkRange(begin, end); err != nil {
+		return nil, err
+	}
+	logs, err := NewRangeFilter(begin, end, f.crit.Addresses, f.crit.Topics).Logs()
+	if err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), nil
+}
+
+// GetFilterChanges returns the logs for the filter with the given id since
+// last time it was called. This can be used for polling.
+//
+// For block filters the result is []common.Hash.
+// For log filters the result is []*types.Log.
+func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
+	log.Debugf("eth_getFilterChanges id %v", id)
+	api.filtersMu.Lock()
+	f, found := api.filters[id]
+	if !found {
+		api.filtersMu.Unlock()
+		return []interface{}{}, fmt.Errorf("filter not found")
+	}
+	if !f.deadline.Stop() {
+		// timer expired but filter is not yet removed in timeout loop
+		// receive timer value and reset timer
+		<-f.deadline.C
+	}
+	f.deadline.Reset(deadline)
+	// the blocks are read without the lock, so polling a filter does not block the other filters
+	typ, crit, lastHeight := f.typ, f.crit, f.lastHeight
+	api.filtersMu.Unlock()
+
+	current := bactor.GetCurrentBlockHeight()
+	switch typ {
+	case BlocksFilter:
+		hashes := make([]common.Hash, 0)
+		for height := lastHeight + 1; height <= current; height++ {
+			hashes = append(hashes, utils2.OntToEthHash(bactor.GetBlockHashFromStore(height)))
+		}
+		api.updateLastHeight(id, f, current)
+		return hashes, nil
+	case LogsFilter:
+		begin := lastHeight + 1
+		if from := resolveHeight(crit.FromBlock, current); crit.FromBlock != nil && from > begin {
+			begin = from
+		}
+		end := resolveHeight(crit.ToBlock, current)
+		if end > current {
+			end = current
+		}
+		if begin > end {
+			return []*types.Log{}, nil
+		}
+		if err := checkRange(begin, end); err != nil {
+			return nil, err
+		}
+		logs, err := NewRangeFilter(begin, end, crit.Addresses, crit.Topics).Logs()
+		if err != nil {
+			return nil, err
+		}
+		api.updateLastHeight(id, f, end)
+		return returnLogs(logs), nil
+	}
+
+	return []interface{}{}, fmt.Errorf("invalid filter type")
+}
+
+// updateLastHeight records the last height delivered by the filter, unless it has been uninstalled meanwhile.
+func (api *PublicFilterAPI) updateLastHeight(id rpc.ID, f *filter, height uint32) {
+	api.filtersMu.Lock()
+	defer api.filtersMu.Unlock()
+	if api.filters[id] == f && height > f.lastHeight {
+		f.lastHeight = height
+	}
+}
+
+// resolveHeight maps the special block numbers to the current height.
+func resolveHeight(number *types2.BlockNumber, current uint32) uint32 {
+	if number == nil || number.IsLatest() || number.IsPending() {
+		return current
+	}
+	if number.Int64() > int64(current) {
+		return current
+	}
+	return uint32(*number)
+}
+
+func checkRange(begin, end uint32) error {
+	if begin > end {
+		return fmt.Errorf("invalid block range: from %d is greater than to %d", begin, end)
+	}
+	if end-begin >= MaxLogsBlockRange {
+		return fmt.Errorf("block range %d-%d exceeds the limit of %d blocks", begin, end, MaxLogsBlockRange)
+	}
+	return nil
+}
+
+// returnLogs is a helper that will return an empty log array in case the given logs array is nil,
+// otherwise the given logs array is returned.
+func returnLogs(logs []*types.Log) []*types.Log {
+	if logs == nil {
+		return []*types.Log{}
+	}
+	return logs
+}
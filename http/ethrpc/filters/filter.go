@@ -0,0 +1,213 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package filters
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	oComm "github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	utils2 "github.com/ontio/ontology/http/ethrpc/utils"
+	"github.com/ontio/ontology/smartcontract/event"
+)
+
+// Filter can be used to retrieve and filter logs.
+type Filter struct {
+	addresses []common.Address
+	topics    [][]common.Hash
+
+	block      *common.Hash // Block hash if filtering a single block
+	begin, end uint32       // Range interval if filtering multiple blocks
+}
+
+// NewRangeFilter creates a new filter which inspects the blocks between begin and end
+// (both inclusive) to figure out whether a particular block is interesting or not.
+func NewRangeFilter(begin, end uint32, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{
+		addresses: addresses,
+		topics:    topics,
+		begin:     begin,
+		end:       end,
+	}
+}
+
+// NewBlockFilter creates a new filter which directly inspects the logs of
+// the block with the given hash.
+func NewBlockFilter(block common.Hash, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{
+		addresses: addresses,
+		topics:    topics,
+		block:     &block,
+	}
+}
+
+// Logs searches the ledger for matching log entries. Blocks whose bloom is indexed and
+// does not match the criteria are skipped without decoding their event notifies.
+func (f *Filter) Logs() ([]*types.Log, error) {
+	if f.block != nil {
+		header, err := bactor.GetHeaderByHash(oComm.Uint256(*f.block))
+		if err != nil && err != scom.ErrNotFound {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("unknown block %s", f.block.Hex())
+		}
+		return f.blockLogs(header.Height)
+	}
+
+	var logs []*types.Log
+	for height := f.begin; height <= f.end; height++ {
+		bloom, err := bactor.GetBloomByHeight(height)
+		if err == nil {
+			if !bloomFilter(bloom, f.addresses, f.topics) {
+				continue
+			}
+		} else if err != scom.ErrNotFound {
+			return nil, err
+		}
+		found, err := f.blockLogs(height)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, found...)
+	}
+	return logs, nil
+}
+
+// blockLogs returns the logs matching the filter criteria within a single block.
+func (f *Filter) blockLogs(height uint32) ([]*types.Log, error) {
+	logs, err := GetBlockLogs(height)
+	if err != nil {
+		return nil, err
+	}
+	return filterLogs(logs, f.addresses, f.topics), nil
+}
+
+// GetBlockLogs decodes the evm logs of all transactions in block, non evm notifies are skipped.
+func GetBlockLogs(height uint32) ([]*types.Log, error) {
+	notifies, err := bactor.GetEventNotifyByHeight(height)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	blockHash := utils2.OntToEthHash(bactor.GetBlockHashFromStore(height))
+	return NotifiesToLogs(notifies, height, blockHash)
+}
+
+// NotifiesToLogs converts the evm notifies of a block to eth logs, the log index is counted across the block.
+func NotifiesToLogs(notifies []*event.ExecuteNotify, height uint32, blockHash common.Hash) ([]*types.Log, error) {
+	var logs []*types.Log
+	var index uint
+	for _, notify := range notifies {
+		for _, n := range notify.Notify {
+			if !n.IsEvm {
+				continue
+			}
+			storageLog, err := event.NotifyEventInfoToEvmLog(n)
+			if err != nil {
+				return nil, err
+			}
+			logs = append(logs, &types.Log{
+				Address:     storageLog.Address,
+				Topics:      storageLog.Topics,
+				Data:        storageLog.Data,
+				BlockNumber: uint64(height),
+				TxHash:      utils2.OntToEthHash(notify.TxHash),
+				TxIndex:     uint(notify.TxIndex),
+				BlockHash:   blockHash,
+				Index:       index,
+				Removed:     false,
+			})
+			index++
+		}
+	}
+	return logs, nil
+}
+
+func includes(addresses []common.Address, a common.Address) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLogs creates a slice of logs matching the given criteria.
+func filterLogs(logs []*types.Log, addresses []common.Address, topics [][]common.Hash) []*types.Log {
+	var ret []*types.Log
+Logs:
+	for _, log := range logs {
+		if len(addresses) > 0 && !includes(addresses, log.Address) {
+			continue
+		}
+		// If the to filtered topics is greater than the amount of topics in logs, skip.
+		if len(topics) > len(log.Topics) {
+			continue Logs
+		}
+		for i, sub := range topics {
+			match := len(sub) == 0 // empty rule set == wildcard
+			for _, topic := range sub {
+				if log.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+// bloomFilter returns false if the block bloom proves no log in block can match the criteria.
+func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, sub := range topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
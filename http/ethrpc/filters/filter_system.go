@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package filters
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ontio/ontology/common/log"
+	otypes "github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/events/message"
+	bactor "github.com/ontio/ontology/http/base/actor"
+	utils2 "github.com/ontio/ontology/http/ethrpc/utils"
+)
+
+// size of the channel buffering events of a single subscription, events are dropped
+// for the subscription when the client can not keep up.
+const subChanSize = 1024
+
+// Type determines the kind of filter and is used to put the filter in to
+// the correct bucket when added.
+type Type byte
+
+const (
+	// LogsSubscription queries for new logs
+	LogsSubscription Type = iota
+	// PendingTransactionsSubscription queries tx hashes for transactions entering the tx pool
+	PendingTransactionsSubscription
+	// BlocksSubscription queries hashes for blocks that are imported
+	BlocksSubscription
+)
+
+// Subscription is created when the client registers itself for a particular event.
+type Subscription struct {
+	ID     rpc.ID
+	typ    Type
+	es     *EventSystem
+	logs   chan []*types.Log
+	hashes chan []common.Hash
+	blocks chan map[string]interface{}
+}
+
+// Unsubscribe uninstalls the subscription from the event system.
+func (sub *Subscription) Unsubscribe() {
+	sub.es.uninstall(sub.ID)
+}
+
+// EventSystem dispatches the saved blocks, evm logs and tx pool transactions published
+// through the event hub to the installed subscriptions.
+type EventSystem struct {
+	lock sync.RWMutex
+	subs map[rpc.ID]*Subscription
+}
+
+// NewEventSystem creates a new event system and subscribes it to the ledger and tx pool events.
+func NewEventSystem() *EventSystem {
+	es := &EventSystem{
+		subs: make(map[rpc.ID]*Subscription),
+	}
+	bactor.SubscribeEvent(message.TOPIC_SAVE_BLOCK_COMPLETE, es.onBlockSaved)
+	bactor.SubscribeEvent(message.TOPIC_TXPOOL_ADD_TX, es.onTxPoolAddTx)
+	return es
+}
+
+// SubscribeNewHeads creates a subscription that writes the header of a block that is saved.
+func (es *EventSystem) SubscribeNewHeads() *Subscription {
+	return es.install(&Subscription{
+		ID:     rpc.NewID(),
+		typ:    BlocksSubscription,
+		blocks: make(chan map[string]interface{}, subChanSize),
+	})
+}
+
+// SubscribeLogs creates a subscription that writes the evm logs of every saved block.
+// Filtering by the criteria is done by the caller.
+func (es *EventSystem) SubscribeLogs() *Subscription {
+	return es.install(&Subscription{
+		ID:   rpc.NewID(),
+		typ:  LogsSubscription,
+		logs: make(chan []*types.Log, subChanSize),
+	})
+}
+
+// SubscribePendingTxs creates a subscription that writes transaction hashes for
+// transactions that enter the transaction pool.
+func (es *EventSystem) SubscribePendingTxs() *Subscription {
+	return es.install(&Subscription{
+		ID:     rpc.NewID(),
+		typ:    PendingTransactionsSubscription,
+		hashes: make(chan []common.Hash, subChanSize),
+	})
+}
+
+func (es *EventSystem) install(sub *Subscription) *Subscription {
+	sub.es = es
+	es.lock.Lock()
+	es.subs[sub.ID] = sub
+	es.lock.Unlock()
+	return sub
+}
+
+func (es *EventSystem) uninstall(id rpc.ID) {
+	es.lock.Lock()
+	delete(es.subs, id)
+	es.lock.Unlock()
+}
+
+func (es *EventSystem) subscriptions(typ Type) []*Subscription {
+	es.lock.RLock()
+	defer es.lock.RUnlock()
+	var subs []*Subscription
+	for _, sub := range es.subs {
+		if sub.typ == typ {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+func (es *EventSystem) onBlockSaved(v interface{}) {
+	block, ok := v.(otypes.Block)
+	if !ok {
+		return
+	}
+	if subs := es.subscriptions(BlocksSubscription); len(subs) != 0 {
+		header := utils2.EthHeaderFromOntology(&block)
+		for _, sub := range subs {
+			select {
+			case sub.blocks <- header:
+			default:
+				log.Warnf("eth subscription %s: drop block %d, client too slow", sub.ID, block.Header.Height)
+			}
+		}
+	}
+	if subs := es.subscriptions(LogsSubscription); len(subs) != 0 {
+		logs, err := GetBlockLogs(block.Header.Height)
+		if err != nil {
+			log.Errorf("eth subscription: get logs of block %d error: %s", block.Header.Height, err)
+			return
+		}
+		if len(logs) == 0 {
+			return
+		}
+		for _, sub := range subs {
+			select {
+			case sub.logs <- logs:
+			default:
+				log.Warnf("eth subscription %s: drop logs of block %d, client too slow", sub.ID, block.Header.Height)
+			}
+		}
+	}
+}
+
+func (es *EventSystem) onTxPoolAddTx(v interface{}) {
+	tx, ok := v.(*otypes.Transaction)
+	if !ok {
+		return
+	}
+	hashes := []common.Hash{utils2.OntToEthHash(tx.Hash())}
+	for _, sub := range es.subscriptions(PendingTransactionsSubscription) {
+		select {
+		case sub.hashes <- hashes:
+		default:
+			log.Warnf("eth subscription %s: drop pending tx %s, client too slow", sub.ID, hashes[0].Hex())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package filters
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	otypes "github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSystemSubscriptions(t *testing.T) {
+	es := &EventSystem{subs: make(map[rpc.ID]*Subscription)}
+	heads := es.SubscribeNewHeads()
+	logs := es.SubscribeLogs()
+	pending := es.SubscribePendingTxs()
+
+	assert.Equal(t, 1, len(es.subscriptions(BlocksSubscription)))
+	assert.Equal(t, 1, len(es.subscriptions(LogsSubscription)))
+	assert.Equal(t, 1, len(es.subscriptions(PendingTransactionsSubscription)))
+
+	tx := &otypes.Transaction{}
+	es.onTxPoolAddTx(tx)
+	hashes := <-pending.hashes
+	assert.Equal(t, 1, len(hashes))
+
+	// values of unexpected type are ignored
+	es.onTxPoolAddTx("invalid")
+	assert.Equal(t, 0, len(pending.hashes))
+
+	heads.Unsubscribe()
+	logs.Unsubscribe()
+	pending.Unsubscribe()
+	assert.Equal(t, 0, len(es.subs))
+}
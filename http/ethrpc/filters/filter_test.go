@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package filters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	oComm "github.com/ontio/ontology/common"
+	otypes "github.com/ontio/ontology/core/types"
+	types2 "github.com/ontio/ontology/http/ethrpc/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	addr1  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	addr2  = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	topic1 = common.HexToHash("0x01")
+	topic2 = common.HexToHash("0x02")
+	topic3 = common.HexToHash("0x03")
+)
+
+func TestFilterLogs(t *testing.T) {
+	logs := []*types.Log{
+		{Address: addr1, Topics: []common.Hash{topic1}},
+		{Address: addr1, Topics: []common.Hash{topic1, topic2}},
+		{Address: addr2, Topics: []common.Hash{topic2, topic3}},
+		{Address: addr2},
+	}
+
+	assert.Equal(t, 4, len(filterLogs(logs, nil, nil)))
+	assert.Equal(t, 2, len(filterLogs(logs, []common.Address{addr1}, nil)))
+	assert.Equal(t, 2, len(filterLogs(logs, nil, [][]common.Hash{{topic1}})))
+	assert.Equal(t, 3, len(filterLogs(logs, nil, [][]common.Hash{{topic1, topic2}})))
+	assert.Equal(t, 2, len(filterLogs(logs, nil, [][]common.Hash{nil, {topic2, topic3}})))
+	assert.Equal(t, 1, len(filterLogs(logs, []common.Address{addr2}, [][]common.Hash{nil, {topic3}})))
+	assert.Equal(t, 0, len(filterLogs(logs, []common.Address{addr1}, [][]common.Hash{{topic3}})))
+}
+
+func TestBloomFilter(t *testing.T) {
+	logs := []*types.Log{{Address: addr1, Topics: []common.Hash{topic1, topic2}}}
+	bloom := types.BytesToBloom(types.LogsBloom(logs))
+
+	assert.True(t, bloomFilter(bloom, nil, nil))
+	assert.True(t, bloomFilter(bloom, []common.Address{addr1}, nil))
+	assert.True(t, bloomFilter(bloom, []common.Address{addr2, addr1}, [][]common.Hash{nil, {topic2}}))
+	assert.False(t, bloomFilter(bloom, []common.Address{addr2}, nil))
+	assert.False(t, bloomFilter(bloom, nil, [][]common.Hash{{topic3}}))
+}
+
+func TestNotifiesToLogs(t *testing.T) {
+	evmLog := &otypes.StorageLog{Address: addr1, Topics: []common.Hash{topic1}, Data: []byte{1, 2}}
+	raw := hexutil.Bytes(oComm.SerializeToBytes(evmLog)).String()
+	notifies := []*event.ExecuteNotify{
+		{
+			TxIndex: 0,
+			Notify: []*event.NotifyEventInfo{
+				{States: []interface{}{"transfer"}},
+				{ContractAddress: oComm.Address(addr1), States: raw, IsEvm: true},
+			},
+		},
+		{
+			TxIndex: 2,
+			Notify:  []*event.NotifyEventInfo{{ContractAddress: oComm.Address(addr1), States: raw, IsEvm: true}},
+		},
+	}
+	logs, err := NotifiesToLogs(notifies, 10, common.Hash{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, uint(0), logs[0].Index)
+	assert.Equal(t, uint(1), logs[1].Index)
+	assert.Equal(t, uint(2), logs[1].TxIndex)
+	assert.Equal(t, uint64(10), logs[1].BlockNumber)
+	assert.Equal(t, []byte{1, 2}, logs[0].Data)
+}
+
+func TestFilterCriteriaUnmarshal(t *testing.T) {
+	var crit types2.FilterCriteria
+	err := json.Unmarshal([]byte(`{"fromBlock":"0x10","toBlock":"latest",
+		"address":"0x1111111111111111111111111111111111111111",
+		"topics":[null,["0x0000000000000000000000000000000000000000000000000000000000000002",
+		"0x0000000000000000000000000000000000000000000000000000000000000003"]]}`), &crit)
+	assert.Nil(t, err)
+	assert.Equal(t, types2.BlockNumber(16), *crit.FromBlock)
+	assert.True(t, crit.ToBlock.IsLatest())
+	assert.Equal(t, []common.Address{addr1}, crit.Addresses)
+	assert.Equal(t, [][]common.Hash{nil, {topic2, topic3}}, crit.Topics)
+
+	err = json.Unmarshal([]byte(`{"fromBlock":"0x10","blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}`), &crit)
+	assert.NotNil(t, err)
+}
+
+func TestResolveHeight(t *testing.T) {
+	latest := types2.LatestBlockNumber
+	past := types2.BlockNumber(5)
+	future := types2.BlockNumber(500)
+	assert.Equal(t, uint32(100), resolveHeight(nil, 100))
+	assert.Equal(t, uint32(100), resolveHeight(&latest, 100))
+	assert.Equal(t, uint32(5), resolveHeight(&past, 100))
+	assert.Equal(t, uint32(100), resolveHeight(&future, 100))
+	assert.NotNil(t, checkRange(10, 5))
+	assert.NotNil(t, checkRange(0, MaxLogsBlockRange))
+	assert.Nil(t, checkRange(5, 10))
+}
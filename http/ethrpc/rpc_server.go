@@ -24,7 +24,9 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	cfg "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/http/ethrpc/debug"
 	"github.com/ontio/ontology/http/ethrpc/eth"
+	"github.com/ontio/ontology/http/ethrpc/filters"
 	"github.com/ontio/ontology/http/ethrpc/net"
 	"github.com/ontio/ontology/http/ethrpc/utils"
 	"github.com/ontio/ontology/http/ethrpc/web3"
@@ -39,6 +41,10 @@ func StartEthServer(txpool *tp.TXPoolServer) error {
 	if err != nil {
 		return err
 	}
+	err = server.RegisterName("eth", filters.NewPublicFilterAPI())
+	if err != nil {
+		return err
+	}
 	netRpcService := net.NewPublicNetAPI()
 	err = server.RegisterName("net", netRpcService)
 	if err != nil {
@@ -49,6 +55,20 @@ func StartEthServer(txpool *tp.TXPoolServer) error {
 	if err != nil {
 		return err
 	}
+	if cfg.DefConfig.Rpc.EthDebugApi {
+		err = server.RegisterName("debug", debug.NewDebugAPI())
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.DefConfig.Rpc.EthWsPort != 0 {
+		go func() {
+			err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.EthWsPort)), server.WebsocketHandler(cfg.DefConfig.Rpc.EthWsOrigins))
+			if err != nil {
+				log.Error("eth websocket server stopped", "err", err)
+			}
+		}()
+	}
 	err = http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.EthJsonPort)), server)
 	if err != nil {
 		return err
@@ -19,11 +19,14 @@ package types
 
 import (
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/payload"
 )
 
 const (
@@ -35,12 +38,13 @@ const (
 type Bloom [BloomByteLength]byte
 
 type CallArgs struct {
-	From     *common.Address `json:"from"`
-	To       *common.Address `json:"to"`
-	Gas      *hexutil.Uint64 `json:"gas"`
-	GasPrice *hexutil.Big    `json:"gasPrice"`
-	Value    *hexutil.Big    `json:"value"`
-	Data     *hexutil.Bytes  `json:"data"`
+	From       *common.Address     `json:"from"`
+	To         *common.Address     `json:"to"`
+	Gas        *hexutil.Uint64     `json:"gas"`
+	GasPrice   *hexutil.Big        `json:"gasPrice"`
+	Value      *hexutil.Big        `json:"value"`
+	Data       *hexutil.Bytes      `json:"data"`
+	AccessList *payload.AccessList `json:"accessList"`
 }
 
 func (args CallArgs) AsMessage(maxGasLimit uint64) types.Message {
@@ -85,20 +89,53 @@ type Account struct {
 }
 
 type Transaction struct {
-	BlockHash        *common.Hash    `json:"blockHash"`
-	BlockNumber      *hexutil.Big    `json:"blockNumber"`
-	From             common.Address  `json:"from"`
-	Gas              hexutil.Uint64  `json:"gas"`
-	GasPrice         *hexutil.Big    `json:"gasPrice"`
-	Hash             common.Hash     `json:"hash"`
-	Input            hexutil.Bytes   `json:"input"`
-	Nonce            hexutil.Uint64  `json:"nonce"`
-	To               *common.Address `json:"to"`
-	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
-	Value            *hexutil.Big    `json:"value"`
-	V                *hexutil.Big    `json:"v"`
-	R                *hexutil.Big    `json:"r"`
-	S                *hexutil.Big    `json:"s"`
+	BlockHash        *common.Hash        `json:"blockHash"`
+	BlockNumber      *hexutil.Big        `json:"blockNumber"`
+	From             common.Address      `json:"from"`
+	Gas              hexutil.Uint64      `json:"gas"`
+	GasPrice         *hexutil.Big        `json:"gasPrice"`
+	GasFeeCap        *hexutil.Big        `json:"maxFeePerGas,omitempty"`
+	GasTipCap        *hexutil.Big        `json:"maxPriorityFeePerGas,omitempty"`
+	Hash             common.Hash         `json:"hash"`
+	Input            hexutil.Bytes       `json:"input"`
+	Nonce            hexutil.Uint64      `json:"nonce"`
+	To               *common.Address     `json:"to"`
+	TransactionIndex *hexutil.Uint64     `json:"transactionIndex"`
+	Value            *hexutil.Big        `json:"value"`
+	Type             hexutil.Uint64      `json:"type"`
+	Accesses         *payload.AccessList `json:"accessList,omitempty"`
+	ChainID          *hexutil.Big        `json:"chainId,omitempty"`
+	V                *hexutil.Big        `json:"v"`
+	R                *hexutil.Big        `json:"r"`
+	S                *hexutil.Big        `json:"s"`
+}
+
+// FeeHistoryResult is the result of eth_feeHistory
+type FeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// DecimalOrHex unmarshals a non-negative decimal or hex parameter
+type DecimalOrHex uint64
+
+func (dh *DecimalOrHex) UnmarshalJSON(data []byte) error {
+	input := strings.TrimSpace(string(data))
+	if len(input) >= 2 && input[0] == '"' && input[len(input)-1] == '"' {
+		input = input[1 : len(input)-1]
+	}
+
+	value, err := strconv.ParseUint(input, 10, 64)
+	if err != nil {
+		value, err = hexutil.DecodeUint64(input)
+	}
+	if err != nil {
+		return err
+	}
+	*dh = DecimalOrHex(value)
+	return nil
 }
 
 type AccountResult struct {
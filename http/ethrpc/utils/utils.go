@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"fmt"
 	"math/big"
 	"reflect"
 
@@ -26,7 +27,9 @@ import (
 	types2 "github.com/ethereum/go-ethereum/core/types"
 	oComm "github.com/ontio/ontology/common"
 	sysconfig "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/core/types"
+	bactor "github.com/ontio/ontology/http/base/actor"
 	types3 "github.com/ontio/ontology/http/ethrpc/types"
 )
 
@@ -47,6 +50,16 @@ func EthBlockFromOntology(block *types.Block, fullTx bool) map[string]interface{
 	return FormatBlock(*block, 0, gasUsed, blockTxs)
 }
 
+// EthHeaderFromOntology returns the block in eth header format, used by newHeads subscription
+func EthHeaderFromOntology(block *types.Block) map[string]interface{} {
+	header := EthBlockFromOntology(block, false)
+	if header != nil {
+		delete(header, "transactions")
+		delete(header, "uncles")
+	}
+	return header
+}
+
 func EthTransactionsFromOntology(txs []*types.Transaction, blockHash common.Hash, blockNumber uint64) ([]common.Hash, *big.Int, []*types3.Transaction) {
 	var transactionHashes []common.Hash
 	var transactions []*types3.Transaction
@@ -65,11 +78,7 @@ func EthTransactionsFromOntology(txs []*types.Transaction, blockHash common.Hash
 }
 
 func OntTxToEthTx(tx types.Transaction, blockHash common.Hash, blockNumber, index uint64) (*types3.Transaction, error) {
-	eip155Tx, err := tx.GetEIP155Tx()
-	if err != nil {
-		return nil, err
-	}
-	return NewTransaction(eip155Tx, common.Hash(tx.Hash()), blockHash, blockNumber, index)
+	return NewTransaction(&tx, blockHash, blockNumber, index)
 }
 
 func FormatBlock(block types.Block, gasLimit uint64, gasUsed *big.Int, transactions interface{}) map[string]interface{} {
@@ -122,25 +131,36 @@ func OntToEthHash(txHash oComm.Uint256) common.Hash {
 	return common.Hash(txHash)
 }
 
-func NewTransaction(tx *types2.Transaction, txHash, blockHash common.Hash, blockNumber, index uint64) (*types3.Transaction, error) {
-	signer := types2.NewEIP155Signer(big.NewInt(int64(GetChainId())))
-	from, err := signer.Sender(tx)
+// NewTransaction returns the eth transaction in rpc format, the sender is the payer whose signature was
+// verified on decoding
+func NewTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber, index uint64) (*types3.Transaction, error) {
+	eip155Tx, err := tx.GetEIP155Tx()
 	if err != nil {
 		return nil, err
 	}
-	v, r, s := tx.RawSignatureValues()
 	rpcTx := &types3.Transaction{
-		From:     from,
-		Gas:      hexutil.Uint64(tx.Gas()),
-		GasPrice: (*hexutil.Big)(tx.GasPrice()),
-		Hash:     txHash,
-		Input:    hexutil.Bytes(tx.Data()),
-		Nonce:    hexutil.Uint64(tx.Nonce()),
-		To:       tx.To(),
-		Value:    (*hexutil.Big)(tx.Value()),
-		V:        (*hexutil.Big)(v),
-		R:        (*hexutil.Big)(r),
-		S:        (*hexutil.Big)(s),
+		From:     common.Address(tx.Payer),
+		Gas:      hexutil.Uint64(eip155Tx.Gas()),
+		GasPrice: (*hexutil.Big)(eip155Tx.GasPrice()),
+		Hash:     common.Hash(tx.Hash()),
+		Input:    hexutil.Bytes(eip155Tx.Data()),
+		Nonce:    hexutil.Uint64(eip155Tx.Nonce()),
+		To:       eip155Tx.To(),
+		Value:    (*hexutil.Big)(eip155Tx.Value()),
+	}
+	if typed := tx.GetEthTypedTx(); typed != nil {
+		rpcTx.Type = hexutil.Uint64(typed.Type)
+		rpcTx.ChainID = (*hexutil.Big)(typed.ChainID)
+		accessList := typed.AccessList
+		rpcTx.Accesses = &accessList
+		if typed.Type == payload.DynamicFeeTxType {
+			rpcTx.GasFeeCap = (*hexutil.Big)(typed.GasFeeCap)
+			rpcTx.GasTipCap = (*hexutil.Big)(typed.GasTipCap)
+		}
+		rpcTx.V, rpcTx.R, rpcTx.S = (*hexutil.Big)(typed.V), (*hexutil.Big)(typed.R), (*hexutil.Big)(typed.S)
+	} else {
+		v, r, s := eip155Tx.RawSignatureValues()
+		rpcTx.V, rpcTx.R, rpcTx.S = (*hexutil.Big)(v), (*hexutil.Big)(r), (*hexutil.Big)(s)
 	}
 
 	if blockHash != (common.Hash{}) {
@@ -155,3 +175,16 @@ func NewTransaction(tx *types2.Transaction, txHash, blockHash common.Hash, block
 func GetChainId() uint32 {
 	return sysconfig.DefConfig.P2PNode.EVMChainId
 }
+
+// ResolveStateHeight return the height of the queried state, latest is true if current state is queried.
+// heights beyond current height are treated as latest
+func ResolveStateHeight(blockNum types3.BlockNumber) (uint32, bool, error) {
+	current := bactor.GetCurrentBlockHeight()
+	if blockNum.IsLatest() || blockNum.IsPending() || blockNum.Int64() >= int64(current) {
+		return current, true, nil
+	}
+	if blockNum.Int64() < 0 {
+		return 0, false, fmt.Errorf("invalid block number %d", blockNum)
+	}
+	return uint32(blockNum), false, nil
+}
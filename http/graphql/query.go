@@ -0,0 +1,309 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package graphql
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	ontErrors "github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/http/base/actor"
+	comm "github.com/ontio/ontology/http/base/common"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+type oep4Balance struct {
+	Contract Addr
+	Addr     Addr
+	Balance  string
+	Height   Uint32
+}
+
+type notifyEvent struct {
+	ContractAddress string
+	States          *JSON
+}
+
+type executeNotify struct {
+	TxHash          H256
+	State           Uint32
+	GasConsumed     Uint64
+	Notify          []*notifyEvent
+	TxIndex         Uint32
+	CreatedContract *string
+}
+
+type merkleProof struct {
+	TransactionsRoot H256
+	BlockHeight      Uint32
+	CurBlockRoot     H256
+	CurBlockHeight   Uint32
+	TargetHashes     []H256
+}
+
+type txPoolAttr struct {
+	Height  Uint32
+	Type    Uint32
+	ErrCode Uint32
+}
+
+type mempoolTxState struct {
+	States []*txPoolAttr
+}
+
+type mempool struct {
+	VerifiedCount Uint32
+	PendingCount  Uint32
+	TxHashes      []H256
+}
+
+type peerPoolItem struct {
+	Index      Uint32
+	PeerPubkey PubKey
+	Address    Addr
+	Status     Uint32
+	InitPos    Uint64
+	TotalPos   Uint64
+}
+
+type peerPool struct {
+	View  Uint32
+	Peers []*peerPoolItem
+}
+
+func NewExecuteNotify(obj *event.ExecuteNotify) *executeNotify {
+	notify := &executeNotify{
+		TxHash:      H256(obj.TxHash),
+		State:       Uint32(obj.State),
+		GasConsumed: Uint64(obj.GasConsumed),
+		TxIndex:     Uint32(obj.TxIndex),
+	}
+	for _, v := range obj.Notify {
+		evt := &notifyEvent{ContractAddress: v.ContractAddress.ToHexString()}
+		if v.States != nil {
+			evt.States = &JSON{Value: v.States}
+		}
+		notify.Notify = append(notify.Notify, evt)
+	}
+	if obj.CreatedContract != common.ADDRESS_EMPTY {
+		created := obj.CreatedContract.ToHexString()
+		notify.CreatedContract = &created
+	}
+	return notify
+}
+
+func (self *resolver) GetOep4Balance(args struct {
+	Contract Addr
+	Addr     Addr
+}) (*oep4Balance, error) {
+	balances, height, err := comm.GetOep4ContractBalance(args.Contract.Address, []common.Address{args.Addr.Address}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oep4Balance{
+		Contract: args.Contract,
+		Addr:     args.Addr,
+		Balance:  balances[0],
+		Height:   Uint32(height),
+	}, nil
+}
+
+func (self *resolver) GetSmartCodeEventByTx(args struct{ Hash H256 }) (*executeNotify, error) {
+	notify, err := actor.GetEventNotifyByTxHash(common.Uint256(args.Hash))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExecuteNotify(notify), nil
+}
+
+func (self *resolver) GetSmartCodeEventByHeight(args struct{ Height Uint32 }) ([]*executeNotify, error) {
+	notifies, err := actor.GetEventNotifyByHeight(uint32(args.Height))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*executeNotify, 0, len(notifies))
+	for _, notify := range notifies {
+		res = append(res, NewExecuteNotify(notify))
+	}
+	return res, nil
+}
+
+func (self *resolver) GetContract(args struct{ Addr Addr }) (*deployCodePayload, error) {
+	contract, err := actor.GetContractStateFromStore(args.Addr.Address)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if contract == nil {
+		return nil, nil
+	}
+
+	return NewTxPayload(contract).pl.(*deployCodePayload), nil
+}
+
+func (self *resolver) GetStorage(args struct {
+	Contract Addr
+	Key      string
+}) (*string, error) {
+	key, err := hex.DecodeString(args.Key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := actor.GetStorageItem(args.Contract.Address, key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	res := common.ToHexString(value)
+	return &res, nil
+}
+
+func (self *resolver) GetMerkleProof(args struct{ Hash H256 }) (*merkleProof, error) {
+	height, _, err := actor.GetTxnWithHeightByTxHash(common.Uint256(args.Hash))
+	if err != nil {
+		return nil, err
+	}
+	header, err := actor.GetHeaderByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	curHeight := actor.GetCurrentBlockHeight()
+	curHeader, err := actor.GetHeaderByHeight(curHeight)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := actor.GetMerkleProof(height, curHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]H256, 0, len(proof))
+	for _, hash := range proof {
+		hashes = append(hashes, H256(hash))
+	}
+	return &merkleProof{
+		TransactionsRoot: H256(header.TransactionsRoot),
+		BlockHeight:      Uint32(height),
+		CurBlockRoot:     H256(curHeader.BlockRoot),
+		CurBlockHeight:   Uint32(curHeight),
+		TargetHashes:     hashes,
+	}, nil
+}
+
+func (self *resolver) GetMempool() *mempool {
+	count := actor.GetTxnCount()
+	var hashes []H256
+	for _, hash := range actor.GetTxnHashList() {
+		hashes = append(hashes, H256(hash))
+	}
+
+	return &mempool{
+		VerifiedCount: Uint32(count[0]),
+		PendingCount:  Uint32(count[1]),
+		TxHashes:      hashes,
+	}
+}
+
+func (self *resolver) GetMempoolTxState(args struct{ Hash H256 }) (*mempoolTxState, error) {
+	entry, err := actor.GetTxFromPool(common.Uint256(args.Hash))
+	if err != nil {
+		return nil, nil
+	}
+
+	state := &mempoolTxState{}
+	for _, attr := range entry.Attrs {
+		state.States = append(state.States, &txPoolAttr{
+			Height:  Uint32(attr.Height),
+			Type:    Uint32(attr.Type),
+			ErrCode: Uint32(attr.ErrCode),
+		})
+	}
+	return state, nil
+}
+
+func (self *resolver) GetPeerPool() (*peerPool, error) {
+	view, peers, err := comm.GetPeerPool()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &peerPool{View: Uint32(view)}
+	for _, peer := range peers {
+		pool.Peers = append(pool.Peers, &peerPoolItem{
+			Index:      Uint32(peer.Index),
+			PeerPubkey: PubKey(peer.PeerPubkey),
+			Address:    Addr{peer.Address},
+			Status:     Uint32(peer.Status),
+			InitPos:    Uint64(peer.InitPos),
+			TotalPos:   Uint64(peer.TotalPos),
+		})
+	}
+	return pool, nil
+}
+
+func (self *resolver) GetUnboundOng(args struct{ Addr Addr }) (Uint64, error) {
+	unbound, err := comm.GetContractAllowance(0, utils.OngContractAddress, utils.OntContractAddress, args.Addr.Address)
+	if err != nil {
+		return 0, err
+	}
+
+	return Uint64(unbound), nil
+}
+
+func (self *resolver) GetGrantOng(args struct{ Addr Addr }) (Uint64, error) {
+	grant, err := comm.GetGrantOng(args.Addr.Address)
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseUint(grant, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return Uint64(val), nil
+}
+
+func (self *resolver) SendRawTransaction(args struct{ Tx string }) (H256, error) {
+	raw, err := common.HexToBytes(args.Tx)
+	if err != nil {
+		return H256{}, err
+	}
+	txn, err := types.TransactionFromRawBytes(raw)
+	if err != nil {
+		return H256{}, err
+	}
+	if errCode, desc := comm.SendTxToPool(txn); errCode != ontErrors.ErrNoError {
+		return H256{}, fmt.Errorf("send transaction %s error: %s", txn.Hash().ToHexString(), desc)
+	}
+
+	return H256(txn.Hash()), nil
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var genesisBlock *types.Block
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "graphql")
+	if err != nil {
+		panic(err)
+	}
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		panic(err)
+	}
+	genesisBlock, err = genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	if err != nil {
+		panic(err)
+	}
+	ledger.DefLedger, err = ledger.InitLedger(dir, 0, bookkeepers, genesisBlock)
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	ledger.DefLedger.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func execQuery(t *testing.T, query string, result interface{}) error {
+	resp := ontSchema.Exec(context.Background(), query, "", nil)
+	if len(resp.Errors) != 0 {
+		return resp.Errors[0]
+	}
+	assert.Nil(t, json.Unmarshal(resp.Data, result))
+	return nil
+}
+
+func TestQueryBlock(t *testing.T) {
+	var result struct {
+		GetBlockByHeight struct {
+			Header struct {
+				Height uint32
+				Hash   string
+			}
+			Transactions []struct {
+				Hash   string
+				TxType string
+			}
+		}
+		GetBlockHash string
+	}
+	err := execQuery(t, `{
+		getBlockByHeight(height: 0) { header { height hash } transactions { hash txType } }
+		getBlockHash(height: 0)
+	}`, &result)
+	assert.Nil(t, err)
+	hash := genesisBlock.Hash()
+	assert.Equal(t, uint32(0), result.GetBlockByHeight.Header.Height)
+	assert.Equal(t, hash.ToHexString(), result.GetBlockByHeight.Header.Hash)
+	assert.Equal(t, hash.ToHexString(), result.GetBlockHash)
+	assert.Equal(t, len(genesisBlock.Transactions), len(result.GetBlockByHeight.Transactions))
+
+	var byHash struct {
+		GetBlockByHash struct{ Header struct{ Height uint32 } }
+	}
+	err = execQuery(t, fmt.Sprintf(`{ getBlockByHash(hash: "%s") { header { height } } }`, hash.ToHexString()), &byHash)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), byHash.GetBlockByHash.Header.Height)
+
+	var missing struct{ GetBlockByHeight *struct{ Header struct{ Height uint32 } } }
+	err = execQuery(t, `{ getBlockByHeight(height: 100) { header { height } } }`, &missing)
+	assert.Nil(t, err)
+	assert.Nil(t, missing.GetBlockByHeight)
+}
+
+func TestQueryTransaction(t *testing.T) {
+	tx := genesisBlock.Transactions[0]
+	var result struct {
+		GetTx struct {
+			Hash   string
+			Height uint32
+			TxType string
+		}
+		GetSmartCodeEventByTx struct {
+			TxHash string
+			State  uint32
+		}
+	}
+	err := execQuery(t, fmt.Sprintf(`{
+		getTx(hash: "%[1]s") { hash height txType }
+		getSmartCodeEventByTx(hash: "%[1]s") { txHash state }
+	}`, tx.Hash().ToHexString()), &result)
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Hash().ToHexString(), result.GetTx.Hash)
+	assert.Equal(t, uint32(0), result.GetTx.Height)
+	assert.Equal(t, tx.Hash().ToHexString(), result.GetSmartCodeEventByTx.TxHash)
+
+	var events struct {
+		GetSmartCodeEventByHeight []struct{ TxHash string }
+	}
+	err = execQuery(t, `{ getSmartCodeEventByHeight(height: 0) { txHash } }`, &events)
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, len(events.GetSmartCodeEventByHeight))
+}
+
+func TestQueryState(t *testing.T) {
+	addr := common.AddressFromVmCode([]byte("graphql"))
+	var result struct {
+		GetBalance struct {
+			Ont    string
+			Ong    string
+			Height uint32
+		}
+		GetStorage  *string
+		GetContract *struct{ Name string }
+	}
+	err := execQuery(t, fmt.Sprintf(`{
+		getBalance(addr: "%[1]s") { ont ong height }
+		getStorage(contract: "%[2]s", key: "00")
+		getContract(addr: "%[1]s") { name }
+	}`, addr.ToBase58(), utils.OntContractAddress.ToBase58()), &result)
+	assert.Nil(t, err)
+	assert.Equal(t, "0", result.GetBalance.Ont)
+	assert.Equal(t, "0", result.GetBalance.Ong)
+	assert.Equal(t, uint32(0), result.GetBalance.Height)
+	assert.Nil(t, result.GetStorage)
+	assert.Nil(t, result.GetContract)
+
+	err = execQuery(t, fmt.Sprintf(`{ getStorage(contract: "%s", key: "zz") }`, addr.ToBase58()), &result)
+	assert.NotNil(t, err)
+}
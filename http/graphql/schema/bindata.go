@@ -96,7 +96,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _schemaGraphql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x55\x4d\x6f\xdb\x30\x0c\xbd\xfb\x57\xb0\xc8\xa5\xbb\xf4\xd0\xb5\xc5\xe0\x5b\xb3\x16\x48\xd1\xaf\x6c\xc9\x36\x0c\x45\x31\x30\x32\x6b\x0b\xb1\x25\x4f\x94\xd3\x18\x45\xff\xfb\x20\xcb\x76\xe5\xc4\x1d\xd0\x53\x22\xea\xf1\xe9\x3d\x92\x92\x27\xb0\x42\xa6\xd3\x2f\xa0\x0d\x64\xb4\x05\xb6\x46\xaa\x14\x30\x49\x0c\x31\x47\x2c\x30\x47\x03\xe7\xed\x72\x12\x62\xf4\x13\x64\xc8\xd9\xf1\xe9\x59\x07\x9b\xb9\xff\xbb\x98\xb2\x5a\xe5\x52\xc0\x9a\xea\x0e\x36\xaf\x56\xd7\x54\x47\xdd\xf2\x87\x54\xf6\xf3\x71\x34\x81\x4a\x2a\x7b\x76\x02\xa4\x84\x4e\x28\x01\xe4\x96\x25\x04\x9e\x9d\x44\x11\xa9\xaa\x80\xe5\x76\x59\x97\x04\x2f\x11\x00\xc0\xd5\xdd\xcf\xfb\xeb\xcb\x3f\x77\x97\xf7\xe1\xf2\xd7\xf9\xe2\xb6\x59\x5f\x5c\xce\x6f\xee\x7f\xf7\xdb\xed\xb2\xd9\x7e\x8d\x22\xa9\x2c\x99\x27\x14\x04\x73\xac\x73\x8d\x49\x4b\xea\x54\x40\x0c\x8b\x46\xc3\x81\x43\x5a\x77\xe2\x95\xda\xe8\x35\x7d\x75\x9b\xb2\x28\x73\x2a\x48\x59\x1e\x49\xdd\xcf\xbc\xa0\x32\xd7\xf5\x47\x32\x5d\x64\x53\x38\xa3\xc3\x98\xc2\x62\x17\x45\x86\xa5\x56\xc3\x20\x56\x36\xd3\x66\x18\xa3\x02\x65\x3e\x0c\x25\xc4\x62\xa0\x76\x02\xd6\xa0\x62\x14\x56\x6a\xe5\x9a\x50\x09\x5b\x19\x72\xdd\xd4\xca\xea\x5c\xa7\xb5\x77\xb4\x0c\x60\x2f\x43\x1d\xbe\xab\xfe\x00\x37\x26\x71\x33\x1d\xad\x7c\xad\x04\x0d\x21\x76\xeb\x5d\xfa\xb6\xfa\x58\x8a\x3c\x37\x72\x17\x99\x22\xdf\xc8\x42\xda\x61\xb4\xc4\x9a\x4c\xdc\x0d\x6a\x1f\x73\x95\x8d\xbb\x12\xfb\x28\xcb\x94\x63\x78\x58\xc8\xf4\xe0\xf1\x20\xf2\xfa\x48\xa6\x59\x40\xd8\x35\x6c\x21\xd3\xd6\x16\xcb\xf4\x02\x2d\xba\x3c\x5f\xa6\xc7\xf6\x88\x66\x94\x1d\x9f\x1f\xea\x2e\x7e\x3b\x20\x9b\xc0\x34\xd7\x62\xfd\xbf\x4a\x7a\x80\x3f\x6c\x02\xcb\x8c\x20\x23\x4c\xc8\x38\xa4\xcd\x24\xc3\xca\x01\x8e\x5a\xb9\x6e\x27\x86\x59\xf3\xdb\x7a\xf0\x49\x41\xdf\x38\xc8\x03\xa9\x44\x5e\x25\x94\x78\x82\x10\x15\xc3\x43\xd0\x45\xa7\xbf\x11\xec\xb9\x41\x3a\x96\x50\x0b\x7a\x42\x2f\xba\x05\x85\xaa\xdb\xfe\xf7\xb2\x7d\xe6\xd1\xf8\x6c\x84\x6e\x91\xb3\xd1\xa4\x70\x76\x02\x7c\x69\x68\x23\x75\xd5\xf9\x73\x28\x8f\x77\x1b\xb3\xf1\x1c\x51\x19\x43\xca\x76\x29\x4d\xd3\x8f\x46\x07\x20\xac\xa8\x2c\x88\x2d\x16\x65\x58\xce\x94\x14\x19\xb4\x7d\x3d\x3b\xcc\x28\x43\x41\x66\x9d\xbb\xd6\x10\x81\xd1\xda\xc2\xb3\xb4\x19\xe4\x84\x1b\x62\x78\x32\xba\x68\xe8\xb8\x27\xb7\x7a\xaf\xe3\xcd\xdf\xef\x5a\xdb\x11\x57\x83\x96\x37\xfc\x23\x23\x63\xb7\xfc\x4e\xba\xd0\x8a\x49\x71\xc5\x90\xa0\xc5\xb1\xdc\x1e\xe1\x6f\x80\x7f\x85\x87\x0e\xab\xdc\xca\xee\x9b\xe1\x28\x84\x56\xba\x65\x55\x3a\x21\x86\xe7\x4c\x83\x40\xd5\x17\x0e\x14\x6d\x6d\x78\x88\x5b\x4f\xb5\x5e\xaf\x89\xca\xc1\x45\x1e\x4a\xdd\x67\xed\x19\xf7\x6a\xd6\xb3\x0d\xaf\x67\x40\xc8\x32\x55\xd8\xdd\xc7\x8f\xb1\x8f\x3d\x08\xdd\xb3\x31\xc5\x1c\x95\xe8\xbe\x4d\x5a\xd9\xb7\xa2\xf9\x40\x3a\x0c\xbc\xf7\xfc\x7c\xab\xc8\xd4\x2d\x4b\x4a\xb6\x79\x22\xa6\xf5\xac\x41\x1f\xee\x24\x7d\x8a\xfd\x13\xb2\x0b\x46\xce\x0e\x83\x2b\x34\x0a\xf3\xa0\x3d\xbe\xb7\xf7\x3a\x25\xbb\xdc\xee\xd0\x04\x8f\x46\x4f\xe6\x7d\x1f\xba\x41\x78\xeb\xa0\x3b\xd2\x6f\x34\xce\x58\x64\x54\x60\xeb\xea\xaf\x73\x18\x7b\xa3\xd1\x6b\xf4\x2f\x00\x00\xff\xff\x28\x4d\x93\xb3\x8d\x08\x00\x00")
+var _schemaGraphql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x95\x58\x6d\x6f\xdb\x36\x10\xfe\xee\x5f\xc1\xc0\x5f\x52\xc0\x2b\xfa\x92\x14\x83\xbe\x35\x69\xb0\x64\x6d\x13\xaf\x71\x3b\x0c\x41\x31\xd0\xd2\x59\xe6\x2c\x91\x1a\x49\x39\x32\x82\xfd\xf7\x1d\xdf\x64\x52\x52\x0a\xb4\x5f\x6a\x91\x77\xc7\x7b\x79\xee\x39\x32\x73\xb2\xa6\x0a\xce\x7f\x25\x42\x92\x2d\x74\x44\x69\xc9\x78\x49\x68\x51\x48\x50\x6a\xa6\x72\x5a\x51\x49\xde\xfb\xcf\x79\x2c\x23\x36\x64\x4b\xd5\xf6\xcd\xf9\xbb\x20\x76\x6d\x7e\x0f\x65\x9a\x76\x5d\xb1\x9c\xec\xe0\x10\xc4\x96\xed\xfa\x23\x7e\x85\xcf\xaf\x8c\xeb\xb7\x6f\x50\xaf\xc5\x1f\xef\xce\x08\xf0\x5c\x14\x50\x10\xaa\xbc\x95\x58\xf0\xdd\x19\x0a\x52\xb9\x66\x5a\x52\x79\x20\xff\x28\xc1\xc9\x9e\x56\x2d\x04\xa1\xdf\xef\xef\x6e\x67\x33\xe0\x6d\x4d\x56\xdd\xea\xd0\x00\x79\x9a\x11\xfc\x77\x73\xfb\xed\xee\xe3\xd5\xdf\xb7\x57\x77\xf1\xe7\x9f\xef\xef\x3f\xdb\xef\x0f\x57\xcb\x4f\x77\x7f\xf5\xdb\xfe\xb3\xdf\xbe\xba\x59\xbe\x3e\x3f\x9f\xfd\x37\x9b\xa1\x0f\x20\x37\x34\x07\xb2\xa4\x87\x4a\xd0\xc2\xdb\x37\x3e\x93\x8c\xdc\x5b\x8f\x4f\x8c\xa4\x36\x87\xdf\xf0\xbd\xd8\xc1\xa5\xd9\x64\x75\x53\x41\x0d\x5c\xab\x09\xd5\xb1\xe6\x07\x68\x2a\x71\xf8\x19\x4d\xb3\xb2\xaf\x4d\xcc\xe9\x1a\xa7\xf5\x50\x0a\xa4\x62\x82\xa7\x8b\xb4\xd5\x5b\x21\xd3\x35\xa8\x29\xab\xd2\xa5\x02\x54\x9e\x78\x3b\x27\xa0\xb7\x20\x01\x33\x8e\x45\xe1\x8a\xe6\x1a\x6d\x13\xc5\x4a\x8e\x55\x7c\x64\x7a\x6b\xd2\xf7\x8b\xc9\x9f\x0d\xcc\xe5\xf2\xc7\x81\xcd\x89\xac\x9a\x1e\x09\x53\xf6\x9f\x89\xff\x19\x67\x0c\x50\xed\xbe\xf9\x91\x6a\x6c\xa4\xa8\x87\x36\x78\x5b\x55\x64\x83\x2d\x91\x0b\x8e\x66\x72\x4d\x72\x09\xb4\x3f\x56\x8b\xa0\xe0\xf2\x2b\x78\x8e\x6e\x38\x78\xfa\x04\x1b\x48\xa6\x56\x4b\xaa\x96\x92\xe5\xe3\xd5\x4f\xac\x66\x3a\x55\x67\xbc\x69\x75\x2a\x98\x6f\x29\xe3\x37\xc5\x20\xf3\x49\xc2\xb5\x6c\x73\xdd\x4a\x30\x5d\x87\x7e\x8b\x4a\x94\x07\x97\xf2\x55\x24\xf6\x94\x22\xc0\x75\xdf\x49\x94\x1c\xd3\xc5\x27\xc3\xc0\x82\x88\xee\x1c\xbe\x5c\x6f\x0d\x23\x8b\x25\xd3\xc8\xc2\x6a\x43\x0f\x80\x18\xf3\x84\xd2\xaf\x99\xd2\x67\x01\x03\x6e\x15\xf1\xa3\x32\xf2\x70\xcf\xca\x93\xef\x27\x33\xe7\x1f\xb0\x72\x1b\x19\x0c\xad\x82\x32\x3e\x2c\x54\xfa\x40\x35\x35\x7a\x2e\x4d\xdf\xfd\x11\x96\x72\x8c\x3d\x47\x3e\x61\xfd\x73\x62\x6c\x4e\x2e\x2a\x91\xef\x7e\x94\x49\x27\x10\x40\xba\xda\x02\x3a\x45\x0b\x90\x46\x52\x6f\x99\x22\x6b\x23\xf0\xd2\xbb\x6b\x76\x30\xa1\xf6\x7f\x1f\x83\x53\x8a\xea\xa6\x22\x3d\x2c\x7c\x5e\xb5\x08\x79\x67\x20\x96\x42\xd7\xa3\x2a\x1a\xff\xad\xc3\xce\x36\x61\xc6\x4a\xec\x0b\x75\x06\x9d\xd3\x5e\x28\xf6\xda\xd7\xbf\x77\xdb\x69\xbe\x9c\xc6\x46\x1c\x2d\x42\x64\x52\x29\xc6\x4e\x24\xdf\x48\xd8\x33\xd1\x86\xf8\x8c\x94\x93\x37\x1b\xd7\xd3\x3a\x79\x2b\x25\x72\x42\x50\xb1\x45\x7f\x39\x09\x80\x38\xa3\xac\x06\xa5\x69\xdd\xc4\xe9\x2c\x81\x83\xa4\xba\xcf\x67\x90\x99\xb4\x50\x83\xdc\x55\xa6\x34\x00\x44\x0a\xa1\x1d\x75\x55\x40\xf7\xa0\x2c\x4b\x58\x73\xaa\x37\xae\xc5\xa8\xe2\xf6\xe7\x17\xd4\x9d\x88\x2a\x29\xb9\xb5\x3f\x01\x19\xdd\xa9\x67\xd4\x91\x89\x14\x70\x85\x99\x2c\x10\xe0\x53\xba\xbd\x84\xeb\x80\xc0\x27\x71\x84\x6d\xa5\x59\x98\xed\xc6\x04\xaa\x08\x6f\x95\x23\x95\x2a\xf2\xb8\x15\x24\xa7\xbc\x4f\x1c\xe1\xd0\xe9\xf8\x10\xf3\x7d\x21\xc4\x6e\x07\xd0\x24\x8d\x9c\xba\x3a\xb6\xda\x5b\x1c\xe5\xac\xb7\x96\xb6\x67\x64\xd0\x8c\x12\x1a\xfa\xf1\xe7\xac\x4f\x11\x42\xa0\x8d\x0b\xbc\x30\x20\xc1\xf9\xbe\xc0\x3e\x4f\x49\x58\xf0\x32\x5d\x78\x8e\x7e\xee\xa0\x39\x4b\x6d\x85\xb1\x31\x20\x3a\x93\xf9\xc1\xd2\x1c\xef\x5f\x4e\x93\x71\x1c\xad\x39\xab\x69\x15\xae\x3d\x36\x3b\x6e\x37\x9d\x05\x13\x8e\xcc\xc9\xad\xd0\x6c\x73\x20\xb0\x37\xad\x03\x48\xbc\x08\x7b\xb2\x3e\x10\x55\x53\xa9\x7b\x8f\x9c\xc7\x4e\xf6\xca\x8a\xa6\x1e\x7b\xe7\xd2\xf3\xb0\x67\x34\xe0\x9a\xbd\x5b\xd9\xc3\xae\x3a\xc8\x5b\x3b\x4e\x50\x1a\x51\xe5\x28\x27\x1e\xcf\x6e\xd4\x5b\x31\xf0\xae\x3d\x79\x88\x5f\x0f\xc6\xcc\x9c\xbc\xb6\xc3\x56\xb5\x79\x8e\x67\x2f\xc8\x2b\xfb\xb9\xc1\xab\x07\xd6\xfc\xe8\xc1\x68\xbe\x5c\x22\xe2\xdb\x1a\x8a\xb4\x4c\xdc\x9e\x86\x15\x8f\xa2\x0c\x7c\xaf\xbb\x1b\x5e\x40\x97\x5a\x9a\xc7\x2d\xa1\x1d\x80\xa3\xa9\xef\xd2\x88\xd7\x8a\xf1\xf5\xc3\x6d\x5f\xf6\xd5\xf6\x97\x82\x00\x8c\xcf\x96\x51\x96\xd8\xec\x9b\x10\x7c\x44\x02\x71\xa3\xf7\xe4\x71\x3d\x28\xad\x3d\xa6\x95\x17\x43\x62\x89\xd7\xa7\x74\x34\x95\x25\x68\x93\x69\x53\xb8\x07\xab\x14\x41\x7f\xd5\x2d\x85\xa8\xde\x6b\x1d\xa6\xc2\x10\x52\xd6\x86\x9d\xf4\xf1\x0a\x48\x79\x69\xaf\x5c\x09\xf2\xbe\x81\x64\x1b\x96\x53\x7f\x0b\x31\x6d\x38\x84\x83\x41\xb7\xc9\xac\xee\x48\x83\x07\x87\xf4\xd4\xe6\x63\xd5\xdd\x5b\x9d\xa7\x04\x6b\x0f\x47\x17\x63\xc7\xbd\x4e\x3f\xcb\xf0\x9e\xbf\x76\x13\x6f\x6f\xbd\xc0\x62\xc5\x39\x0e\xf3\xcc\xee\x5c\x8a\x96\xeb\x61\xe9\x8f\x06\x12\x82\x6e\xb9\x99\x98\xfb\x28\x32\x37\xb5\x80\x17\x58\xe1\x09\x4b\x0e\xd6\x93\xc9\x5e\x02\x48\x13\xcb\x8d\x86\xda\x3b\xce\xc6\x28\x6c\x8c\x54\xbb\xc6\x77\x52\xe6\x5f\x48\x47\xd2\xb0\x1d\x39\xe0\x8d\x57\x19\xf6\x5e\xc9\x14\x3e\x47\xa0\x58\x90\xd7\x99\x21\xee\x82\xe1\x74\x80\x05\x79\x93\x1d\xc7\xc1\x82\xbc\xcd\xc8\xbf\x2d\xd3\xf1\xd2\x99\x5b\xd2\x18\xcc\x82\x9c\x67\x08\x3e\x9a\xef\x2a\x63\xad\xe8\xcb\xd0\xaa\xd4\x43\xc6\x99\x5e\x0a\x95\x76\x9b\x16\x9a\x56\xc9\xea\x30\xec\x70\xe3\x64\xf0\x38\x8e\xd8\x72\x7e\x94\x9f\x38\x6f\x7f\xb4\x20\x03\x6b\x20\x98\x2d\xd6\x2f\x0e\x0e\xed\xa7\x03\xc0\xbe\xc8\xdc\xd5\x6c\x28\x8c\x35\x39\x8d\xae\x26\x93\x62\x4e\x68\x64\xef\xd8\x68\x28\xb8\xea\x06\x66\x56\x03\x2e\x30\xc6\x1c\x57\x9f\xa6\x34\x6f\x8e\x74\x1b\xbd\xb1\x68\x60\x9c\x8e\x47\xc5\x82\x8c\x0c\x44\x0a\xbd\x91\x7b\xc3\xeb\xa6\x1b\x2d\xc1\x5d\x1c\x46\x1e\x26\xe4\xfb\x9c\xd6\xb3\xd9\x7c\x48\xd4\x03\x7f\xa2\x85\xc0\x76\xe3\x30\x8f\x2f\x56\x0f\x51\xfb\x0a\x0a\xa4\xaa\xb4\x90\xb4\x04\xf3\x87\x00\xc3\x06\x5b\xe8\x16\xee\x9d\xc5\x36\x86\xb2\x09\x74\x88\xbe\xde\x4d\x27\x3c\x99\x1d\xdb\x21\x7e\x3c\xbd\x48\x1e\x62\xa8\x18\x51\xee\x20\x1d\xd1\xce\xc9\x51\xda\xb2\x49\x16\x68\x65\xb8\xe1\xa9\x69\x64\x29\xde\x0c\x2a\x01\xc5\x59\x0f\xfc\xde\xda\x57\xbe\x46\xc2\x28\xee\x78\x39\x4e\x5a\xdc\x4b\x28\xfa\x1b\xc2\x4a\xff\x50\xb0\x67\xc2\x56\xd3\xe8\x41\x37\x27\xd8\xda\x85\xcd\xb4\xa4\x8f\x43\xee\x35\x7f\x92\x31\xf7\x55\x47\xbf\x0b\x64\x0e\xbc\x4e\x79\x4a\x9e\x7a\x26\x1b\x5b\x5f\xe8\x63\x04\xf2\x53\xdd\xc5\x59\x77\xcd\xd1\x3f\xc0\xda\xb5\xca\x25\x6b\x12\x7f\xec\x3c\x53\x44\xe1\xfd\xb9\x30\x87\x57\x50\x94\x20\xfd\x2d\xf2\xd1\x76\x9e\xef\xc6\xfe\xe9\xbe\xb7\x7f\x0a\x40\xc0\x78\xa5\x88\x91\x17\x64\xc3\x2a\xcb\x75\x66\x1a\x27\x53\x3a\x9a\xde\x6e\xf6\x1b\x48\x95\x0c\x8d\xb9\xa1\x65\xad\x8e\xa1\x34\x6c\x11\x1b\x8e\xca\xb7\x50\x53\x1f\xc3\xbf\x86\x80\x32\xc7\x43\x76\xa1\xf6\x39\xcf\xfa\xec\xbb\x6c\x45\xe1\x67\x49\x32\xd0\xe4\xff\x9d\xd3\x66\x65\x62\x13\x00\x00")
 
 func schemaGraphqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -111,7 +111,7 @@ func schemaGraphql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "schema.graphql", size: 2189, mode: os.FileMode(438), modTime: time.Unix(1598341445, 0)}
+	info := bindataFileInfo{name: "schema.graphql", size: 4962, mode: os.FileMode(438), modTime: time.Unix(1598341445, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -23,6 +23,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 	"github.com/ontio/ontology/common"
@@ -83,6 +85,19 @@ type deployCodePayload struct {
 	Desc    string
 }
 
+type eip155CodePayload struct {
+	Code     string
+	Hash     string
+	From     string
+	To       *string
+	Nonce    Uint64
+	Value    string
+	GasPrice string
+	GasLimit Uint64
+	Input    string
+	ChainId  string
+}
+
 type TxPayload struct {
 	pl interface{}
 }
@@ -97,12 +112,19 @@ func (self *TxPayload) ToDeployCode() (*deployCodePayload, bool) {
 	return pl, ok
 }
 
+func (self *TxPayload) ToEIP155Code() (*eip155CodePayload, bool) {
+	pl, ok := self.pl.(*eip155CodePayload)
+	return pl, ok
+}
+
 func (self *TxPayload) Code() string {
 	switch pd := self.pl.(type) {
 	case *invokeCodePayload:
 		return pd.Code
 	case *deployCodePayload:
 		return pd.Code
+	case *eip155CodePayload:
+		return pd.Code
 	default:
 		panic("unreachable")
 	}
@@ -127,11 +149,45 @@ func NewTxPayload(pl types.Payload) *TxPayload {
 			Desc:    val.Description,
 		}
 		return &TxPayload{pl: dp}
+	case *payload.EIP155Code:
+		return &TxPayload{pl: newEIP155CodePayload(val)}
 	default:
 		panic("unreachable")
 	}
 }
 
+func newEIP155CodePayload(pl *payload.EIP155Code) *eip155CodePayload {
+	sink := common.NewZeroCopySink(nil)
+	pl.Serialization(sink)
+	code, _, _, _ := common.NewZeroCopySource(sink.Bytes()).NextVarBytes()
+	tx := pl.EIPTx
+	res := &eip155CodePayload{
+		Code:     common.ToHexString(code),
+		Hash:     tx.Hash().Hex(),
+		Nonce:    Uint64(tx.Nonce()),
+		Value:    tx.Value().String(),
+		GasPrice: tx.GasPrice().String(),
+		GasLimit: Uint64(tx.Gas()),
+		Input:    hexutil.Encode(tx.Data()),
+		ChainId:  tx.ChainId().String(),
+	}
+	// the sender is verified when the transaction is accepted
+	if pl.TypedTx != nil {
+		res.Hash = pl.TypedTx.Hash().Hex()
+		res.ChainId = pl.TypedTx.ChainID.String()
+		if from, err := pl.TypedTx.Sender(); err == nil {
+			res.From = from.Hex()
+		}
+	} else if from, err := ethtypes.NewEIP155Signer(tx.ChainId()).Sender(tx); err == nil {
+		res.From = from.Hex()
+	}
+	if tx.To() != nil {
+		to := tx.To().Hex()
+		res.To = &to
+	}
+	return res
+}
+
 func NewTransaction(tx *types.Transaction, height uint32) *transaction {
 	ty := convTxType(tx)
 	var sigs []*Sig
@@ -212,6 +268,9 @@ func (self *resolver) GetBlockByHeight(args struct{ Height Uint32 }) (*block, er
 	if err != nil {
 		return nil, err
 	}
+	if b == nil {
+		return nil, nil
+	}
 
 	return NewBlock(b), nil
 }
@@ -241,6 +300,7 @@ const INVOKE_NEO TxType = "INVOKE_NEO"
 const INVOKE_WASM TxType = "INVOKE_WASM"
 const DEPLOY_NEO TxType = "DEPLOY_NEO"
 const DEPLOY_WASM TxType = "DEPLOY_WASM"
+const EIP155 TxType = "EIP155"
 
 func convTxType(tx *types.Transaction) TxType {
 	switch pl := tx.Payload.(type) {
@@ -259,6 +319,8 @@ func convTxType(tx *types.Transaction) TxType {
 		default:
 			panic("unreachable")
 		}
+	case *payload.EIP155Code:
+		return EIP155
 	default:
 		panic("unreachable")
 	}
@@ -317,6 +379,8 @@ func StartServer(cfg *config.GraphQLConfig) {
 	}))
 
 	serverMut.Handle("/query", &relay.Handler{Schema: ontSchema})
+	hub = newBlockHub()
+	serverMut.Handle("/subscriptions", newWsServer(cfg.WsOrigins))
 
 	server := &http.Server{Handler: serverMut}
 	listener, err := net.Listen("tcp", ":"+strconv.Itoa(int(cfg.GraphQLPort)))
@@ -0,0 +1,353 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/events/message"
+	"github.com/ontio/ontology/http/base/actor"
+	"github.com/ontio/ontology/smartcontract/event"
+)
+
+// size of the channel buffering blocks of a single subscription, blocks are dropped
+// for the subscription when the client can not keep up.
+const subChanSize = 64
+
+const (
+	// max number of the websocket connections served at the same time
+	maxWsConns = 128
+	// max number of the operations running on a websocket connection
+	maxWsOperations = 16
+)
+
+var errSubscriptionDisabled = errors.New("subscription is not enabled")
+
+// blockHub dispatches the saved blocks to the subscriptions
+type blockHub struct {
+	lock   sync.RWMutex
+	nextId uint64
+	subs   map[uint64]chan *types.Block
+}
+
+var hub *blockHub
+
+func newBlockHub() *blockHub {
+	h := &blockHub{subs: make(map[uint64]chan *types.Block)}
+	actor.SubscribeEvent(message.TOPIC_SAVE_BLOCK_COMPLETE, h.onBlockSaved)
+	return h
+}
+
+// subscribe returns the channel of saved blocks, which is closed when ctx is done
+func (self *blockHub) subscribe(ctx context.Context) <-chan *types.Block {
+	ch := make(chan *types.Block, subChanSize)
+	self.lock.Lock()
+	id := self.nextId
+	self.nextId++
+	self.subs[id] = ch
+	self.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		self.lock.Lock()
+		delete(self.subs, id)
+		self.lock.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+func (self *blockHub) onBlockSaved(v interface{}) {
+	block, ok := v.(types.Block)
+	if !ok {
+		return
+	}
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	for id, ch := range self.subs {
+		select {
+		case ch <- &block:
+		default:
+			log.Warnf("graphql subscription %d: drop block %d, client too slow", id, block.Header.Height)
+		}
+	}
+}
+
+func (self *resolver) NewBlock(ctx context.Context) (<-chan *block, error) {
+	if hub == nil {
+		return nil, errSubscriptionDisabled
+	}
+	blocks := hub.subscribe(ctx)
+	out := make(chan *block)
+	go func() {
+		defer close(out)
+		for b := range blocks {
+			select {
+			case out <- NewBlock(b):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (self *resolver) Events(ctx context.Context, args struct{ Contract *Addr }) (<-chan *executeNotify, error) {
+	if hub == nil {
+		return nil, errSubscriptionDisabled
+	}
+	blocks := hub.subscribe(ctx)
+	out := make(chan *executeNotify)
+	go func() {
+		defer close(out)
+		for b := range blocks {
+			notifies, err := actor.GetEventNotifyByHeight(b.Header.Height)
+			if err != nil {
+				log.Errorf("graphql subscription: get events of block %d error: %s", b.Header.Height, err)
+				continue
+			}
+			for _, notify := range notifies {
+				if args.Contract != nil && !hasContract(notify, args.Contract.Address) {
+					continue
+				}
+				select {
+				case out <- NewExecuteNotify(notify):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func hasContract(notify *event.ExecuteNotify, contract common.Address) bool {
+	for _, evt := range notify.Notify {
+		if evt.ContractAddress == contract {
+			return true
+		}
+	}
+	return false
+}
+
+// message types of the graphql-ws protocol
+const (
+	wsConnectionInit      = "connection_init"
+	wsConnectionAck       = "connection_ack"
+	wsConnectionTerminate = "connection_terminate"
+	wsStart               = "start"
+	wsData                = "data"
+	wsError               = "error"
+	wsComplete            = "complete"
+	wsStop                = "stop"
+)
+
+type wsMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsStartPayload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// wsConn serves the operations of a websocket connection with the graphql-ws protocol
+type wsConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	lock    sync.Mutex
+	ops     map[string]context.CancelFunc
+}
+
+// wsServer upgrades the requests of the allowed origins to websocket connections serving the graphql-ws protocol
+type wsServer struct {
+	upgrader websocket.Upgrader
+	conns    int32
+}
+
+func newWsServer(origins []string) *wsServer {
+	return &wsServer{upgrader: websocket.Upgrader{
+		Subprotocols: []string{"graphql-ws"},
+		CheckOrigin:  newOriginChecker(origins),
+	}}
+}
+
+// newOriginChecker returns the origin check of websocket upgrade, localhost is allowed if origins is empty. Browsers
+// always send the Origin header, the requests without it are not from browsers and are accepted. The scheme and port
+// of an allowed origin are only compared if it has them.
+func newOriginChecker(origins []string) func(r *http.Request) bool {
+	if len(origins) == 0 {
+		origins = []string{"localhost", "127.0.0.1"}
+	}
+	return func(r *http.Request) bool {
+		if _, ok := r.Header["Origin"]; !ok {
+			return true
+		}
+		origin := r.Header.Get("Origin")
+		for _, allowed := range origins {
+			if allowed == "*" || originMatches(allowed, origin) {
+				return true
+			}
+		}
+		log.Debugf("graphql websocket: reject origin %s", origin)
+		return false
+	}
+}
+
+func originMatches(allowed, origin string) bool {
+	allowedURL, err := parseOrigin(allowed)
+	if err != nil {
+		return false
+	}
+	originURL, err := parseOrigin(origin)
+	if err != nil {
+		return false
+	}
+	if allowedURL.Scheme != "" && allowedURL.Scheme != originURL.Scheme {
+		return false
+	}
+	if allowedURL.Port() != "" && allowedURL.Port() != originURL.Port() {
+		return false
+	}
+	return allowedURL.Hostname() == originURL.Hostname()
+}
+
+func parseOrigin(origin string) (*url.URL, error) {
+	origin = strings.ToLower(origin)
+	if !strings.Contains(origin, "://") {
+		origin = "//" + origin
+	}
+	return url.Parse(origin)
+}
+
+func (self *wsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.AddInt32(&self.conns, 1) > maxWsConns {
+		atomic.AddInt32(&self.conns, -1)
+		http.Error(w, "too many websocket connections", http.StatusServiceUnavailable)
+		return
+	}
+	defer atomic.AddInt32(&self.conns, -1)
+	conn, err := self.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debugf("graphql websocket upgrade error: %s", err)
+		return
+	}
+	ws := &wsConn{conn: conn, ops: make(map[string]context.CancelFunc)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		conn.Close()
+	}()
+
+	for {
+		msg := &wsMessage{}
+		if err := conn.ReadJSON(msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case wsConnectionInit:
+			ws.write(&wsMessage{Type: wsConnectionAck})
+		case wsStart:
+			ws.start(ctx, msg)
+		case wsStop:
+			ws.stop(msg.Id)
+		case wsConnectionTerminate:
+			return
+		default:
+			ws.writeError(msg.Id, errors.New("unknown message type "+msg.Type))
+		}
+	}
+}
+
+func (self *wsConn) start(ctx context.Context, msg *wsMessage) {
+	payload := &wsStartPayload{}
+	if err := json.Unmarshal(msg.Payload, payload); err != nil {
+		self.writeError(msg.Id, err)
+		return
+	}
+	self.lock.Lock()
+	if _, ok := self.ops[msg.Id]; ok {
+		self.lock.Unlock()
+		self.writeError(msg.Id, errors.New("duplicated operation id "+msg.Id))
+		return
+	}
+	if len(self.ops) >= maxWsOperations {
+		self.lock.Unlock()
+		self.writeError(msg.Id, errors.New("too many operations on the connection"))
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	self.ops[msg.Id] = cancel
+	self.lock.Unlock()
+
+	responses, err := ontSchema.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables)
+	if err != nil {
+		self.stop(msg.Id)
+		self.writeError(msg.Id, err)
+		return
+	}
+	go func() {
+		for resp := range responses {
+			data, err := json.Marshal(resp)
+			if err != nil {
+				log.Errorf("graphql subscription: marshal response error: %s", err)
+				continue
+			}
+			self.write(&wsMessage{Id: msg.Id, Type: wsData, Payload: data})
+		}
+		self.stop(msg.Id)
+		self.write(&wsMessage{Id: msg.Id, Type: wsComplete})
+	}()
+}
+
+func (self *wsConn) stop(id string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if cancel, ok := self.ops[id]; ok {
+		cancel()
+		delete(self.ops, id)
+	}
+}
+
+func (self *wsConn) writeError(id string, err error) {
+	payload, _ := json.Marshal(map[string]string{"message": err.Error()})
+	self.write(&wsMessage{Id: id, Type: wsError, Payload: payload})
+}
+
+func (self *wsConn) write(msg *wsMessage) {
+	self.writeMu.Lock()
+	defer self.writeMu.Unlock()
+	if err := self.conn.WriteJSON(msg); err != nil {
+		log.Debugf("graphql websocket write error: %s", err)
+	}
+}
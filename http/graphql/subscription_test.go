@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHub replaces the block hub with one not subscribing to the saved blocks of ledger
+func newTestHub(t *testing.T) {
+	hub = &blockHub{subs: make(map[uint64]chan *types.Block)}
+	t.Cleanup(func() { hub = nil })
+}
+
+func waitSubscriptions(t *testing.T, count int) {
+	for i := 0; i < 100; i++ {
+		hub.lock.RLock()
+		n := len(hub.subs)
+		hub.lock.RUnlock()
+		if n == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("subscriptions not %d", count)
+}
+
+func TestSubscriptionResolvers(t *testing.T) {
+	_, err := (&resolver{}).NewBlock(context.Background())
+	assert.Equal(t, errSubscriptionDisabled, err)
+
+	newTestHub(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	blocks, err := (&resolver{}).NewBlock(ctx)
+	assert.Nil(t, err)
+	ontContract := &Addr{utils.OntContractAddress}
+	events, err := (&resolver{}).Events(ctx, struct{ Contract *Addr }{ontContract})
+	assert.Nil(t, err)
+	waitSubscriptions(t, 2)
+
+	hub.onBlockSaved(*genesisBlock)
+	b := <-blocks
+	assert.Equal(t, H256(genesisBlock.Hash()), b.Header.Hash)
+	notify := <-events
+	assert.NotEqual(t, 0, len(notify.Notify))
+
+	cancel()
+	waitSubscriptions(t, 0)
+	for range blocks {
+	}
+	for range events {
+	}
+}
+
+func dialTestServer(t *testing.T, server *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) *wsMessage {
+	msg := &wsMessage{}
+	assert.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	assert.Nil(t, conn.ReadJSON(msg))
+	return msg
+}
+
+func startMessage(id, query string) *wsMessage {
+	payload, _ := json.Marshal(&wsStartPayload{Query: query})
+	return &wsMessage{Id: id, Type: wsStart, Payload: payload}
+}
+
+func TestWebsocketSubscribe(t *testing.T) {
+	newTestHub(t)
+	server := httptest.NewServer(newWsServer(nil))
+	defer server.Close()
+	conn, _, err := dialTestServer(t, server, "")
+	assert.Nil(t, err)
+
+	assert.Nil(t, conn.WriteJSON(&wsMessage{Type: wsConnectionInit}))
+	assert.Equal(t, wsConnectionAck, readMessage(t, conn).Type)
+
+	assert.Nil(t, conn.WriteJSON(startMessage("1", `subscription { newBlock { header { height hash } } }`)))
+	waitSubscriptions(t, 1)
+	hub.onBlockSaved(*genesisBlock)
+	msg := readMessage(t, conn)
+	assert.Equal(t, "1", msg.Id)
+	assert.Equal(t, wsData, msg.Type)
+	hash := genesisBlock.Hash()
+	assert.Equal(t, fmt.Sprintf(`{"data":{"newBlock":{"header":{"height":0,"hash":"%s"}}}}`, hash.ToHexString()),
+		string(msg.Payload))
+
+	assert.Nil(t, conn.WriteJSON(&wsMessage{Id: "1", Type: wsStop}))
+	msg = readMessage(t, conn)
+	assert.Equal(t, "1", msg.Id)
+	assert.Equal(t, wsComplete, msg.Type)
+	waitSubscriptions(t, 0)
+
+	// a query operation completes after its result
+	assert.Nil(t, conn.WriteJSON(startMessage("2", `{ getBlockHash(height: 0) }`)))
+	msg = readMessage(t, conn)
+	assert.Equal(t, wsData, msg.Type)
+	assert.Equal(t, fmt.Sprintf(`{"data":{"getBlockHash":"%s"}}`, hash.ToHexString()), string(msg.Payload))
+	assert.Equal(t, wsComplete, readMessage(t, conn).Type)
+
+	assert.Nil(t, conn.WriteJSON(&wsMessage{Type: wsConnectionTerminate}))
+	_, _, err = conn.ReadMessage()
+	assert.NotNil(t, err)
+}
+
+func TestWebsocketMaxOperations(t *testing.T) {
+	newTestHub(t)
+	server := httptest.NewServer(newWsServer(nil))
+	defer server.Close()
+	conn, _, err := dialTestServer(t, server, "")
+	assert.Nil(t, err)
+
+	for i := 0; i <= maxWsOperations; i++ {
+		assert.Nil(t, conn.WriteJSON(startMessage(strconv.Itoa(i), `subscription { newBlock { header { height } } }`)))
+	}
+	msg := readMessage(t, conn)
+	assert.Equal(t, strconv.Itoa(maxWsOperations), msg.Id)
+	assert.Equal(t, wsError, msg.Type)
+	waitSubscriptions(t, maxWsOperations)
+
+	// the operation can be started after another one is stopped
+	assert.Nil(t, conn.WriteJSON(&wsMessage{Id: "0", Type: wsStop}))
+	assert.Equal(t, wsComplete, readMessage(t, conn).Type)
+	assert.Nil(t, conn.WriteJSON(startMessage(strconv.Itoa(maxWsOperations), `subscription { newBlock { header { height } } }`)))
+	waitSubscriptions(t, maxWsOperations)
+}
+
+func TestWebsocketOrigin(t *testing.T) {
+	server := httptest.NewServer(newWsServer(nil))
+	defer server.Close()
+	_, _, err := dialTestServer(t, server, "http://localhost:8080")
+	assert.Nil(t, err)
+	_, resp, err := dialTestServer(t, server, "http://evil.com")
+	assert.Equal(t, websocket.ErrBadHandshake, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	server = httptest.NewServer(newWsServer([]string{"https://app.example.com"}))
+	defer server.Close()
+	_, _, err = dialTestServer(t, server, "https://APP.example.com")
+	assert.Nil(t, err)
+	_, _, err = dialTestServer(t, server, "http://app.example.com")
+	assert.NotNil(t, err)
+	_, _, err = dialTestServer(t, server, "http://localhost")
+	assert.NotNil(t, err)
+
+	server = httptest.NewServer(newWsServer([]string{"*"}))
+	defer server.Close()
+	_, _, err = dialTestServer(t, server, "http://evil.com")
+	assert.Nil(t, err)
+}
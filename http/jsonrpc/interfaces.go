@@ -20,6 +20,7 @@ package jsonrpc
 
 import (
 	"encoding/hex"
+	"math"
 
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
@@ -33,6 +34,7 @@ import (
 	berr "github.com/ontio/ontology/http/base/error"
 	"github.com/ontio/ontology/http/base/rpc"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/trace"
 )
 
 //get best block hash
@@ -130,6 +132,17 @@ func GetSyncStatus(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(status)
 }
 
+//get the round state of vbft consensus
+func GetConsensusStatus(params []interface{}) map[string]interface{} {
+	status, err := bactor.GetConsensusStatus()
+	if err != nil {
+		log.Errorf("GetConsensusStatus error:%s", err)
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+
+	return rpc.ResponseSuccess(status)
+}
+
 //get memory pool transaction count
 func GetMemPoolTxCount(params []interface{}) map[string]interface{} {
 	count := bactor.GetTxnCount()
@@ -257,6 +270,8 @@ func GetStorage(params []interface{}) map[string]interface{} {
 //send raw transaction
 // A JSON example for sendrawtransaction method as following:
 //   {"jsonrpc": "2.0", "method": "sendrawtransaction", "params": ["raw transactioin in hex"], "id": 0}
+// The pre-executed neovm and wasmvm invocation is traced if trace config is given:
+//   {"jsonrpc": "2.0", "method": "sendrawtransaction", "params": ["raw transactioin in hex", 1, {"enableSteps": true}], "id": 0}
 func SendRawTransaction(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
@@ -278,6 +293,9 @@ func SendRawTransaction(params []interface{}) map[string]interface{} {
 		if len(params) > 1 {
 			preExec, ok := params[1].(float64)
 			if ok && preExec == 1 {
+				if len(params) > 2 {
+					return preExecuteWithTrace(txn, params[2])
+				}
 				result, err := bactor.PreExecuteContract(txn)
 				if err != nil {
 					log.Infof("PreExec: ", err)
@@ -299,6 +317,56 @@ func SendRawTransaction(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(hash.ToHexString())
 }
 
+func preExecuteWithTrace(txn *types.Transaction, param interface{}) map[string]interface{} {
+	cfg, err := bcomn.ParseTraceConfig(param)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	tracer := trace.NewCallTracer(cfg)
+	result, err := bactor.PreExecuteContractWithTracer(txn, tracer)
+	if err != nil {
+		log.Infof("PreExec: %s", err)
+		return rpc.ResponsePack(berr.SMARTCODE_ERROR, bcomn.TraceErrorResult{Error: err.Error(), Trace: tracer.Result()})
+	}
+	preResult := bcomn.ConvertPreExecuteResult(result)
+	preResult.Trace = tracer.Result()
+	return rpc.ResponseSuccess(preResult)
+}
+
+//trace the execution of neovm or wasmvm invoke transaction, archive state is required
+//   {"jsonrpc": "2.0", "method": "tracetransaction", "params": ["tx hash", {"enableSteps": true, "stepLimit": 1000}], "id": 0}
+func TraceTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	var param interface{}
+	if len(params) > 1 {
+		param = params[1]
+	}
+	cfg, err := bcomn.ParseTraceConfig(param)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	tracer := trace.NewCallTracer(cfg)
+	eventInfo, err := bactor.TraceTransaction(hash, tracer)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return rpc.ResponsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
+		}
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	_, notify := bcomn.GetExecuteNotify(eventInfo)
+	return rpc.ResponseSuccess(bcomn.TraceResult{Notify: notify, Trace: tracer.Result()})
+}
+
 //get node version
 func GetNodeVersion(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(config.Version)
@@ -666,3 +734,112 @@ func GetCrossStatesProof(params []interface{}) map[string]interface{} {
 	}
 	return rpc.ResponseSuccess(bcomn.CrossStatesProof{"CrossStatesProof", hex.EncodeToString(proof)})
 }
+
+//get the transactions involving address from address index
+//   {"jsonrpc": "2.0", "method": "getaddresstransactions", "params": ["AXK2KtCfcJnSMyRzSwTuwTKgNrtx5aXfFX", 0, 20], "id": 0}
+func GetAddressTransactions(params []interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableAddressIndex {
+		return rpc.ResponsePack(berr.INVALID_METHOD, "")
+	}
+	address, offset, limit, ok := parseAddressIndexParams(params)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	txs, err := bcomn.GetAddressTransactions(address, offset, limit)
+	if err != nil {
+		log.Errorf("GetAddressTransactions error:%s", err)
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, "")
+	}
+	return rpc.ResponseSuccess(txs)
+}
+
+//get the ONT/ONG transfers involving address from address index
+//   {"jsonrpc": "2.0", "method": "getaddresstransfers", "params": ["AXK2KtCfcJnSMyRzSwTuwTKgNrtx5aXfFX", 0, 20], "id": 0}
+func GetAddressTransfers(params []interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableAddressIndex {
+		return rpc.ResponsePack(berr.INVALID_METHOD, "")
+	}
+	address, offset, limit, ok := parseAddressIndexParams(params)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	transfers, err := bcomn.GetAddressTransfers(address, offset, limit)
+	if err != nil {
+		log.Errorf("GetAddressTransfers error:%s", err)
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, "")
+	}
+	return rpc.ResponseSuccess(transfers)
+}
+
+// params are address, optional offset and limit, the limit defaults to the max page size
+func parseAddressIndexParams(params []interface{}) (common.Address, uint32, uint32, bool) {
+	if len(params) < 1 || len(params) > 3 {
+		return common.ADDRESS_EMPTY, 0, 0, false
+	}
+	addrBase58, ok := params[0].(string)
+	if !ok {
+		return common.ADDRESS_EMPTY, 0, 0, false
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return common.ADDRESS_EMPTY, 0, 0, false
+	}
+	page := []uint32{0, bcomn.MAX_ADDRESS_INDEX_LIMIT}
+	for i, param := range params[1:] {
+		value, ok := param.(float64)
+		if !ok || value < 0 || value > math.MaxUint32 {
+			return common.ADDRESS_EMPTY, 0, 0, false
+		}
+		page[i] = uint32(value)
+	}
+	return address, page[0], page[1], true
+}
+
+//get the events of contract between heights from contract event index, the event name, cursor and limit are optional
+//   {"jsonrpc": "2.0", "method": "getcontractevents", "params": ["0100000000000000000000000000000000000000", 100, 200, "transfer", "", 20], "id": 0}
+func GetContractEvents(params []interface{}) map[string]interface{} {
+	if !config.DefConfig.Common.EnableContractEventIndex {
+		return rpc.ResponsePack(berr.INVALID_METHOD, "")
+	}
+	if len(params) < 3 || len(params) > 6 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	// event name and cursor
+	strs := []string{"", ""}
+	for i := 3; i < len(params) && i < 5; i++ {
+		if strs[i-3], ok = params[i].(string); !ok {
+			return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+		}
+	}
+	// start height, end height and limit
+	values := []uint32{0, 0, bcomn.MAX_CONTRACT_EVENT_LIMIT}
+	numbers := params[1:3:3]
+	if len(params) > 5 {
+		numbers = append(numbers, params[5])
+	}
+	for i, param := range numbers {
+		value, ok := param.(float64)
+		if !ok || value < 0 || value > math.MaxUint32 {
+			return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+		}
+		values[i] = uint32(value)
+	}
+	from, err := bcomn.GetContractEventStart(values[0], values[1], strs[1])
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	events, err := bcomn.GetContractEvents(contract, from, values[1], strs[0], values[2])
+	if err != nil {
+		log.Errorf("GetContractEvents error:%s", err)
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, "")
+	}
+	return rpc.ResponseSuccess(events)
+}
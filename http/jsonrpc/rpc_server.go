@@ -31,6 +31,7 @@ import (
 
 func StartRPCServer() error {
 	log.Debug()
+	rpc.SetRateLimit(float64(cfg.DefConfig.Rpc.HttpRateLimit), cfg.DefConfig.Rpc.HttpRateBurst)
 	http.HandleFunc("/", rpc.Handle)
 	rpc.HandleFunc("getbestblockhash", GetBestBlockHash)
 	rpc.HandleFunc("getblock", GetBlock)
@@ -38,6 +39,7 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getblockhash", GetBlockHash)
 	rpc.HandleFunc("getconnectioncount", GetConnectionCount)
 	rpc.HandleFunc("getsyncstatus", GetSyncStatus)
+	rpc.HandleFunc("getconsensusstatus", GetConsensusStatus)
 	//HandleFunc("getrawmempool", GetRawMemPool)
 
 	rpc.HandleFunc("getrawtransaction", GetRawTransaction)
@@ -52,6 +54,9 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getmempooltxhashlist", GetMemPoolTxHashList)
 	rpc.HandleFunc("getsmartcodeevent", GetSmartCodeEvent)
 	rpc.HandleFunc("getblockheightbytxhash", GetBlockHeightByTxHash)
+	rpc.HandleFunc("getaddresstransactions", GetAddressTransactions)
+	rpc.HandleFunc("getaddresstransfers", GetAddressTransfers)
+	rpc.HandleFunc("getcontractevents", GetContractEvents)
 
 	rpc.HandleFunc("getbalance", GetBalance)
 	rpc.HandleFunc("getoep4balance", GetOep4Balance)
@@ -65,6 +70,11 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getcrosschainmsg", GetCrossChainMsg)
 	rpc.HandleFunc("getcrossstatesproof", GetCrossStatesProof)
 
+	// tracing re-executes the transaction on the archive state, it is opt-in like the eth debug namespace
+	if cfg.DefConfig.Rpc.EthDebugApi {
+		rpc.HandleFunc("tracetransaction", TraceTransaction)
+	}
+
 	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpJsonPort)), nil)
 	if err != nil {
 		return fmt.Errorf("ListenAndServe error:%s", err)
@@ -36,7 +36,10 @@ const (
 
 func StartLocalServer() error {
 	log.Debug()
-	http.HandleFunc(LOCAL_DIR, rpc.Handle)
+	// the methods are shared with json rpc server, but the rate limit of the public rpc does not apply. The local
+	// handler is not registered to the default mux, which is also served on the public json rpc port
+	mux := http.NewServeMux()
+	mux.HandleFunc(LOCAL_DIR, rpc.HandleLocal)
 
 	rpc.HandleFunc("getneighbor", GetNeighbor)
 	rpc.HandleFunc("getnodestate", GetNodeState)
@@ -45,7 +48,7 @@ func StartLocalServer() error {
 	rpc.HandleFunc("setdebuginfo", SetDebugInfo)
 
 	// TODO: only listen to local host
-	err := http.ListenAndServe(LOCAL_HOST+":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), nil)
+	err := http.ListenAndServe(LOCAL_HOST+":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), mux)
 	if err != nil {
 		return fmt.Errorf("ListenAndServe error:%s", err)
 	}
@@ -19,10 +19,12 @@
 package nodeinfo
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/core/ledger"
+	bactor "github.com/ontio/ontology/http/base/actor"
 	"github.com/ontio/ontology/p2pserver/net/netserver"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
 	"github.com/ontio/ontology/p2pserver/protocols"
@@ -59,11 +61,58 @@ var (
 		Name: "ontology_p2p_reconnect_count",
 		Help: "ontology p2p reconnect count",
 	})
+
+	vbftStateMetric = prom.NewGaugeVec(prom.GaugeOpts{
+		Name: "ontology_vbft_state",
+		Help: "ontology vbft server state, 1 for the current state",
+	}, []string{"state"})
+
+	vbftBlockNumMetric = prom.NewGauge(prom.GaugeOpts{
+		Name: "ontology_vbft_block_num",
+		Help: "ontology vbft current consensus block num",
+	})
+
+	vbftCommittedBlockNumMetric = prom.NewGauge(prom.GaugeOpts{
+		Name: "ontology_vbft_committed_block_num",
+		Help: "ontology vbft committed block num",
+	})
+
+	vbftProposerMetric = prom.NewGauge(prom.GaugeOpts{
+		Name: "ontology_vbft_proposer_index",
+		Help: "ontology vbft proposer index of the current round",
+	})
+
+	vbftRoundMsgMetric = prom.NewGaugeVec(prom.GaugeOpts{
+		Name: "ontology_vbft_round_msg_count",
+		Help: "ontology vbft consensus msg count of the current round",
+	}, []string{"type"})
+
+	vbftTimerEventMetric = prom.NewGaugeVec(prom.GaugeOpts{
+		Name: "ontology_vbft_timer_event_count",
+		Help: "ontology vbft fired timer event count",
+	}, []string{"event"})
+
+	vbftCatchConsensusMetric = prom.NewGauge(prom.GaugeOpts{
+		Name: "ontology_vbft_catch_consensus_count",
+		Help: "ontology vbft catch consensus count",
+	})
+
+	vbftRestartSyncingMetric = prom.NewGauge(prom.GaugeOpts{
+		Name: "ontology_vbft_restart_syncing_count",
+		Help: "ontology vbft restart syncing count",
+	})
+
+	vbftPeerHeartbeatAgeMetric = prom.NewGaugeVec(prom.GaugeOpts{
+		Name: "ontology_vbft_peer_heartbeat_age_seconds",
+		Help: "ontology vbft seconds since the last heartbeat of peer, -1 if never received",
+	}, []string{"index", "id"})
 )
 
 var (
 	metrics = []prom.Collector{nodePortMetric, blockHeightMetric, inboundsCountMetric,
-		outboundsCountMetric, peerStatusMetric, reconnectCountMetric}
+		outboundsCountMetric, peerStatusMetric, reconnectCountMetric, vbftStateMetric, vbftBlockNumMetric,
+		vbftCommittedBlockNumMetric, vbftProposerMetric, vbftRoundMsgMetric, vbftTimerEventMetric,
+		vbftCatchConsensusMetric, vbftRestartSyncingMetric, vbftPeerHeartbeatAgeMetric}
 )
 
 func initMetric() error {
@@ -81,6 +130,8 @@ func metricUpdate(n p2p.P2P) {
 
 	blockHeightMetric.Set(float64(ledger.DefLedger.GetCurrentBlockHeight()))
 
+	consensusMetricUpdate()
+
 	ns, ok := n.(*netserver.NetServer)
 	if !ok {
 		return
@@ -106,6 +157,38 @@ func metricUpdate(n p2p.P2P) {
 	reconnectCountMetric.Set(float64(mh.ReconnectService().ReconnectCount()))
 }
 
+func consensusMetricUpdate() {
+	status, err := bactor.GetConsensusStatus()
+	if err != nil {
+		return
+	}
+
+	vbftStateMetric.Reset()
+	vbftStateMetric.WithLabelValues(status.State).Set(1)
+	vbftBlockNumMetric.Set(float64(status.CurrentBlockNum))
+	vbftCommittedBlockNumMetric.Set(float64(status.CommittedBlockNum))
+	vbftProposerMetric.Set(float64(status.ProposerIndex))
+
+	vbftRoundMsgMetric.Reset()
+	for _, round := range status.Rounds {
+		if round.BlockNum == status.CurrentBlockNum {
+			vbftRoundMsgMetric.WithLabelValues("proposal").Set(float64(round.Proposals))
+			vbftRoundMsgMetric.WithLabelValues("endorse").Set(float64(round.Endorsements))
+			vbftRoundMsgMetric.WithLabelValues("commit").Set(float64(round.Commits))
+		}
+	}
+	for event, count := range status.TimerEvents {
+		vbftTimerEventMetric.WithLabelValues(event).Set(float64(count))
+	}
+	vbftCatchConsensusMetric.Set(float64(status.CatchConsensus))
+	vbftRestartSyncingMetric.Set(float64(status.RestartSyncing))
+
+	vbftPeerHeartbeatAgeMetric.Reset()
+	for _, peer := range status.Peers {
+		vbftPeerHeartbeatAgeMetric.WithLabelValues(strconv.Itoa(int(peer.Index)), peer.PubKey).Set(peer.HeartbeatAge)
+	}
+}
+
 func updateMetric(n p2p.P2P) {
 	tk := time.NewTicker(time.Minute)
 	defer tk.Stop()
@@ -35,6 +35,7 @@ import (
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
+	"github.com/ontio/ontology/http/base/ratelimit"
 	"github.com/ontio/ontology/http/base/rest"
 	"golang.org/x/net/netutil"
 )
@@ -51,6 +52,7 @@ type restServer struct {
 	server   *http.Server
 	postMap  map[string]Action //post method map
 	getMap   map[string]Action //get method map
+	limiter  *ratelimit.Limiter
 }
 
 const (
@@ -79,6 +81,9 @@ const (
 	GET_MEMPOOL_TXHASHS   = "/api/v1/mempool/txhashlist"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
+	GET_ADDRESS_TXS       = "/api/v1/address/transactions/:addr"
+	GET_ADDRESS_TRANSFERS = "/api/v1/address/transfers/:addr"
+	GET_CONTRACT_EVTS     = "/api/v1/smartcode/event/contract/:contract"
 
 	POST_RAW_TX = "/api/v1/transaction"
 )
@@ -88,6 +93,7 @@ func InitRestServer() rest.ApiServer {
 	rt := &restServer{}
 
 	rt.router = NewRouter()
+	rt.limiter = ratelimit.NewLimiter(float64(cfg.DefConfig.Restful.HttpRateLimit), cfg.DefConfig.Restful.HttpRateBurst)
 	rt.registryMethod()
 	rt.initGetHandler()
 	rt.initPostHandler()
@@ -174,6 +180,9 @@ func (this *restServer) registryMethod() {
 		GET_MEMPOOL_TXHASHS:   {name: "getmempooltxhashlist", handler: rest.GetMemPoolTxHashList},
 		GET_VERSION:           {name: "getversion", handler: rest.GetNodeVersion},
 		GET_NETWORKID:         {name: "getnetworkid", handler: rest.GetNetworkId},
+		GET_ADDRESS_TXS:       {name: "getaddresstransactions", handler: rest.GetAddressTransactions},
+		GET_ADDRESS_TRANSFERS: {name: "getaddresstransfers", handler: rest.GetAddressTransfers},
+		GET_CONTRACT_EVTS:     {name: "getcontractevents", handler: rest.GetContractEvents},
 	}
 
 	postMethodMap := map[string]Action{
@@ -217,6 +226,12 @@ func (this *restServer) getPath(url string) string {
 		return GET_GRANTONG
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
+	} else if strings.Contains(url, strings.TrimRight(GET_ADDRESS_TXS, ":addr")) {
+		return GET_ADDRESS_TXS
+	} else if strings.Contains(url, strings.TrimRight(GET_ADDRESS_TRANSFERS, ":addr")) {
+		return GET_ADDRESS_TRANSFERS
+	} else if strings.Contains(url, strings.TrimRight(GET_CONTRACT_EVTS, ":contract")) {
+		return GET_CONTRACT_EVTS
 	}
 	return url
 }
@@ -261,6 +276,14 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
+	case GET_ADDRESS_TXS, GET_ADDRESS_TRANSFERS:
+		req["Addr"] = getParam(r, "addr")
+		req["Offset"], req["Limit"] = r.FormValue("offset"), r.FormValue("limit")
+	case GET_CONTRACT_EVTS:
+		req["Contract"] = getParam(r, "contract")
+		req["StartHeight"], req["EndHeight"] = r.FormValue("start"), r.FormValue("end")
+		req["EventName"] = r.FormValue("name")
+		req["Cursor"], req["Limit"] = r.FormValue("cursor"), r.FormValue("limit")
 	default:
 	}
 	return req
@@ -277,8 +300,12 @@ func (this *restServer) initGetHandler() {
 
 			url := this.getPath(r.URL.Path)
 			if h, ok := this.getMap[url]; ok {
-				req = this.getParams(r, url, req)
-				resp = h.handler(req)
+				if this.limiter.Allow(ratelimit.RemoteIP(r), h.name) {
+					req = this.getParams(r, url, req)
+					resp = h.handler(req)
+				} else {
+					resp = rest.ResponsePack(berr.SERVICE_CEILING)
+				}
 				resp["Action"] = h.name
 			} else {
 				resp = rest.ResponsePack(berr.INVALID_METHOD)
@@ -299,7 +326,10 @@ func (this *restServer) initPostHandler() {
 
 			url := this.getPath(r.URL.Path)
 			if h, ok := this.postMap[url]; ok {
-				if err := decoder.Decode(&req); err == nil {
+				if !this.limiter.Allow(ratelimit.RemoteIP(r), h.name) {
+					resp = rest.ResponsePack(berr.SERVICE_CEILING)
+					resp["Action"] = h.name
+				} else if err := decoder.Decode(&req); err == nil {
 					req = this.getParams(r, url, req)
 					resp = h.handler(req)
 					resp["Action"] = h.name
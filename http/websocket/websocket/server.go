@@ -204,6 +204,7 @@ func (self *WsServer) registryMethod() {
 		"getmempooltxhashlist":      {handler: rest.GetMemPoolTxHashList},
 		"getversion":                {handler: rest.GetNodeVersion},
 		"getnetworkid":              {handler: rest.GetNetworkId},
+		"getcontractevents":         {handler: rest.GetContractEvents},
 
 		"getsessioncount": {handler: getsessioncount},
 	}
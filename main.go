@@ -73,6 +73,9 @@ func setupAPP() *cli.App {
 		cmd.ContractCommand,
 		cmd.ImportCommand,
 		cmd.ExportCommand,
+		cmd.ReindexCommand,
+		cmd.SnapshotCommand,
+		cmd.DbCommand,
 		cmd.TxCommond,
 		cmd.SigTxCommand,
 		cmd.MultiSigAddrCommand,
@@ -90,6 +93,15 @@ func setupAPP() *cli.App {
 		utils.DataDirFlag,
 		utils.ETHTxGasLimitFlag,
 		utils.WasmVerifyMethodFlag,
+		utils.EnableEthLogBloomFlag,
+		utils.EnableArchiveStateFlag,
+		utils.EnableAddressIndexFlag,
+		utils.EnableContractEventIndexFlag,
+		utils.EnableSnapshotServeFlag,
+		utils.EnableParallelExecFlag,
+		utils.EnableStateUndoFlag,
+		utils.StateGCHistoryFlag,
+		utils.DBEngineFlag,
 		//account setting
 		utils.WalletFileFlag,
 		utils.AccountAddressFlag,
@@ -112,6 +124,8 @@ func setupAPP() *cli.App {
 		utils.MaxConnInBoundFlag,
 		utils.MaxConnOutBoundFlag,
 		utils.MaxConnInBoundForSingleIPFlag,
+		utils.EnableSnapshotSyncFlag,
+		utils.SnapshotSyncManifestFlag,
 		//test mode setting
 		utils.EnableTestModeFlag,
 		utils.TestModeGenBlockTimeFlag,
@@ -119,16 +133,24 @@ func setupAPP() *cli.App {
 		utils.RPCDisabledFlag,
 		utils.RPCPortFlag,
 		utils.ETHRPCPortFlag,
+		utils.ETHWSPortFlag,
+		utils.ETHWSOriginsFlag,
+		utils.ETHDebugApiFlag,
 		utils.RPCLocalEnableFlag,
 		utils.RPCLocalProtFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateBurstFlag,
 		//rest setting
 		utils.RestfulEnableFlag,
 		utils.RestfulPortFlag,
 		utils.RestfulMaxConnsFlag,
+		utils.RestfulRateLimitFlag,
+		utils.RestfulRateBurstFlag,
 		//graphql setting
 		utils.GraphQLEnableFlag,
 		utils.GraphQLPortFlag,
 		utils.GraphQLMaxConnsFlag,
+		utils.GraphQLWsOriginsFlag,
 		//ws setting
 		utils.WsEnabledFlag,
 		utils.WsPortFlag,
@@ -282,6 +304,9 @@ func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error
 	if err != nil {
 		return nil, fmt.Errorf("NewLedger error: %s", err)
 	}
+	if history := config.DefConfig.Common.StateGCHistory; history > 0 {
+		ledger.DefLedger.EnableStateGC(history)
+	}
 
 	log.Infof("Ledger init success")
 	return ledger.DefLedger, nil
@@ -118,6 +118,11 @@ const (
 	GET_SUBNET_MEMBERS_TYPE = "getmembers" // request subnet members
 	SUBNET_MEMBERS_TYPE     = "members"    // response subnet members
 	SUBNET_OFFLINE_TYPE     = "offline"    // offline witness message
+
+	GET_SNAPSHOT_TYPE = "getsnapshot" // request the latest state snapshot manifest
+	SNAPSHOT_TYPE     = "snapshot"    // state snapshot manifest
+	GET_STATE_TYPE    = "getstate"    // request a chunk of snapshot states
+	STATE_TYPE        = "state"       // chunk of snapshot states
 )
 
 //ParseIPAddr return ip address
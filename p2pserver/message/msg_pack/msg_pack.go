@@ -21,6 +21,7 @@ package msgpack
 import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
 	ct "github.com/ontio/ontology/core/types"
 	msgCommon "github.com/ontio/ontology/p2pserver/common"
 	mt "github.com/ontio/ontology/p2pserver/message/types"
@@ -173,3 +174,23 @@ func NewFindNodeReq(id msgCommon.PeerId) mt.Message {
 
 	return &req
 }
+
+//state snapshot manifest request package
+func NewSnapshotReq() mt.Message {
+	return &mt.SnapshotReq{}
+}
+
+//state snapshot manifest package
+func NewSnapshot(manifest *scom.SnapshotManifest, blocks []*ct.Block) mt.Message {
+	return &mt.Snapshot{Manifest: manifest, Blocks: blocks}
+}
+
+//snapshot states request package
+func NewStateReq(height uint32, start []byte) mt.Message {
+	return &mt.StateReq{Height: height, Start: start}
+}
+
+//snapshot states chunk package
+func NewStateChunk(height uint32, start []byte, kvs []*scom.SnapshotKV, next []byte) mt.Message {
+	return &mt.StateChunk{Height: height, Start: start, KVs: kvs, Next: next}
+}
@@ -203,6 +203,14 @@ func makeEmptyMessage(cmdType string) Message {
 		return &SubnetMembers{}
 	case common.SUBNET_OFFLINE_TYPE:
 		return &OfflineWitnessMsg{}
+	case common.GET_SNAPSHOT_TYPE:
+		return &SnapshotReq{}
+	case common.SNAPSHOT_TYPE:
+		return &Snapshot{}
+	case common.GET_STATE_TYPE:
+		return &StateReq{}
+	case common.STATE_TYPE:
+		return &StateChunk{}
 	default:
 		return &UnknownMessage{Cmd: cmdType}
 	}
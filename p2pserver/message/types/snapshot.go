@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"errors"
+	"io"
+
+	comm "github.com/ontio/ontology/common"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+//SnapshotReq request the manifest of the latest state snapshot of peer
+type SnapshotReq struct{}
+
+//Serialize message payload
+func (this *SnapshotReq) Serialization(sink *comm.ZeroCopySink) {}
+
+func (this *SnapshotReq) CmdType() string {
+	return common.GET_SNAPSHOT_TYPE
+}
+
+//Deserialize message payload
+func (this *SnapshotReq) Deserialization(source *comm.ZeroCopySource) error {
+	return nil
+}
+
+//Snapshot is the manifest of a state snapshot, with the snapshot block and the vbft config block it refers to
+type Snapshot struct {
+	Manifest *scom.SnapshotManifest
+	Blocks   []*types.Block
+}
+
+//Serialize message payload
+func (this *Snapshot) Serialization(sink *comm.ZeroCopySink) {
+	this.Manifest.Serialization(sink)
+	sink.WriteVarUint(uint64(len(this.Blocks)))
+	for _, block := range this.Blocks {
+		block.Serialization(sink)
+	}
+}
+
+func (this *Snapshot) CmdType() string {
+	return common.SNAPSHOT_TYPE
+}
+
+//Deserialize message payload
+func (this *Snapshot) Deserialization(source *comm.ZeroCopySource) error {
+	this.Manifest = new(scom.SnapshotManifest)
+	if err := this.Manifest.Deserialization(source); err != nil {
+		return err
+	}
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if count > 2 {
+		return errors.New("too many snapshot blocks")
+	}
+	for i := uint64(0); i < count; i++ {
+		block := new(types.Block)
+		if err := block.Deserialization(source); err != nil {
+			return err
+		}
+		this.Blocks = append(this.Blocks, block)
+	}
+	return nil
+}
+
+//StateReq request the states of snapshot at Height in key order starting from the key Start
+type StateReq struct {
+	Height uint32
+	Start  []byte
+}
+
+//Serialize message payload
+func (this *StateReq) Serialization(sink *comm.ZeroCopySink) {
+	sink.WriteUint32(this.Height)
+	sink.WriteVarBytes(this.Start)
+}
+
+func (this *StateReq) CmdType() string {
+	return common.GET_STATE_TYPE
+}
+
+//Deserialize message payload
+func (this *StateReq) Deserialization(source *comm.ZeroCopySource) error {
+	var eof, irregular bool
+	this.Height, eof = source.NextUint32()
+	this.Start, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//StateChunk is the response of StateReq, Next is the start key of the next chunk, empty if no more states
+type StateChunk struct {
+	Height uint32
+	Start  []byte
+	KVs    []*scom.SnapshotKV
+	Next   []byte
+}
+
+//Serialize message payload
+func (this *StateChunk) Serialization(sink *comm.ZeroCopySink) {
+	sink.WriteUint32(this.Height)
+	sink.WriteVarBytes(this.Start)
+	sink.WriteVarUint(uint64(len(this.KVs)))
+	for _, kv := range this.KVs {
+		kv.Serialization(sink)
+	}
+	sink.WriteVarBytes(this.Next)
+}
+
+func (this *StateChunk) CmdType() string {
+	return common.STATE_TYPE
+}
+
+//Deserialize message payload
+func (this *StateChunk) Deserialization(source *comm.ZeroCopySource) error {
+	var eof, irregular bool
+	this.Height, eof = source.NextUint32()
+	this.Start, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	count, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof || count > source.Len() {
+		return io.ErrUnexpectedEOF
+	}
+	this.KVs = make([]*scom.SnapshotKV, 0, count)
+	for i := uint64(0); i < count; i++ {
+		kv := new(scom.SnapshotKV)
+		if err := kv.Deserialization(source); err != nil {
+			return err
+		}
+		this.KVs = append(this.KVs, kv)
+	}
+	this.Next, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
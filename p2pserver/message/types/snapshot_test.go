@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"testing"
+
+	scom "github.com/ontio/ontology/core/store/common"
+)
+
+func TestSnapshotReqSerializationDeserialization(t *testing.T) {
+	MessageTest(t, &SnapshotReq{})
+}
+
+func TestStateReqSerializationDeserialization(t *testing.T) {
+	MessageTest(t, &StateReq{Height: 10000, Start: []byte{0x05, 0x01}})
+}
+
+func TestStateChunkSerializationDeserialization(t *testing.T) {
+	msg := &StateChunk{
+		Height: 10000,
+		Start:  []byte{0x05, 0x01},
+		KVs: []*scom.SnapshotKV{
+			{Key: []byte{0x05, 0x01}, Value: []byte("value1")},
+			{Key: []byte{0x05, 0x02}, Value: []byte("value2")},
+		},
+		Next: []byte{0x05, 0x03},
+	}
+	MessageTest(t, msg)
+}
@@ -116,6 +116,8 @@ func (self *P2PServer) reachMinConnection() bool {
 		minCount = config.SOLO_MIN_NODE_NUM
 	case "vbft":
 		minCount = self.getVbftGovNodeCount()
+	case "sbft":
+		minCount = config.SBFT_MIN_NODE_NUM
 	}
 	return self.network.GetConnectionCnt()+1 >= minCount
 }
@@ -249,6 +249,7 @@ type BlockSyncMgr struct {
 	ledger         *ledger.Ledger                       //ledger
 	lock           sync.RWMutex                         //lock
 	nodeWeights    map[p2pComm.PeerId]*NodeWeight       //Map NodeID => NodeStatus, using for getNextNode
+	headerOnly     int32                                //Only sync headers without forward limit while the states are fetched by snapshot sync
 }
 
 //NewBlockSyncMgr return a BlockSyncMgr instance
@@ -457,7 +458,7 @@ func (this *BlockSyncMgr) syncHeader() {
 
 	curHeaderHeight := this.ledger.GetCurrentHeaderHeight()
 	//Waiting for block catch up header
-	if curHeaderHeight-curBlockHeight >= SYNC_MAX_HEADER_FORWARD_SIZE {
+	if !this.IsHeaderOnly() && curHeaderHeight-curBlockHeight >= SYNC_MAX_HEADER_FORWARD_SIZE {
 		return
 	}
 	NextHeaderId := curHeaderHeight + 1
@@ -480,6 +481,9 @@ func (this *BlockSyncMgr) syncHeader() {
 }
 
 func (this *BlockSyncMgr) syncBlock() {
+	if this.IsHeaderOnly() {
+		return
+	}
 	if this.tryGetSyncBlockLock() {
 		return
 	}
@@ -580,6 +584,10 @@ func (this *BlockSyncMgr) OnHeaderReceive(fromID p2pComm.PeerId, headers []*type
 	sort.Slice(headers, func(i, j int) bool {
 		return headers[i].Height < headers[j].Height
 	})
+	if this.IsHeaderOnly() {
+		this.syncHeader()
+		return
+	}
 	curHeaderHeight = this.ledger.GetCurrentHeaderHeight()
 	curBlockHeight := this.ledger.GetCurrentBlockHeight()
 	for _, header := range headers {
@@ -618,6 +626,9 @@ func (this *BlockSyncMgr) OnBlockReceive(fromID p2pComm.PeerId, blockSize uint32
 	height := block.Header.Height
 	blockHash := block.Hash()
 	log.Tracef("[block-sync] OnBlockReceive Height:%d", height)
+	if this.IsHeaderOnly() {
+		return
+	}
 	flightInfo := this.getFlightBlock(blockHash, fromID)
 	if flightInfo != nil {
 		t := (time.Now().UnixNano() - flightInfo.GetStartTime()) / int64(time.Millisecond)
@@ -641,6 +652,21 @@ func (this *BlockSyncMgr) OnBlockReceive(fromID p2pComm.PeerId, blockSize uint32
 	this.syncBlock()
 }
 
+//SetHeaderOnly switch whether to only sync headers. Used by snapshot sync to get the headers up to the snapshot
+//before the states are imported, the blocks are synced from the snapshot height after switching back.
+func (this *BlockSyncMgr) SetHeaderOnly(headerOnly bool) {
+	var v int32
+	if headerOnly {
+		v = 1
+	}
+	atomic.StoreInt32(&this.headerOnly, v)
+}
+
+//IsHeaderOnly return whether only headers are synced
+func (this *BlockSyncMgr) IsHeaderOnly() bool {
+	return atomic.LoadInt32(&this.headerOnly) == 1
+}
+
 //OnAddPeer to node list when a new node added
 func (this *BlockSyncMgr) OnAddNode(nodeId p2pComm.PeerId) {
 	log.Debugf("[block-sync] OnAddNode:%s", nodeId.ToHexString())
@@ -735,6 +761,9 @@ func (this *BlockSyncMgr) releaseSaveBlockLock() {
 }
 
 func (this *BlockSyncMgr) saveBlock() {
+	if this.IsHeaderOnly() {
+		return
+	}
 	if this.tryGetSaveBlockLock() {
 		return
 	}
@@ -40,6 +40,7 @@ import (
 	"github.com/ontio/ontology/p2pserver/protocols/heatbeat"
 	"github.com/ontio/ontology/p2pserver/protocols/recent_peers"
 	"github.com/ontio/ontology/p2pserver/protocols/reconnect"
+	"github.com/ontio/ontology/p2pserver/protocols/snapshot_sync"
 	"github.com/ontio/ontology/p2pserver/protocols/subnet"
 	"github.com/ontio/ontology/p2pserver/protocols/utils"
 	common2 "github.com/ontio/ontology/txnpool/common"
@@ -55,6 +56,7 @@ var txCache, _ = lru.NewARC(msgCommon.MAX_TX_CACHE_SIZE)
 type MsgHandler struct {
 	seeds                    *utils.HostsResolver
 	blockSync                *block_sync.BlockSyncMgr
+	snapshotSync             *snapshot_sync.SnapshotSyncMgr
 	reconnect                *reconnect.ReconnectService
 	discovery                *discovery.Discovery
 	heatBeat                 *heatbeat.HeartBeat
@@ -93,6 +95,7 @@ func (self *MsgHandler) GetSubnetMembersInfo() []msgCommon.SubnetMemberInfo {
 
 func (self *MsgHandler) start(net p2p.P2P) {
 	self.blockSync = block_sync.NewBlockSyncMgr(net, self.ledger)
+	self.snapshotSync = snapshot_sync.NewSnapshotSyncMgr(net, self.ledger, self.blockSync)
 	self.reconnect = reconnect.NewReconectService(net, self.staticReserveFilter)
 	maskFilter := self.subnet.GetMaskAddrFilter()
 	self.discovery = discovery.NewDiscovery(net, config.DefConfig.P2PNode.ReservedCfg.MaskPeers, maskFilter, 0)
@@ -101,6 +104,7 @@ func (self *MsgHandler) start(net p2p.P2P) {
 	self.persistRecentPeerService = recent_peers.NewPersistRecentPeerService(net)
 	go self.persistRecentPeerService.Start()
 	go self.blockSync.Start()
+	go self.snapshotSync.Start()
 	go self.reconnect.Start()
 	go self.discovery.Start()
 	go self.heatBeat.Start()
@@ -112,6 +116,7 @@ func (self *MsgHandler) start(net p2p.P2P) {
 
 func (self *MsgHandler) stop() {
 	self.blockSync.Stop()
+	self.snapshotSync.Stop()
 	self.reconnect.Stop()
 	self.discovery.Stop()
 	self.persistRecentPeerService.Stop()
@@ -133,6 +138,7 @@ func (self *MsgHandler) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage)
 		self.subnet.OnAddPeer(net, m.Info)
 	case p2p.PeerDisConnected:
 		self.blockSync.OnDelNode(m.Info.Id)
+		self.snapshotSync.OnDelNode(m.Info.Id)
 		self.reconnect.OnDelPeer(m.Info)
 		self.discovery.OnDelPeer(m.Info)
 		self.bootstrap.OnDelPeer(m.Info)
@@ -180,6 +186,14 @@ func (self *MsgHandler) HandlePeerMessage(ctx *p2p.Context, msg msgTypes.Message
 		self.subnet.OnMembersResponse(ctx, m)
 	case *msgTypes.OfflineWitnessMsg:
 		self.subnet.OnOfflineWitnessMsg(ctx, m)
+	case *msgTypes.SnapshotReq:
+		self.snapshotSync.OnSnapshotReq(ctx)
+	case *msgTypes.Snapshot:
+		self.snapshotSync.OnSnapshot(ctx, m)
+	case *msgTypes.StateReq:
+		self.snapshotSync.OnStateReq(ctx, m)
+	case *msgTypes.StateChunk:
+		self.snapshotSync.OnStateChunk(ctx, m)
 	case *msgTypes.NotFound:
 		log.Debug("[p2p]receive notFound message, hash is ", m.Hash)
 	default:
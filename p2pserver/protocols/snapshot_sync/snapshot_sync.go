@@ -0,0 +1,338 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package snapshot_sync fast sync a new node by downloading the states of a recent snapshot from peers instead of
+//executing all the blocks.
+//
+//The state merkle root is neither committed in block headers nor signed by the consensus nodes in the ledger, so
+//nothing ties a snapshot to the chain except the block hash of the manifest, which must match the synced header.
+//The manifest hash, logged by a trusted node when its snapshot is ready to serve, must therefore be pinned with
+//SnapshotSyncManifest: only the pinned manifest is accepted, and every state chunk received from peers is checked
+//against the chunk hashes of the manifest before being imported.
+package snapshot_sync
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/ledger"
+	scom "github.com/ontio/ontology/core/store/common"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/protocols/block_sync"
+)
+
+const (
+	SNAPSHOT_MANIFEST_INTERVAL = 10 * time.Second //Interval of requesting snapshot manifests from peers
+	SNAPSHOT_WAIT_TIMEOUT      = 5 * time.Minute  //Fall back to full sync if the pinned manifest is not found in time
+	SNAPSHOT_CHUNK_TIMEOUT     = 30 * time.Second //Request the state chunk from another peer if not received in time
+)
+
+type syncState int
+
+const (
+	stateIdle     syncState = iota //snapshot sync is disabled or finished
+	stateManifest                  //syncing headers and waiting for the pinned manifest
+	stateFetching                  //fetching the state chunks of the manifest
+)
+
+//SnapshotSyncMgr serve the state snapshots of local ledger to peers, and fetch the states of a snapshot from peers
+//when the node starts with an empty ledger and snapshot sync is enabled
+type SnapshotSyncMgr struct {
+	net       p2p.P2P
+	ledger    *ledger.Ledger
+	blockSync *block_sync.BlockSyncMgr
+	exitCh    chan interface{}
+
+	lock            sync.Mutex
+	state           syncState
+	startTime       time.Time
+	lastManifestReq time.Time
+	importBegun     bool                                  //the ledger states have been cleared for import, can not fall back to full sync
+	manifests       map[p2pComm.PeerId]*msgTypes.Snapshot //latest manifest received from each peer
+	badManifests    map[common.Uint256]bool               //manifests failed to import
+	pinned          common.Uint256                        //hash of the only manifest accepted
+	target          *scom.SnapshotManifest
+	sources         []p2pComm.PeerId //peers serving the target manifest
+	sourceIndex     int
+	next            []byte //start key of the next chunk
+	chunkIndex      int    //index of the next chunk in the chunk hashes of the target manifest
+	flightPeer      p2pComm.PeerId
+	flightTime      time.Time
+	imported        uint64
+}
+
+//NewSnapshotSyncMgr return the snapshot sync manager. Block sync is switched to sync headers only until the
+//snapshot is imported, if snapshot sync is enabled and the ledger is empty.
+func NewSnapshotSyncMgr(net p2p.P2P, ld *ledger.Ledger, blockSync *block_sync.BlockSyncMgr) *SnapshotSyncMgr {
+	this := &SnapshotSyncMgr{
+		net:          net,
+		ledger:       ld,
+		blockSync:    blockSync,
+		exitCh:       make(chan interface{}, 1),
+		manifests:    make(map[p2pComm.PeerId]*msgTypes.Snapshot),
+		badManifests: make(map[common.Uint256]bool),
+	}
+	if !config.DefConfig.P2PNode.EnableSnapshotSync || ld.GetCurrentBlockHeight() != 0 {
+		return this
+	}
+	pinned := config.DefConfig.P2PNode.SnapshotSyncManifest
+	hash, err := common.Uint256FromHexString(pinned)
+	if err != nil {
+		log.Errorf("[snapshot-sync] invalid snapshot manifest hash %q: %s, snapshot sync disabled", pinned, err)
+		return this
+	}
+	this.pinned = hash
+	log.Infof("[snapshot-sync] syncing headers and waiting for state snapshot %s from peers", pinned)
+	this.state = stateManifest
+	this.startTime = time.Now()
+	blockSync.SetHeaderOnly(true)
+	return this
+}
+
+//Start the snapshot sync loop
+func (this *SnapshotSyncMgr) Start() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-this.exitCh:
+			return
+		case <-ticker.C:
+			this.lock.Lock()
+			switch this.state {
+			case stateManifest:
+				this.selectManifest()
+			case stateFetching:
+				this.checkTimeout()
+			}
+			this.lock.Unlock()
+		}
+	}
+}
+
+//Stop the snapshot sync loop
+func (this *SnapshotSyncMgr) Stop() {
+	close(this.exitCh)
+}
+
+//OnDelNode remove the peer from the snapshot sources when it disconnect
+func (this *SnapshotSyncMgr) OnDelNode(nodeId p2pComm.PeerId) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	delete(this.manifests, nodeId)
+	this.removeSource(nodeId)
+}
+
+//OnSnapshotReq send the manifest of the latest local state snapshot to peer
+func (this *SnapshotSyncMgr) OnSnapshotReq(ctx *p2p.Context) {
+	manifest, blocks, err := this.ledger.GetSnapshotManifest()
+	if err != nil {
+		log.Debugf("[snapshot-sync] no state snapshot to serve: %s", err)
+		return
+	}
+	err = ctx.Sender().Send(msgpack.NewSnapshot(manifest, blocks))
+	if err != nil {
+		log.Warn(err)
+	}
+}
+
+//OnStateReq send a chunk of the local state snapshot to peer
+func (this *SnapshotSyncMgr) OnStateReq(ctx *p2p.Context, req *msgTypes.StateReq) {
+	kvs, next, err := this.ledger.GetSnapshotChunk(req.Height, req.Start)
+	if err != nil {
+		log.Debugf("[snapshot-sync] get state chunk of snapshot %d error: %s", req.Height, err)
+		return
+	}
+	err = ctx.Sender().Send(msgpack.NewStateChunk(req.Height, req.Start, kvs, next))
+	if err != nil {
+		log.Warn(err)
+	}
+}
+
+//OnSnapshot record the manifest received from peer
+func (this *SnapshotSyncMgr) OnSnapshot(ctx *p2p.Context, snapshot *msgTypes.Snapshot) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.state != stateManifest {
+		return
+	}
+	this.manifests[ctx.Sender().GetID()] = snapshot
+}
+
+//OnStateChunk import the state chunk received from peer, and request the next one
+func (this *SnapshotSyncMgr) OnStateChunk(ctx *p2p.Context, chunk *msgTypes.StateChunk) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	fromID := ctx.Sender().GetID()
+	if this.state != stateFetching || fromID != this.flightPeer || chunk.Height != this.target.Height ||
+		!bytes.Equal(chunk.Start, this.next) {
+		return
+	}
+	// a chunk must make progress, otherwise the peer could keep us fetching forever
+	if len(chunk.Next) != 0 && (len(chunk.KVs) == 0 || bytes.Compare(chunk.Next, chunk.KVs[len(chunk.KVs)-1].Key) <= 0) {
+		log.Warnf("[snapshot-sync] invalid state chunk from peer %s", fromID.ToHexString())
+		this.removeSource(fromID)
+		this.requestChunk()
+		return
+	}
+	if this.chunkIndex >= len(this.target.ChunkHashes) ||
+		scom.SnapshotChunkHash(chunk.KVs, chunk.Next) != this.target.ChunkHashes[this.chunkIndex] {
+		log.Warnf("[snapshot-sync] state chunk %d from peer %s mismatch the snapshot manifest", this.chunkIndex,
+			fromID.ToHexString())
+		this.removeSource(fromID)
+		this.requestChunk()
+		return
+	}
+	err := this.ledger.ImportSnapshotChunk(chunk.KVs)
+	if err != nil {
+		log.Warnf("[snapshot-sync] import state chunk from peer %s error: %s", fromID.ToHexString(), err)
+		this.removeSource(fromID)
+		this.requestChunk()
+		return
+	}
+	this.imported += uint64(len(chunk.KVs))
+	this.next = chunk.Next
+	this.chunkIndex += 1
+	if len(this.next) != 0 {
+		log.Infof("[snapshot-sync] imported %d/%d states of snapshot %d", this.imported, this.target.KeyCount,
+			this.target.Height)
+		this.requestChunk()
+		return
+	}
+	if this.chunkIndex == len(this.target.ChunkHashes) {
+		err = this.ledger.FinishSnapshotImport()
+	} else {
+		err = fmt.Errorf("imported %d state chunks, expect %d", this.chunkIndex, len(this.target.ChunkHashes))
+	}
+	if err != nil {
+		log.Errorf("[snapshot-sync] finish snapshot %d import error: %s", this.target.Height, err)
+		this.badManifests[this.target.Hash()] = true
+		this.target = nil
+		this.state = stateManifest
+		return
+	}
+	log.Infof("[snapshot-sync] snapshot %d imported, resume block sync", this.target.Height)
+	this.finish()
+}
+
+//selectManifest wait for the pinned manifest served by peers, and begin to import it after its header synced
+func (this *SnapshotSyncMgr) selectManifest() {
+	now := time.Now()
+	if now.Sub(this.lastManifestReq) >= SNAPSHOT_MANIFEST_INTERVAL {
+		this.lastManifestReq = now
+		go this.net.Broadcast(msgpack.NewSnapshotReq())
+	}
+
+	var sources []p2pComm.PeerId
+	var target *scom.SnapshotManifest
+	if !this.badManifests[this.pinned] {
+		for id, snapshot := range this.manifests {
+			if snapshot.Manifest.Hash() == this.pinned {
+				sources = append(sources, id)
+				target = snapshot.Manifest
+			}
+		}
+	}
+	if target == nil {
+		if !this.importBegun && now.Sub(this.startTime) >= SNAPSHOT_WAIT_TIMEOUT {
+			log.Warnf("[snapshot-sync] state snapshot %s not served by peers, fall back to full sync",
+				this.pinned.ToHexString())
+			this.finish()
+		}
+		return
+	}
+	if this.ledger.GetCurrentHeaderHeight() < target.Height {
+		return
+	}
+
+	var err error
+	for _, id := range sources {
+		err = this.ledger.BeginSnapshotImport(this.manifests[id].Manifest, this.manifests[id].Blocks)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		log.Warnf("[snapshot-sync] begin snapshot %d import error: %s", target.Height, err)
+		this.badManifests[this.pinned] = true
+		return
+	}
+	this.importBegun = true
+	this.target = target
+	this.sources = sources
+	this.sourceIndex = 0
+	this.next = nil
+	this.chunkIndex = 0
+	this.imported = 0
+	this.state = stateFetching
+	this.requestChunk()
+}
+
+//requestChunk request the next state chunk from the snapshot sources in turn
+func (this *SnapshotSyncMgr) requestChunk() {
+	for len(this.sources) > 0 {
+		this.sourceIndex = (this.sourceIndex + 1) % len(this.sources)
+		id := this.sources[this.sourceIndex]
+		peer := this.net.GetPeer(id)
+		if peer == nil {
+			this.removeSource(id)
+			continue
+		}
+		this.flightPeer = id
+		this.flightTime = time.Now()
+		err := this.net.Send(peer, msgpack.NewStateReq(this.target.Height, this.next))
+		if err != nil {
+			log.Warnf("[snapshot-sync] request state chunk from peer %s error: %s", id.ToHexString(), err)
+		}
+		return
+	}
+	log.Warnf("[snapshot-sync] no peer serves snapshot %d any more, select another one", this.target.Height)
+	this.target = nil
+	this.state = stateManifest
+}
+
+func (this *SnapshotSyncMgr) checkTimeout() {
+	if time.Since(this.flightTime) < SNAPSHOT_CHUNK_TIMEOUT {
+		return
+	}
+	log.Debugf("[snapshot-sync] state chunk request to peer %s timeout", this.flightPeer.ToHexString())
+	this.requestChunk()
+}
+
+func (this *SnapshotSyncMgr) removeSource(id p2pComm.PeerId) {
+	for i, source := range this.sources {
+		if source == id {
+			this.sources = append(this.sources[:i], this.sources[i+1:]...)
+			return
+		}
+	}
+}
+
+func (this *SnapshotSyncMgr) finish() {
+	this.state = stateIdle
+	this.manifests = make(map[p2pComm.PeerId]*msgTypes.Snapshot)
+	this.blockSync.SetHeaderOnly(false)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/trace"
 )
 
 // ContextRef is a interface of smart context
@@ -30,6 +31,7 @@ import (
 // when need to check authorization, use CheckWitness
 // when smart contract execute trigger event, use PushNotifications push it to smart contract notifications
 // when need to invoke a smart contract, use AppCall to invoke it
+// when need to trace the execution, use GetTracer to get the tracer, which is nil if tracing is disabled
 type ContextRef interface {
 	PushContext(context *Context)
 	CurrentContext() *Context
@@ -46,6 +48,7 @@ type ContextRef interface {
 	SetInternalErr()
 	IsInternalErr() bool
 	PutCrossStateHashes(hashes []common.Uint256)
+	GetTracer() trace.Tracer
 }
 
 type Engine interface {
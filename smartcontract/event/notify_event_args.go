@@ -83,17 +83,23 @@ func NotifyEventInfoToEvmLog(info *NotifyEventInfo) (*types.StorageLog, error) {
 	if !n.IsEvm {
 		return nil, fmt.Errorf("not evm event")
 	}
-	states, ok := n.States.(string)
-	if !ok {
+	var data []byte
+	switch states := n.States.(type) {
+	case hexutil.Bytes:
+		// notify generated by execution and not yet persisted to event store
+		data = states
+	case string:
+		raw, err := hexutil.Decode(states)
+		if err != nil {
+			return nil, err
+		}
+		data = raw
+	default:
 		return nil, errors.New("event info states is not string")
 	}
-	data, err := hexutil.Decode(states)
-	if err != nil {
-		return nil, err
-	}
 	source := common.NewZeroCopySource(data)
 	var storageLog types.StorageLog
-	err = storageLog.Deserialization(source)
+	err := storageLog.Deserialization(source)
 	if err != nil {
 		return nil, err
 	}
@@ -32,7 +32,7 @@ import (
 	"github.com/ontio/ontology/vm/evm/params"
 )
 
-func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight uint32, tx *types.Transaction, usedGas *uint64, evm *evm.EVM, feeReceiver common.Address) (*types2.ExecutionResult, *otypes.Receipt, error) {
+func applyTransaction(msg Message, statedb *storage.StateDB, blockHeight uint32, tx *otypes.Transaction, usedGas *uint64, evm *evm.EVM, feeReceiver common.Address) (*types2.ExecutionResult, *otypes.Receipt, error) {
 	// Create a new context to be used in the EVM environment
 	txContext := NewEVMTxContext(msg)
 	// Add addresses to access list if applicable
@@ -66,12 +66,12 @@ func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight u
 	// Create a new receipt for the transaction, storing the intermediate root and gas used by the tx
 	// based on the eip phase, we're passing whether the root touch-delete accounts.
 	receipt := otypes.NewReceipt(result.Failed(), *usedGas)
-	receipt.TxHash = tx.Hash()
+	receipt.TxHash = common2.Hash(tx.Hash())
 	receipt.GasUsed = result.UsedGas
-	receipt.GasPrice = tx.GasPrice().Uint64() // safe since ong should be in uint64 range
+	receipt.GasPrice = tx.GasPrice
 	// if the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
-		receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, msg.Nonce())
 	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs()
@@ -84,18 +84,25 @@ func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight u
 // ApplyTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. It returns the receipt
 // for the transaction, gas used and an error if the transaction failed,
-// indicating the block was invalid.
-func ApplyTransaction(config *params.ChainConfig, bc store.LedgerStore, statedb *storage.StateDB, blockHeight, timestamp uint32, tx *types.Transaction, usedGas *uint64, feeReceiver common.Address, cfg evm.Config, checkNonce bool) (*types2.ExecutionResult, *otypes.Receipt, error) {
-	signer := types.NewEIP155Signer(config.ChainID)
-	msg, err := tx.AsMessage(signer)
+// indicating the block was invalid. The sender is the payer of tx, whose
+// signature is verified when the eth transaction is decoded.
+func ApplyTransaction(config *params.ChainConfig, bc store.LedgerStore, statedb *storage.StateDB, blockHeight, timestamp uint32, tx *otypes.Transaction, usedGas *uint64, feeReceiver common.Address, cfg evm.Config, checkNonce bool) (*types2.ExecutionResult, *otypes.Receipt, error) {
+	eiptx, err := tx.GetEIP155Tx()
 	if err != nil {
 		return nil, nil, err
 	}
-
-	msg = types.NewMessage(msg.From(), msg.To(), msg.Nonce(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data(), checkNonce)
+	if err := tx.CheckEthTypedTx(blockHeight); err != nil {
+		return nil, nil, err
+	}
+	msg := types.NewMessage(common2.Address(tx.Payer), eiptx.To(), eiptx.Nonce(), eiptx.Value(), eiptx.Gas(),
+		eiptx.GasPrice(), eiptx.Data(), checkNonce)
+	var evmMsg Message = msg
+	if typed := tx.GetEthTypedTx(); typed != nil && len(typed.AccessList) != 0 {
+		evmMsg = NewTypedMessage(msg, typed.AccessList)
+	}
 
 	// Create a new context to be used in the EVM environment
 	blockContext := NewEVMBlockContext(blockHeight, timestamp, bc)
 	vmenv := evm.NewEVM(blockContext, evm.TxContext{}, statedb, config, cfg)
-	return applyTransaction(msg, statedb, blockHeight, tx, usedGas, vmenv, feeReceiver)
+	return applyTransaction(evmMsg, statedb, blockHeight, tx, usedGas, vmenv, feeReceiver)
 }
@@ -24,6 +24,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ontio/ontology/core/payload"
 	"github.com/ontio/ontology/smartcontract/service/evm/types"
 	"github.com/ontio/ontology/vm/evm"
 	"github.com/ontio/ontology/vm/evm/params"
@@ -109,8 +110,25 @@ type Message interface {
 	Data() []byte
 }
 
-// IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 bool) uint64 {
+type accessListMessage interface {
+	AccessList() payload.AccessList
+}
+
+type typedMessage struct {
+	Message
+	accessList payload.AccessList
+}
+
+func (m typedMessage) AccessList() payload.AccessList { return m.accessList }
+
+// NewTypedMessage attaches the EIP-2930 access list of a typed eth transaction to the message. The access list is
+// only charged as intrinsic gas, since the evm has no EIP-2929 warm and cold access pricing to discount.
+func NewTypedMessage(msg Message, accessList payload.AccessList) Message {
+	return typedMessage{Message: msg, accessList: accessList}
+}
+
+// IntrinsicGas computes the 'intrinsic gas' for a message with the given data and access list.
+func IntrinsicGas(data []byte, accessList payload.AccessList, contractCreation, isHomestead bool, isEIP2028 bool) uint64 {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if contractCreation && isHomestead {
@@ -145,6 +163,15 @@ func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 boo
 		}
 		gas += z * params.TxDataZeroGas
 	}
+	return gas + AccessListGas(accessList)
+}
+
+// AccessListGas computes the EIP-2930 intrinsic gas of the access list.
+func AccessListGas(accessList payload.AccessList) uint64 {
+	var gas uint64
+	for _, tuple := range accessList {
+		gas += params.TxAccessListAddressGas + uint64(len(tuple.StorageKeys))*params.TxAccessListStorageKeyGas
+	}
 	return gas
 }
 
@@ -248,7 +275,11 @@ func (st *StateTransition) TransitionDb() (*types.ExecutionResult, error) {
 		vmerr error // vm errors do not effect consensus and are therefore not assigned to err
 	)
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
-	gas := IntrinsicGas(st.data, contractCreation, homestead, istanbul)
+	var accessList payload.AccessList
+	if typed, ok := msg.(accessListMessage); ok {
+		accessList = typed.AccessList()
+	}
+	gas := IntrinsicGas(st.data, accessList, contractCreation, homestead, istanbul)
 	if st.gas < gas {
 		vmerr = fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, st.gas, gas)
 		gas = st.gas
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package evm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrinsicGas(t *testing.T) {
+	data := []byte{0x00, 0x01}
+	assert.Equal(t, uint64(21000+4+16), IntrinsicGas(data, nil, false, true, true))
+	assert.Equal(t, uint64(53000+4+16), IntrinsicGas(data, nil, true, true, true))
+
+	accessList := payload.AccessList{
+		{Address: common.Address{0x01}, StorageKeys: []common.Hash{{0x01}, {0x02}}},
+		{Address: common.Address{0x02}},
+	}
+	assert.Equal(t, uint64(2*2400+2*1900), AccessListGas(accessList))
+	assert.Equal(t, uint64(21000+4+16+2*2400+2*1900), IntrinsicGas(data, accessList, false, true, true))
+
+	msg := types.NewMessage(common.Address{}, nil, 0, nil, 0, nil, nil, false)
+	_, ok := Message(msg).(accessListMessage)
+	assert.False(t, ok)
+	typed, ok := NewTypedMessage(msg, accessList).(accessListMessage)
+	assert.True(t, ok)
+	assert.Equal(t, accessList, typed.AccessList())
+}
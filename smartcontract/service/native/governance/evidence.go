@@ -0,0 +1,285 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	vbftconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature"
+	cstates "github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	//evidence type
+	EVIDENCE_PROPOSAL uint8 = iota
+	EVIDENCE_ENDORSE
+)
+
+//the endorse vote is signed on a domain separated hash, the endorser signature on the bare block hash can not tell
+//an endorsement from a commitment or an empty block endorsement. The network id is in the domain, so that a vote
+//signed on a test network with the same key is no evidence on another network.
+const ENDORSE_VOTE_DOMAIN = "ontology vbft endorse vote"
+
+//an evidence must be submitted within EVIDENCE_MAX_AGE blocks after its block, the consensus peers submit the
+//evidences as soon as they detect them among the msgs of the recent blocks
+const EVIDENCE_MAX_AGE uint32 = 10000
+
+//EndorseVoteHash is the hash a vbft endorser signs for its endorsement of blockHash at blockNum on networkId
+func EndorseVoteHash(networkId uint32, blockNum uint32, blockHash common.Uint256, forEmpty bool) common.Uint256 {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(ENDORSE_VOTE_DOMAIN)
+	sink.WriteUint32(networkId)
+	sink.WriteUint32(blockNum)
+	sink.WriteHash(blockHash)
+	sink.WriteBool(forEmpty)
+	return sha256.Sum256(sink.Bytes())
+}
+
+//ProposalEvidence is a vbft proposal, the headers of its block and empty block are signed by the proposer.
+//An honest proposer signs a single proposal for each block.
+type ProposalEvidence struct {
+	Block      *types.Header
+	EmptyBlock *types.Header
+}
+
+func (this *ProposalEvidence) Serialization(sink *common.ZeroCopySink) {
+	this.Block.Serialization(sink)
+	this.EmptyBlock.Serialization(sink)
+}
+
+func (this *ProposalEvidence) Deserialization(source *common.ZeroCopySource) error {
+	this.Block, this.EmptyBlock = new(types.Header), new(types.Header)
+	if err := this.Block.Deserialization(source); err != nil {
+		return fmt.Errorf("deserialize block header error: %v", err)
+	}
+	if err := this.EmptyBlock.Deserialization(source); err != nil {
+		return fmt.Errorf("deserialize empty block header error: %v", err)
+	}
+	return nil
+}
+
+func (this *ProposalEvidence) verify(pubKey string, peerIndex uint32, blockNum uint32, prevBlockHash common.Uint256) error {
+	pk, err := vbftconfig.Pubkey(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid peer pubkey: %v", err)
+	}
+	for _, header := range []*types.Header{this.Block, this.EmptyBlock} {
+		if header.Height != blockNum {
+			return fmt.Errorf("proposal of block %d, evidence of block %d", header.Height, blockNum)
+		}
+		//a proposal on another parent may be made on a fork the peer was misled to, it is no equivocation
+		if header.PrevBlockHash != prevBlockHash {
+			return fmt.Errorf("proposal of block %d not on the local block %d", blockNum, blockNum-1)
+		}
+		info := new(vbftconfig.VbftBlockInfo)
+		if err := json.Unmarshal(header.ConsensusPayload, info); err != nil {
+			return fmt.Errorf("unmarshal vbft block info error: %v", err)
+		}
+		//the peer signs the blocks of other proposers as endorser and committer
+		if info.Proposer != peerIndex {
+			return fmt.Errorf("proposal of peer %d, evidence of peer %d", info.Proposer, peerIndex)
+		}
+		if len(header.SigData) == 0 {
+			return fmt.Errorf("proposal without signature")
+		}
+		hash := header.Hash()
+		if err := signature.Verify(pk, hash[:], header.SigData[0]); err != nil {
+			return fmt.Errorf("verify proposal signature error: %v", err)
+		}
+	}
+	if this.Block.Hash() == this.EmptyBlock.Hash() {
+		return fmt.Errorf("proposal with the same block and empty block")
+	}
+	return nil
+}
+
+func (this *ProposalEvidence) same(other *ProposalEvidence) bool {
+	b1, e1, b2, e2 := this.Block.Hash(), this.EmptyBlock.Hash(), other.Block.Hash(), other.EmptyBlock.Hash()
+	return (b1 == b2 && e1 == e2) || (b1 == e2 && e1 == b2)
+}
+
+//EndorseEvidence is a vbft endorsement, Sig is the signature of the endorser on EndorseVoteHash.
+//An honest endorser signs a single vote for the block and a single vote for the empty block of each height.
+type EndorseEvidence struct {
+	BlockHash common.Uint256
+	ForEmpty  bool
+	Sig       []byte
+}
+
+func (this *EndorseEvidence) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteBool(this.ForEmpty)
+	sink.WriteVarBytes(this.Sig)
+}
+
+func (this *EndorseEvidence) Deserialization(source *common.ZeroCopySource) error {
+	var irregular, eof bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return fmt.Errorf("deserialize block hash eof")
+	}
+	this.ForEmpty, irregular, eof = source.NextBool()
+	if irregular || eof {
+		return fmt.Errorf("deserialize for empty irregular: %v, eof: %v", irregular, eof)
+	}
+	this.Sig, _, irregular, eof = source.NextVarBytes()
+	if irregular || eof {
+		return fmt.Errorf("deserialize signature irregular: %v, eof: %v", irregular, eof)
+	}
+	return nil
+}
+
+func (this *EndorseEvidence) verify(pubKey string, networkId uint32, blockNum uint32) error {
+	pk, err := vbftconfig.Pubkey(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid peer pubkey: %v", err)
+	}
+	hash := EndorseVoteHash(networkId, blockNum, this.BlockHash, this.ForEmpty)
+	if err := signature.Verify(pk, hash[:], this.Sig); err != nil {
+		return fmt.Errorf("verify endorse signature error: %v", err)
+	}
+	return nil
+}
+
+//EvidenceParam is two conflicting messages signed by one consensus peer for the same block
+type EvidenceParam struct {
+	PeerPubkey string
+	Type       uint8
+	BlockNum   uint32
+	Proposals  [2]*ProposalEvidence //for EVIDENCE_PROPOSAL
+	Endorses   [2]*EndorseEvidence  //for EVIDENCE_ENDORSE
+}
+
+func (this *EvidenceParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteUint8(this.Type)
+	sink.WriteUint32(this.BlockNum)
+	switch this.Type {
+	case EVIDENCE_PROPOSAL:
+		this.Proposals[0].Serialization(sink)
+		this.Proposals[1].Serialization(sink)
+	case EVIDENCE_ENDORSE:
+		this.Endorses[0].Serialization(sink)
+		this.Endorses[1].Serialization(sink)
+	}
+}
+
+func (this *EvidenceParam) Deserialization(source *common.ZeroCopySource) error {
+	var irregular, eof bool
+	this.PeerPubkey, _, irregular, eof = source.NextString()
+	if irregular || eof {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey irregular: %v, eof: %v", irregular, eof)
+	}
+	this.Type, eof = source.NextUint8()
+	if eof {
+		return fmt.Errorf("source.NextUint8, deserialize type eof")
+	}
+	this.BlockNum, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("source.NextUint32, deserialize blockNum eof")
+	}
+	switch this.Type {
+	case EVIDENCE_PROPOSAL:
+		for i := range this.Proposals {
+			this.Proposals[i] = new(ProposalEvidence)
+			if err := this.Proposals[i].Deserialization(source); err != nil {
+				return err
+			}
+		}
+	case EVIDENCE_ENDORSE:
+		for i := range this.Endorses {
+			this.Endorses[i] = new(EndorseEvidence)
+			if err := this.Endorses[i].Deserialization(source); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown evidence type %d", this.Type)
+	}
+	if source.Len() != 0 {
+		return fmt.Errorf("evidence with trailing bytes")
+	}
+	return nil
+}
+
+//Verify checks that both messages are signed by the peer of peerIndex for the block and that they conflict. The
+//proposals must be on prevBlockHash, the hash of the local block before, and the endorsements for networkId.
+func (this *EvidenceParam) Verify(peerIndex uint32, networkId uint32, prevBlockHash common.Uint256) error {
+	switch this.Type {
+	case EVIDENCE_PROPOSAL:
+		for _, p := range this.Proposals {
+			if err := p.verify(this.PeerPubkey, peerIndex, this.BlockNum, prevBlockHash); err != nil {
+				return err
+			}
+		}
+		if this.Proposals[0].same(this.Proposals[1]) {
+			return fmt.Errorf("proposals are the same")
+		}
+	case EVIDENCE_ENDORSE:
+		for _, e := range this.Endorses {
+			if err := e.verify(this.PeerPubkey, networkId, this.BlockNum); err != nil {
+				return err
+			}
+		}
+		if this.Endorses[0].ForEmpty != this.Endorses[1].ForEmpty {
+			return fmt.Errorf("endorsements of a block and an empty block")
+		}
+		if this.Endorses[0].BlockHash == this.Endorses[1].BlockHash {
+			return fmt.Errorf("endorsements of the same block")
+		}
+	default:
+		return fmt.Errorf("unknown evidence type %d", this.Type)
+	}
+	return nil
+}
+
+func evidenceKey(contract common.Address, param *EvidenceParam) ([]byte, error) {
+	peerPubkeyPrefix, err := hex.DecodeString(param.PeerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(param.BlockNum)
+	sink.WriteUint8(param.Type)
+	return utils.ConcatKey(contract, []byte(EVIDENCE), peerPubkeyPrefix, sink.Bytes()), nil
+}
+
+func putEvidence(native *native.NativeService, contract common.Address, param *EvidenceParam) error {
+	key, err := evidenceKey(contract, param)
+	if err != nil {
+		return err
+	}
+	evidenceBytes, err := native.CacheDB.Get(key)
+	if err != nil {
+		return fmt.Errorf("native.CacheDB.Get, get evidence error: %v", err)
+	}
+	if evidenceBytes != nil {
+		return fmt.Errorf("evidence of block %d is already submitted", param.BlockNum)
+	}
+	native.CacheDB.Put(key, cstates.GenRawStorageItem(common.SerializeToBytes(param)))
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	vbftconfig "github.com/ontio/ontology/consensus/vbft/config"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+)
+
+var testPrevBlockHash = common.Uint256{9}
+
+func signedHeader(t *testing.T, acc *account.Account, proposer uint32, blockNum uint32, nonce uint64) *types.Header {
+	payload, err := json.Marshal(&vbftconfig.VbftBlockInfo{Proposer: proposer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Height: blockNum, PrevBlockHash: testPrevBlockHash, ConsensusData: nonce,
+		ConsensusPayload: payload}
+	hash := header.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.SigData = [][]byte{sig}
+	return header
+}
+
+func signedEndorse(t *testing.T, acc *account.Account, blockNum uint32, blockHash common.Uint256) *EndorseEvidence {
+	hash := EndorseVoteHash(1, blockNum, blockHash, false)
+	sig, err := signature.Sign(acc, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &EndorseEvidence{BlockHash: blockHash, Sig: sig}
+}
+
+func roundTrip(t *testing.T, param *EvidenceParam) *EvidenceParam {
+	result := new(EvidenceParam)
+	if err := result.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(param))); err != nil {
+		t.Fatalf("deserialize evidence: %s", err)
+	}
+	return result
+}
+
+func TestProposalEvidence(t *testing.T) {
+	acc := account.NewAccount("")
+	proposal := func(nonce uint64) *ProposalEvidence {
+		return &ProposalEvidence{
+			Block:      signedHeader(t, acc, 3, 10, nonce),
+			EmptyBlock: signedHeader(t, acc, 3, 10, nonce+1),
+		}
+	}
+	param := roundTrip(t, &EvidenceParam{
+		PeerPubkey: vbftconfig.PubkeyID(acc.PublicKey),
+		Type:       EVIDENCE_PROPOSAL,
+		BlockNum:   10,
+		Proposals:  [2]*ProposalEvidence{proposal(1), proposal(3)},
+	})
+	if err := param.Verify(3, 1, testPrevBlockHash); err != nil {
+		t.Fatalf("verify evidence: %s", err)
+	}
+	if err := param.Verify(4, 1, testPrevBlockHash); err == nil {
+		t.Fatalf("evidence verified against another proposer")
+	}
+	if err := param.Verify(3, 1, common.Uint256{8}); err == nil {
+		t.Fatalf("evidence verified on another parent block")
+	}
+
+	param.Proposals[1] = &ProposalEvidence{Block: param.Proposals[0].EmptyBlock, EmptyBlock: param.Proposals[0].Block}
+	if err := param.Verify(3, 1, testPrevBlockHash); err == nil {
+		t.Fatalf("same proposal verified as evidence")
+	}
+	param.Proposals[1] = proposal(3)
+	param.Proposals[1].Block = signedHeader(t, account.NewAccount(""), 3, 10, 5)
+	if err := param.Verify(3, 1, testPrevBlockHash); err == nil {
+		t.Fatalf("evidence verified with the sig of another key")
+	}
+}
+
+func TestEndorseEvidence(t *testing.T) {
+	acc := account.NewAccount("")
+	param := roundTrip(t, &EvidenceParam{
+		PeerPubkey: vbftconfig.PubkeyID(acc.PublicKey),
+		Type:       EVIDENCE_ENDORSE,
+		BlockNum:   10,
+		Endorses:   [2]*EndorseEvidence{signedEndorse(t, acc, 10, common.Uint256{1}), signedEndorse(t, acc, 10, common.Uint256{2})},
+	})
+	if err := param.Verify(0, 1, testPrevBlockHash); err != nil {
+		t.Fatalf("verify evidence: %s", err)
+	}
+	if err := param.Verify(0, 2, testPrevBlockHash); err == nil {
+		t.Fatalf("evidence verified on another network")
+	}
+
+	param.BlockNum = 11
+	if err := param.Verify(0, 1, testPrevBlockHash); err == nil {
+		t.Fatalf("evidence verified for another block")
+	}
+	param.BlockNum = 10
+	param.Endorses[1] = signedEndorse(t, acc, 10, common.Uint256{1})
+	if err := param.Verify(0, 1, testPrevBlockHash); err == nil {
+		t.Fatalf("endorsements of the same block verified as evidence")
+	}
+}
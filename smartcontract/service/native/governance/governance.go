@@ -81,6 +81,8 @@ const (
 	GET_PEER_POOL                    = "getPeerPool"
 	GET_PEER_INFO                    = "getPeerInfo"
 	GET_PEER_POOL_BY_ADDRESS         = "getPeerPoolByAddress"
+	SUBMIT_EVIDENCE                  = "submitEvidence"
+	ROTATE_PEER_KEY                  = "rotatePeerKey"
 
 	//key prefix
 	GLOBAL_PARAM      = "globalParam"
@@ -100,6 +102,7 @@ const (
 	PROMISE_POS       = "promisePos"
 	PRE_CONFIG        = "preConfig"
 	GAS_ADDRESS       = "gasAddress"
+	EVIDENCE          = "evidence"
 
 	//global
 	PRECISE            = 1000000
@@ -144,12 +147,14 @@ func RegisterGovernanceContract(native *native.NativeService) {
 	native.Register(WITHDRAW_FEE, WithdrawFee)
 	native.Register(ADD_INIT_POS, AddInitPos)
 	native.Register(REDUCE_INIT_POS, ReduceInitPos)
+	native.Register(SUBMIT_EVIDENCE, SubmitEvidence)
 
 	native.Register(INIT_CONFIG, InitConfig)
 	native.Register(APPROVE_CANDIDATE, ApproveCandidate)
 	native.Register(REJECT_CANDIDATE, RejectCandidate)
 	native.Register(BLACK_NODE, BlackNode)
 	native.Register(WHITE_NODE, WhiteNode)
+	native.Register(ROTATE_PEER_KEY, RotatePeerKey)
 	native.Register(COMMIT_DPOS, CommitDpos)
 	native.Register(UPDATE_CONFIG, UpdateConfig)
 	native.Register(UPDATE_GLOBAL_PARAM, UpdateGlobalParam)
@@ -624,28 +629,203 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 	}
 	commit := false
 	for _, peerPubkey := range params.PeerPubkeyList {
-		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
-		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
-		}
 		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
 		if !ok {
 			return utils.BYTE_FALSE, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
 		}
+		consensus, err := blackPeer(native, contract, peerPoolItem)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("blackPeer, black peer error: %v", err)
+		}
+		commit = commit || consensus
+	}
+	err = putPeerPoolMap(native, contract, view, peerPoolMap)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+	}
 
-		blackListItem := &BlackListItem{
-			PeerPubkey: peerPoolItem.PeerPubkey,
-			Address:    peerPoolItem.Address,
-			InitPos:    peerPoolItem.InitPos,
+	//commitDpos
+	if commit {
+		err = executeCommitDpos(native, contract)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
+		}
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+//Replace the key of a candidate or consensus peer at once, e.g. when the key is compromised.
+//The peer keeps its index, stake and authorizations. If it is a consensus peer, commitDpos is executed so that
+//the new key takes part in consensus from the next block. The old key is put into black list.
+func RotatePeerKey(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetRotatePeerKeyHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(RotatePeerKeyParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+
+	// get admin from database
+	adminAddress, err := global_params.GetStorageRole(native,
+		global_params.GenerateOperatorKey(utils.ParamContractAddress))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getAdmin, get admin error: %v", err)
+	}
+
+	//check witness
+	err = utils.ValidateOwner(native, adminAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, checkWitness error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//check new peerPubkey
+	if err := validatePeerPubKeyFormat(params.NewPeerPubkey); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, invalid new peer pubkey: %v", err)
+	}
+	newPeerPubkeyPrefix, err := hex.DecodeString(params.NewPeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, newPeerPubkey format error: %v", err)
+	}
+	blackList, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(BLACK_LIST), newPeerPubkeyPrefix))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("native.CacheDB.Get, get BlackList error: %v", err)
+	}
+	if blackList != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey is in BlackList")
+	}
+	//a key which has been registered keeps its index, it can not be taken by another peer
+	indexBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PEER_INDEX), newPeerPubkeyPrefix))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("native.CacheDB.Get, get indexBytes error: %v", err)
+	}
+	if indexBytes != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey is already registered")
+	}
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	if _, ok := peerPoolMap.PeerPoolMap[params.NewPeerPubkey]; ok {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, new peerPubkey is already in peerPoolMap")
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status != CandidateStatus && peerPoolItem.Status != ConsensusStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerKey, peer status is not candidate or consensus")
+	}
+
+	err = rotatePeerStorage(native, contract, params.PeerPubkey, params.NewPeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("rotatePeerStorage, rotate peer storage error: %v", err)
+	}
+	//the old key may be compromised, it can not be registered again
+	peerPubkeyPrefix, err := hex.DecodeString(params.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	blackListItem := &BlackListItem{
+		PeerPubkey: params.PeerPubkey,
+		Address:    peerPoolItem.Address,
+		InitPos:    peerPoolItem.InitPos,
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(common.SerializeToBytes(blackListItem)))
+
+	delete(peerPoolMap.PeerPoolMap, params.PeerPubkey)
+	peerPoolItem.PeerPubkey = params.NewPeerPubkey
+	peerPoolMap.PeerPoolMap[params.NewPeerPubkey] = peerPoolItem
+	err = putPeerPoolMap(native, contract, view, peerPoolMap)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+	}
+
+	//the fee of last view is split by the peerPoolMap of last view
+	if view > 0 {
+		prePeerPoolMap, err := GetPeerPoolMap(native, contract, view-1)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get last peerPoolMap error: %v", err)
 		}
-		//put peer into black list
-		native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(common.SerializeToBytes(blackListItem)))
-		//change peerPool status
-		if peerPoolItem.Status == ConsensusStatus {
-			commit = true
+		if prePeerPoolItem, ok := prePeerPoolMap.PeerPoolMap[params.PeerPubkey]; ok {
+			delete(prePeerPoolMap.PeerPoolMap, params.PeerPubkey)
+			prePeerPoolItem.PeerPubkey = params.NewPeerPubkey
+			prePeerPoolMap.PeerPoolMap[params.NewPeerPubkey] = prePeerPoolItem
+			err = putPeerPoolMap(native, contract, view-1, prePeerPoolMap)
+			if err != nil {
+				return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put last peerPoolMap error: %v", err)
+			}
 		}
-		peerPoolItem.Status = BlackStatus
-		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+	}
+
+	//commitDpos
+	if peerPoolItem.Status == ConsensusStatus {
+		err = executeCommitDpos(native, contract)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
+		}
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+//Submit evidence that a consensus peer signed two conflicting vbft messages for the same block.
+//Anyone can submit it, the peer is put into black list and its stake is penalized as in blackNode.
+func SubmitEvidence(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetSubmitEvidenceHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	buf, err := utils.DecodeVarBytes(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("serialization.ReadVarBytes, contract params deserialize error: %v", err)
+	}
+	params := new(EvidenceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(buf)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	if params.BlockNum > native.Height || params.BlockNum == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, evidence of invalid block %d", params.BlockNum)
+	}
+	if params.BlockNum+EVIDENCE_MAX_AGE < native.Height {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, evidence of block %d is too old", params.BlockNum)
+	}
+	prevBlockHash := native.Store.GetBlockHash(params.BlockNum - 1)
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status == BlackStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, peer is already in black list")
+	}
+	if err := params.Verify(peerPoolItem.Index, config.DefConfig.P2PNode.NetworkId, prevBlockHash); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, verify evidence error: %v", err)
+	}
+	if err := putEvidence(native, contract, params); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putEvidence, put evidence error: %v", err)
+	}
+
+	commit, err := blackPeer(native, contract, peerPoolItem)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("blackPeer, black peer error: %v", err)
 	}
 	err = putPeerPoolMap(native, contract, view, peerPoolMap)
 	if err != nil {
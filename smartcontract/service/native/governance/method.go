@@ -1455,3 +1455,110 @@ func splitNodeFee(native *native.NativeService, contract common.Address, peerPub
 	}
 	return nil
 }
+
+//move the index, authorize infos, attributes, penalty stake and promise pos of a peer to its new pubkey
+func rotatePeerStorage(native *native.NativeService, contract common.Address, peerPubkey, newPeerPubkey string) error {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	newPeerPubkeyPrefix, err := hex.DecodeString(newPeerPubkey)
+	if err != nil {
+		return fmt.Errorf("hex.DecodeString, newPeerPubkey format error: %v", err)
+	}
+
+	//peer index
+	indexKey := utils.ConcatKey(contract, []byte(PEER_INDEX), peerPubkeyPrefix)
+	indexBytes, err := native.CacheDB.Get(indexKey)
+	if err != nil {
+		return fmt.Errorf("native.CacheDB.Get, get indexBytes error: %v", err)
+	}
+	if indexBytes == nil {
+		return fmt.Errorf("peer index of peerPubkey is not found")
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PEER_INDEX), newPeerPubkeyPrefix), indexBytes)
+	native.CacheDB.Delete(indexKey)
+
+	//authorize info
+	var authorizeInfos []*AuthorizeInfo
+	iter := native.CacheDB.NewIterator(utils.ConcatKey(contract, AUTHORIZE_INFO_POOL, peerPubkeyPrefix))
+	defer iter.Release()
+	for has := iter.First(); has; has = iter.Next() {
+		authorizeInfoStore, err := cstates.GetValueFromRawStorageItem(iter.Value())
+		if err != nil {
+			return fmt.Errorf("authorizeInfoStore is not available!:%v", err)
+		}
+		authorizeInfo := new(AuthorizeInfo)
+		if err := authorizeInfo.Deserialization(common.NewZeroCopySource(authorizeInfoStore)); err != nil {
+			return fmt.Errorf("deserialize, deserialize authorizeInfo error: %v", err)
+		}
+		authorizeInfos = append(authorizeInfos, authorizeInfo)
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	for _, authorizeInfo := range authorizeInfos {
+		native.CacheDB.Delete(utils.ConcatKey(contract, AUTHORIZE_INFO_POOL, peerPubkeyPrefix, authorizeInfo.Address[:]))
+		authorizeInfo.PeerPubkey = newPeerPubkey
+		err = putAuthorizeInfo(native, contract, authorizeInfo)
+		if err != nil {
+			return fmt.Errorf("putAuthorizeInfo, put authorizeInfo error: %v", err)
+		}
+	}
+
+	//peer attributes, penalty stake and promise pos are moved only if present, the getters return defaults
+	peerAttributesKey := utils.ConcatKey(contract, []byte(PEER_ATTRIBUTES), peerPubkeyPrefix)
+	peerAttributesBytes, err := native.CacheDB.Get(peerAttributesKey)
+	if err != nil {
+		return fmt.Errorf("native.CacheDB.Get, get peerAttributesBytes error: %v", err)
+	}
+	if peerAttributesBytes != nil {
+		peerAttributes, err := getPeerAttributes(native, contract, peerPubkey)
+		if err != nil {
+			return fmt.Errorf("getPeerAttributes error: %v", err)
+		}
+		native.CacheDB.Delete(peerAttributesKey)
+		peerAttributes.PeerPubkey = newPeerPubkey
+		err = putPeerAttributes(native, contract, peerAttributes)
+		if err != nil {
+			return fmt.Errorf("putPeerAttributes error: %v", err)
+		}
+	}
+
+	penaltyStakeKey := utils.ConcatKey(contract, []byte(PENALTY_STAKE), peerPubkeyPrefix)
+	penaltyStakeBytes, err := native.CacheDB.Get(penaltyStakeKey)
+	if err != nil {
+		return fmt.Errorf("native.CacheDB.Get, get penaltyStakeBytes error: %v", err)
+	}
+	if penaltyStakeBytes != nil {
+		penaltyStake, err := getPenaltyStake(native, contract, peerPubkey)
+		if err != nil {
+			return fmt.Errorf("getPenaltyStake, get penaltyStake error: %v", err)
+		}
+		native.CacheDB.Delete(penaltyStakeKey)
+		penaltyStake.PeerPubkey = newPeerPubkey
+		err = putPenaltyStake(native, contract, penaltyStake)
+		if err != nil {
+			return fmt.Errorf("putPenaltyStake, put penaltyStake error: %v", err)
+		}
+	}
+
+	promisePosKey := utils.ConcatKey(contract, []byte(PROMISE_POS), peerPubkeyPrefix)
+	promisePosBytes, err := native.CacheDB.Get(promisePosKey)
+	if err != nil {
+		return fmt.Errorf("native.CacheDB.Get, get promisePosBytes error: %v", err)
+	}
+	if promisePosBytes != nil {
+		promisePos, err := getPromisePos(native, contract, peerPubkey)
+		if err != nil {
+			return fmt.Errorf("getPromisePos, get promisePos error: %v", err)
+		}
+		native.CacheDB.Delete(promisePosKey)
+		promisePos.PeerPubkey = newPeerPubkey
+		err = putPromisePos(native, contract, promisePos)
+		if err != nil {
+			return fmt.Errorf("putPromisePos, put promisePos error: %v", err)
+		}
+	}
+	return nil
+}
@@ -916,3 +916,27 @@ func (this *GasAddress) Deserialization(source *common.ZeroCopySource) error {
 	this.Address = address
 	return nil
 }
+
+type RotatePeerKeyParam struct {
+	PeerPubkey    string
+	NewPeerPubkey string
+}
+
+func (this *RotatePeerKeyParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteString(this.NewPeerPubkey)
+}
+
+func (this *RotatePeerKeyParam) Deserialization(source *common.ZeroCopySource) error {
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	newPeerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize newPeerPubkey error: %v", err)
+	}
+	this.PeerPubkey = peerPubkey
+	this.NewPeerPubkey = newPeerPubkey
+	return nil
+}
@@ -59,6 +59,25 @@ func GetPeerPoolMap(native *native.NativeService, contract common.Address, view
 	return peerPoolMap, nil
 }
 
+//put the peer into black list and change its status, return whether it was a consensus peer
+func blackPeer(native *native.NativeService, contract common.Address, peerPoolItem *PeerPoolItem) (bool, error) {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPoolItem.PeerPubkey)
+	if err != nil {
+		return false, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	blackListItem := &BlackListItem{
+		PeerPubkey: peerPoolItem.PeerPubkey,
+		Address:    peerPoolItem.Address,
+		InitPos:    peerPoolItem.InitPos,
+	}
+	//put peer into black list
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(common.SerializeToBytes(blackListItem)))
+	//change peerPool status
+	consensus := peerPoolItem.Status == ConsensusStatus
+	peerPoolItem.Status = BlackStatus
+	return consensus, nil
+}
+
 func putPeerPoolMap(native *native.NativeService, contract common.Address, view uint32, peerPoolMap *PeerPoolMap) error {
 	sink := common.NewZeroCopySink(nil)
 	if err := peerPoolMap.Serialization(sink); err != nil {
@@ -19,6 +19,7 @@
 package native
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/ontio/ontology/common"
@@ -30,6 +31,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/event"
 	"github.com/ontio/ontology/smartcontract/states"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 )
 
 type (
@@ -64,6 +66,20 @@ func (this *NativeService) Register(methodName string, handler Handler) {
 }
 
 func (this *NativeService) Invoke() ([]byte, error) {
+	tracer := this.ContextRef.GetTracer()
+	if tracer == nil {
+		return this.invoke(nil)
+	}
+	contract := this.InvokeParam
+	gasLeft, _ := this.ContextRef.GetGasInfo()
+	tracer.CaptureEnter(trace.NATIVE, contract.Address, contract.Method, contract.Args, gasLeft)
+	result, err := this.invoke(tracer)
+	gasLeft, _ = this.ContextRef.GetGasInfo()
+	tracer.CaptureExit(hex.EncodeToString(result), gasLeft, err)
+	return result, err
+}
+
+func (this *NativeService) invoke(tracer trace.Tracer) ([]byte, error) {
 	contract := this.InvokeParam
 	services, ok := Contracts[contract.Address]
 	if !ok {
@@ -87,6 +103,11 @@ func (this *NativeService) Invoke() ([]byte, error) {
 		return result, errors.NewDetailErr(err, errors.ErrNoCode, "[Invoke] Native serivce function execute error!")
 	}
 	this.ContextRef.PopContext()
+	if tracer != nil {
+		for _, notify := range this.Notifications {
+			tracer.CaptureNotify(notify)
+		}
+	}
 	this.ContextRef.PushNotifications(this.Notifications)
 	this.ContextRef.PutCrossStateHashes(this.CrossHashes)
 	this.Notifications = notifications
@@ -39,6 +39,8 @@ var (
 	STORAGE_GET_GAS               uint64 = 200
 	STORAGE_PUT_GAS               uint64 = 4000
 	STORAGE_DELETE_GAS            uint64 = 100
+	STORAGE_FIND_GAS              uint64 = 200
+	STORAGE_FIND_ITEM_GAS         uint64 = 100
 	RUNTIME_CHECKWITNESS_GAS      uint64 = 200
 	RUNTIME_VERIFYMUTISIG_GAS     uint64 = 400
 	RUNTIME_GETGASINFO_GAS        uint64 = 10
@@ -52,10 +54,11 @@ var (
 	HASH256_GAS                   uint64 = 20
 	OPCODE_GAS                    uint64 = 1
 
-	PER_UNIT_CODE_LEN    = 1024
-	METHOD_LENGTH_LIMIT  = 1024
-	DUPLICATE_STACK_SIZE = 1024 * 2
-	VM_STEP_LIMIT        = 400000
+	PER_UNIT_CODE_LEN      = 1024
+	METHOD_LENGTH_LIMIT    = 1024
+	DUPLICATE_STACK_SIZE   = 1024 * 2
+	STORAGE_FIND_MAX_COUNT = 1024
+	VM_STEP_LIMIT          = 400000
 
 	// API Name
 	ATTRIBUTE_GETUSAGE_NAME = "Ontology.Attribute.GetUsage"
@@ -95,6 +98,7 @@ var (
 	STORAGE_DELETE_NAME             = "System.Storage.Delete"
 	STORAGE_GETCONTEXT_NAME         = "System.Storage.GetContext"
 	STORAGE_GETREADONLYCONTEXT_NAME = "System.Storage.GetReadOnlyContext"
+	STORAGE_FIND_NAME               = "Ontology.Storage.Find"
 
 	STORAGECONTEXT_ASREADONLY_NAME = "System.StorageContext.AsReadOnly"
 
@@ -43,6 +43,11 @@ func GasPrice(gasTable map[string]uint64, engine *vm.Executor, name string) (uin
 	switch name {
 	case STORAGE_PUT_NAME:
 		return StoreGasCost(gasTable, engine)
+	case STORAGE_FIND_NAME:
+		if value, ok := gasTable[name]; ok {
+			return value, nil
+		}
+		return STORAGE_FIND_GAS, nil
 	default:
 		if value, ok := gasTable[name]; ok {
 			return value, nil
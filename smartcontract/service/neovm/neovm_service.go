@@ -32,6 +32,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/context"
 	"github.com/ontio/ontology/smartcontract/event"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 	vm "github.com/ontio/ontology/vm/neovm"
 	vmty "github.com/ontio/ontology/vm/neovm/types"
 )
@@ -66,6 +67,11 @@ var (
 		BLOCKCHAIN_GETHEADER_NAME: BlockChainGetHeaderNew,
 	}
 
+	// Register services which are available after storage find height
+	ServiceMapStorageFind = map[string]ServiceHandler{
+		STORAGE_FIND_NAME: StorageFind,
+	}
+
 	// Register all service for smart contract execute
 	ServiceMap = map[string]ServiceHandler{
 		BLOCKCHAIN_GETCONTRACT_NAME: BlockChainGetContract,
@@ -140,6 +146,24 @@ type NeoVmService struct {
 
 // Invoke a smart contract
 func (this *NeoVmService) Invoke() (interface{}, error) {
+	tracer := this.ContextRef.GetTracer()
+	if tracer == nil {
+		return this.invoke(nil)
+	}
+	gasLeft, _ := this.ContextRef.GetGasInfo()
+	tracer.CaptureEnter(trace.NEOVM, scommon.AddressFromVmCode(this.Code), "", nil, gasLeft)
+	result, err := this.invoke(tracer)
+	var output interface{}
+	if err == nil && result != nil {
+		// the value is only converted for display, a conversion error should not fail the invocation
+		output, _ = result.(*vmty.VmValue).ConvertNeoVmValueHexString()
+	}
+	gasLeft, _ = this.ContextRef.GetGasInfo()
+	tracer.CaptureExit(output, gasLeft, err)
+	return result, err
+}
+
+func (this *NeoVmService) invoke(tracer trace.Tracer) (interface{}, error) {
 	if len(this.Code) == 0 {
 		return nil, ERR_EXECUTE_CODE
 	}
@@ -175,6 +199,10 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 			gasTable[opCode] = price
 		}
 
+		if tracer != nil {
+			tracer.CaptureNeoVMStep(this.Engine.Context.GetInstructionPointer()-1, opName(opCode), price,
+				this.Engine.EvalStack.Count())
+		}
 		if !this.ContextRef.CheckUseGas(price) {
 			return nil, ERR_GAS_INSUFFICIENT
 		}
@@ -250,6 +278,13 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 	return nil, nil
 }
 
+func opName(opCode vm.OpCode) string {
+	if opCode >= vm.PUSHBYTES1 && opCode <= vm.PUSHBYTES75 {
+		return fmt.Sprintf("PUSHBYTES%d", opCode)
+	}
+	return vm.OpExecList[opCode].Name
+}
+
 // SystemCall provide register service for smart contract to interaction with blockchain
 func (this *NeoVmService) SystemCall(engine *vm.Executor) error {
 	serviceName, err := engine.Context.OpReader.ReadVarString(vm.MAX_BYTEARRAY_SIZE)
@@ -264,6 +299,9 @@ func (this *NeoVmService) SystemCall(engine *vm.Executor) error {
 			serviceHandler, ok = ServiceMapNew[serviceName]
 		}
 	}
+	if !ok && this.Height >= config.GetStorageFindHeight() {
+		serviceHandler, ok = ServiceMapStorageFind[serviceName]
+	}
 
 	if !ok {
 		return errors.NewErr(fmt.Sprintf("[SystemCall] the given service is not supported: %s", serviceName))
@@ -272,6 +310,9 @@ func (this *NeoVmService) SystemCall(engine *vm.Executor) error {
 	if err != nil {
 		return err
 	}
+	if tracer := this.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureHostCall(serviceName, price)
+	}
 	if !this.ContextRef.CheckUseGas(price) {
 		return ERR_GAS_INSUFFICIENT
 	}
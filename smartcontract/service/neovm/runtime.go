@@ -148,7 +148,11 @@ func RuntimeNotify(service *NeoVmService, engine *vm.Executor) error {
 	if err != nil {
 		return err
 	}
-	service.Notifications = append(service.Notifications, &event.NotifyEventInfo{ContractAddress: context.ContractAddress, States: states})
+	notify := &event.NotifyEventInfo{ContractAddress: context.ContractAddress, States: states}
+	if tracer := service.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureNotify(notify)
+	}
+	service.Notifications = append(service.Notifications, notify)
 	return nil
 }
 
@@ -24,7 +24,9 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/smartcontract/trace"
 	vm "github.com/ontio/ontology/vm/neovm"
+	vmty "github.com/ontio/ontology/vm/neovm/types"
 )
 
 // StoragePut put smart contract storage item to cache
@@ -53,6 +55,9 @@ func StoragePut(service *NeoVmService, engine *vm.Executor) error {
 		return err
 	}
 
+	if tracer := service.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureStorage(trace.STORAGE_PUT, key, value)
+	}
 	service.CacheDB.Put(genStorageKey(context.Address, key), states.GenRawStorageItem(value))
 	return nil
 }
@@ -73,6 +78,9 @@ func StorageDelete(service *NeoVmService, engine *vm.Executor) error {
 	if err != nil {
 		return err
 	}
+	if tracer := service.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureStorage(trace.STORAGE_DELETE, ba, nil)
+	}
 	service.CacheDB.Delete(genStorageKey(context.Address, ba))
 
 	return nil
@@ -95,13 +103,20 @@ func StorageGet(service *NeoVmService, engine *vm.Executor) error {
 		return err
 	}
 
+	tracer := service.ContextRef.GetTracer()
 	if len(raw) == 0 {
+		if tracer != nil {
+			tracer.CaptureStorage(trace.STORAGE_GET, ba, nil)
+		}
 		return engine.EvalStack.PushBytes([]byte{})
 	}
 	value, err := states.GetValueFromRawStorageItem(raw)
 	if err != nil {
 		return err
 	}
+	if tracer != nil {
+		tracer.CaptureStorage(trace.STORAGE_GET, ba, value)
+	}
 	return engine.EvalStack.PushBytes(value)
 }
 
@@ -145,3 +160,70 @@ func genStorageKey(address common.Address, key []byte) []byte {
 	res = append(res, key...)
 	return res
 }
+
+// StorageFind push at most count smart contract storage items with key in [start, end) to vm stack as an array of
+// key value structs, in ascending order of key or descending order if reverse. Empty end means no upper bound
+func StorageFind(service *NeoVmService, engine *vm.Executor) error {
+	context, err := getContext(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StorageFind] get pop context error!")
+	}
+	start, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	end, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	reverse, err := engine.EvalStack.PopAsBool()
+	if err != nil {
+		return err
+	}
+	count, err := engine.EvalStack.PopAsInt64()
+	if err != nil {
+		return err
+	}
+	if count <= 0 || count > int64(STORAGE_FIND_MAX_COUNT) {
+		return errors.NewErr("[StorageFind] invalid count")
+	}
+
+	items, err := service.CacheDB.FindStorage(context.Address, start, end, reverse, int(count))
+	if err != nil {
+		return err
+	}
+	tracer := service.ContextRef.GetTracer()
+	arr := vmty.NewArrayValue()
+	for _, item := range items {
+		value, err := states.GetValueFromRawStorageItem(item.Value)
+		if err != nil {
+			return err
+		}
+		if tracer != nil {
+			tracer.CaptureStorage(trace.STORAGE_GET, item.Key, value)
+		}
+		cost := uint64((len(item.Key)+len(value)-1)/1024+1) * STORAGE_FIND_ITEM_GAS
+		if !service.ContextRef.CheckUseGas(cost) {
+			return ERR_GAS_INSUFFICIENT
+		}
+		key, err := vmty.VmValueFromBytes(item.Key)
+		if err != nil {
+			return err
+		}
+		val, err := vmty.VmValueFromBytes(value)
+		if err != nil {
+			return err
+		}
+		kv := vmty.NewStructValue()
+		if err := kv.Append(key); err != nil {
+			return err
+		}
+		if err := kv.Append(val); err != nil {
+			return err
+		}
+		if err := arr.Append(vmty.VmValueFromStructVal(kv)); err != nil {
+			return err
+		}
+	}
+	return engine.EvalStack.Push(vmty.VmValueFromArrayVal(arr))
+}
@@ -36,6 +36,9 @@ var (
 	STORAGE_GET_GAS          uint64 = 200
 	STORAGE_PUT_GAS          uint64 = 4000
 	STORAGE_DELETE_GAS       uint64 = 100
+	STORAGE_FIND_GAS         uint64 = 200
+	STORAGE_FIND_ITEM_GAS    uint64 = 100
+	STORAGE_FIND_MAX_COUNT   uint32 = 1024
 	UINT_DEPLOY_CODE_LEN_GAS uint64 = 200000
 	PER_UNIT_CODE_LEN        uint64 = 1024
 
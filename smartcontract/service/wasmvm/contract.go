@@ -44,7 +44,7 @@ func ContractCreate(proc *exec.Process,
 	}
 
 	cost := CONTRACT_CREATE_GAS + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*UINT_DEPLOY_CODE_LEN_GAS
-	self.checkGas(cost)
+	self.checkGas("ontio_contract_create", cost)
 
 	name, err := ReadWasmMemory(proc, namePtr, nameLen)
 	if err != nil {
@@ -107,7 +107,7 @@ func ContractMigrate(proc *exec.Process,
 	}
 
 	cost := CONTRACT_CREATE_GAS + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*UINT_DEPLOY_CODE_LEN_GAS
-	self.checkGas(cost)
+	self.checkGas("ontio_contract_migrate", cost)
 
 	name, err := ReadWasmMemory(proc, namePtr, nameLen)
 	if err != nil {
@@ -58,19 +58,19 @@ type Runtime struct {
 
 func Timestamp(proc *exec.Process) uint64 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(TIMESTAMP_GAS)
+	self.checkGas("ontio_timestamp", TIMESTAMP_GAS)
 	return uint64(self.Service.Time)
 }
 
 func BlockHeight(proc *exec.Process) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(BLOCK_HEGHT_GAS)
+	self.checkGas("ontio_block_height", BLOCK_HEGHT_GAS)
 	return self.Service.Height
 }
 
 func SelfAddress(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(SELF_ADDRESS_GAS)
+	self.checkGas("ontio_self_address", SELF_ADDRESS_GAS)
 	selfaddr := self.Service.ContextRef.CurrentContext().ContractAddress
 	_, err := proc.WriteAt(selfaddr[:], int64(dst))
 	if err != nil {
@@ -80,7 +80,7 @@ func SelfAddress(proc *exec.Process, dst uint32) {
 
 func GetGasInfo(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(GET_GAS_INFO_GAS)
+	self.checkGas("ontio_gas_info", GET_GAS_INFO_GAS)
 	sink := common.NewZeroCopySink(nil)
 	sink.WriteUint64(*self.Service.vm.ExecMetrics.GasLimit)
 	sink.WriteUint64(self.Service.GasPrice)
@@ -93,7 +93,7 @@ func GetGasInfo(proc *exec.Process, dst uint32) {
 func Sha256(proc *exec.Process, src uint32, slen uint32, dst uint32) {
 	self := proc.HostData().(*Runtime)
 	cost := uint64((slen/1024)+1) * SHA256_GAS
-	self.checkGas(cost)
+	self.checkGas("ontio_sha256", cost)
 
 	bs, err := ReadWasmMemory(proc, src, slen)
 	if err != nil {
@@ -112,7 +112,7 @@ func Sha256(proc *exec.Process, src uint32, slen uint32, dst uint32) {
 
 func CallerAddress(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CALLER_ADDRESS_GAS)
+	self.checkGas("ontio_caller_address", CALLER_ADDRESS_GAS)
 	if self.Service.ContextRef.CallingContext() != nil {
 		calleraddr := self.Service.ContextRef.CallingContext().ContractAddress
 		_, err := proc.WriteAt(calleraddr[:], int64(dst))
@@ -130,7 +130,7 @@ func CallerAddress(proc *exec.Process, dst uint32) {
 
 func EntryAddress(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(ENTRY_ADDRESS_GAS)
+	self.checkGas("ontio_entry_address", ENTRY_ADDRESS_GAS)
 	entryAddress := self.Service.ContextRef.EntryContext().ContractAddress
 	_, err := proc.WriteAt(entryAddress[:], int64(dst))
 	if err != nil {
@@ -140,7 +140,7 @@ func EntryAddress(proc *exec.Process, dst uint32) {
 
 func Checkwitness(proc *exec.Process, dst uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CHECKWITNESS_GAS)
+	self.checkGas("ontio_check_witness", CHECKWITNESS_GAS)
 	var addr common.Address
 	_, err := proc.ReadAt(addr[:], int64(dst))
 	if err != nil {
@@ -187,6 +187,10 @@ func notify(service *WasmVmService, bs []byte) error {
 	val := crossvm_codec.DeserializeNotify(bs)
 	notify.States = val
 
+	if tracer := service.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureNotify(notify)
+	}
+
 	notifys := make([]*event.NotifyEventInfo, 1)
 	notifys[0] = notify
 	service.ContextRef.PushNotifications(notifys)
@@ -234,7 +238,7 @@ func GetCallOut(proc *exec.Process, dst uint32) {
 
 func GetCurrentTxHash(proc *exec.Process, ptr uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CURRENT_TX_HASH_GAS)
+	self.checkGas("ontio_current_txhash", CURRENT_TX_HASH_GAS)
 
 	txhash := self.Service.Tx.Hash()
 
@@ -259,7 +263,7 @@ func RaiseException(proc *exec.Process, ptr uint32, len uint32) {
 func CallContract(proc *exec.Process, contractAddr uint32, inputPtr uint32, inputLen uint32) uint32 {
 	self := proc.HostData().(*Runtime)
 
-	self.checkGas(CALL_CONTRACT_GAS)
+	self.checkGas("ontio_call_contract", CALL_CONTRACT_GAS)
 	var contractAddress common.Address
 	_, err := proc.ReadAt(contractAddress[:], int64(contractAddr))
 	if err != nil {
@@ -352,6 +356,12 @@ func NewHostModule() *wasm.Module {
 				Form:       0, // value for the 'func' type constructor
 				ParamTypes: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
 			},
+			//func(uint32,uint32,uint32,uint32,uint32,uint32)uint32  [12]
+			{
+				Form:        0, // value for the 'func' type constructor
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
 		},
 	}
 	m.FunctionIndexSpace = []wasm.Function{
@@ -480,6 +490,11 @@ func NewHostModule() *wasm.Module {
 			Host: reflect.ValueOf(GetGasInfo),
 			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
 		},
+		{ //25
+			Sig:  &m.Types.Entries[12],
+			Host: reflect.ValueOf(StorageFind),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
 	}
 
 	m.Export = &wasm.SectionExports{
@@ -609,6 +624,11 @@ func NewHostModule() *wasm.Module {
 				Kind:     wasm.ExternalFunction,
 				Index:    24,
 			},
+			"ontio_storage_find": {
+				FieldStr: "ontio_storage_find",
+				Kind:     wasm.ExternalFunction,
+				Index:    25,
+			},
 		},
 	}
 
@@ -648,7 +668,10 @@ func checkGasInner(gasLimit *uint64, cost uint64) error {
 	return nil
 }
 
-func (self *Runtime) checkGas(gaslimit uint64) {
+func (self *Runtime) checkGas(name string, gaslimit uint64) {
+	if tracer := self.Service.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureHostCall(name, gaslimit)
+	}
 	err := checkGasInner(self.Service.vm.ExecMetrics.GasLimit, gaslimit)
 	if err != nil {
 		panic(err)
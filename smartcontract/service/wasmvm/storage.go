@@ -21,7 +21,9 @@ import (
 	"errors"
 	"math"
 
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/smartcontract/trace"
 	"github.com/ontio/wagon/exec"
 )
 
@@ -34,6 +36,7 @@ func storageRead(service *WasmVmService, keybytes []byte, klen uint32, vlen uint
 	}
 
 	if raw == nil {
+		traceStorage(service, trace.STORAGE_GET, keybytes, nil)
 		return []byte{}, math.MaxUint32, nil
 	}
 
@@ -41,6 +44,7 @@ func storageRead(service *WasmVmService, keybytes []byte, klen uint32, vlen uint
 	if err != nil {
 		return []byte{}, 0, err
 	}
+	traceStorage(service, trace.STORAGE_GET, keybytes, item)
 
 	length := vlen
 	itemlen := uint32(len(item))
@@ -57,7 +61,7 @@ func storageRead(service *WasmVmService, keybytes []byte, klen uint32, vlen uint
 
 func StorageRead(proc *exec.Process, keyPtr uint32, klen uint32, val uint32, vlen uint32, offset uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(STORAGE_GET_GAS)
+	self.checkGas("ontio_storage_read", STORAGE_GET_GAS)
 	keybytes, err := ReadWasmMemory(proc, keyPtr, klen)
 	if err != nil {
 		panic(err)
@@ -92,21 +96,80 @@ func StorageWrite(proc *exec.Process, keyPtr uint32, keyLen uint32, valPtr uint3
 	}
 
 	cost := uint64((len(keybytes)+len(valbytes)-1)/1024+1) * STORAGE_PUT_GAS
-	self.checkGas(cost)
+	self.checkGas("ontio_storage_write", cost)
 
 	key := serializeStorageKey(self.Service.ContextRef.CurrentContext().ContractAddress, keybytes)
 
+	traceStorage(self.Service, trace.STORAGE_PUT, keybytes, valbytes)
 	self.Service.CacheDB.Put(key, states.GenRawStorageItem(valbytes))
 }
 
 func StorageDelete(proc *exec.Process, keyPtr uint32, keyLen uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(STORAGE_DELETE_GAS)
+	self.checkGas("ontio_storage_delete", STORAGE_DELETE_GAS)
 	keybytes, err := ReadWasmMemory(proc, keyPtr, keyLen)
 	if err != nil {
 		panic(err)
 	}
 	key := serializeStorageKey(self.Service.ContextRef.CurrentContext().ContractAddress, keybytes)
 
+	traceStorage(self.Service, trace.STORAGE_DELETE, keybytes, nil)
 	self.Service.CacheDB.Delete(key)
 }
+
+//storageFind return the serialized storage items of the contract with key in [start, end), and the gas cost of them
+func storageFind(service *WasmVmService, start, end []byte, reverse bool, count uint32) ([]byte, uint64, error) {
+	if count == 0 || count > STORAGE_FIND_MAX_COUNT {
+		return nil, 0, errors.New("invalid storage find count")
+	}
+	addr := service.ContextRef.CurrentContext().ContractAddress
+	items, err := service.CacheDB.FindStorage(addr, start, end, reverse, int(count))
+	if err != nil {
+		return nil, 0, err
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(items)))
+	cost := uint64(0)
+	for _, item := range items {
+		value, err := states.GetValueFromRawStorageItem(item.Value)
+		if err != nil {
+			return nil, 0, err
+		}
+		traceStorage(service, trace.STORAGE_GET, item.Key, value)
+		sink.WriteVarBytes(item.Key)
+		sink.WriteVarBytes(value)
+		cost += uint64((len(item.Key)+len(value)-1)/1024+1) * STORAGE_FIND_ITEM_GAS
+	}
+	return sink.Bytes(), cost, nil
+}
+
+//StorageFind read at most count storage items of the contract with key in [start, end) to the call output, in
+//ascending order of key or descending order if reverse is not 0. Empty end means no upper bound. The output is the
+//item count as varuint followed by the key and value of each item as varbytes, and its length is returned.
+func StorageFind(proc *exec.Process, startPtr uint32, startLen uint32, endPtr uint32, endLen uint32, reverse uint32,
+	count uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	self.checkGas("ontio_storage_find", STORAGE_FIND_GAS)
+	start, err := ReadWasmMemory(proc, startPtr, startLen)
+	if err != nil {
+		panic(err)
+	}
+	end, err := ReadWasmMemory(proc, endPtr, endLen)
+	if err != nil {
+		panic(err)
+	}
+
+	output, cost, err := storageFind(self.Service, start, end, reverse != 0, count)
+	if err != nil {
+		panic(err)
+	}
+	self.checkGas("ontio_storage_find", cost)
+	self.CallOutPut = output
+	return uint32(len(output))
+}
+
+func traceStorage(service *WasmVmService, op trace.StorageOp, key, value []byte) {
+	if tracer := service.ContextRef.GetTracer(); tracer != nil {
+		tracer.CaptureStorage(op, key, value)
+	}
+}
@@ -80,6 +80,30 @@ func checkOntoWasm(m *wasm.Module) error {
 	return nil
 }
 
+//hostFuncHeight return the block height from which the host function can be imported
+func hostFuncHeight(name string) uint32 {
+	switch name {
+	case "ontio_storage_find":
+		return config.GetStorageFindHeight()
+	default:
+		return 0
+	}
+}
+
+//CheckHostImports make sure the host functions imported by the module are available at height. The wasmjit library
+//does not provide the host functions added after it, so the contracts importing them can not run in jit mode.
+func CheckHostImports(module *exec.CompiledModule, height uint32) error {
+	if module.RawModule.Import == nil {
+		return nil
+	}
+	for _, entry := range module.RawModule.Import.Entries {
+		if entry.ModuleName == "env" && height < hostFuncHeight(entry.FieldName) {
+			return fmt.Errorf("host function %s is not available at height %d", entry.FieldName, height)
+		}
+	}
+	return nil
+}
+
 func ReadWasmModule(code []byte, verify config.VerifyMethod) (*exec.CompiledModule, error) {
 	m, err := wasm.ReadModule(bytes.NewReader(code), func(name string) (*wasm.Module, error) {
 		switch name {
@@ -18,6 +18,7 @@
 package wasmvm
 
 import (
+	"encoding/hex"
 	"fmt"
 	"sync"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/event"
 	"github.com/ontio/ontology/smartcontract/states"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 	"github.com/ontio/wagon/exec"
 )
 
@@ -134,6 +136,24 @@ func (this *WasmVmService) Invoke() (interface{}, error) {
 		return nil, err
 	}
 
+	tracer := this.ContextRef.GetTracer()
+	if tracer == nil {
+		return this.invoke(contract)
+	}
+	tracer.CaptureEnter(trace.WASMVM, contract.Address, "", contract.Args, *this.GasLimit)
+	output, err := this.invoke(contract)
+	var result interface{}
+	if err == nil {
+		result = hex.EncodeToString(output)
+	}
+	tracer.CaptureExit(result, *this.GasLimit, err)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (this *WasmVmService) invoke(contract *states.WasmContractParam) ([]byte, error) {
 	code, _, err := this.CacheDB.GetContract(contract.Address)
 	if err != nil {
 		return nil, err
@@ -184,6 +204,9 @@ func invokeInterpreter(this *WasmVmService, contract *states.WasmContractParam,
 		compiled = module
 		CodeCache.Add(contract.Address.ToHexString(), compiled)
 	}
+	if err := CheckHostImports(compiled, this.Height); err != nil {
+		return nil, err
+	}
 
 	vm, err := exec.NewVMWithCompiled(compiled, WASM_MEM_LIMITATION)
 	if err != nil {
@@ -241,10 +264,13 @@ func (self *WasmVmService) DeployContract(code []byte, vmType uint32, name, vers
 	if err != nil {
 		return addr, err
 	}
-	_, err = ReadWasmModule(wasmCode, config.DefConfig.Common.WasmVerifyMethod)
+	module, err := ReadWasmModule(wasmCode, config.DefConfig.Common.WasmVerifyMethod)
 	if err != nil {
 		return addr, err
 	}
+	if err := CheckHostImports(module, self.Height); err != nil {
+		return addr, err
+	}
 
 	addr = dep.Address()
 	err = self.ensureContractUndeployed(addr)
@@ -37,6 +37,7 @@ import (
 	states2 "github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/errors"
 	"github.com/ontio/ontology/smartcontract/states"
+	"github.com/ontio/ontology/smartcontract/trace"
 )
 
 const (
@@ -211,6 +212,7 @@ func ontio_storage_write_cgo(service_index C.uint64_t, key_s C.wasmjit_slice_t,
 	valbytes := jitSliceToBytes(val_s)
 
 	key := serializeStorageKey(service.ContextRef.CurrentContext().ContractAddress, keybytes)
+	traceStorage(service, trace.STORAGE_PUT, keybytes, valbytes)
 	service.CacheDB.Put(key, states2.GenRawStorageItem(valbytes))
 }
 
@@ -220,6 +222,7 @@ func ontio_storage_delete_cgo(service_index C.uint64_t, key_s C.wasmjit_slice_t)
 	keybytes := jitSliceToBytes(key_s)
 
 	key := serializeStorageKey(service.ContextRef.CurrentContext().ContractAddress, keybytes)
+	traceStorage(service, trace.STORAGE_DELETE, keybytes, nil)
 	service.CacheDB.Delete(key)
 }
 
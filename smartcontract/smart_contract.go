@@ -31,6 +31,7 @@ import (
 	"github.com/ontio/ontology/smartcontract/service/neovm"
 	"github.com/ontio/ontology/smartcontract/service/wasmvm"
 	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/smartcontract/trace"
 	vm "github.com/ontio/ontology/vm/neovm"
 )
 
@@ -53,6 +54,7 @@ type SmartContract struct {
 	PreExec       bool
 	internelErr   bool
 	CrossHashes   []common.Uint256
+	Tracer        trace.Tracer // optional, trace the execution of contracts
 }
 
 // Config describe smart contract need parameters configuration
@@ -130,6 +132,10 @@ func (this *SmartContract) PutCrossStateHashes(hashes []common.Uint256) {
 	this.CrossHashes = append(this.CrossHashes, hashes...)
 }
 
+func (this *SmartContract) GetTracer() trace.Tracer {
+	return this.Tracer
+}
+
 func (this *SmartContract) checkContexts() bool {
 	if len(this.Contexts) > MAX_EXECUTE_ENGINE {
 		return false
@@ -19,6 +19,8 @@
 package storage
 
 import (
+	"bytes"
+
 	comm "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/core/payload"
@@ -212,6 +214,52 @@ func (self *Iter) Key() []byte {
 	return key
 }
 
+func (self *Iter) Seek(key []byte) bool {
+	pkey := make([]byte, 1+len(key))
+	pkey[0] = byte(common.ST_STORAGE)
+	copy(pkey[1:], key)
+	return self.JoinIter.Seek(pkey)
+}
+
+// KeyValue is a storage key of contract and its raw storage item
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// FindStorage return at most limit storage items of the contract with key in [start, end), empty end means no upper
+// bound. The items are in ascending order of key, or descending order if reverse. The keys do not include the contract
+// address.
+func (self *CacheDB) FindStorage(addr comm.Address, start, end []byte, reverse bool, limit int) ([]KeyValue, error) {
+	iter := self.NewIterator(addr[:])
+	defer iter.Release()
+	var ok bool
+	if !reverse {
+		ok = iter.Seek(serializeStorageKey(addr, start))
+	} else if len(end) == 0 || !iter.Seek(serializeStorageKey(addr, end)) {
+		ok = iter.Last()
+	} else {
+		ok = iter.Prev()
+	}
+	var items []KeyValue
+	for ok && len(items) < limit {
+		key := iter.Key()[comm.ADDR_LEN:]
+		if reverse && bytes.Compare(key, start) < 0 || !reverse && len(end) != 0 && bytes.Compare(key, end) >= 0 {
+			break
+		}
+		items = append(items, KeyValue{
+			Key:   append([]byte(nil), key...),
+			Value: append([]byte(nil), iter.Value()...),
+		})
+		if reverse {
+			ok = iter.Prev()
+		} else {
+			ok = iter.Next()
+		}
+	}
+	return items, iter.Error()
+}
+
 func (self *CacheDB) MigrateContractStorage(oldAddress, newAddress comm.Address, height uint32) error {
 	self.DeleteContract(oldAddress, height)
 
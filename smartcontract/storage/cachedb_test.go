@@ -21,6 +21,7 @@ import (
 	"math/rand"
 	"testing"
 
+	comm "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/store/overlaydb"
@@ -83,3 +84,40 @@ func TestCacheDB(t *testing.T) {
 	}
 
 }
+
+func TestFindStorage(t *testing.T) {
+	overlay := overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore())
+	cache := NewCacheDB(overlay)
+	addr := comm.AddressFromVmCode([]byte("contract"))
+	other := comm.AddressFromVmCode([]byte("other"))
+	for _, key := range []string{"a", "b", "c", "d"} {
+		cache.Put(serializeStorageKey(addr, []byte(key)), []byte(key))
+		cache.Put(serializeStorageKey(other, []byte(key)), []byte(key))
+	}
+	cache.Commit()
+	// uncommitted changes are visible
+	cache.Delete(serializeStorageKey(addr, []byte("b")))
+	cache.Put(serializeStorageKey(addr, []byte("bb")), []byte("bb"))
+	cache.Put(serializeStorageKey(addr, []byte("c")), []byte("cc"))
+
+	keys := func(items []KeyValue, err error) []string {
+		assert.Nil(t, err)
+		var keys []string
+		for _, item := range items {
+			keys = append(keys, string(item.Key))
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"a", "bb", "c", "d"}, keys(cache.FindStorage(addr, nil, nil, false, 10)))
+	assert.Equal(t, []string{"d", "c", "bb", "a"}, keys(cache.FindStorage(addr, nil, nil, true, 10)))
+	assert.Equal(t, []string{"bb", "c"}, keys(cache.FindStorage(addr, []byte("b"), []byte("d"), false, 10)))
+	assert.Equal(t, []string{"c", "bb"}, keys(cache.FindStorage(addr, []byte("b"), []byte("d"), true, 10)))
+	assert.Equal(t, []string{"d", "c"}, keys(cache.FindStorage(addr, nil, nil, true, 2)))
+	assert.Equal(t, []string{"d"}, keys(cache.FindStorage(addr, []byte("c0"), nil, false, 10)))
+	assert.Equal(t, []string{"d", "c"}, keys(cache.FindStorage(addr, []byte("c"), []byte("e"), true, 10)))
+	assert.Nil(t, keys(cache.FindStorage(addr, []byte("e"), nil, false, 10)))
+
+	items, err := cache.FindStorage(addr, []byte("c"), []byte("c0"), false, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []KeyValue{{Key: []byte("c"), Value: []byte("cc")}}, items)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/trace"
+	"github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeoVmCallTracer(t *testing.T) {
+	notify := "System.Runtime.Notify"
+	byteCode := []byte{byte(neovm.PUSH5), byte(neovm.SYSCALL), byte(len(notify))}
+	byteCode = append(byteCode, notify...)
+	byteCode = append(byteCode, byte(neovm.PUSH2), byte(neovm.PUSH3), byte(neovm.ADD))
+
+	tracer := trace.NewCallTracer(&trace.Config{EnableSteps: true, StepLimit: 4})
+	sc := smartcontract.SmartContract{
+		Config: &smartcontract.Config{Time: 10, Height: 10, Tx: &types.Transaction{}},
+		Gas:    200,
+		Tracer: tracer,
+	}
+	engine, _ := sc.NewExecuteEngine(byteCode, types.InvokeNeo)
+	_, err := engine.Invoke()
+	assert.Nil(t, err)
+
+	frame := tracer.Result()
+	assert.Equal(t, trace.NEOVM, frame.Type)
+	assert.Equal(t, "05", frame.Output)
+	assert.Equal(t, 200-sc.Gas, frame.GasUsed)
+	assert.Empty(t, frame.Error)
+	assert.Len(t, frame.Steps, 4)
+	assert.Equal(t, "PUSH5", frame.Steps[0].Op)
+	assert.Equal(t, "SYSCALL", frame.Steps[1].Op)
+	assert.Equal(t, 1, frame.Steps[1].StackSize)
+	assert.Len(t, frame.HostCalls, 1)
+	assert.Equal(t, notify, frame.HostCalls[0].Name)
+	assert.Equal(t, []interface{}{"05"}, frame.Notify)
+	assert.Empty(t, frame.Calls)
+
+	// failed invocation keeps the frames traced before the failure
+	tracer = trace.NewCallTracer(nil)
+	sc = smartcontract.SmartContract{
+		Config: &smartcontract.Config{Time: 10, Height: 10, Tx: &types.Transaction{}},
+		Gas:    3,
+		Tracer: tracer,
+	}
+	engine, _ = sc.NewExecuteEngine(byteCode, types.InvokeNeo)
+	_, err = engine.Invoke()
+	assert.NotNil(t, err)
+	frame = tracer.Result()
+	assert.Equal(t, err.Error(), frame.Error)
+	assert.Nil(t, frame.Output)
+	assert.Empty(t, frame.Steps)
+}
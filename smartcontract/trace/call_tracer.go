@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package trace
+
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/event"
+)
+
+// Config are the options of CallTracer
+type Config struct {
+	EnableSteps bool `json:"enableSteps"` // record the neovm opcode steps
+	StepLimit   int  `json:"stepLimit"`   // maximum number of recorded steps, zero means unlimited
+}
+
+// Step is a neovm opcode executed in a frame
+type Step struct {
+	Pc        int    `json:"pc"`
+	Op        string `json:"op"`
+	GasCost   uint64 `json:"gasCost"`
+	StackSize int    `json:"stackSize"`
+}
+
+// HostCall is a neovm syscall or wasm host function invoked in a frame
+type HostCall struct {
+	Name    string `json:"name"`
+	GasCost uint64 `json:"gasCost"`
+}
+
+// StorageAccess is a read or write of the storage of the frame contract
+type StorageAccess struct {
+	Op    StorageOp `json:"op"`
+	Key   string    `json:"key"`
+	Value string    `json:"value,omitempty"`
+}
+
+// Frame is the execution of a contract invocation, the nested invocations are in Calls
+type Frame struct {
+	Type      VmType           `json:"type"`
+	Contract  string           `json:"contract"`
+	Method    string           `json:"method,omitempty"`
+	Input     string           `json:"input,omitempty"`
+	Output    interface{}      `json:"output,omitempty"`
+	GasUsed   uint64           `json:"gasUsed"`
+	Error     string           `json:"error,omitempty"`
+	Steps     []*Step          `json:"steps,omitempty"`
+	HostCalls []*HostCall      `json:"hostCalls,omitempty"`
+	Storage   []*StorageAccess `json:"storage,omitempty"`
+	Notify    []interface{}    `json:"notify,omitempty"`
+	Calls     []*Frame         `json:"calls,omitempty"`
+
+	gasLeft uint64
+}
+
+// CallTracer is a Tracer which builds the call tree of the transaction
+type CallTracer struct {
+	cfg   Config
+	root  *Frame
+	stack []*Frame
+	steps int
+}
+
+// NewCallTracer returns a call tracer, opcode steps are not recorded if cfg is nil
+func NewCallTracer(cfg *Config) *CallTracer {
+	tracer := &CallTracer{}
+	if cfg != nil {
+		tracer.cfg = *cfg
+	}
+	return tracer
+}
+
+// Result return the outermost frame, nil if no contract is invoked
+func (self *CallTracer) Result() *Frame {
+	return self.root
+}
+
+func (self *CallTracer) current() *Frame {
+	if len(self.stack) == 0 {
+		return nil
+	}
+	return self.stack[len(self.stack)-1]
+}
+
+func (self *CallTracer) CaptureEnter(vmType VmType, contract common.Address, method string, input []byte, gasLeft uint64) {
+	frame := &Frame{
+		Type:     vmType,
+		Contract: contract.ToHexString(),
+		Method:   method,
+		gasLeft:  gasLeft,
+	}
+	if len(input) != 0 {
+		frame.Input = common.ToHexString(input)
+	}
+	if parent := self.current(); parent != nil {
+		parent.Calls = append(parent.Calls, frame)
+	} else if self.root == nil {
+		self.root = frame
+	} else {
+		// only the first invocation of the transaction is traced
+		return
+	}
+	self.stack = append(self.stack, frame)
+}
+
+func (self *CallTracer) CaptureExit(output interface{}, gasLeft uint64, err error) {
+	frame := self.current()
+	if frame == nil {
+		return
+	}
+	self.stack = self.stack[:len(self.stack)-1]
+	if frame.gasLeft > gasLeft {
+		frame.GasUsed = frame.gasLeft - gasLeft
+	}
+	if err != nil {
+		frame.Error = err.Error()
+		return
+	}
+	frame.Output = output
+}
+
+func (self *CallTracer) CaptureNeoVMStep(pc int, op string, gasCost uint64, stackSize int) {
+	frame := self.current()
+	if frame == nil || !self.cfg.EnableSteps {
+		return
+	}
+	if self.cfg.StepLimit != 0 && self.steps >= self.cfg.StepLimit {
+		return
+	}
+	self.steps += 1
+	frame.Steps = append(frame.Steps, &Step{Pc: pc, Op: op, GasCost: gasCost, StackSize: stackSize})
+}
+
+func (self *CallTracer) CaptureHostCall(name string, gasCost uint64) {
+	if frame := self.current(); frame != nil {
+		frame.HostCalls = append(frame.HostCalls, &HostCall{Name: name, GasCost: gasCost})
+	}
+}
+
+func (self *CallTracer) CaptureStorage(op StorageOp, key, value []byte) {
+	frame := self.current()
+	if frame == nil {
+		return
+	}
+	access := &StorageAccess{Op: op, Key: common.ToHexString(key)}
+	if len(value) != 0 {
+		access.Value = common.ToHexString(value)
+	}
+	frame.Storage = append(frame.Storage, access)
+}
+
+func (self *CallTracer) CaptureNotify(notify *event.NotifyEventInfo) {
+	if frame := self.current(); frame != nil {
+		frame.Notify = append(frame.Notify, notify.States)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package trace defines the hooks to trace the execution of neovm, wasmvm and native contracts.
+package trace
+
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/event"
+)
+
+// VmType is the type of the engine which executes a call frame
+type VmType string
+
+const (
+	NEOVM  VmType = "neovm"
+	WASMVM VmType = "wasmvm"
+	NATIVE VmType = "native"
+)
+
+// StorageOp is the kind of a storage access
+type StorageOp string
+
+const (
+	STORAGE_GET    StorageOp = "get"
+	STORAGE_PUT    StorageOp = "put"
+	STORAGE_DELETE StorageOp = "delete"
+)
+
+// Tracer is notified during the execution of a transaction. A call frame is entered each time a contract
+// is invoked, all the other hooks belong to the innermost frame entered and not exited yet.
+// gasLeft is the gas left of the transaction when the frame is entered or exited.
+type Tracer interface {
+	CaptureEnter(vmType VmType, contract common.Address, method string, input []byte, gasLeft uint64)
+	// output is a json encodable result of the frame, nil if err is not nil
+	CaptureExit(output interface{}, gasLeft uint64, err error)
+	// CaptureNeoVMStep is called before each neovm opcode is executed
+	CaptureNeoVMStep(pc int, op string, gasCost uint64, stackSize int)
+	// CaptureHostCall is called when a neovm syscall or a wasm host function is invoked
+	CaptureHostCall(name string, gasCost uint64)
+	CaptureStorage(op StorageOp, key, value []byte)
+	CaptureNotify(notify *event.NotifyEventInfo)
+}
@@ -26,13 +26,14 @@ import (
 	"time"
 
 	ethcomm "github.com/ethereum/go-ethereum/common"
-	ethtype "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ontio/ontology-eventbus/actor"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/ledger"
 	txtypes "github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/events"
+	"github.com/ontio/ontology/events/message"
 	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
 	tc "github.com/ontio/ontology/txnpool/common"
@@ -147,6 +148,9 @@ func (s *TXPoolServer) movePendingTxToPool(txEntry *tc.VerifiedTx) { //solve the
 
 	errCode := s.txPool.AddTxList(txEntry)
 	s.removePendingTxLocked(txEntry.Tx.Hash(), errCode)
+	if errCode == errors.ErrNoError && events.DefActorPublisher != nil {
+		events.DefActorPublisher.Publish(message.TOPIC_TXPOOL_ADD_TX, &message.TxPoolAddTxMsg{Tx: txEntry.Tx})
+	}
 	log.Infof("tx moved from pending pool to tx pool: %s, err: %s", txEntry.Tx.Hash().ToHexString(), errCode.Error())
 }
 
@@ -531,32 +535,27 @@ func (s *TXPoolServer) Nonce(addr common.Address) uint64 {
 	return nonce
 }
 
-func (s *TXPoolServer) PendingEIPTransactions() []*ethtype.Transaction {
+func (s *TXPoolServer) PendingEIPTransactions() []*txtypes.Transaction {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	ret := make([]*ethtype.Transaction, 0)
+	ret := make([]*txtypes.Transaction, 0)
 	for _, v := range s.allPendingTxs {
-		tx, err := v.tx.GetEIP155Tx()
-		if err != nil {
+		if !v.tx.IsEipTx() {
 			continue
 		}
-		ret = append(ret, tx)
+		ret = append(ret, v.tx)
 	}
 
 	return ret
 }
 
-func (s *TXPoolServer) PendingTransactionsByHash(target ethcomm.Hash) *ethtype.Transaction {
+func (s *TXPoolServer) PendingTransactionsByHash(target ethcomm.Hash) *txtypes.Transaction {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	tx := s.allPendingTxs[common.Uint256(target)]
-	if tx == nil {
-		return nil
-	}
-	eip, err := tx.tx.GetEIP155Tx()
-	if err != nil {
+	if tx == nil || !tx.tx.IsEipTx() {
 		return nil
 	}
 
-	return eip
+	return tx.tx
 }
@@ -54,6 +54,8 @@ func (self *ValidatorPool) SubmitVerifyTask(tx *types.Transaction, rspCh chan<-
 			response.ErrCode = errors.ErrUnknown
 		} else if exist {
 			response.ErrCode = errors.ErrDuplicatedTx
+		} else if tx.CheckEthTypedTx(height+1) != nil {
+			response.ErrCode = errors.ErrTransactionPayload
 		} else if tx.IsEipTx() {
 			ethacct, err := ledger.DefLedger.GetEthAccount(ethcomm.Address(tx.Payer))
 			if err != nil {
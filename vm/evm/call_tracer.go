@@ -0,0 +1,245 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package evm
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// CallFrame is a call captured by CallTracer, it is encoded in the same format as the
+// callTracer of geth.
+type CallFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []*CallFrame    `json:"calls,omitempty"`
+
+	// bookkeeping of the calling opcode
+	gasIn      uint64
+	gasCost    uint64
+	outOff     uint64
+	outLen     uint64
+	precompile PrecompiledContract
+}
+
+// NativeCallType is the type of the frames of native contract invocations
+const NativeCallType = "NATIVE"
+
+// CallTracer is a Tracer which collects the nested calls of a transaction, it is a port of the
+// callTracer of geth. Calls into precompiled contracts execute no opcode, they are reported with
+// the gas required by the precompile. The native ong contract moving the value of a call is reported
+// as a NATIVE call nested in it, the gas fee transfer after the execution is not part of the trace.
+type CallTracer struct {
+	callstack []*CallFrame
+	descended bool
+}
+
+// NewCallTracer returns a new call tracer
+func NewCallTracer() *CallTracer {
+	return &CallTracer{callstack: []*CallFrame{{}}}
+}
+
+// CaptureStart implements the Tracer interface to fill the outermost call.
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	call := t.callstack[0]
+	call.Type = CALL.String()
+	if create {
+		call.Type = CREATE.String()
+	}
+	call.From = from
+	call.To = &to
+	call.Input = common.CopyBytes(input)
+	call.Gas = newHexUint64(gas)
+	if value == nil {
+		value = new(big.Int)
+	}
+	call.Value = (*hexutil.Big)(new(big.Int).Set(value))
+}
+
+// CaptureState implements the Tracer interface, it enters a new call on the calling opcodes and
+// exits the call when the execution returns to the depth of its caller.
+func (t *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory,
+	stack *Stack, rStack *ReturnStack, rData []byte, contract *Contract, depth int, err error) {
+	if err != nil {
+		t.CaptureFault(env, pc, op, gas, cost, memory, stack, rStack, contract, depth, err)
+		return
+	}
+	switch op {
+	case CREATE, CREATE2:
+		inOff, inLen := stack.Back(1).Uint64(), stack.Back(2).Uint64()
+		t.callstack = append(t.callstack, &CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			Input:   memory.GetCopy(int64(inOff), int64(inLen)),
+			Value:   (*hexutil.Big)(stack.Back(0).ToBig()),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return
+	case SELFDESTRUCT:
+		to := common.Address(stack.Back(0).Bytes20())
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    &to,
+			Value: (*hexutil.Big)(env.StateDB.GetBalance(contract.Address())),
+		})
+		return
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		to := common.Address(stack.Back(1).Bytes20())
+		off := 1
+		if op == DELEGATECALL || op == STATICCALL {
+			off = 0
+		}
+		inOff, inLen := stack.Back(2+off).Uint64(), stack.Back(3+off).Uint64()
+		call := &CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			To:      &to,
+			Input:   memory.GetCopy(int64(inOff), int64(inLen)),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(4 + off).Uint64(),
+			outLen:  stack.Back(5 + off).Uint64(),
+		}
+		if off == 1 {
+			call.Value = (*hexutil.Big)(stack.Back(2).ToBig())
+		}
+		call.precompile, _ = env.precompile(to)
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return
+	}
+	if t.descended {
+		// the first step of the callee tells the gas given to it, calls to precompiles and
+		// accounts without code have no step
+		if depth >= len(t.callstack) {
+			t.callstack[len(t.callstack)-1].Gas = newHexUint64(gas)
+		}
+		t.descended = false
+	}
+	if op == REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return
+	}
+	if depth == len(t.callstack)-1 {
+		t.exit(env, memory, stack, gas)
+	}
+}
+
+// CaptureNative implements the NativeTracer interface, the native invocation is nested in the current call.
+func (t *CallTracer) CaptureNative(from common.Address, contract common.Address, method string, input []byte, value *big.Int) {
+	top := t.callstack[len(t.callstack)-1]
+	top.Calls = append(top.Calls, &CallFrame{
+		Type:   NativeCallType,
+		From:   from,
+		To:     &contract,
+		Method: method,
+		Input:  common.CopyBytes(input),
+		Value:  (*hexutil.Big)(new(big.Int).Set(value)),
+	})
+}
+
+// exit pops the returned call and fills its result from the state of the caller
+func (t *CallTracer) exit(env *EVM, memory *Memory, stack *Stack, gas uint64) {
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	ret := stack.Back(0)
+	if call.Type == CREATE.String() || call.Type == CREATE2.String() {
+		call.GasUsed = newHexUint64(call.gasIn - call.gasCost - gas)
+		if !ret.IsZero() {
+			to := common.Address(ret.Bytes20())
+			call.To = &to
+			call.Output = env.StateDB.GetCode(to)
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	} else {
+		if call.Gas != nil {
+			call.GasUsed = newHexUint64(call.gasIn - call.gasCost + uint64(*call.Gas) - gas)
+		} else if call.precompile != nil {
+			call.GasUsed = newHexUint64(call.precompile.RequiredGas(call.Input))
+		}
+		if !ret.IsZero() {
+			call.Output = memory.GetCopy(int64(call.outOff), int64(call.outLen))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	}
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+}
+
+// CaptureFault implements the Tracer interface, the failed call consumes all of its gas.
+func (t *CallTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory,
+	stack *Stack, rStack *ReturnStack, contract *Contract, depth int, err error) {
+	// a reverted call is exited by its caller
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.Error = err.Error()
+	if call.Gas != nil {
+		call.GasUsed = call.Gas
+	}
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd implements the Tracer interface to fill the result of the outermost call.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	call := t.callstack[0]
+	call.GasUsed = newHexUint64(gasUsed)
+	call.Output = common.CopyBytes(output)
+	if call.Error == "" && err != nil {
+		call.Error = err.Error()
+	}
+	// only the revert reason is kept as output of a failed call
+	if call.Error != "" && call.Error != "execution reverted" {
+		call.Output = nil
+	}
+}
+
+// Result returns the outermost call of the trace.
+func (t *CallTracer) Result() *CallFrame {
+	return t.callstack[0]
+}
+
+func newHexUint64(v uint64) *hexutil.Uint64 {
+	return (*hexutil.Uint64)(&v)
+}
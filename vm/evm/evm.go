@@ -220,6 +220,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 			evm.vmConfig.Tracer.CaptureEnd(ret, startGas-gas, time.Since(startTime), err)
 		}(gas, time.Now())
 	}
+	evm.captureOngTransfer(caller.Address(), addr, value)
 
 	if isPrecompile {
 		ret, gas, err = RunPrecompiledContract(p, input, gas)
@@ -431,6 +432,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	if evm.vmConfig.Debug && evm.depth == 0 {
 		evm.vmConfig.Tracer.CaptureStart(caller.Address(), address, true, codeAndHash.code, gas, value)
 	}
+	evm.captureOngTransfer(caller.Address(), address, value)
 	start := time.Now()
 
 	ret, err := evm.interpreter.Run(contract, nil, false)
@@ -489,6 +491,20 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
+// captureOngTransfer reports the value transfer to the tracer as an invocation of the native ong contract,
+// it must be called after the tracer enters the call carrying the value. The EVM has no precompile bound to the
+// other native contracts, so the value transfer is the only native invocation inside the EVM. The gas fee paid to
+// the governance contract is settled by the state transition after the call returns, and is not traced.
+func (evm *EVM) captureOngTransfer(from, to common.Address, value *big.Int) {
+	if !evm.vmConfig.Debug || value.Sign() == 0 {
+		return
+	}
+	if tracer, ok := evm.vmConfig.Tracer.(NativeTracer); ok {
+		input := append(common.LeftPadBytes(to[:], 32), common.BigToHash(value).Bytes()...)
+		tracer.CaptureNative(from, common.Address(utils.OngContractAddress), "transfer", input, value)
+	}
+}
+
 // make native ong transfer Log
 func MakeOngTransferLog(stateDB StateDB, from, to common.Address, value *big.Int) {
 	if value.Cmp(big0) > 0 {
@@ -118,6 +118,12 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error)
 }
 
+// NativeTracer is implemented by the tracers which also record the native contracts invoked by the EVM,
+// like the native ong contract moving the value of a call.
+type NativeTracer interface {
+	CaptureNative(from common.Address, contract common.Address, method string, input []byte, value *big.Int)
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
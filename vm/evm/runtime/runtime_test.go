@@ -34,6 +34,7 @@ import (
 	"github.com/ontio/ontology/core/store/leveldbstore"
 	"github.com/ontio/ontology/core/store/overlaydb"
 	"github.com/ontio/ontology/smartcontract/service/native/ong"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
 	"github.com/ontio/ontology/smartcontract/storage"
 	"github.com/ontio/ontology/vm/evm"
 	"github.com/ontio/ontology/vm/evm/params"
@@ -1091,3 +1092,92 @@ func TestCreateOnDeletedAddress(t *testing.T) {
 	a.Nil(err, "fail")
 	a.True((big.NewInt(0).SetBytes(ret).Cmp(big.NewInt(0)) == 0), "should not get previous value 0x1234")
 }
+
+func TestCallTracer(t *testing.T) {
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore()))
+	statedb := storage.NewStateDB(db, common.Hash{}, common.Hash{}, ong.OngBalanceHandle{})
+	caller := common.HexToAddress("0x0a")
+	reverter := common.HexToAddress("0x0b")
+	identity := common.BytesToAddress([]byte{4})
+	statedb.SetCode(caller, []byte{
+		// mstore(0, 42)
+		byte(evm.PUSH1), 42, byte(evm.PUSH1), 0, byte(evm.MSTORE),
+		// staticcall(gas, identity, 0, 32, 32, 32)
+		byte(evm.PUSH1), 32, byte(evm.PUSH1), 32, byte(evm.PUSH1), 32, byte(evm.PUSH1), 0,
+		byte(evm.PUSH1), 4, byte(evm.GAS), byte(evm.STATICCALL), byte(evm.POP),
+		// call(gas, reverter, 0, 0, 0, 0, 0)
+		byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.PUSH1), 0,
+		byte(evm.PUSH1), 0x0b, byte(evm.GAS), byte(evm.CALL), byte(evm.POP),
+		byte(evm.STOP),
+	})
+	statedb.SetCode(reverter, []byte{
+		byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.REVERT),
+	})
+
+	tracer := evm.NewCallTracer()
+	_, _, err := Call(caller, nil, &Config{State: statedb,
+		EVMConfig: evm.Config{
+			Debug:  true,
+			Tracer: tracer,
+		}})
+	require.NoError(t, err)
+
+	result := tracer.Result()
+	require.Equal(t, "CALL", result.Type)
+	require.Equal(t, caller, *result.To)
+	require.Empty(t, result.Error)
+	require.Len(t, result.Calls, 2)
+
+	precompile := result.Calls[0]
+	require.Equal(t, "STATICCALL", precompile.Type)
+	require.Equal(t, identity, *precompile.To)
+	require.Equal(t, common.LeftPadBytes([]byte{42}, 32), []byte(precompile.Input))
+	require.Equal(t, []byte(precompile.Input), []byte(precompile.Output))
+	require.Equal(t, uint64(18), uint64(*precompile.GasUsed))
+
+	reverted := result.Calls[1]
+	require.Equal(t, "CALL", reverted.Type)
+	require.Equal(t, reverter, *reverted.To)
+	require.Equal(t, "execution reverted", reverted.Error)
+	require.NotNil(t, reverted.Gas)
+	require.NotNil(t, reverted.GasUsed)
+}
+
+func TestCallTracerNativeTransfer(t *testing.T) {
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore()))
+	statedb := storage.NewStateDB(db, common.Hash{}, common.Hash{}, ong.OngBalanceHandle{})
+	caller := common.HexToAddress("0x0a")
+	receiver := common.HexToAddress("0x0c")
+	statedb.AddBalance(caller, big.NewInt(10))
+	statedb.SetCode(caller, []byte{
+		// call(gas, receiver, 5, 0, 0, 0, 0)
+		byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.PUSH1), 0, byte(evm.PUSH1), 5,
+		byte(evm.PUSH1), 0x0c, byte(evm.GAS), byte(evm.CALL), byte(evm.POP),
+		byte(evm.STOP),
+	})
+
+	tracer := evm.NewCallTracer()
+	_, _, err := Call(caller, nil, &Config{State: statedb,
+		EVMConfig: evm.Config{
+			Debug:  true,
+			Tracer: tracer,
+		}})
+	require.NoError(t, err)
+
+	result := tracer.Result()
+	require.Len(t, result.Calls, 1)
+	transfer := result.Calls[0]
+	require.Equal(t, "CALL", transfer.Type)
+	require.Equal(t, receiver, *transfer.To)
+	require.Len(t, transfer.Calls, 1)
+
+	native := transfer.Calls[0]
+	require.Equal(t, evm.NativeCallType, native.Type)
+	require.Equal(t, caller, native.From)
+	require.Equal(t, common.Address(utils.OngContractAddress), *native.To)
+	require.Equal(t, "transfer", native.Method)
+	require.Equal(t, big.NewInt(5), native.Value.ToInt())
+	require.Equal(t, append(common.LeftPadBytes(receiver[:], 32), common.BigToHash(big.NewInt(5)).Bytes()...),
+		[]byte(native.Input))
+	require.Equal(t, big.NewInt(5), statedb.GetBalance(receiver))
+}